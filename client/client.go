@@ -141,6 +141,18 @@ type SecurityOption struct {
 
 // NewClient creates a PD client.
 func NewClient(pdAddrs []string, security SecurityOption) (Client, error) {
+	c, err := newClient(pdAddrs, security)
+	if err != nil {
+		return nil, err
+	}
+	c.wg.Add(2)
+	go c.tsLoop()
+	go c.tsCancelLoop()
+	return c, nil
+}
+
+// newClient creates a client which updates the leader in background.
+func newClient(pdAddrs []string, security SecurityOption) (*client, error) {
 	log.Info("[pd] create pd client with endpoints", zap.Strings("pd-address", pdAddrs))
 	ctx, cancel := context.WithCancel(context.Background())
 	c := &client{
@@ -162,9 +174,7 @@ func NewClient(pdAddrs []string, security SecurityOption) (Client, error) {
 	}
 	log.Info("[pd] init cluster id", zap.Uint64("cluster-id", c.clusterID))
 
-	c.wg.Add(3)
-	go c.tsLoop()
-	go c.tsCancelLoop()
+	c.wg.Add(1)
 	go c.leaderLoop()
 
 	return c, nil
@@ -0,0 +1,161 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/configpb"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// watchRetryInterval is the interval to watch the component config again
+// after the stream fails.
+const watchRetryInterval = time.Second
+
+// ConfigClient is a client to get and watch the configs of the components
+// hosted by PD. It should not be used after calling Close().
+type ConfigClient interface {
+	// GetClusterID gets the cluster ID from PD.
+	GetClusterID(ctx context.Context) uint64
+	// GetComponentConfig gets the config of a component instance, which is
+	// merged from the global, the component and the instance layers.
+	GetComponentConfig(ctx context.Context, component, componentID string) (*ComponentConfig, error)
+	// WatchComponentConfig watches the config of a component instance. The
+	// config is sent to the channel each time its version is greater than the
+	// given version, and the channel is closed when the context is done or
+	// the client is closed. It reconnects to the PD leader if the stream fails.
+	WatchComponentConfig(ctx context.Context, component, componentID string, version uint64) <-chan *ComponentConfig
+	// Close closes the client.
+	Close()
+}
+
+// ComponentConfig is the config of a component instance.
+type ComponentConfig struct {
+	Version uint64
+	// Config is the config in JSON.
+	Config string
+}
+
+// Unmarshal decodes the config to v.
+func (c *ComponentConfig) Unmarshal(v interface{}) error {
+	return errors.WithStack(json.Unmarshal([]byte(c.Config), v))
+}
+
+type configClient struct {
+	*client
+}
+
+// NewConfigClient creates a client of the component configs.
+func NewConfigClient(pdAddrs []string, security SecurityOption) (ConfigClient, error) {
+	c, err := newClient(pdAddrs, security)
+	if err != nil {
+		return nil, err
+	}
+	return &configClient{client: c}, nil
+}
+
+// leaderConfigClient gets the config client of current PD leader.
+func (c *configClient) leaderConfigClient() configpb.ConfigClient {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+
+	return configpb.NewConfigClient(c.connMu.clientConns[c.connMu.leader])
+}
+
+func (c *configClient) GetComponentConfig(ctx context.Context, component, componentID string) (*ComponentConfig, error) {
+	start := time.Now()
+	defer func() { cmdDurationGetComponentConfig.Observe(time.Since(start).Seconds()) }()
+
+	ctx, cancel := context.WithTimeout(ctx, pdTimeout)
+	resp, err := c.leaderConfigClient().GetComponentConfig(ctx, &configpb.GetComponentConfigRequest{
+		Header:      c.requestHeader(),
+		Component:   component,
+		ComponentId: componentID,
+	})
+	cancel()
+
+	if err != nil {
+		cmdFailedDurationGetComponentConfig.Observe(time.Since(start).Seconds())
+		c.ScheduleCheckLeader()
+		return nil, errors.WithStack(err)
+	}
+	return &ComponentConfig{Version: resp.GetVersion(), Config: resp.GetConfig()}, nil
+}
+
+func (c *configClient) WatchComponentConfig(ctx context.Context, component, componentID string, version uint64) <-chan *ComponentConfig {
+	ch := make(chan *ComponentConfig, 1)
+	// The watch stops when the client is closed.
+	ctx, cancel := context.WithCancel(ctx)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(ch)
+		defer cancel()
+
+		go func() {
+			select {
+			case <-c.ctx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		req := &configpb.WatchComponentConfigRequest{
+			Header:      c.requestHeader(),
+			Component:   component,
+			ComponentId: componentID,
+			Version:     version,
+		}
+		for {
+			err := c.watchComponentConfig(ctx, req, ch)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn("[pd] failed to watch component config", zap.String("component", component), zap.String("component-id", componentID), zap.Error(err))
+			c.ScheduleCheckLeader()
+			select {
+			case <-time.After(watchRetryInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// watchComponentConfig sends the configs received from the stream to ch until
+// the stream fails. The version of the request is updated with the configs.
+func (c *configClient) watchComponentConfig(ctx context.Context, req *configpb.WatchComponentConfigRequest, ch chan<- *ComponentConfig) error {
+	stream, err := c.leaderConfigClient().WatchComponentConfig(ctx, req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		req.Version = resp.GetVersion()
+		select {
+		case ch <- &ComponentConfig{Version: resp.GetVersion(), Config: resp.GetConfig()}:
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"context"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server"
+)
+
+var _ = Suite(&testConfigClientSuite{})
+
+type testConfigClientSuite struct {
+	cleanup server.CleanupFunc
+	srv     *server.Server
+	client  ConfigClient
+}
+
+func (s *testConfigClientSuite) SetUpSuite(c *C) {
+	var err error
+	_, s.srv, s.cleanup, err = server.NewTestServer(c)
+	c.Assert(err, IsNil)
+	mustWaitLeader(c, map[string]*server.Server{s.srv.GetAddr(): s.srv})
+
+	s.client, err = NewConfigClient(s.srv.GetEndpoints(), SecurityOption{})
+	c.Assert(err, IsNil)
+}
+
+func (s *testConfigClientSuite) TearDownSuite(c *C) {
+	s.client.Close()
+	s.cleanup()
+}
+
+func (s *testConfigClientSuite) TestComponentConfig(c *C) {
+	m := s.srv.GetComponentConfigManager()
+	_, err := m.Update("", "", map[string]interface{}{"log-level": "info"})
+	c.Assert(err, IsNil)
+	_, err = m.Update("tikv", "store1", map[string]interface{}{"log-level": "debug"})
+	c.Assert(err, IsNil)
+
+	cfg, err := s.client.GetComponentConfig(context.Background(), "tikv", "store1")
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Config, Equals, `{"log-level":"debug"}`)
+	var v struct {
+		LogLevel string `json:"log-level"`
+	}
+	c.Assert(cfg.Unmarshal(&v), IsNil)
+	c.Assert(v.LogLevel, Equals, "debug")
+	cfg2, err := s.client.GetComponentConfig(context.Background(), "tikv", "store2")
+	c.Assert(err, IsNil)
+	c.Assert(cfg2.Config, Equals, `{"log-level":"info"}`)
+	c.Assert(cfg2.Version, Less, cfg.Version)
+	_, err = s.client.GetComponentConfig(context.Background(), "", "")
+	c.Assert(err, NotNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := s.client.WatchComponentConfig(ctx, "tikv", "store2", cfg2.Version)
+	_, err = m.Update("tikv", "", map[string]interface{}{"log-level": "warn"})
+	c.Assert(err, IsNil)
+	select {
+	case watched := <-ch:
+		c.Assert(watched.Config, Equals, `{"log-level":"warn"}`)
+		c.Assert(watched.Version, Greater, cfg.Version)
+	case <-time.After(5 * time.Second):
+		c.Fatal("config is not watched")
+	}
+	// The change of store1 is not sent to the watcher of store2.
+	_, err = m.Update("tikv", "store1", map[string]interface{}{"log-level": "error"})
+	c.Assert(err, IsNil)
+	select {
+	case watched := <-ch:
+		c.Fatalf("unexpected config %v", watched)
+	case <-time.After(100 * time.Millisecond):
+	}
+	// The channel is closed after the watch is canceled.
+	cancel()
+	_, ok := <-ch
+	c.Assert(ok, IsFalse)
+}
@@ -16,9 +16,11 @@ package main
 import (
 	"context"
 	"flag"
+	"math/rand"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/pingcap/log"
@@ -78,6 +80,8 @@ func main() {
 	}
 
 	server.LogPDInfo()
+	// pd-simulator seeds the randomness of the scheduling with its own seed.
+	rand.Seed(time.Now().UnixNano())
 
 	for _, msg := range cfg.WarningMsgs {
 		log.Warn(msg)
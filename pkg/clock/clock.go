@@ -0,0 +1,336 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clock
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// virtual is the virtual clock in use, the wall clock is used if it is nil.
+var virtual atomic.Value
+
+// SetVirtual makes the scheduling use the virtual clock, nil restores the
+// wall clock. It should be called before the timers and tickers are created.
+func SetVirtual(v *Virtual) {
+	virtual.Store(v)
+}
+
+// GetVirtual returns the virtual clock in use, it is nil if the wall clock is
+// used.
+func GetVirtual() *Virtual {
+	v, _ := virtual.Load().(*Virtual)
+	return v
+}
+
+// Now returns the current time of the clock.
+func Now() time.Time {
+	if v := GetVirtual(); v != nil {
+		return v.Now()
+	}
+	return time.Now()
+}
+
+// Since returns the time elapsed since t.
+func Since(t time.Time) time.Duration {
+	return Now().Sub(t)
+}
+
+// Source provides the time of the clock to the libraries which accept a
+// custom clock, such as juju/ratelimit.
+type Source struct{}
+
+// Now returns the current time of the clock.
+func (Source) Now() time.Time {
+	return Now()
+}
+
+// Sleep waits for the duration on the clock.
+func (Source) Sleep(d time.Duration) {
+	t := NewTimer(d)
+	<-t.C
+	t.Done()
+}
+
+// StartWork records a work caused by the simulation, such as a message between
+// PD and the simulated stores. The virtual clock is settled when all the works
+// end. It does nothing on the wall clock.
+func StartWork() {
+	if v := GetVirtual(); v != nil {
+		v.startWork()
+	}
+}
+
+// EndWork records that a work started by StartWork ends.
+func EndWork() {
+	if v := GetVirtual(); v != nil {
+		v.endWork()
+	}
+}
+
+// ResetWork forgets the unfinished works, it should be called if they are
+// lost, such as the messages on a broken stream.
+func ResetWork() {
+	if v := GetVirtual(); v != nil {
+		v.resetWork()
+	}
+}
+
+// Ticker delivers ticks at intervals like time.Ticker.
+type Ticker struct {
+	C      <-chan time.Time
+	ticker *time.Ticker
+	w      *waiter
+}
+
+// NewTicker returns a new Ticker.
+func NewTicker(d time.Duration) *Ticker {
+	if v := GetVirtual(); v != nil {
+		w := v.add(d, d)
+		return &Ticker{C: w.ch, w: w}
+	}
+	t := time.NewTicker(d)
+	return &Ticker{C: t.C, ticker: t}
+}
+
+// Stop turns off the ticker.
+func (t *Ticker) Stop() {
+	if t.w != nil {
+		t.w.v.remove(t.w)
+		return
+	}
+	t.ticker.Stop()
+}
+
+// Done tells the virtual clock that the last tick has been handled, it does
+// nothing if the tick is handled or on the wall clock. It is usually called
+// before waiting for the next tick.
+func (t *Ticker) Done() {
+	if t.w != nil {
+		t.w.v.done(t.w)
+	}
+}
+
+// Timer sends the time once like time.Timer.
+type Timer struct {
+	C     <-chan time.Time
+	timer *time.Timer
+	w     *waiter
+}
+
+// NewTimer returns a new Timer.
+func NewTimer(d time.Duration) *Timer {
+	if v := GetVirtual(); v != nil {
+		w := v.add(d, 0)
+		return &Timer{C: w.ch, w: w}
+	}
+	t := time.NewTimer(d)
+	return &Timer{C: t.C, timer: t}
+}
+
+// Stop prevents the timer from firing, it returns false if the timer has
+// expired or been stopped.
+func (t *Timer) Stop() bool {
+	if t.w != nil {
+		return t.w.v.remove(t.w)
+	}
+	return t.timer.Stop()
+}
+
+// Reset changes the timer to expire after duration d.
+func (t *Timer) Reset(d time.Duration) bool {
+	if t.w != nil {
+		return t.w.v.reset(t.w, d)
+	}
+	return t.timer.Reset(d)
+}
+
+// Done tells the virtual clock that the expiration has been handled, it does
+// nothing if it is handled or on the wall clock.
+func (t *Timer) Done() {
+	if t.w != nil {
+		t.w.v.done(t.w)
+	}
+}
+
+// Virtual is a clock which only moves forward when it is advanced. The timers
+// and tickers fire one at a time, and the clock waits for them to be handled
+// and the works caused by them to end, so the handling is in a fixed order.
+type Virtual struct {
+	mu      sync.Mutex
+	settled *sync.Cond
+	now     time.Time
+	waiters map[*waiter]struct{}
+	// seq orders the waiters which expire at the same time by creation.
+	seq uint64
+	// busy is the number of the fired waiters which are not handled.
+	busy int
+	// works is the number of the works which do not end.
+	works int
+}
+
+type waiter struct {
+	v        *Virtual
+	seq      uint64
+	deadline time.Time
+	// period is 0 for the timers.
+	period time.Duration
+	ch     chan time.Time
+	busy   bool
+}
+
+// NewVirtual creates a virtual clock starting from start.
+func NewVirtual(start time.Time) *Virtual {
+	v := &Virtual{
+		now:     start,
+		waiters: make(map[*waiter]struct{}),
+	}
+	v.settled = sync.NewCond(&v.mu)
+	return v
+}
+
+// Now returns the current time of the virtual clock.
+func (v *Virtual) Now() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.now
+}
+
+// Advance moves the clock forward and fires the expired timers and tickers in
+// the order of the deadlines. It waits for the clock to be settled after each
+// firing. Like time.Ticker, a ticker fires once even if several periods pass.
+func (v *Virtual) Advance(d time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.now = v.now.Add(d)
+	for {
+		w := v.nextExpiredLocked()
+		if w == nil {
+			return
+		}
+		if w.period == 0 {
+			delete(v.waiters, w)
+		} else {
+			for !w.deadline.After(v.now) {
+				w.deadline = w.deadline.Add(w.period)
+			}
+		}
+		select {
+		case w.ch <- v.now:
+			w.busy = true
+			v.busy++
+		default:
+		}
+		v.settleLocked()
+	}
+}
+
+// Settle blocks until the fired timers and tickers are handled and the works
+// end.
+func (v *Virtual) Settle() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.settleLocked()
+}
+
+func (v *Virtual) settleLocked() {
+	for v.busy > 0 || v.works > 0 {
+		v.settled.Wait()
+	}
+}
+
+func (v *Virtual) nextExpiredLocked() *waiter {
+	var next *waiter
+	for w := range v.waiters {
+		if w.deadline.After(v.now) {
+			continue
+		}
+		if next == nil || w.deadline.Before(next.deadline) ||
+			(w.deadline.Equal(next.deadline) && w.seq < next.seq) {
+			next = w
+		}
+	}
+	return next
+}
+
+func (v *Virtual) add(d, period time.Duration) *waiter {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.seq++
+	w := &waiter{
+		v:        v,
+		seq:      v.seq,
+		deadline: v.now.Add(d),
+		period:   period,
+		ch:       make(chan time.Time, 1),
+	}
+	v.waiters[w] = struct{}{}
+	return w
+}
+
+func (v *Virtual) remove(w *waiter) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.doneLocked(w)
+	_, ok := v.waiters[w]
+	delete(v.waiters, w)
+	return ok
+}
+
+func (v *Virtual) reset(w *waiter, d time.Duration) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, ok := v.waiters[w]
+	w.deadline = v.now.Add(d)
+	v.waiters[w] = struct{}{}
+	return ok
+}
+
+func (v *Virtual) done(w *waiter) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.doneLocked(w)
+}
+
+func (v *Virtual) doneLocked(w *waiter) {
+	if w.busy {
+		w.busy = false
+		v.busy--
+		v.settled.Broadcast()
+	}
+}
+
+func (v *Virtual) startWork() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.works++
+}
+
+func (v *Virtual) endWork() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// The works may have been reset.
+	if v.works > 0 {
+		v.works--
+		v.settled.Broadcast()
+	}
+}
+
+func (v *Virtual) resetWork() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.works = 0
+	v.settled.Broadcast()
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+func TestClock(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testClockSuite{})
+
+type testClockSuite struct{}
+
+func (s *testClockSuite) TearDownTest(c *C) {
+	SetVirtual(nil)
+}
+
+func received(ch <-chan time.Time) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *testClockSuite) TestWallClock(c *C) {
+	c.Assert(GetVirtual(), IsNil)
+	c.Assert(Since(time.Now().Add(-time.Hour)), GreaterEqual, time.Hour)
+	timer := NewTimer(time.Millisecond)
+	<-timer.C
+	ticker := NewTicker(time.Millisecond)
+	<-ticker.C
+	ticker.Stop()
+}
+
+func (s *testClockSuite) TestVirtualClock(c *C) {
+	start := time.Unix(1000, 0)
+	v := NewVirtual(start)
+	SetVirtual(v)
+	c.Assert(Now(), Equals, start)
+
+	timer := NewTimer(time.Second)
+	ticker := NewTicker(300 * time.Millisecond)
+	go func() {
+		<-ticker.C
+		ticker.Done()
+	}()
+	v.Advance(500 * time.Millisecond)
+	c.Assert(Since(start), Equals, 500*time.Millisecond)
+	c.Assert(received(timer.C), IsFalse)
+	c.Assert(received(ticker.C), IsFalse)
+
+	// The ticker fires once even if several periods pass.
+	go func() {
+		<-ticker.C
+		ticker.Done()
+		<-timer.C
+		timer.Done()
+	}()
+	v.Advance(600 * time.Millisecond)
+	c.Assert(received(timer.C), IsFalse)
+	c.Assert(received(ticker.C), IsFalse)
+	c.Assert(timer.Stop(), IsFalse)
+
+	c.Assert(timer.Reset(time.Second), IsFalse)
+	c.Assert(timer.Stop(), IsTrue)
+	ticker.Stop()
+	v.Advance(time.Hour)
+	c.Assert(received(timer.C), IsFalse)
+	c.Assert(received(ticker.C), IsFalse)
+
+	src := Source{}
+	c.Assert(src.Now(), Equals, start.Add(time.Hour+1100*time.Millisecond))
+	done := make(chan struct{})
+	go func() {
+		src.Sleep(time.Second)
+		close(done)
+	}()
+	for {
+		v.Advance(time.Second)
+		select {
+		case <-done:
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func (s *testClockSuite) TestVirtualClockOrder(c *C) {
+	v := NewVirtual(time.Unix(1000, 0))
+	SetVirtual(v)
+
+	// The timers fire in the order of the deadlines and then the creation,
+	// and the next one fires after the last one is handled.
+	var order []int
+	timers := []*Timer{NewTimer(2 * time.Second), NewTimer(time.Second), NewTimer(2 * time.Second)}
+	for i, t := range timers {
+		go func(i int, t *Timer) {
+			<-t.C
+			order = append(order, i)
+			t.Done()
+		}(i, t)
+	}
+	v.Advance(3 * time.Second)
+	c.Assert(order, DeepEquals, []int{1, 0, 2})
+
+	// A stopped ticker does not block the clock.
+	ticker := NewTicker(time.Second)
+	go func() {
+		<-ticker.C
+		ticker.Stop()
+	}()
+	v.Advance(time.Second)
+}
+
+func (s *testClockSuite) TestSettle(c *C) {
+	v := NewVirtual(time.Unix(1000, 0))
+	SetVirtual(v)
+
+	StartWork()
+	StartWork()
+	settled := make(chan struct{})
+	go func() {
+		v.Settle()
+		close(settled)
+	}()
+	EndWork()
+	select {
+	case <-settled:
+		c.Fatal("settled with a work")
+	case <-time.After(10 * time.Millisecond):
+	}
+	EndWork()
+	<-settled
+
+	// The lost works are forgotten.
+	StartWork()
+	ResetWork()
+	EndWork()
+	v.Settle()
+
+	// The timer fired by the last Advance must be handled.
+	timer := NewTimer(time.Second)
+	go v.Advance(time.Second)
+	<-timer.C
+	StartWork()
+	timer.Done()
+	EndWork()
+	v.Settle()
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configpb
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testConfigpbSuite{})
+
+type testConfigpbSuite struct{}
+
+func (s *testConfigpbSuite) TestMarshal(c *C) {
+	req := &WatchComponentConfigRequest{
+		Header:      &pdpb.RequestHeader{ClusterId: 1},
+		Component:   "tikv",
+		ComponentId: "127.0.0.1:20160",
+		Version:     3,
+	}
+	data, err := proto.Marshal(req)
+	c.Assert(err, IsNil)
+	decoded := &WatchComponentConfigRequest{}
+	c.Assert(proto.Unmarshal(data, decoded), IsNil)
+	c.Assert(proto.Equal(decoded, req), IsTrue)
+
+	resp := &GetComponentConfigResponse{
+		Header:  &pdpb.ResponseHeader{ClusterId: 1, Error: &pdpb.Error{Message: "error"}},
+		Version: 3,
+		Config:  `{"log-level":"info"}`,
+	}
+	data, err = proto.Marshal(resp)
+	c.Assert(err, IsNil)
+	decodedResp := &GetComponentConfigResponse{}
+	c.Assert(proto.Unmarshal(data, decodedResp), IsNil)
+	c.Assert(proto.Equal(decodedResp, resp), IsTrue)
+}
@@ -0,0 +1,21 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package configpb is the gRPC service for the components to get and watch
+// their configs hosted by PD.
+//
+// configpb.pb.go is generated from configpb.proto by scripts/generate-configpb.sh,
+// do not edit it. The service is kept here until it is stable, then it should
+// be moved to kvproto with the other services of PD, so that TiKV and TiDB can
+// generate the clients from the same file.
+package configpb
@@ -0,0 +1,14 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+# Make sure protoc 3.x is installed before executing it. protoc-gen-go is built
+# with the version of github.com/golang/protobuf in go.mod, and pdpb.proto is
+# imported from the version of kvproto in go.mod.
+
+export GO111MODULE=on
+go build -o bin/protoc-gen-go github.com/golang/protobuf/protoc-gen-go
+KVPROTO=$(go list -m -f '{{.Dir}}' github.com/pingcap/kvproto)
+protoc -I pkg/configpb -I "$KVPROTO/proto" -I "$KVPROTO/include" \
+    --plugin=protoc-gen-go=bin/protoc-gen-go \
+    --go_out=plugins=grpc,Mpdpb.proto=github.com/pingcap/kvproto/pkg/pdpb:pkg/configpb \
+    configpb.proto
@@ -16,8 +16,10 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pingcap/pd/pkg/apiutil"
 	"github.com/pingcap/pd/server"
 	"github.com/unrolled/render"
 )
@@ -51,3 +53,63 @@ func (h *adminHandler) HandleDropCacheRegion(w http.ResponseWriter, r *http.Requ
 	cluster.DropCacheRegion(regionID)
 	h.rd.JSON(w, http.StatusOK, nil)
 }
+
+type removeFailedStoresInput struct {
+	Stores []uint64 `json:"stores"`
+	// Timeout is in seconds.
+	Timeout uint64 `json:"timeout"`
+}
+
+func (h *adminHandler) RemoveFailedStores(w http.ResponseWriter, r *http.Request) {
+	cluster := h.svr.GetRaftCluster()
+	if cluster == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+
+	var input removeFailedStoresInput
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	if len(input.Stores) == 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "no failed store is specified")
+		return
+	}
+	timeout := time.Duration(input.Timeout) * time.Second
+	if err := cluster.RemoveFailedStores(input.Stores, timeout); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
+func (h *adminHandler) GetRemoveFailedStoresStatus(w http.ResponseWriter, r *http.Request) {
+	cluster := h.svr.GetRaftCluster()
+	if cluster == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, cluster.GetRemoveFailedStoresStatus())
+}
+
+func (h *adminHandler) CheckRegionStorage(w http.ResponseWriter, r *http.Request) {
+	h.checkRegionStorage(w, false)
+}
+
+func (h *adminHandler) RepairRegionStorage(w http.ResponseWriter, r *http.Request) {
+	h.checkRegionStorage(w, true)
+}
+
+func (h *adminHandler) checkRegionStorage(w http.ResponseWriter, repair bool) {
+	cluster := h.svr.GetRaftCluster()
+	if cluster == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+	reports, err := cluster.CheckRegionStorage(repair)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, reports)
+}
@@ -16,9 +16,11 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/audit"
 	"github.com/pingcap/pd/server/core"
 )
 
@@ -78,3 +80,43 @@ func (s *testAdminSuite) TestDropRegion(c *C) {
 	c.Assert(region.GetRegionEpoch().ConfVer, Equals, uint64(50))
 	c.Assert(region.GetRegionEpoch().Version, Equals, uint64(50))
 }
+
+func (s *testAdminSuite) TestAudit(c *C) {
+	url := fmt.Sprintf("%s/config", s.urlPrefix)
+	c.Assert(postJSON(url, []byte(`{"max-snapshot-count": 8}`)), IsNil)
+	c.Assert(postJSON(url, []byte(`{"max-snapshot-count": "8"}`)), NotNil)
+
+	var records []*audit.Record
+	err := readJSONWithURL(fmt.Sprintf("%s/admin/audit?method=POST&limit=2", s.urlPrefix), &records)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].Path, Equals, "/pd/api/v1/config")
+	c.Assert(records[0].Body, Equals, `{"max-snapshot-count": "8"}`)
+	c.Assert(records[0].Result, Equals, audit.ResultFailed)
+	c.Assert(records[1].Body, Equals, `{"max-snapshot-count": 8}`)
+	c.Assert(records[1].Result, Equals, audit.ResultSuccess)
+	c.Assert(records[1].StatusCode, Equals, http.StatusOK)
+
+	// The GET requests are not recorded.
+	err = readJSONWithURL(fmt.Sprintf("%s/admin/audit?method=GET", s.urlPrefix), &records)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 0)
+
+	// The redirector header is not trusted without the certificate of a member.
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"max-snapshot-count": 9}`))
+	c.Assert(err, IsNil)
+	req.Header.Set(redirectorHeader, "pd")
+	res, err := dialClient.Do(req)
+	c.Assert(err, IsNil)
+	res.Body.Close()
+	err = readJSONWithURL(fmt.Sprintf("%s/admin/audit?method=POST&limit=1", s.urlPrefix), &records)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Body, Equals, `{"max-snapshot-count": 9}`)
+	c.Assert(records[0].Forwarded, IsTrue)
+
+	res, err = http.Get(fmt.Sprintf("%s/admin/audit?limit=-1", s.urlPrefix))
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+	res.Body.Close()
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/audit"
+	"github.com/unrolled/render"
+	"github.com/urfave/negroni"
+)
+
+// auditMiddleware records the mutating requests. It runs before the
+// redirector, so the requests are recorded by the PD server which receives
+// them from the clients.
+type auditMiddleware struct {
+	svr *server.Server
+}
+
+func newAuditMiddleware(svr *server.Server) *auditMiddleware {
+	return &auditMiddleware{svr: svr}
+}
+
+func (m *auditMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	auditor := m.svr.GetAuditor()
+	if !auditor.IsEnabled() || !isMutatingMethod(r.Method) {
+		next(w, r)
+		return
+	}
+	// The requests redirected by the members have been recorded by them.
+	forwarded := r.Header.Get(redirectorHeader) != ""
+	if forwarded && isRedirectedByMember(m.svr, r) {
+		next(w, r)
+		return
+	}
+
+	start := time.Now()
+	body := &limitedBuffer{limit: auditor.MaxBodySize()}
+	if r.Body != nil {
+		r.Body = &teeReadCloser{Reader: io.TeeReader(r.Body, body), Closer: r.Body}
+	}
+	rw := negroni.NewResponseWriter(w)
+	next(rw, r)
+
+	record := &audit.Record{
+		Time:          start,
+		RemoteAddr:    r.RemoteAddr,
+		Method:        r.Method,
+		Path:          r.URL.RequestURI(),
+		Body:          string(body.data),
+		BodyTruncated: body.truncated,
+		StatusCode:    rw.Status(),
+		Result:        audit.ResultSuccess,
+		Duration:      time.Since(start),
+		Forwarded:     forwarded,
+	}
+	if record.StatusCode == 0 {
+		record.StatusCode = http.StatusOK
+	}
+	if record.StatusCode >= http.StatusBadRequest {
+		record.Result = audit.ResultFailed
+	}
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		record.User = r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	auditor.Append(record)
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// limitedBuffer keeps the first limit bytes written to it.
+type limitedBuffer struct {
+	data      []byte
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if left := b.limit - len(b.data); left < len(p) {
+		b.data = append(b.data, p[:left]...)
+		b.truncated = true
+	} else {
+		b.data = append(b.data, p...)
+	}
+	return len(p), nil
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type auditHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newAuditHandler(svr *server.Server, rd *render.Render) *auditHandler {
+	return &auditHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// List returns the records of the PD server which receives the request, it
+// is not redirected to the leader.
+func (h *auditHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := &audit.Filter{
+		User:   query.Get("user"),
+		Method: query.Get("method"),
+	}
+	for _, item := range []struct {
+		name string
+		t    *time.Time
+	}{
+		{"start_time", &filter.StartTime},
+		{"end_time", &filter.EndTime},
+	} {
+		if value := query.Get(item.name); value != "" {
+			sec, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				h.rd.JSON(w, http.StatusBadRequest, "invalid "+item.name)
+				return
+			}
+			*item.t = time.Unix(sec, 0)
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			h.rd.JSON(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		filter.Limit = limit
+	}
+	h.rd.JSON(w, http.StatusOK, h.svr.GetAuditor().Query(filter))
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/componentconfig"
+	"github.com/unrolled/render"
+)
+
+type componentConfigHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newComponentConfigHandler(svr *server.Server, rd *render.Render) *componentConfigHandler {
+	return &componentConfigHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+func (h *componentConfigHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetComponentConfigManager().GetAll())
+}
+
+// Get returns a layer of the configs, the layer is the global one if there is
+// no component in the path, or the component one if there is no instance.
+func (h *componentConfigHandler) Get(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	e, err := h.svr.GetComponentConfigManager().Get(vars["component"], vars["instance"])
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, e)
+}
+
+// GetMerged returns the merged config of a component or an instance.
+func (h *componentConfigHandler) GetMerged(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	e, err := h.svr.GetComponentConfigManager().GetMerged(vars["component"], vars["instance"])
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, e)
+}
+
+// Update applies the JSON merge patch in the body to a layer of the configs.
+func (h *componentConfigHandler) Update(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	patch, err := componentconfig.DecodeConfig(data)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	vars := mux.Vars(r)
+	e, err := h.svr.GetComponentConfigManager().Update(vars["component"], vars["instance"], patch)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, e)
+}
+
+// Delete clears a layer of the configs.
+func (h *componentConfigHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.svr.GetComponentConfigManager().Delete(vars["component"], vars["instance"]); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
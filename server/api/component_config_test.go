@@ -0,0 +1,101 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/componentconfig"
+	"github.com/pkg/errors"
+)
+
+var _ = Suite(&testComponentConfigSuite{})
+
+type testComponentConfigSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testComponentConfigSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/component-config", addr, apiPrefix)
+}
+
+func (s *testComponentConfigSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testComponentConfigSuite) TestComponentConfig(c *C) {
+	s.svr.GetComponentConfigManager().RegisterValidator("tikv", func(component, instance string, cfg map[string]interface{}) error {
+		if _, ok := cfg["capacity"].(string); ok {
+			return errors.New("capacity should be a number")
+		}
+		return nil
+	})
+
+	c.Assert(postJSON(s.urlPrefix+"/global", []byte(`{"log-level": "info", "raftstore": {"sync-log": true}}`)), IsNil)
+	c.Assert(postJSON(s.urlPrefix+"/components/tikv", []byte(`{"capacity": 18446744073709551615}`)), IsNil)
+	c.Assert(postJSON(s.urlPrefix+"/components/tikv/instances/127.0.0.1:20160", []byte(`{"raftstore": {"sync-log": false}}`)), IsNil)
+
+	e := &componentconfig.Entry{}
+	c.Assert(readJSONWithURL(s.urlPrefix+"/components/tikv/instances/127.0.0.1:20160/merged", e), IsNil)
+	c.Assert(e.Config, DeepEquals, map[string]interface{}{
+		"log-level": "info",
+		"capacity":  float64(18446744073709551615),
+		"raftstore": map[string]interface{}{"sync-log": false},
+	})
+	version := e.Version
+	e = &componentconfig.Entry{}
+	c.Assert(readJSONWithURL(s.urlPrefix+"/components/tikv/merged", e), IsNil)
+	c.Assert(e.Config["raftstore"], DeepEquals, map[string]interface{}{"sync-log": true})
+	c.Assert(e.Version, Less, version)
+
+	// The numbers keep the precision.
+	var raw struct {
+		Config map[string]json.RawMessage `json:"config"`
+	}
+	c.Assert(readJSONWithURL(s.urlPrefix+"/components/tikv", &raw), IsNil)
+	c.Assert(string(raw.Config["capacity"]), Equals, "18446744073709551615")
+
+	// Remove a key and a layer.
+	c.Assert(postJSON(s.urlPrefix+"/global", []byte(`{"log-level": null}`)), IsNil)
+	c.Assert(doDelete(s.urlPrefix+"/components/tikv/instances/127.0.0.1:20160"), IsNil)
+	e = &componentconfig.Entry{}
+	c.Assert(readJSONWithURL(s.urlPrefix+"/components/tikv/instances/127.0.0.1:20160/merged", e), IsNil)
+	c.Assert(e.Config, DeepEquals, map[string]interface{}{
+		"capacity":  float64(18446744073709551615),
+		"raftstore": map[string]interface{}{"sync-log": true},
+	})
+	c.Assert(e.Version, Greater, version)
+	all := &componentconfig.Configs{}
+	c.Assert(readJSONWithURL(s.urlPrefix, all), IsNil)
+	c.Assert(all.Version, Equals, e.Version)
+	c.Assert(all.Components, HasKey, "tikv")
+	c.Assert(all.Instances, HasLen, 0)
+
+	// The invalid configs are rejected.
+	err := postJSON(s.urlPrefix+"/components/tikv", []byte(`{"capacity": "1GB"}`))
+	c.Assert(err, ErrorMatches, "(?s).*capacity should be a number.*")
+	err = postJSON(s.urlPrefix+"/global", []byte(`{"raftstore.sync-log": true}`))
+	c.Assert(err, ErrorMatches, "(?s).*invalid key.*")
+	c.Assert(postJSON(s.urlPrefix+"/global", []byte(`[1]`)), NotNil)
+	c.Assert(postJSON(s.urlPrefix+"/components/tidb", []byte(`{"capacity": "1GB"}`)), IsNil)
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+	"github.com/unrolled/render"
+)
+
+// defaultConfigHistoryLimit is the number of the versions returned if the
+// limit is not specified.
+const defaultConfigHistoryLimit = 100
+
+type configHistoryHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newConfigHistoryHandler(svr *server.Server, rd *render.Render) *configHistoryHandler {
+	return &configHistoryHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+func (h *configHistoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	end, err := parseUintQuery(r, "end", 0)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := parseUintQuery(r, "limit", defaultConfigHistoryLimit)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	history, err := h.svr.GetConfigHistory(end, int(limit))
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, history)
+}
+
+func (h *configHistoryHandler) Get(w http.ResponseWriter, r *http.Request) {
+	v, ok := h.getVersion(w, mux.Vars(r)["version"])
+	if !ok {
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, v)
+}
+
+// Diff shows the changes from the base version to the version, the base is
+// the previous version by default. Base 0 means all the items are added.
+func (h *configHistoryHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	v, ok := h.getVersion(w, mux.Vars(r)["version"])
+	if !ok {
+		return
+	}
+	var old *core.ConfigVersion
+	if base := r.URL.Query().Get("base"); base != "" {
+		if base != "0" {
+			if old, ok = h.getVersion(w, base); !ok {
+				return
+			}
+		}
+	} else if v.Version > 1 {
+		if old, ok = h.getVersion(w, strconv.FormatUint(v.Version-1, 10)); !ok {
+			return
+		}
+	}
+	changes, err := core.DiffConfigVersions(old, v)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, changes)
+}
+
+func (h *configHistoryHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	v, ok := h.getVersion(w, mux.Vars(r)["version"])
+	if !ok {
+		return
+	}
+	if err := h.svr.RollbackConfig(v); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
+// getVersion loads the version, and responds the error if it fails.
+func (h *configHistoryHandler) getVersion(w http.ResponseWriter, s string) (*core.ConfigVersion, bool) {
+	version, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	v, err := h.svr.GetConfigVersion(version)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	if v == nil {
+		h.rd.JSON(w, http.StatusNotFound, server.ErrConfigVersionNotFound(version).Error())
+		return nil, false
+	}
+	return v, true
+}
+
+func parseUintQuery(r *http.Request, name string, def uint64) (uint64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+)
+
+var _ = Suite(&testConfigHistorySuite{})
+
+type testConfigHistorySuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testConfigHistorySuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testConfigHistorySuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testConfigHistorySuite) getHistory(c *C) []*core.ConfigVersion {
+	var history []*core.ConfigVersion
+	c.Assert(readJSONWithURL(fmt.Sprintf("%s/config/history", s.urlPrefix), &history), IsNil)
+	return history
+}
+
+func (s *testConfigHistorySuite) TestConfigHistory(c *C) {
+	c.Assert(s.getHistory(c), HasLen, 0)
+	limit := s.svr.GetScheduleConfig().LeaderScheduleLimit + 1
+	c.Assert(postJSON(fmt.Sprintf("%s/config", s.urlPrefix), []byte(fmt.Sprintf(`{"leader-schedule-limit": %d}`, limit))), IsNil)
+	history := s.getHistory(c)
+	c.Assert(history, HasLen, 1)
+	base := history[0].Version
+
+	c.Assert(postJSON(fmt.Sprintf("%s/config", s.urlPrefix), []byte(fmt.Sprintf(`{"leader-schedule-limit": %d}`, limit+1))), IsNil)
+	c.Assert(postJSON(fmt.Sprintf("%s/schedulers", s.urlPrefix), []byte(`{"name": "shuffle-leader-scheduler"}`)), IsNil)
+
+	history = s.getHistory(c)
+	c.Assert(history[0].Version, Equals, base+2)
+	c.Assert(history[0].Source, Equals, core.ConfigSourceAPI)
+	c.Assert(history[0].Config, IsNil)
+	c.Assert(history[1].Version, Equals, base+1)
+	var limited []*core.ConfigVersion
+	c.Assert(readJSONWithURL(fmt.Sprintf("%s/config/history?end=%d&limit=1", s.urlPrefix, base+1), &limited), IsNil)
+	c.Assert(limited, HasLen, 1)
+	c.Assert(limited[0].Version, Equals, base+1)
+
+	var v core.ConfigVersion
+	c.Assert(readJSONWithURL(fmt.Sprintf("%s/config/history/%d", s.urlPrefix, base+2), &v), IsNil)
+	c.Assert(v.SchedulerConfigs, HasKey, "shuffle-leader-scheduler")
+
+	var changes []*core.ConfigChange
+	c.Assert(readJSONWithURL(fmt.Sprintf("%s/config/history/%d/diff", s.urlPrefix, base+1), &changes), IsNil)
+	c.Assert(changes, HasLen, 1)
+	c.Assert(changes[0].Key, Equals, "schedule.leader-schedule-limit")
+	c.Assert(changes[0].Old, Equals, float64(limit))
+	c.Assert(changes[0].New, Equals, float64(limit+1))
+	c.Assert(readJSONWithURL(fmt.Sprintf("%s/config/history/%d/diff?base=%d", s.urlPrefix, base+2, base), &changes), IsNil)
+	keys := make([]string, 0, len(changes))
+	for _, change := range changes {
+		keys = append(keys, change.Key)
+	}
+	c.Assert(keys, DeepEquals, []string{"schedule.leader-schedule-limit", "schedule.schedulers-v2", "scheduler-configs.shuffle-leader-scheduler"})
+
+	// Roll back to the base version.
+	c.Assert(postJSON(fmt.Sprintf("%s/config/rollback/%d", s.urlPrefix, base), nil), IsNil)
+	c.Assert(s.svr.GetScheduleConfig().LeaderScheduleLimit, Equals, limit)
+	schedulers, err := s.svr.GetHandler().GetSchedulers()
+	c.Assert(err, IsNil)
+	for _, name := range schedulers {
+		c.Assert(name, Not(Equals), "shuffle-leader-scheduler")
+	}
+	history = s.getHistory(c)
+	c.Assert(history[0].Source, Equals, core.ConfigSourceRollback)
+	c.Assert(readJSONWithURL(fmt.Sprintf("%s/config/history/%d/diff?base=%d", s.urlPrefix, history[0].Version, base), &changes), IsNil)
+	// The default schedulers may be created by the coordinator after the base
+	// version, only their configs are changed.
+	for _, change := range changes {
+		c.Assert(strings.HasPrefix(change.Key, "scheduler-configs."), IsTrue, Commentf(change.Key))
+		c.Assert(change.Key, Not(Equals), "scheduler-configs.shuffle-leader-scheduler")
+	}
+
+	// The version does not exist.
+	status, body := requestStatusBody(c, dialClient, http.MethodGet, fmt.Sprintf("%s/config/history/%d", s.urlPrefix, 100000))
+	c.Assert(status, Equals, http.StatusNotFound)
+	var msg string
+	c.Assert(json.Unmarshal(body, &msg), IsNil)
+	c.Assert(msg, Equals, "config version 100000 not found")
+	status, _ = requestStatusBody(c, dialClient, http.MethodPost, fmt.Sprintf("%s/config/rollback/%d", s.urlPrefix, 100000))
+	c.Assert(status, Equals, http.StatusNotFound)
+	status, _ = requestStatusBody(c, dialClient, http.MethodGet, fmt.Sprintf("%s/config/history/abc/diff", s.urlPrefix))
+	c.Assert(status, Equals, http.StatusBadRequest)
+}
@@ -53,6 +53,7 @@ const (
 	memberLostPeers
 	memberLostPeersMoreThanHalf
 	memberLeaderChanged
+	memberEtcdQuota
 	tikvCap70
 	tikvCap80
 	tikvCap90
@@ -67,6 +68,7 @@ var (
 		memberLostPeers:             {modMember, levelMajor, "some PD instances is down.", "please check host load and traffic."},
 		memberLostPeersMoreThanHalf: {modMember, levelCritical, "more than half PD instances is down.", "please check host load and traffic."},
 		memberLeaderChanged:         {modMember, levelMinor, "PD cluster leader is changed.", "please check host load and traffic."},
+		memberEtcdQuota:             {modMember, levelMajor, "the etcd db of some PD instances is close to the backend quota.", "please check the etcd compaction, defragment the members or increase quota-backend-bytes."},
 		tikvCap70:                   {modTiKV, levelWarning, "some TiKV storage used more than 70%.", "please add TiKV node."},
 		tikvCap80:                   {modTiKV, levelMinor, "some TiKV storage used more than 80%.", "please add TiKV node."},
 		tikvCap90:                   {modTiKV, levelMajor, "some TiKV storage used more than 90%.", "please add TiKV node."},
@@ -153,6 +155,13 @@ func (d *diagnoseHandler) membersDiagnose(rdd *[]*Recommendation) error {
 	if float64(lenMembers)/2 < float64(lenLostMembers) {
 		*rdd = append(*rdd, diagnosePD(memberLostPeersMoreThanHalf, "", ""))
 	}
+	if alerts := d.svr.GetEtcdMaintainer().GetQuotaAlerts(); len(alerts) > 0 {
+		names := "members"
+		for _, status := range alerts {
+			names = fmt.Sprintf("%s %s(%.0f%%),", names, status.Name, status.QuotaUsage*100)
+		}
+		*rdd = append(*rdd, diagnosePD(memberEtcdQuota, names, ""))
+	}
 	return nil
 }
 
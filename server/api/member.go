@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/pd/pkg/apiutil"
 	"github.com/pingcap/pd/pkg/etcdutil"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/member"
 	"github.com/pkg/errors"
 	"github.com/unrolled/render"
 	"go.uber.org/zap"
@@ -42,13 +43,22 @@ func newMemberHandler(svr *server.Server, rd *render.Render) *memberHandler {
 	}
 }
 
+// membersResponse is the members with the db status of the etcd members.
+type membersResponse struct {
+	*pdpb.GetMembersResponse
+	EtcdDBStatus []*member.DBStatus `json:"etcd_db_status,omitempty"`
+}
+
 func (h *memberHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
 	members, err := h.getMembers()
 	if err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	h.rd.JSON(w, http.StatusOK, members)
+	h.rd.JSON(w, http.StatusOK, &membersResponse{
+		GetMembersResponse: members,
+		EtcdDBStatus:       h.svr.GetEtcdMaintainer().GetDBStatus(),
+	})
 }
 
 func (h *memberHandler) getMembers() (*pdpb.GetMembersResponse, error) {
@@ -0,0 +1,82 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/pd/pkg/apiutil"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/config"
+	"github.com/unrolled/render"
+)
+
+// newRateLimitMiddleware returns a middleware which rejects the requests
+// exceeding the rate limit of the routes with 429. The routes are identified
+// by the path templates without the prefix.
+func newRateLimitMiddleware(svr *server.Server, prefix string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			endpoint := r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					endpoint = tpl
+				}
+			}
+			endpoint = strings.TrimPrefix(endpoint, prefix)
+			release, err := svr.GetLimiter().Allow(endpoint)
+			if err != nil {
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, endpoint+": "+err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			defer release()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type rateLimitHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newRateLimitHandler(svr *server.Server, rd *render.Render) *rateLimitHandler {
+	return &rateLimitHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+func (h *rateLimitHandler) Get(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetRateLimitConfig())
+}
+
+func (h *rateLimitHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var limit config.EndpointLimit
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &limit); err != nil {
+		return
+	}
+	if limit.Endpoint == "" {
+		h.rd.JSON(w, http.StatusBadRequest, "endpoint is required")
+		return
+	}
+	if err := h.svr.SetRateLimit(limit); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/config"
+)
+
+var _ = Suite(&testRateLimitSuite{})
+
+type testRateLimitSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRateLimitSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testRateLimitSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRateLimitSuite) getStatus(c *C, url string) int {
+	resp, err := dialClient.Get(url)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func (s *testRateLimitSuite) TestRateLimit(c *C) {
+	url := fmt.Sprintf("%s/config/rate-limit", s.urlPrefix)
+	c.Assert(postJSON(url, []byte(`{"endpoint": "/api/v1/store/*", "qps": 0.001, "burst": 1}`)), IsNil)
+	c.Assert(postJSON(url, []byte(`{"qps": 1}`)), NotNil)
+	c.Assert(postJSON(url, []byte(`{"endpoint": "/api/v1/stores", "qps": -1}`)), NotNil)
+
+	var cfg config.RateLimitConfig
+	c.Assert(readJSONWithURL(url, &cfg), IsNil)
+	c.Assert(cfg.Limits, HasLen, 1)
+	c.Assert(cfg.Limits[0].Endpoint, Equals, "/api/v1/store/*")
+
+	// The route is identified by the path template.
+	c.Assert(s.getStatus(c, fmt.Sprintf("%s/store/1", s.urlPrefix)), Equals, http.StatusOK)
+	c.Assert(s.getStatus(c, fmt.Sprintf("%s/store/2", s.urlPrefix)), Equals, http.StatusTooManyRequests)
+	c.Assert(s.getStatus(c, fmt.Sprintf("%s/stores", s.urlPrefix)), Equals, http.StatusOK)
+
+	c.Assert(postJSON(url, []byte(`{"endpoint": "/api/v1/store/*"}`)), IsNil)
+	c.Assert(s.getStatus(c, fmt.Sprintf("%s/store/1", s.urlPrefix)), Equals, http.StatusOK)
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/apiutil"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/config"
+	"github.com/unrolled/render"
+	"go.uber.org/zap"
+)
+
+// rbacMiddleware denies the requests which are not allowed by the RBAC config
+// with 403. It runs before the redirector, so the requests are authorized by
+// the PD server which receives them from the clients.
+type rbacMiddleware struct {
+	svr *server.Server
+}
+
+func newRBACMiddleware(svr *server.Server) *rbacMiddleware {
+	return &rbacMiddleware{svr: svr}
+}
+
+func (m *rbacMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	// The requests redirected by the members have been authorized by them.
+	if isRedirectedByMember(m.svr, r) {
+		next(w, r)
+		return
+	}
+	user, err := m.svr.GetRBACManager().Authorize(r)
+	if err != nil {
+		log.Warn("request is denied",
+			zap.String("user", user),
+			zap.String("remote-addr", r.RemoteAddr),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Error(err))
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	next(w, r)
+}
+
+type rbacHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newRBACHandler(svr *server.Server, rd *render.Render) *rbacHandler {
+	return &rbacHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+func (h *rbacHandler) Get(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetRBACManager().GetConfig())
+}
+
+func (h *rbacHandler) Set(w http.ResponseWriter, r *http.Request) {
+	cfg := &config.RBACConfig{}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, cfg); err != nil {
+		return
+	}
+	if err := h.svr.GetRBACManager().SetConfig(cfg); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/config"
+)
+
+var _ = Suite(&testRBACSuite{})
+
+type testRBACSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRBACSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c, func(cfg *config.Config) {
+		cfg.Security.MemberCertAllowedCN = []string{"pd-server"}
+	})
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testRBACSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRBACSuite) request(c *C, method, url, token string, data []byte) int {
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(data))
+	c.Assert(err, IsNil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := dialClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func (s *testRBACSuite) TestRBAC(c *C) {
+	url := fmt.Sprintf("%s/config/rbac", s.urlPrefix)
+	cfg := &config.RBACConfig{
+		Enable:      true,
+		DefaultRole: "viewer",
+		Users:       []config.RBACUser{{Name: "pd-ctl", Token: "secret", Role: "admin"}},
+	}
+	data, err := json.Marshal(cfg)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(url, data), IsNil)
+
+	configURL := fmt.Sprintf("%s/config", s.urlPrefix)
+	c.Assert(s.request(c, "GET", configURL, "", nil), Equals, http.StatusOK)
+	c.Assert(s.request(c, "POST", configURL, "", []byte(`{"max-snapshot-count": 8}`)), Equals, http.StatusForbidden)
+	c.Assert(s.request(c, "POST", configURL, "secret", []byte(`{"max-snapshot-count": 8}`)), Equals, http.StatusOK)
+	c.Assert(s.request(c, "GET", configURL, "wrong", nil), Equals, http.StatusForbidden)
+
+	// The token is redacted.
+	var got config.RBACConfig
+	c.Assert(readJSONWithURL(url, &got), IsNil)
+	c.Assert(got.Users[0].Token, Equals, "******")
+	var all config.Config
+	c.Assert(readJSONWithURL(configURL, &all), IsNil)
+	c.Assert(all.Security.RBAC.Users[0].Token, Equals, "******")
+
+	got.Enable = false
+	data, err = json.Marshal(got)
+	c.Assert(err, IsNil)
+	c.Assert(s.request(c, "POST", url, "", data), Equals, http.StatusForbidden)
+	c.Assert(s.request(c, "POST", url, "secret", data), Equals, http.StatusOK)
+	c.Assert(s.request(c, "POST", configURL, "", []byte(`{"max-snapshot-count": 16}`)), Equals, http.StatusOK)
+	c.Assert(s.request(c, "POST", url, "", []byte(`{"default-role": "unknown"}`)), Equals, http.StatusBadRequest)
+}
+
+func (s *testRBACSuite) TestRedirectedByMember(c *C) {
+	manager := s.svr.GetRBACManager()
+	c.Assert(manager.SetConfig(&config.RBACConfig{Enable: true}), IsNil)
+	defer manager.SetConfig(&config.RBACConfig{})
+
+	serve := func(cn string) int {
+		request, err := http.NewRequest("POST", fmt.Sprintf("%s/config", s.urlPrefix), nil)
+		c.Assert(err, IsNil)
+		request.Header.Set(redirectorHeader, s.svr.Name())
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+		request.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+			VerifiedChains:   [][]*x509.Certificate{{cert}},
+		}
+		w := httptest.NewRecorder()
+		newRBACMiddleware(s.svr).ServeHTTP(w, request, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		return w.Code
+	}
+	// The requests redirected by the members are authorized by them.
+	c.Assert(serve("pd-server"), Equals, http.StatusOK)
+	c.Assert(serve("client"), Equals, http.StatusForbidden)
+}
@@ -89,6 +89,18 @@ func (h *redirector) ServeHTTP(w http.ResponseWriter, r *http.Request, next http
 	newCustomReverseProxies(urls).ServeHTTP(w, r)
 }
 
+// isRedirectedByMember checks whether the request is redirected by a member of
+// the cluster. The header alone can be set by any client, so the verified TLS
+// client certificate should be the one of the members, and the member in the
+// header should be in the member list.
+func isRedirectedByMember(s *server.Server, r *http.Request) bool {
+	name := r.Header.Get(redirectorHeader)
+	if name == "" || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return false
+	}
+	return s.IsMemberCertCN(r.TLS.PeerCertificates[0].Subject.CommonName) && s.GetMember().HasMember(name)
+}
+
 type customReverseProxies struct {
 	urls   []url.URL
 	client *http.Client
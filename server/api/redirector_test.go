@@ -14,10 +14,14 @@
 package api
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"net/http"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/config"
 )
 
 var _ = Suite(&testRedirectorSuite{})
@@ -28,7 +32,9 @@ type testRedirectorSuite struct {
 }
 
 func (s *testRedirectorSuite) SetUpSuite(c *C) {
-	_, s.servers, s.cleanup = mustNewCluster(c, 3)
+	_, s.servers, s.cleanup = mustNewCluster(c, 3, func(cfg *config.Config) {
+		cfg.Security.MemberCertAllowedCN = []string{"pd-server"}
+	})
 }
 
 func (s *testRedirectorSuite) TearDownSuite(c *C) {
@@ -77,6 +83,30 @@ func (s *testRedirectorSuite) TestNotLeader(c *C) {
 	c.Assert(resp.StatusCode, Not(Equals), http.StatusOK)
 }
 
+func (s *testRedirectorSuite) TestRedirectedByMember(c *C) {
+	svr := s.servers[0]
+	newRequest := func(name, cn string, verified bool) *http.Request {
+		request, err := http.NewRequest("POST", svr.GetAddr(), nil)
+		c.Assert(err, IsNil)
+		request.Header.Set(redirectorHeader, name)
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+		request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		if verified {
+			request.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+		}
+		return request
+	}
+	name := s.servers[1].Name()
+	c.Assert(isRedirectedByMember(svr, newRequest(name, "pd-server", true)), IsTrue)
+	c.Assert(isRedirectedByMember(svr, newRequest(name, "pd-server", false)), IsFalse)
+	c.Assert(isRedirectedByMember(svr, newRequest(name, name, true)), IsFalse)
+	c.Assert(isRedirectedByMember(svr, newRequest("unknown", "pd-server", true)), IsFalse)
+	c.Assert(isRedirectedByMember(svr, newRequest("", "pd-server", true)), IsFalse)
+	request := newRequest(name, "pd-server", true)
+	request.TLS = nil
+	c.Assert(isRedirectedByMember(svr, request), IsFalse)
+}
+
 func mustRequest(c *C, s *server.Server) *http.Response {
 	resp, err := http.Get(s.GetAddr() + apiPrefix + "/api/v1/version")
 	c.Assert(err, IsNil)
@@ -30,6 +30,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	})
 
 	router := mux.NewRouter().PathPrefix(prefix).Subrouter()
+	router.Use(newRateLimitMiddleware(svr, prefix))
 	handler := svr.GetHandler()
 
 	operatorHandler := newOperatorHandler(handler, rd)
@@ -64,6 +65,34 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	router.HandleFunc("/api/v1/config/cluster-version", confHandler.GetClusterVersion).Methods("GET")
 	router.HandleFunc("/api/v1/config/cluster-version", confHandler.SetClusterVersion).Methods("POST")
 
+	configHistoryHandler := newConfigHistoryHandler(svr, rd)
+	router.HandleFunc("/api/v1/config/history", configHistoryHandler.List).Methods("GET")
+	router.HandleFunc("/api/v1/config/history/{version}", configHistoryHandler.Get).Methods("GET")
+	router.HandleFunc("/api/v1/config/history/{version}/diff", configHistoryHandler.Diff).Methods("GET")
+	router.HandleFunc("/api/v1/config/rollback/{version}", configHistoryHandler.Rollback).Methods("POST")
+
+	rbacHandler := newRBACHandler(svr, rd)
+	router.HandleFunc("/api/v1/config/rbac", rbacHandler.Get).Methods("GET")
+	router.HandleFunc("/api/v1/config/rbac", rbacHandler.Set).Methods("POST")
+
+	rateLimitHandler := newRateLimitHandler(svr, rd)
+	router.HandleFunc("/api/v1/config/rate-limit", rateLimitHandler.Get).Methods("GET")
+	router.HandleFunc("/api/v1/config/rate-limit", rateLimitHandler.Set).Methods("POST")
+
+	componentConfigHandler := newComponentConfigHandler(svr, rd)
+	router.HandleFunc("/api/v1/component-config", componentConfigHandler.GetAll).Methods("GET")
+	router.HandleFunc("/api/v1/component-config/global", componentConfigHandler.Get).Methods("GET")
+	router.HandleFunc("/api/v1/component-config/global", componentConfigHandler.Update).Methods("POST")
+	router.HandleFunc("/api/v1/component-config/global", componentConfigHandler.Delete).Methods("DELETE")
+	router.HandleFunc("/api/v1/component-config/components/{component}", componentConfigHandler.Get).Methods("GET")
+	router.HandleFunc("/api/v1/component-config/components/{component}", componentConfigHandler.Update).Methods("POST")
+	router.HandleFunc("/api/v1/component-config/components/{component}", componentConfigHandler.Delete).Methods("DELETE")
+	router.HandleFunc("/api/v1/component-config/components/{component}/instances/{instance}", componentConfigHandler.Get).Methods("GET")
+	router.HandleFunc("/api/v1/component-config/components/{component}/instances/{instance}", componentConfigHandler.Update).Methods("POST")
+	router.HandleFunc("/api/v1/component-config/components/{component}/instances/{instance}", componentConfigHandler.Delete).Methods("DELETE")
+	router.HandleFunc("/api/v1/component-config/components/{component}/merged", componentConfigHandler.GetMerged).Methods("GET")
+	router.HandleFunc("/api/v1/component-config/components/{component}/instances/{instance}/merged", componentConfigHandler.GetMerged).Methods("GET")
+
 	storeHandler := newStoreHandler(handler, rd)
 	router.HandleFunc("/api/v1/store/{id}", storeHandler.Get).Methods("GET")
 	router.HandleFunc("/api/v1/store/{id}", storeHandler.Delete).Methods("DELETE")
@@ -76,6 +105,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	router.HandleFunc("/api/v1/stores/remove-tombstone", storesHandler.RemoveTombStone).Methods("DELETE")
 	router.HandleFunc("/api/v1/stores/limit", storesHandler.GetAllLimit).Methods("GET")
 	router.HandleFunc("/api/v1/stores/limit", storesHandler.SetAllLimit).Methods("POST")
+	router.HandleFunc("/api/v1/stores/progress", storesHandler.GetProgress).Methods("GET")
 
 	labelsHandler := newLabelsHandler(svr, rd)
 	router.HandleFunc("/api/v1/labels", labelsHandler.Get).Methods("GET")
@@ -128,12 +158,21 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 
 	statsHandler := newStatsHandler(svr, rd)
 	router.HandleFunc("/api/v1/stats/region", statsHandler.Region).Methods("GET")
+	router.HandleFunc("/api/v1/stats/label", statsHandler.Label).Methods("GET")
+
+	replicationModeHandler := newReplicationModeHandler(svr, rd)
+	router.HandleFunc("/api/v1/replication_mode/status", replicationModeHandler.GetStatus).Methods("GET")
+	router.HandleFunc("/api/v1/replication_mode/state", replicationModeHandler.SetState).Methods("POST")
 
 	trendHandler := newTrendHandler(svr, rd)
 	router.HandleFunc("/api/v1/trend", trendHandler.Handle).Methods("GET")
 
 	adminHandler := newAdminHandler(svr, rd)
 	router.HandleFunc("/api/v1/admin/cache/region/{id}", adminHandler.HandleDropCacheRegion).Methods("DELETE")
+	router.HandleFunc("/api/v1/admin/remove-failed-stores", adminHandler.RemoveFailedStores).Methods("POST")
+	router.HandleFunc("/api/v1/admin/remove-failed-stores/show", adminHandler.GetRemoveFailedStoresStatus).Methods("GET")
+	router.HandleFunc("/api/v1/admin/region-storage/check", adminHandler.CheckRegionStorage).Methods("GET")
+	router.HandleFunc("/api/v1/admin/region-storage/repair", adminHandler.RepairRegionStorage).Methods("POST")
 
 	logHanler := newlogHandler(svr, rd)
 	router.HandleFunc("/api/v1/admin/log", logHanler.Handle).Methods("POST")
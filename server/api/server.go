@@ -18,6 +18,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/pingcap/pd/server"
+	"github.com/unrolled/render"
 	"github.com/urfave/negroni"
 )
 
@@ -29,8 +30,14 @@ func NewHandler(svr *server.Server) http.Handler {
 
 	recovery := negroni.NewRecovery()
 	engine.Use(recovery)
+	engine.Use(newAuditMiddleware(svr))
+	engine.Use(newRBACMiddleware(svr))
 
 	router := mux.NewRouter()
+	// The audit records are kept by each PD server, so the query is not
+	// redirected to the leader.
+	auditHandler := newAuditHandler(svr, render.New(render.Options{IndentJSON: true}))
+	router.HandleFunc(apiPrefix+"/api/v1/admin/audit", auditHandler.List).Methods("GET")
 	router.PathPrefix(apiPrefix).Handler(negroni.New(
 		newRedirector(svr),
 		negroni.Wrap(createRouter(apiPrefix, svr)),
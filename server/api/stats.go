@@ -42,3 +42,18 @@ func (h *statsHandler) Region(w http.ResponseWriter, r *http.Request) {
 	stats := cluster.GetRegionStats([]byte(startKey), []byte(endKey))
 	h.rd.JSON(w, http.StatusOK, stats)
 }
+
+func (h *statsHandler) Label(w http.ResponseWriter, r *http.Request) {
+	cluster := h.svr.GetRaftCluster()
+	if cluster == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		h.rd.JSON(w, http.StatusBadRequest, "missing label key")
+		return
+	}
+	stats := cluster.GetStoreLabelStats(key)
+	h.rd.JSON(w, http.StatusOK, stats)
+}
@@ -176,3 +176,25 @@ func (s *testStatsSuite) TestRegionStats(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(stats, DeepEquals, stats23)
 }
+
+func (s *testStatsSuite) TestLabelStats(c *C) {
+	statsURL := s.urlPrefix + "/stats/label"
+	mustPutStore(c, s.svr, 11, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: "z1"}})
+	mustPutStore(c, s.svr, 12, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: "z1"}})
+	mustPutStore(c, s.svr, 13, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: "z2"}})
+
+	res, err := http.Get(statsURL)
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+	res.Body.Close()
+
+	var stats []*statistics.StoreLabelStats
+	err = readJSONWithURL(statsURL+"?key=zone", &stats)
+	c.Assert(err, IsNil)
+	counts := make(map[string]int)
+	for _, s := range stats {
+		counts[s.Value] = s.StoreCount
+	}
+	c.Assert(counts["z1"], Equals, 2)
+	c.Assert(counts["z2"], Equals, 1)
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/pingcap/pd/pkg/typeutil"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/statistics"
 	"github.com/unrolled/render"
 )
@@ -46,6 +47,10 @@ type trendStore struct {
 	HotWriteRegionFlows []float64 `json:"hot_write_region_flows"`
 	HotReadFlow         float64   `json:"hot_read_flow"`
 	HotReadRegionFlows  []float64 `json:"hot_read_region_flows"`
+
+	// Trend is the saved time series of the store, it is only returned when
+	// the time range is specified.
+	Trend []*core.StoreTrendPoint `json:"trend,omitempty"`
 }
 
 type trendHistory struct {
@@ -77,7 +82,8 @@ func newTrendHandler(s *server.Server, rd *render.Render) *trendHandler {
 
 func (h *trendHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	var from time.Time
-	if fromStr := r.URL.Query()["from"]; len(fromStr) > 0 {
+	fromStr := r.URL.Query()["from"]
+	if len(fromStr) > 0 {
 		fromInt, err := strconv.ParseInt(fromStr[0], 10, 64)
 		if err != nil {
 			h.rd.JSON(w, http.StatusBadRequest, err.Error())
@@ -85,12 +91,30 @@ func (h *trendHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		}
 		from = time.Unix(fromInt, 0)
 	}
+	to := time.Now()
+	if toStr := r.URL.Query()["to"]; len(toStr) > 0 {
+		toInt, err := strconv.ParseInt(toStr[0], 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		to = time.Unix(toInt, 0)
+	}
 
 	stores, err := h.getTrendStores()
 	if err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if len(fromStr) > 0 {
+		for i := range stores {
+			stores[i].Trend, err = h.GetStoreTrend(stores[i].ID, from, to)
+			if err != nil {
+				h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+	}
 
 	history, err := h.getTrendHistory(from)
 	if err != nil {
@@ -0,0 +1,184 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/pd/server/config"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Results of the audited operations.
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+)
+
+// Record is an audited operation.
+type Record struct {
+	Time       time.Time `json:"time"`
+	RemoteAddr string    `json:"remote_addr"`
+	// User is the common name of the TLS client certificate.
+	User   string `json:"user,omitempty"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body,omitempty"`
+	// BodyTruncated means the body exceeds the size limit and is truncated.
+	BodyTruncated bool          `json:"body_truncated,omitempty"`
+	StatusCode    int           `json:"status_code"`
+	Result        string        `json:"result"`
+	Duration      time.Duration `json:"duration"`
+	// Forwarded means the request claims to be redirected by another PD
+	// server, but the sender is not verified as a member of the cluster.
+	Forwarded bool `json:"forwarded,omitempty"`
+}
+
+// Filter is used to query the records.
+type Filter struct {
+	// StartTime and EndTime are ignored if they are zero.
+	StartTime time.Time
+	EndTime   time.Time
+	User      string
+	Method    string
+	// Limit is the max number of the latest records to return, 0 means no limit.
+	Limit int
+}
+
+func (f *Filter) match(r *Record) bool {
+	if !f.StartTime.IsZero() && r.Time.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && !r.Time.Before(f.EndTime) {
+		return false
+	}
+	if f.User != "" && r.User != f.User {
+		return false
+	}
+	if f.Method != "" && r.Method != f.Method {
+		return false
+	}
+	return true
+}
+
+// Auditor keeps the latest records in memory, and writes all records to the
+// rotating file if it is configured.
+type Auditor struct {
+	cfg config.AuditConfig
+
+	mu      sync.RWMutex
+	records []*Record
+	// next is the index in records to put the next record.
+	next int
+	full bool
+	file io.WriteCloser
+}
+
+// NewAuditor creates an Auditor.
+func NewAuditor(cfg config.AuditConfig) (*Auditor, error) {
+	a := &Auditor{
+		cfg:     cfg,
+		records: make([]*Record, cfg.MaxRecords),
+	}
+	if cfg.File.Filename != "" {
+		if st, err := os.Stat(cfg.File.Filename); err == nil && st.IsDir() {
+			return nil, errors.New("can't use directory as audit file name")
+		}
+		a.file = &lumberjack.Logger{
+			Filename:   cfg.File.Filename,
+			MaxSize:    cfg.File.MaxSize,
+			MaxBackups: cfg.File.MaxBackups,
+			MaxAge:     cfg.File.MaxDays,
+			LocalTime:  true,
+		}
+	}
+	return a, nil
+}
+
+// IsEnabled returns if the auditor is enabled.
+func (a *Auditor) IsEnabled() bool {
+	return a != nil && a.cfg.Enable
+}
+
+// MaxBodySize returns the max size of the body to record.
+func (a *Auditor) MaxBodySize() int {
+	return int(a.cfg.MaxBodySize)
+}
+
+// Append adds a record.
+func (a *Auditor) Append(r *Record) {
+	auditCounter.WithLabelValues(r.Method, r.Result).Inc()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.records) > 0 {
+		a.records[a.next] = r
+		a.next = (a.next + 1) % len(a.records)
+		if a.next == 0 {
+			a.full = true
+		}
+	}
+	if a.file == nil {
+		return
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		log.Error("failed to marshal the audit record", zap.Error(err))
+		return
+	}
+	if _, err := a.file.Write(append(data, '\n')); err != nil {
+		log.Error("failed to write the audit record", zap.Error(err))
+	}
+}
+
+// Query returns the records matching the filter, the latest one is the first.
+func (a *Auditor) Query(f *Filter) []*Record {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	count := a.next
+	if a.full {
+		count = len(a.records)
+	}
+	res := make([]*Record, 0)
+	for i := 1; i <= count; i++ {
+		r := a.records[(a.next-i+len(a.records))%len(a.records)]
+		if !f.match(r) {
+			continue
+		}
+		res = append(res, r)
+		if f.Limit > 0 && len(res) >= f.Limit {
+			break
+		}
+	}
+	return res
+}
+
+// Close closes the audit file.
+func (a *Auditor) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return errors.WithStack(err)
+}
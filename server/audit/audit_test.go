@@ -0,0 +1,92 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server/config"
+)
+
+func TestAudit(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testAuditSuite{})
+
+type testAuditSuite struct{}
+
+func (s *testAuditSuite) TestQuery(c *C) {
+	a, err := NewAuditor(config.AuditConfig{Enable: true, MaxRecords: 3})
+	c.Assert(err, IsNil)
+	c.Assert(a.IsEnabled(), IsTrue)
+	c.Assert((*Auditor)(nil).IsEnabled(), IsFalse)
+
+	start := time.Unix(1000, 0)
+	for i, method := range []string{"POST", "DELETE", "POST", "PUT"} {
+		a.Append(&Record{
+			Time:   start.Add(time.Duration(i) * time.Second),
+			User:   []string{"usera", "userb"}[i%2],
+			Method: method,
+		})
+	}
+	methods := func(records []*Record) string {
+		var res []string
+		for _, r := range records {
+			res = append(res, r.Method)
+		}
+		return strings.Join(res, ",")
+	}
+	// The first record is overwritten.
+	c.Assert(methods(a.Query(&Filter{})), Equals, "PUT,POST,DELETE")
+	c.Assert(methods(a.Query(&Filter{Limit: 2})), Equals, "PUT,POST")
+	c.Assert(methods(a.Query(&Filter{Method: "POST"})), Equals, "POST")
+	c.Assert(methods(a.Query(&Filter{User: "userb"})), Equals, "PUT,DELETE")
+	c.Assert(methods(a.Query(&Filter{StartTime: start.Add(2 * time.Second)})), Equals, "PUT,POST")
+	c.Assert(methods(a.Query(&Filter{EndTime: start.Add(2 * time.Second)})), Equals, "DELETE")
+	c.Assert(a.Close(), IsNil)
+}
+
+func (s *testAuditSuite) TestFile(c *C) {
+	dir, err := ioutil.TempDir("", "audit")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+
+	cfg := config.AuditConfig{Enable: true}
+	cfg.File.Filename = filepath.Join(dir, "audit.log")
+	cfg.File.MaxSize = 1
+	a, err := NewAuditor(cfg)
+	c.Assert(err, IsNil)
+	// The records are still written to the file if none is kept in memory.
+	a.Append(&Record{Method: "POST", Path: "/pd/api/v1/config", Result: ResultSuccess})
+	c.Assert(a.Query(&Filter{}), HasLen, 0)
+	c.Assert(a.Close(), IsNil)
+
+	data, err := ioutil.ReadFile(cfg.File.Filename)
+	c.Assert(err, IsNil)
+	var r Record
+	c.Assert(json.Unmarshal(data, &r), IsNil)
+	c.Assert(r.Path, Equals, "/pd/api/v1/config")
+
+	cfg.File.Filename = dir
+	_, err = NewAuditor(cfg)
+	c.Assert(err, NotNil)
+}
@@ -14,8 +14,6 @@
 package server
 
 import (
-	"fmt"
-	"path"
 	"sync"
 	"time"
 
@@ -26,13 +24,16 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/pkg/logutil"
 	"github.com/pingcap/pd/pkg/typeutil"
 	"github.com/pingcap/pd/server/config"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/id"
+	"github.com/pingcap/pd/server/kv"
 	"github.com/pingcap/pd/server/namespace"
 	syncer "github.com/pingcap/pd/server/region_syncer"
+	"github.com/pingcap/pd/server/replication"
 	"github.com/pingcap/pd/server/schedule"
 	"github.com/pingcap/pd/server/schedule/checker"
 	"github.com/pingcap/pd/server/statistics"
@@ -42,6 +43,7 @@ import (
 
 var (
 	backgroundJobInterval      = time.Minute
+	maxSlowStoreHeartbeatGap   = 5 * time.Minute
 	defaultChangedRegionsLimit = 10000
 )
 
@@ -76,6 +78,9 @@ type RaftCluster struct {
 	regionStats     *statistics.RegionStatistics
 	storesStats     *statistics.StoresStats
 	hotSpotCache    *statistics.HotCache
+	storesProgress  *statistics.StoreProgressManager
+	failedStores    *failedStoresRemover
+	replicationMode *replication.ModeManager
 
 	coordinator *coordinator
 
@@ -150,6 +155,8 @@ func (c *RaftCluster) initCluster(id id.Allocator, opt *config.ScheduleOption, s
 	c.prepareChecker = newPrepareChecker()
 	c.changedRegions = make(chan *core.RegionInfo, defaultChangedRegionsLimit)
 	c.hotSpotCache = statistics.NewHotCache()
+	c.storesProgress = statistics.NewStoreProgressManager()
+	c.failedStores = newFailedStoresRemover(c)
 }
 
 func (c *RaftCluster) start() error {
@@ -175,12 +182,18 @@ func (c *RaftCluster) start() error {
 		return err
 	}
 
+	c.replicationMode, err = replication.NewReplicationModeManager(c.s.cfg.ReplicationMode, c.storage, cluster, c.s.idAllocator)
+	if err != nil {
+		return err
+	}
+
 	c.coordinator = newCoordinator(cluster, c.s.hbStreams, c.s.classifier)
 	c.regionStats = statistics.NewRegionStatistics(c.s.scheduleOpt, c.s.classifier)
 	c.quit = make(chan struct{})
 
-	c.wg.Add(3)
+	c.wg.Add(4)
 	go c.runCoordinator()
+	go c.runReplicationMode()
 	failpoint.Inject("highFrequencyClusterJobs", func() {
 		backgroundJobInterval = 100 * time.Microsecond
 	})
@@ -242,7 +255,9 @@ func (c *RaftCluster) runBackgroundJobs(interval time.Duration) {
 			return
 		case <-ticker.C:
 			c.checkStores()
+			c.storesProgress.Observe(c.GetStores(), time.Now())
 			c.collectMetrics()
+			c.collectStoreTrend()
 			c.coordinator.opController.PruneHistory()
 		}
 	}
@@ -260,6 +275,11 @@ func (c *RaftCluster) runCoordinator() {
 	log.Info("coordinator is stopping")
 }
 
+func (c *RaftCluster) runReplicationMode() {
+	defer c.wg.Done()
+	c.replicationMode.Run(c.quit)
+}
+
 func (c *RaftCluster) syncRegions() {
 	defer logutil.LogPanic()
 	defer c.wg.Done()
@@ -319,10 +339,23 @@ func (c *RaftCluster) handleStoreHeartbeat(stats *pdpb.StoreStats) error {
 	if store == nil {
 		return core.NewStoreNotFoundErr(storeID)
 	}
-	newStore := store.Clone(core.SetStoreStats(stats), core.SetLastHeartbeatTS(time.Now()))
+	now := clock.Now()
+	if last := store.GetLastHeartbeatTS(); !last.IsZero() {
+		// A long gap is usually caused by restarting or network partition
+		// rather than a slow store, so it is ignored.
+		if gap := now.Sub(last); gap < maxSlowStoreHeartbeatGap {
+			c.storesStats.ObserveHeartbeatGap(storeID, gap.Seconds())
+		}
+	}
+	newStore := store.Clone(
+		core.SetStoreStats(stats),
+		core.SetLastHeartbeatTS(now),
+		core.SetSlowScore(c.storesStats.GetSlowScore(storeID)),
+	)
 	c.core.PutStore(newStore)
 	c.storesStats.Observe(newStore.GetID(), newStore.GetStoreStats())
 	c.storesStats.UpdateTotalBytesRate(c.core.GetStores)
+	c.failedStores.onStoreHeartbeat(storeID)
 	return nil
 }
 
@@ -490,22 +523,6 @@ func (c *RaftCluster) updateStoreStatusLocked(id uint64) {
 	c.core.UpdateStoreStatus(id, leaderCount, regionCount, pendingPeerCount, leaderRegionSize, regionSize)
 }
 
-func makeStoreKey(clusterRootPath string, storeID uint64) string {
-	return path.Join(clusterRootPath, "s", fmt.Sprintf("%020d", storeID))
-}
-
-func makeRegionKey(clusterRootPath string, regionID uint64) string {
-	return path.Join(clusterRootPath, "r", fmt.Sprintf("%020d", regionID))
-}
-
-func makeRaftClusterStatusPrefix(clusterRootPath string) string {
-	return path.Join(clusterRootPath, "status")
-}
-
-func makeBootstrapTimeKey(clusterRootPath string) string {
-	return path.Join(makeRaftClusterStatusPrefix(clusterRootPath), "raft_bootstrap_time")
-}
-
 func checkBootstrapRequest(clusterID uint64, req *pdpb.BootstrapRequest) error {
 	// TODO: do more check for request fields validation.
 
@@ -678,6 +695,64 @@ func (c *RaftCluster) GetRegionStats(startKey, endKey []byte) *statistics.Region
 	return statistics.GetRegionStats(c.core.ScanRange(startKey, endKey, -1))
 }
 
+// GetStoreLabelStats returns the statistics of the stores grouped by the label key.
+func (c *RaftCluster) GetStoreLabelStats(key string) []*statistics.StoreLabelStats {
+	c.RLock()
+	defer c.RUnlock()
+	return statistics.GetStoreLabelStats(c.GetStores(), c.storesStats, key)
+}
+
+// RemoveFailedStores starts to remove the peers on the failed stores from all
+// regions.
+func (c *RaftCluster) RemoveFailedStores(storeIDs []uint64, timeout time.Duration) error {
+	c.RLock()
+	quit := c.quit
+	c.RUnlock()
+	return c.failedStores.removeFailedStores(storeIDs, timeout, quit)
+}
+
+// GetRemoveFailedStoresStatus returns the status of removing the failed stores.
+func (c *RaftCluster) GetRemoveFailedStoresStatus() *RemoveFailedStoresStatus {
+	return c.failedStores.getStatus()
+}
+
+// CheckRegionStorage checks the regions saved in etcd and the region storage
+// against the regions in memory. If repair is true, the stale regions are
+// removed from the storages.
+func (c *RaftCluster) CheckRegionStorage(repair bool) ([]*core.RegionStorageReport, error) {
+	report, err := core.CheckRegions("etcd", c.storage.Base, c.core, repair)
+	if err != nil {
+		return nil, err
+	}
+	reports := []*core.RegionStorageReport{report}
+	if regionStorage := c.storage.GetRegionStorage(); regionStorage != nil {
+		// Flush the dirty regions first, or they are reported as stale.
+		if err := regionStorage.FlushRegion(); err != nil {
+			return nil, err
+		}
+		report, err := core.CheckRegions("region-storage", regionStorage, c.core, repair)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// GetReplicationMode returns the replication mode manager.
+func (c *RaftCluster) GetReplicationMode() *replication.ModeManager {
+	c.RLock()
+	defer c.RUnlock()
+	return c.replicationMode
+}
+
+// GetStoresProgress returns the progress of the offline stores and the new stores.
+func (c *RaftCluster) GetStoresProgress() []*statistics.StoreProgress {
+	c.RLock()
+	defer c.RUnlock()
+	return c.storesProgress.GetProgresses()
+}
+
 // GetStoresStats returns stores' statistics from cluster.
 func (c *RaftCluster) GetStoresStats() *statistics.StoresStats {
 	c.RLock()
@@ -905,21 +980,18 @@ func (c *RaftCluster) SetStoreWeight(storeID uint64, leaderWeight, regionWeight
 		return core.NewStoreNotFoundErr(storeID)
 	}
 
-	if err := c.s.storage.SaveStoreWeight(storeID, leaderWeight, regionWeight); err != nil {
-		return err
-	}
-
 	newStore := store.Clone(
 		core.SetLeaderWeight(leaderWeight),
 		core.SetRegionWeight(regionWeight),
 	)
 
-	return c.putStoreLocked(newStore)
+	// The weight is saved in the same transaction as the store.
+	return c.putStoreLocked(newStore, c.s.storage.SaveStoreWeightOps(storeID, leaderWeight, regionWeight)...)
 }
 
-func (c *RaftCluster) putStoreLocked(store *core.StoreInfo) error {
+func (c *RaftCluster) putStoreLocked(store *core.StoreInfo, ops ...kv.Op) error {
 	if c.storage != nil {
-		if err := c.storage.SaveStore(store.GetMeta()); err != nil {
+		if err := c.storage.SaveStore(store.GetMeta(), ops...); err != nil {
 			return err
 		}
 	}
@@ -998,6 +1070,11 @@ func (c *RaftCluster) deleteStoreLocked(store *core.StoreInfo) error {
 		if err := c.storage.DeleteStore(store.GetMeta()); err != nil {
 			return err
 		}
+		if trendStorage := c.storage.GetTrendStorage(); trendStorage != nil {
+			if err := trendStorage.DeleteStoreTrend(store.GetID()); err != nil {
+				return err
+			}
+		}
 	}
 	c.core.DeleteStore(store)
 	c.storesStats.RemoveRollingStoreStats(store.GetID())
@@ -1018,6 +1095,49 @@ func (c *RaftCluster) collectMetrics() {
 	c.collectHealthStatus()
 }
 
+// collectStoreTrend saves a sample of every store into the trend storage.
+func (c *RaftCluster) collectStoreTrend() {
+	if c.storage == nil || c.storage.GetTrendStorage() == nil {
+		return
+	}
+	trendStorage := c.storage.GetTrendStorage()
+	now := time.Now()
+	for _, store := range c.GetStores() {
+		if store.IsTombstone() {
+			continue
+		}
+		p := &core.StoreTrendPoint{
+			Timestamp:   now.Unix(),
+			Capacity:    store.GetCapacity(),
+			Available:   store.GetAvailable(),
+			UsedSize:    store.GetUsedSize(),
+			RegionCount: store.GetRegionCount(),
+			LeaderCount: store.GetLeaderCount(),
+		}
+		if rolling := c.storesStats.GetRollingStoreStats(store.GetID()); rolling != nil {
+			p.BytesWriteRate, p.BytesReadRate = rolling.GetBytesRate()
+			p.KeysWriteRate, p.KeysReadRate = rolling.GetKeysWriteRate(), rolling.GetKeysReadRate()
+		}
+		if end := store.GetStoreStats().GetInterval().GetEndTimestamp(); end > 0 {
+			latency := store.GetLastHeartbeatTS().Sub(time.Unix(int64(end), 0))
+			if latency > 0 {
+				p.HeartbeatLatency = float64(latency) / float64(time.Millisecond)
+			}
+		}
+		if err := trendStorage.AppendStoreTrend(store.GetID(), p); err != nil {
+			log.Error("failed to save store trend", zap.Uint64("store-id", store.GetID()), zap.Error(err))
+		}
+	}
+}
+
+// GetStoreTrend returns the saved time series of the store in [start, end).
+func (c *RaftCluster) GetStoreTrend(storeID uint64, start, end time.Time) ([]*core.StoreTrendPoint, error) {
+	if c.storage == nil || c.storage.GetTrendStorage() == nil {
+		return nil, nil
+	}
+	return c.storage.GetTrendStorage().LoadStoreTrend(storeID, start, end)
+}
+
 func (c *RaftCluster) resetMetrics() {
 	statsMap := statistics.NewStoreStatisticsMap(c.opt, c.GetNamespaceClassifier())
 	statsMap.Reset()
@@ -1035,6 +1155,7 @@ func (c *RaftCluster) collectClusterMetrics() {
 	}
 	c.regionStats.Collect()
 	c.labelLevelStats.Collect()
+	c.storesProgress.Collect()
 	// collect hot cache metrics
 	c.hotSpotCache.CollectMetrics(c.storesStats)
 }
@@ -1047,6 +1168,7 @@ func (c *RaftCluster) resetClusterMetrics() {
 	}
 	c.regionStats.Reset()
 	c.labelLevelStats.Reset()
+	c.storesProgress.Reset()
 	// reset hot cache metrics
 	c.hotSpotCache.ResetMetrics()
 }
@@ -1425,14 +1547,14 @@ type prepareChecker struct {
 
 func newPrepareChecker() *prepareChecker {
 	return &prepareChecker{
-		start:           time.Now(),
+		start:           clock.Now(),
 		reactiveRegions: make(map[uint64]int),
 	}
 }
 
 // Before starting up the scheduler, we need to take the proportion of the regions on each store into consideration.
 func (checker *prepareChecker) check(c *RaftCluster) bool {
-	if checker.isPrepared || time.Since(checker.start) > collectTimeout {
+	if checker.isPrepared || clock.Since(checker.start) > collectTimeout {
 		return true
 	}
 	// The number of active regions should be more than total region of all stores * collectFactor
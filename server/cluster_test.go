@@ -31,6 +31,8 @@ import (
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/kv"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -57,6 +59,18 @@ func (kv *testErrorKV) Save(key, value string) error {
 	return errors.New("save failed")
 }
 
+func (kv *testErrorKV) Load(key string) (string, error) {
+	return "", nil
+}
+
+func (kv *testErrorKV) LoadRange(key, endKey string, limit int) ([]string, []string, error) {
+	return nil, nil, nil
+}
+
+func (kv *testErrorKV) Txn(conds []kv.Cmp, ops ...kv.Op) error {
+	return errors.New("save failed")
+}
+
 func (s *baseCluster) allocID(c *C) uint64 {
 	id, err := s.svr.idAllocator.Alloc()
 	c.Assert(err, IsNil)
@@ -482,6 +496,72 @@ func (s *testClusterSuite) TestGetPDMembers(c *C) {
 	c.Assert(len(resp.GetMembers()), Not(Equals), 0)
 }
 
+func (s *testClusterSuite) TestRateLimit(c *C) {
+	var err error
+	var cleanup func()
+	_, s.svr, cleanup, err = NewTestServer(c)
+	defer cleanup()
+	c.Assert(err, IsNil)
+	mustWaitLeader(c, []*Server{s.svr})
+	s.grpcPDClient = testutil.MustNewGrpcClient(c, s.svr.GetAddr())
+	req := &pdpb.ScanRegionsRequest{
+		Header: testutil.NewRequestHeader(s.svr.ClusterID()),
+	}
+
+	c.Assert(s.svr.SetRateLimit(config.EndpointLimit{Endpoint: "ScanRegions", QPS: 0.001, Burst: 1}), IsNil)
+	_, err = s.grpcPDClient.ScanRegions(context.Background(), req)
+	c.Assert(err, IsNil)
+	_, err = s.grpcPDClient.ScanRegions(context.Background(), req)
+	c.Assert(status.Code(err), Equals, codes.ResourceExhausted)
+
+	// The limit is persisted.
+	opt := config.NewScheduleOption(s.svr.cfg)
+	c.Assert(opt.Reload(s.svr.storage), IsNil)
+	c.Assert(opt.LoadRateLimitConfig().Limits, HasLen, 1)
+
+	c.Assert(s.svr.SetRateLimit(config.EndpointLimit{Endpoint: "ScanRegions"}), IsNil)
+	c.Assert(s.svr.GetRateLimitConfig().Limits, HasLen, 0)
+	_, err = s.grpcPDClient.ScanRegions(context.Background(), req)
+	c.Assert(err, IsNil)
+}
+
+func (s *testClusterSuite) TestRateLimitExemption(c *C) {
+	var err error
+	var cleanup func()
+	_, s.svr, cleanup, err = NewTestServer(c)
+	defer cleanup()
+	c.Assert(err, IsNil)
+	mustWaitLeader(c, []*Server{s.svr})
+	s.grpcPDClient = testutil.MustNewGrpcClient(c, s.svr.GetAddr())
+	header := testutil.NewRequestHeader(s.svr.ClusterID())
+
+	// The limit matches all methods, but the heartbeats, TSO and ID allocation
+	// are never limited.
+	c.Assert(s.svr.SetRateLimit(config.EndpointLimit{Endpoint: "*", QPS: 0.001, Burst: 1}), IsNil)
+	tsoClient, err := s.grpcPDClient.Tso(context.Background())
+	c.Assert(err, IsNil)
+	defer tsoClient.CloseSend()
+	for i := 0; i < 3; i++ {
+		_, err = s.grpcPDClient.AllocID(context.Background(), &pdpb.AllocIDRequest{Header: header})
+		c.Assert(err, IsNil)
+		_, err = s.grpcPDClient.StoreHeartbeat(context.Background(), &pdpb.StoreHeartbeatRequest{
+			Header: header,
+			Stats:  &pdpb.StoreStats{StoreId: 1},
+		})
+		c.Assert(err, IsNil)
+		c.Assert(tsoClient.Send(&pdpb.TsoRequest{Header: header, Count: 1}), IsNil)
+		_, err = tsoClient.Recv()
+		c.Assert(err, IsNil)
+	}
+
+	// The exempted requests do not take the quota of the expensive ones.
+	req := &pdpb.ScanRegionsRequest{Header: header}
+	_, err = s.grpcPDClient.ScanRegions(context.Background(), req)
+	c.Assert(err, IsNil)
+	_, err = s.grpcPDClient.ScanRegions(context.Background(), req)
+	c.Assert(status.Code(err), Equals, codes.ResourceExhausted)
+}
+
 func (s *testClusterSuite) TestStoreVersionChange(c *C) {
 	var err error
 	var cleanup func()
@@ -0,0 +1,101 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pingcap/pd/pkg/configpb"
+	"github.com/pingcap/pd/server/componentconfig"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// componentConfigCheckInterval is the interval to check the leadership when
+// watching the component configs, the stream is closed if the server is not
+// the leader any more.
+const componentConfigCheckInterval = time.Second
+
+var _ configpb.ConfigServer = (*Server)(nil)
+
+// GetComponentConfig implements gRPC ConfigServer.
+func (s *Server) GetComponentConfig(ctx context.Context, request *configpb.GetComponentConfigRequest) (*configpb.GetComponentConfigResponse, error) {
+	if err := s.validateRequest(request.GetHeader()); err != nil {
+		return nil, err
+	}
+	release, err := s.limit("GetComponentConfig")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	e, cfg, err := s.getMergedComponentConfig(request.GetComponent(), request.GetComponentId())
+	if err != nil {
+		return nil, err
+	}
+	return &configpb.GetComponentConfigResponse{
+		Header:  s.header(),
+		Version: e.Version,
+		Config:  cfg,
+	}, nil
+}
+
+// WatchComponentConfig implements gRPC ConfigServer.
+func (s *Server) WatchComponentConfig(request *configpb.WatchComponentConfigRequest, stream configpb.Config_WatchComponentConfigServer) error {
+	ticker := time.NewTicker(componentConfigCheckInterval)
+	defer ticker.Stop()
+	version := request.GetVersion()
+	for {
+		// Get the channel first to not miss the changes.
+		changed := s.componentConfig.Watch()
+		if err := s.validateRequest(request.GetHeader()); err != nil {
+			return err
+		}
+		e, cfg, err := s.getMergedComponentConfig(request.GetComponent(), request.GetComponentId())
+		if err != nil {
+			return err
+		}
+		if e.Version > version {
+			err = stream.Send(&configpb.WatchComponentConfigResponse{
+				Header:  s.header(),
+				Version: e.Version,
+				Config:  cfg,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			version = e.Version
+		}
+		select {
+		case <-changed:
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *Server) getMergedComponentConfig(component, instance string) (*componentconfig.Entry, string, error) {
+	e, err := s.componentConfig.GetMerged(component, instance)
+	if err != nil {
+		return nil, "", status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	cfg, err := json.Marshal(e.Config)
+	if err != nil {
+		return nil, "", status.Errorf(codes.Unknown, err.Error())
+	}
+	return e, string(cfg), nil
+}
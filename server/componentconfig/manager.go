@@ -0,0 +1,506 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package componentconfig hosts the configs of the components, such as TiKV
+// and TiDB, so that they can be changed in one place.
+//
+// The configs are JSON objects in 3 layers: the global layer for all the
+// components, the component layer for all the instances of a component, and
+// the instance layer. The config of an instance merges the 3 layers, the later
+// ones take precedence.
+package componentconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	globalKey    = "global"
+	componentKey = "components"
+	instanceKey  = "instances"
+)
+
+var errStorageNotReady = errors.New("storage is not ready")
+
+// Entry is a layer of the configs, or the merged config of an instance.
+type Entry struct {
+	// Version is the version when the layer is changed last time. For the
+	// merged config, it is the max version of the layers.
+	Version uint64                 `json:"version"`
+	Config  map[string]interface{} `json:"config"`
+}
+
+func (e *Entry) clone() *Entry {
+	return &Entry{Version: e.Version, Config: cloneObject(e.Config)}
+}
+
+// DecodeEntry decodes the entry in JSON, the numbers in the config are decoded
+// as json.Number to keep the precision.
+func DecodeEntry(data []byte) (*Entry, error) {
+	e := &Entry{}
+	if err := decodeJSON(data, e); err != nil {
+		return nil, err
+	}
+	if e.Config == nil {
+		e.Config = make(map[string]interface{})
+	}
+	return e, nil
+}
+
+// DecodeConfig decodes the config or the patch in JSON like DecodeEntry.
+func DecodeConfig(data []byte) (map[string]interface{}, error) {
+	var cfg map[string]interface{}
+	if err := decodeJSON(data, &cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func decodeJSON(data []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return errors.WithStack(d.Decode(v))
+}
+
+// Configs are all the layers of the configs.
+type Configs struct {
+	Version    uint64                       `json:"version"`
+	Global     *Entry                       `json:"global"`
+	Components map[string]*Entry            `json:"components"`
+	Instances  map[string]map[string]*Entry `json:"instances"`
+}
+
+// Validator checks the merged config of a component or an instance before the
+// change is saved. The instance is empty for the merged config of the global
+// and the component layers. The numbers in the config are json.Number.
+type Validator func(component, instance string, cfg map[string]interface{}) error
+
+// Manager manages the configs of the components. Each change of a layer is a
+// new version of the configs.
+type Manager struct {
+	mu         sync.RWMutex
+	storage    *core.Storage
+	version    uint64
+	global     *Entry
+	components map[string]*Entry
+	instances  map[string]map[string]*Entry
+	validators map[string][]Validator
+	// changed is closed and replaced when the configs are changed.
+	changed chan struct{}
+}
+
+// NewManager creates a Manager.
+func NewManager() *Manager {
+	return &Manager{
+		global:     &Entry{Config: make(map[string]interface{})},
+		components: make(map[string]*Entry),
+		instances:  make(map[string]map[string]*Entry),
+		validators: make(map[string][]Validator),
+		changed:    make(chan struct{}),
+	}
+}
+
+// SetStorage sets the storage to save the configs.
+func (m *Manager) SetStorage(storage *core.Storage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.storage = storage
+}
+
+// RegisterValidator adds a validator of the component.
+func (m *Manager) RegisterValidator(component string, v Validator) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.validators[component] = append(m.validators[component], v)
+}
+
+// Reload loads the configs from the storage, it should be called when the
+// server becomes the leader.
+func (m *Manager) Reload() error {
+	m.mu.RLock()
+	storage := m.storage
+	m.mu.RUnlock()
+	if storage == nil {
+		return errStorageNotReady
+	}
+	global := &Entry{Config: make(map[string]interface{})}
+	components := make(map[string]*Entry)
+	instances := make(map[string]map[string]*Entry)
+	version, err := storage.LoadComponentConfigs(func(key, value string) error {
+		e, err := DecodeEntry([]byte(value))
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(key, "/")
+		switch {
+		case len(parts) == 1 && parts[0] == globalKey:
+			global = e
+		case len(parts) == 2 && parts[0] == componentKey:
+			components[parts[1]] = e
+		case len(parts) == 3 && parts[0] == instanceKey:
+			if instances[parts[1]] == nil {
+				instances[parts[1]] = make(map[string]*Entry)
+			}
+			instances[parts[1]][parts[2]] = e
+		default:
+			log.Warn("unknown component config key", zap.String("key", key))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.version, m.global, m.components, m.instances = version, global, components, instances
+	m.notify()
+	log.Info("component configs are loaded", zap.Uint64("version", version))
+	return nil
+}
+
+// Watch returns a channel which is closed when the configs are changed.
+func (m *Manager) Watch() <-chan struct{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.changed
+}
+
+func (m *Manager) notify() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// GetAll returns all the layers of the configs, the empty ones are omitted.
+func (m *Manager) GetAll() *Configs {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cfgs := &Configs{
+		Version:    m.version,
+		Global:     m.global.clone(),
+		Components: make(map[string]*Entry),
+		Instances:  make(map[string]map[string]*Entry),
+	}
+	for component, e := range m.components {
+		if len(e.Config) > 0 {
+			cfgs.Components[component] = e.clone()
+		}
+	}
+	for component, instances := range m.instances {
+		for instance, e := range instances {
+			if len(e.Config) == 0 {
+				continue
+			}
+			if cfgs.Instances[component] == nil {
+				cfgs.Instances[component] = make(map[string]*Entry)
+			}
+			cfgs.Instances[component][instance] = e.clone()
+		}
+	}
+	return cfgs
+}
+
+// Get returns a layer of the configs. The component is empty for the global
+// layer, and the instance is empty for the component layer.
+func (m *Manager) Get(component, instance string) (*Entry, error) {
+	if err := checkScope(component, instance); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if e := m.getLocked(component, instance); e != nil {
+		return e.clone(), nil
+	}
+	return &Entry{Config: make(map[string]interface{})}, nil
+}
+
+func (m *Manager) getLocked(component, instance string) *Entry {
+	switch {
+	case component == "":
+		return m.global
+	case instance == "":
+		return m.components[component]
+	default:
+		return m.instances[component][instance]
+	}
+}
+
+// GetMerged returns the merged config of a component instance. If the instance
+// is empty, only the global and the component layers are merged.
+func (m *Manager) GetMerged(component, instance string) (*Entry, error) {
+	if component == "" {
+		return nil, errors.New("component is empty")
+	}
+	if err := checkScope(component, instance); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.mergeLocked(component, instance, nil), nil
+}
+
+// mergeLocked merges the layers of the instance, the layer in the scope of
+// the change is replaced by it.
+func (m *Manager) mergeLocked(component, instance string, change *layerChange) *Entry {
+	layers := []*Entry{m.global, m.components[component]}
+	if instance != "" {
+		layers = append(layers, m.instances[component][instance])
+	}
+	if change != nil {
+		switch {
+		case change.component == "":
+			layers[0] = change.entry
+		case change.component != component:
+		case change.instance == "":
+			layers[1] = change.entry
+		case change.instance == instance:
+			layers[2] = change.entry
+		}
+	}
+	merged := &Entry{Config: make(map[string]interface{})}
+	for _, e := range layers {
+		if e == nil {
+			continue
+		}
+		if e.Version > merged.Version {
+			merged.Version = e.Version
+		}
+		mergeObject(merged.Config, e.Config)
+	}
+	return merged
+}
+
+type layerChange struct {
+	component string
+	instance  string
+	entry     *Entry
+}
+
+// Update applies the patch to a layer of the configs, and returns the layer.
+// The keys with null values in the patch are removed from the layer, and the
+// nested objects are merged.
+func (m *Manager) Update(component, instance string, patch map[string]interface{}) (*Entry, error) {
+	if err := checkScope(component, instance); err != nil {
+		return nil, err
+	}
+	if err := checkKeys(patch, ""); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cfg := make(map[string]interface{})
+	if e := m.getLocked(component, instance); e != nil {
+		cfg = cloneObject(e.Config)
+	}
+	applyPatch(cfg, patch)
+	return m.saveLocked(component, instance, cfg)
+}
+
+// Delete clears a layer of the configs.
+func (m *Manager) Delete(component, instance string) error {
+	if err := checkScope(component, instance); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// The empty layer is kept with the new version, so that the version of
+	// the merged configs always increases.
+	_, err := m.saveLocked(component, instance, make(map[string]interface{}))
+	return err
+}
+
+func (m *Manager) saveLocked(component, instance string, cfg map[string]interface{}) (*Entry, error) {
+	change := &layerChange{
+		component: component,
+		instance:  instance,
+		entry:     &Entry{Version: m.version + 1, Config: cfg},
+	}
+	if m.storage == nil {
+		return nil, errStorageNotReady
+	}
+	if err := m.validateLocked(change); err != nil {
+		return nil, err
+	}
+	if err := m.storage.SaveComponentConfig(layerKey(component, instance), change.entry, change.entry.Version); err != nil {
+		return nil, err
+	}
+	m.version = change.entry.Version
+	switch {
+	case component == "":
+		m.global = change.entry
+	case instance == "":
+		m.components[component] = change.entry
+	default:
+		if m.instances[component] == nil {
+			m.instances[component] = make(map[string]*Entry)
+		}
+		m.instances[component][instance] = change.entry
+	}
+	m.notify()
+	log.Info("component config is updated",
+		zap.String("component", component),
+		zap.String("instance", instance),
+		zap.Uint64("version", m.version))
+	return change.entry.clone(), nil
+}
+
+// validateLocked runs the validators with the merged configs affected by the
+// change.
+func (m *Manager) validateLocked(change *layerChange) error {
+	components := make([]string, 0, len(m.validators))
+	for component := range m.validators {
+		if change.component == "" || change.component == component {
+			components = append(components, component)
+		}
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		instances := []string{""}
+		if change.instance != "" {
+			instances = append(instances, change.instance)
+		} else {
+			for instance := range m.instances[component] {
+				instances = append(instances, instance)
+			}
+			sort.Strings(instances[1:])
+		}
+		for _, instance := range instances {
+			cfg := m.mergeLocked(component, instance, change).Config
+			for _, v := range m.validators[component] {
+				if err := v(component, instance, cfg); err != nil {
+					return errors.Wrapf(err, "invalid config of %s", path.Join(component, instance))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func layerKey(component, instance string) string {
+	switch {
+	case component == "":
+		return globalKey
+	case instance == "":
+		return path.Join(componentKey, component)
+	default:
+		return path.Join(instanceKey, component, instance)
+	}
+}
+
+func checkScope(component, instance string) error {
+	if component == "" && instance != "" {
+		return errors.New("component is empty")
+	}
+	for _, name := range []string{component, instance} {
+		if strings.ContainsAny(name, "/") {
+			return errors.Errorf("name %q should not contain '/'", name)
+		}
+	}
+	return nil
+}
+
+// checkKeys checks the keys of the config are not empty and have no dots,
+// which separate the keys in a path.
+func checkKeys(cfg map[string]interface{}, prefix string) error {
+	for k, v := range cfg {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if k == "" || strings.Contains(k, ".") {
+			return errors.Errorf("invalid key %q", key)
+		}
+		if sub, ok := v.(map[string]interface{}); ok {
+			if err := checkKeys(sub, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyPatch applies the JSON merge patch to the config.
+func applyPatch(cfg, patch map[string]interface{}) {
+	for k, v := range patch {
+		if v == nil {
+			delete(cfg, k)
+			continue
+		}
+		sub, ok := v.(map[string]interface{})
+		if !ok {
+			cfg[k] = cloneValue(v)
+			continue
+		}
+		old, ok := cfg[k].(map[string]interface{})
+		if !ok {
+			old = make(map[string]interface{})
+		}
+		applyPatch(old, sub)
+		if len(old) == 0 {
+			delete(cfg, k)
+		} else {
+			cfg[k] = old
+		}
+	}
+}
+
+// mergeObject merges the src object to the dst one, the values in src take
+// precedence.
+func mergeObject(dst, src map[string]interface{}) {
+	for k, v := range src {
+		sub, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = cloneValue(v)
+			continue
+		}
+		old, ok := dst[k].(map[string]interface{})
+		if !ok {
+			old = make(map[string]interface{})
+			dst[k] = old
+		}
+		mergeObject(old, sub)
+	}
+}
+
+func cloneObject(m map[string]interface{}) map[string]interface{} {
+	cloned := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		cloned[k] = cloneValue(v)
+	}
+	return cloned
+}
+
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return cloneObject(v)
+	case []interface{}:
+		cloned := make([]interface{}, len(v))
+		for i := range v {
+			cloned[i] = cloneValue(v[i])
+		}
+		return cloned
+	default:
+		return v
+	}
+}
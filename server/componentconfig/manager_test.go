@@ -0,0 +1,136 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package componentconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/kv"
+	"github.com/pkg/errors"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testManagerSuite{})
+
+type testManagerSuite struct{}
+
+func mustDecode(c *C, s string) map[string]interface{} {
+	e, err := DecodeEntry([]byte(`{"config":` + s + `}`))
+	c.Assert(err, IsNil)
+	return e.Config
+}
+
+func (s *testManagerSuite) TestLayers(c *C) {
+	storage := core.NewStorage(kv.NewMemoryKV())
+	m := NewManager()
+	c.Assert(m.Reload(), NotNil)
+	m.SetStorage(storage)
+	c.Assert(m.Reload(), IsNil)
+
+	_, err := m.Update("", "", mustDecode(c, `{"log-level": "info", "raftstore": {"sync-log": true, "capacity": 100}}`))
+	c.Assert(err, IsNil)
+	_, err = m.Update("tikv", "", mustDecode(c, `{"raftstore": {"capacity": 200}}`))
+	c.Assert(err, IsNil)
+	e, err := m.Update("tikv", "store1", mustDecode(c, `{"log-level": "debug"}`))
+	c.Assert(err, IsNil)
+	c.Assert(e.Version, Equals, uint64(3))
+
+	merged, err := m.GetMerged("tikv", "store1")
+	c.Assert(err, IsNil)
+	c.Assert(merged.Version, Equals, uint64(3))
+	c.Assert(merged.Config, DeepEquals, mustDecode(c, `{"log-level": "debug", "raftstore": {"sync-log": true, "capacity": 200}}`))
+	c.Assert(merged.Config["raftstore"].(map[string]interface{})["capacity"], Equals, json.Number("200"))
+	merged, err = m.GetMerged("tikv", "store2")
+	c.Assert(err, IsNil)
+	c.Assert(merged.Version, Equals, uint64(2))
+	c.Assert(merged.Config["log-level"], Equals, "info")
+	merged, err = m.GetMerged("tidb", "")
+	c.Assert(err, IsNil)
+	c.Assert(merged.Version, Equals, uint64(1))
+
+	// The null values remove the keys.
+	e, err = m.Update("", "", mustDecode(c, `{"raftstore": {"sync-log": null, "capacity": null}}`))
+	c.Assert(err, IsNil)
+	c.Assert(e.Config, DeepEquals, mustDecode(c, `{"log-level": "info"}`))
+
+	// The version of the merged config increases after the layer is deleted.
+	changed := m.Watch()
+	c.Assert(m.Delete("tikv", "store1"), IsNil)
+	<-changed
+	merged, err = m.GetMerged("tikv", "store1")
+	c.Assert(err, IsNil)
+	c.Assert(merged.Version, Equals, uint64(5))
+	c.Assert(merged.Config, DeepEquals, mustDecode(c, `{"log-level": "info", "raftstore": {"capacity": 200}}`))
+	all := m.GetAll()
+	c.Assert(all.Version, Equals, uint64(5))
+	c.Assert(all.Components, HasKey, "tikv")
+	c.Assert(all.Instances, HasLen, 0)
+
+	// Reload from the storage.
+	m2 := NewManager()
+	m2.SetStorage(storage)
+	c.Assert(m2.Reload(), IsNil)
+	c.Assert(m2.GetAll(), DeepEquals, all)
+	merged2, err := m2.GetMerged("tikv", "store1")
+	c.Assert(err, IsNil)
+	c.Assert(merged2, DeepEquals, merged)
+
+	// The version is conflicted with the one saved by m2.
+	_, err = m2.Update("tidb", "", mustDecode(c, `{"lease": "45s"}`))
+	c.Assert(err, IsNil)
+	_, err = m.Update("tidb", "", mustDecode(c, `{"lease": "10s"}`))
+	c.Assert(err, NotNil)
+}
+
+func (s *testManagerSuite) TestValidate(c *C) {
+	m := NewManager()
+	m.SetStorage(core.NewStorage(kv.NewMemoryKV()))
+	var checked []string
+	m.RegisterValidator("tikv", func(component, instance string, cfg map[string]interface{}) error {
+		checked = append(checked, component+"/"+instance)
+		if v, ok := cfg["log-level"]; ok && v != "info" && v != "debug" {
+			return errors.Errorf("unknown log level %v", v)
+		}
+		return nil
+	})
+	_, err := m.Update("tikv", "store1", mustDecode(c, `{"log-level": "debug"}`))
+	c.Assert(err, IsNil)
+	c.Assert(checked, DeepEquals, []string{"tikv/", "tikv/store1"})
+
+	// The global layer is checked with the merged configs of the instances.
+	checked = nil
+	_, err = m.Update("", "", mustDecode(c, `{"log-level": "warn"}`))
+	c.Assert(err, ErrorMatches, "invalid config of tikv: unknown log level warn")
+	c.Assert(checked, DeepEquals, []string{"tikv/"})
+	_, err = m.Update("tidb", "", mustDecode(c, `{"log-level": "warn"}`))
+	c.Assert(err, IsNil)
+
+	_, err = m.Update("tikv", "", mustDecode(c, `{"raftstore.capacity": 1}`))
+	c.Assert(err, ErrorMatches, `invalid key "raftstore.capacity"`)
+	_, err = m.Update("tikv", "", mustDecode(c, `{"raftstore": {"": 1}}`))
+	c.Assert(err, ErrorMatches, `invalid key "raftstore."`)
+	_, err = m.Update("", "store1", nil)
+	c.Assert(err, ErrorMatches, "component is empty")
+	_, err = m.Update("tikv", "a/b", nil)
+	c.Assert(err, NotNil)
+	e, err := m.Get("", "")
+	c.Assert(err, IsNil)
+	c.Assert(e.Config, HasLen, 0)
+}
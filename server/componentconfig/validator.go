@@ -0,0 +1,117 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package componentconfig
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Names of the components with the builtin validators.
+const (
+	TiKV = "tikv"
+	TiDB = "tidb"
+)
+
+// RegisterBuiltinValidators registers the validators of TiKV and TiDB. They
+// only check the basic items, such as the log levels and the sections, the
+// unknown items are left to the components, which reject the invalid configs
+// when they are received.
+func RegisterBuiltinValidators(m *Manager) {
+	m.RegisterValidator(TiKV, ValidateTiKVConfig)
+	m.RegisterValidator(TiDB, ValidateTiDBConfig)
+}
+
+// ValidateTiKVConfig checks the basic items of the TiKV config.
+func ValidateTiKVConfig(component, instance string, cfg map[string]interface{}) error {
+	if err := checkEnum(cfg, "log-level", "trace", "debug", "info", "warn", "warning", "error", "critical"); err != nil {
+		return err
+	}
+	if err := checkObjects(cfg, "readpool", "server", "storage", "pd", "metric", "raftstore",
+		"coprocessor", "rocksdb", "raftdb", "security", "import", "pessimistic-txn"); err != nil {
+		return err
+	}
+	return checkPositiveIntegers(cfg, "server.grpc-concurrency", "server.grpc-raft-conn-num",
+		"storage.scheduler-concurrency", "storage.scheduler-worker-pool-size")
+}
+
+// ValidateTiDBConfig checks the basic items of the TiDB config.
+func ValidateTiDBConfig(component, instance string, cfg map[string]interface{}) error {
+	if err := checkEnum(cfg, "log.level", "debug", "info", "warn", "error", "fatal"); err != nil {
+		return err
+	}
+	if err := checkObjects(cfg, "log", "security", "status", "performance", "prepared-plan-cache",
+		"opentracing", "proxy-protocol", "tikv-client", "binlog", "txn-local-latches", "pessimistic-txn"); err != nil {
+		return err
+	}
+	return checkPositiveIntegers(cfg, "token-limit", "tikv-client.grpc-connection-count")
+}
+
+// lookup returns the item of the dot-separated key.
+func lookup(cfg map[string]interface{}, key string) (interface{}, bool) {
+	names := strings.Split(key, ".")
+	for _, name := range names[:len(names)-1] {
+		section, ok := cfg[name].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		cfg = section
+	}
+	v, ok := cfg[names[len(names)-1]]
+	return v, ok
+}
+
+func checkEnum(cfg map[string]interface{}, key string, values ...string) error {
+	v, ok := lookup(cfg, key)
+	if !ok {
+		return nil
+	}
+	if s, ok := v.(string); ok {
+		for _, value := range values {
+			if strings.EqualFold(s, value) {
+				return nil
+			}
+		}
+	}
+	return errors.Errorf("%s should be one of %s, but it is %v", key, strings.Join(values, ", "), v)
+}
+
+func checkObjects(cfg map[string]interface{}, keys ...string) error {
+	for _, key := range keys {
+		if v, ok := lookup(cfg, key); ok {
+			if _, ok := v.(map[string]interface{}); !ok {
+				return errors.Errorf("%s should be an object, but it is %v", key, v)
+			}
+		}
+	}
+	return nil
+}
+
+func checkPositiveIntegers(cfg map[string]interface{}, keys ...string) error {
+	for _, key := range keys {
+		v, ok := lookup(cfg, key)
+		if !ok {
+			continue
+		}
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil && i > 0 {
+				continue
+			}
+		}
+		return errors.Errorf("%s should be a positive integer, but it is %v", key, v)
+	}
+	return nil
+}
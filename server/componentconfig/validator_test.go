@@ -0,0 +1,78 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package componentconfig
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/kv"
+)
+
+var _ = Suite(&testValidatorSuite{})
+
+type testValidatorSuite struct{}
+
+func (s *testValidatorSuite) TestTiKV(c *C) {
+	for _, cfg := range []string{
+		`{}`,
+		`{"log-level": "INFO", "raftstore": {"sync-log": true}, "server": {"grpc-concurrency": 4}}`,
+		`{"log": {"level": "trace"}, "unknown": 1}`,
+	} {
+		c.Assert(ValidateTiKVConfig(TiKV, "", mustDecode(c, cfg)), IsNil, Commentf(cfg))
+	}
+	for _, cfg := range []string{
+		`{"log-level": "fatal"}`,
+		`{"log-level": 1}`,
+		`{"raftstore": true}`,
+		`{"server": {"grpc-concurrency": 0}}`,
+		`{"storage": {"scheduler-worker-pool-size": "4"}}`,
+		`{"server": {"grpc-raft-conn-num": 1.5}}`,
+	} {
+		c.Assert(ValidateTiKVConfig(TiKV, "", mustDecode(c, cfg)), NotNil, Commentf(cfg))
+	}
+}
+
+func (s *testValidatorSuite) TestTiDB(c *C) {
+	for _, cfg := range []string{
+		`{}`,
+		`{"log": {"level": "fatal"}, "token-limit": 1000, "performance": {"max-procs": 0}}`,
+		`{"log-level": "critical"}`,
+	} {
+		c.Assert(ValidateTiDBConfig(TiDB, "", mustDecode(c, cfg)), IsNil, Commentf(cfg))
+	}
+	for _, cfg := range []string{
+		`{"log": {"level": "trace"}}`,
+		`{"log": "info"}`,
+		`{"token-limit": -1}`,
+		`{"tikv-client": {"grpc-connection-count": 0}}`,
+	} {
+		c.Assert(ValidateTiDBConfig(TiDB, "", mustDecode(c, cfg)), NotNil, Commentf(cfg))
+	}
+}
+
+func (s *testValidatorSuite) TestBuiltinValidators(c *C) {
+	m := NewManager()
+	m.SetStorage(core.NewStorage(kv.NewMemoryKV()))
+	RegisterBuiltinValidators(m)
+
+	// The global layer is checked with the config of each component.
+	_, err := m.Update("", "", mustDecode(c, `{"log-level": "critical"}`))
+	c.Assert(err, IsNil)
+	_, err = m.Update("", "", mustDecode(c, `{"log": {"level": "critical"}}`))
+	c.Assert(err, ErrorMatches, "invalid config of tidb.*")
+	_, err = m.Update(TiKV, "store1", mustDecode(c, `{"server": {"grpc-concurrency": 0}}`))
+	c.Assert(err, ErrorMatches, "invalid config of tikv/store1.*")
+	_, err = m.Update("tiflash", "", mustDecode(c, `{"server": {"grpc-concurrency": 0}}`))
+	c.Assert(err, IsNil)
+}
@@ -15,9 +15,12 @@ package config
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -89,6 +92,16 @@ type Config struct {
 
 	PDServerCfg PDServerConfig `toml:"pd-server" json:"pd-server"`
 
+	RateLimit RateLimitConfig `toml:"rate-limit" json:"rate-limit"`
+
+	ReplicationMode ReplicationModeConfig `toml:"replication-mode" json:"replication-mode"`
+
+	EtcdMaintenance EtcdMaintenanceConfig `toml:"etcd-maintenance" json:"etcd-maintenance"`
+
+	Audit AuditConfig `toml:"audit" json:"audit"`
+
+	HeartbeatRecord HeartbeatRecordConfig `toml:"heartbeat-record" json:"heartbeat-record"`
+
 	ClusterVersion semver.Version `json:"cluster-version"`
 
 	// QuotaBackendBytes Raise alarms when backend size exceeds the given quota. 0 means use the default quota.
@@ -206,6 +219,20 @@ const (
 	defaultStrictlyMatchLabel  = false
 	defaultEnableGRPCGateway   = true
 	defaultDisableErrorVerbose = true
+
+	defaultDRWaitStoreTimeout = time.Minute
+
+	defaultMaintenanceCheckInterval = time.Minute
+	defaultDefragRatio              = 0.5
+	defaultDefragMinDBSize          = 128 * 1024 * 1024
+	defaultQuotaAlertRatio          = 0.8
+
+	defaultEnableAudit      = true
+	defaultAuditMaxRecords  = 1000
+	defaultAuditMaxBodySize = 4 * 1024
+	defaultAuditFileMaxSize = 100 // MB
+
+	defaultHeartbeatRecordFileMaxSize = 300 // MB
 )
 
 func adjustString(v *string, defValue string) {
@@ -432,6 +459,24 @@ func (c *Config) Adjust(meta *toml.MetaData) error {
 		return err
 	}
 
+	if err := c.RateLimit.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.ReplicationMode.adjust(configMetaData.Child("replication-mode")); err != nil {
+		return err
+	}
+
+	if err := c.EtcdMaintenance.adjust(); err != nil {
+		return err
+	}
+
+	c.Audit.adjust(configMetaData.Child("audit"))
+
+	if err := c.HeartbeatRecord.adjust(); err != nil {
+		return err
+	}
+
 	c.adjustLog(configMetaData.Child("log"))
 	adjustDuration(&c.HeartbeatStreamBindInterval, defaultHeartbeatStreamRebindInterval)
 
@@ -714,6 +759,7 @@ var defaultSchedulers = SchedulerConfigs{
 	{Type: "balance-leader"},
 	{Type: "hot-region"},
 	{Type: "label"},
+	{Type: "evict-slow-store"},
 }
 
 // IsDefaultScheduler checks whether the scheduler is enable by default.
@@ -808,6 +854,63 @@ type SecurityConfig struct {
 	CertPath string `toml:"cert-path" json:"cert-path"`
 	// KeyPath is the path of file that contains X509 key in PEM format.
 	KeyPath string `toml:"key-path" json:"key-path"`
+	// MemberCertAllowedCN is the common names of the TLS certificates of the
+	// PD members. The requests redirected by the members are trusted only if
+	// the certificate matches one of them. The common name of the certificate
+	// of this server is used if it is empty.
+	MemberCertAllowedCN []string `toml:"member-cert-allowed-cn" json:"member-cert-allowed-cn"`
+	// RBAC is the access control of the HTTP API. It is only used before a
+	// config is saved through the API.
+	RBAC RBACConfig `toml:"rbac" json:"rbac"`
+}
+
+// RBACConfig is the configuration for the role-based access control of the
+// HTTP API.
+type RBACConfig struct {
+	Enable bool `toml:"enable" json:"enable"`
+	// DefaultRole is the role of the clients which are not in the users. They
+	// are denied if it is empty.
+	DefaultRole string     `toml:"default-role" json:"default-role"`
+	Users       []RBACUser `toml:"users" json:"users"`
+	// Roles are the custom roles besides the builtin viewer, operator and
+	// admin.
+	Roles []RBACRole `toml:"roles" json:"roles"`
+}
+
+// Clone returns a cloned RBAC configuration.
+func (c *RBACConfig) Clone() *RBACConfig {
+	cfg := *c
+	cfg.Users = append([]RBACUser(nil), c.Users...)
+	cfg.Roles = make([]RBACRole, 0, len(c.Roles))
+	for _, role := range c.Roles {
+		role.Permissions = append([]RBACPermission(nil), role.Permissions...)
+		cfg.Roles = append(cfg.Roles, role)
+	}
+	return &cfg
+}
+
+// RBACUser is a client of the HTTP API.
+type RBACUser struct {
+	// Name matches the common name of the TLS client certificate.
+	Name string `toml:"name" json:"name"`
+	// Token matches the bearer token in the Authorization header, so the user
+	// can be identified without the client certificate.
+	Token string `toml:"token" json:"token,omitempty"`
+	Role  string `toml:"role" json:"role"`
+}
+
+// RBACRole is a set of permissions.
+type RBACRole struct {
+	Name        string           `toml:"name" json:"name"`
+	Permissions []RBACPermission `toml:"permissions" json:"permissions"`
+}
+
+// RBACPermission allows the requests matching the methods and the path.
+type RBACPermission struct {
+	// Methods are the HTTP methods, all methods match if it is empty.
+	Methods []string `toml:"methods" json:"methods,omitempty"`
+	// Path is the URL path, a trailing "*" matches any suffix.
+	Path string `toml:"path" json:"path"`
 }
 
 // ToTLSConfig generatres tls config.
@@ -827,6 +930,27 @@ func (s SecurityConfig) ToTLSConfig() (*tls.Config, error) {
 	return tlsConfig, nil
 }
 
+// GetMemberCertAllowedCN returns the common names of the TLS certificates of
+// the PD members.
+func (s SecurityConfig) GetMemberCertAllowedCN() ([]string, error) {
+	if len(s.MemberCertAllowedCN) > 0 || len(s.CertPath) == 0 {
+		return s.MemberCertAllowedCN, nil
+	}
+	data, err := ioutil.ReadFile(s.CertPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Errorf("failed to decode the certificate %s", s.CertPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return []string{cert.Subject.CommonName}, nil
+}
+
 // PDServerConfig is the configuration for pd server.
 type PDServerConfig struct {
 	// UseRegionStorage enables the independent region storage.
@@ -840,6 +964,260 @@ func (c *PDServerConfig) adjust(meta *configMetaData) error {
 	return nil
 }
 
+// RateLimitConfig is the configuration for limiting the requests of the
+// expensive endpoints on the leader.
+type RateLimitConfig struct {
+	Limits []EndpointLimit `toml:"limits" json:"limits"`
+}
+
+// EndpointLimit is the limit of an endpoint.
+type EndpointLimit struct {
+	// Endpoint is the path template of a HTTP route without the "/pd" prefix,
+	// such as "/api/v1/regions", or the name of a gRPC method, such as
+	// "ScanRegions". A trailing "*" matches any suffix, and the matched
+	// endpoints share the limit. Only the region queries and the operator and
+	// config methods of gRPC can be limited.
+	Endpoint string `toml:"endpoint" json:"endpoint"`
+	// QPS is the max requests per second, 0 means no limit.
+	QPS float64 `toml:"qps" json:"qps"`
+	// Burst is the max requests at once under the QPS limit, it is the QPS
+	// rounded up if it is 0.
+	Burst int64 `toml:"burst" json:"burst"`
+	// Concurrency is the max requests in progress, 0 means no limit.
+	Concurrency int64 `toml:"concurrency" json:"concurrency"`
+}
+
+// IsUnlimited returns if the limit does not limit anything.
+func (l *EndpointLimit) IsUnlimited() bool {
+	return l.QPS == 0 && l.Concurrency == 0
+}
+
+// Clone returns a cloned rate limit configuration.
+func (c *RateLimitConfig) Clone() *RateLimitConfig {
+	return &RateLimitConfig{Limits: append([]EndpointLimit(nil), c.Limits...)}
+}
+
+// Validate is used to validate if some rate limit configurations are right.
+func (c *RateLimitConfig) Validate() error {
+	endpoints := make(map[string]struct{})
+	for _, l := range c.Limits {
+		if l.Endpoint == "" {
+			return errors.New("endpoint of the rate limit is empty")
+		}
+		if _, ok := endpoints[l.Endpoint]; ok {
+			return errors.Errorf("rate limit of %s is duplicated", l.Endpoint)
+		}
+		endpoints[l.Endpoint] = struct{}{}
+		if l.QPS < 0 || l.Burst < 0 || l.Concurrency < 0 {
+			return errors.Errorf("rate limit of %s should not be negative", l.Endpoint)
+		}
+	}
+	return nil
+}
+
+// SetLimit replaces the limit of the endpoint, the limit is removed if it is
+// unlimited.
+func (c *RateLimitConfig) SetLimit(limit EndpointLimit) {
+	limits := make([]EndpointLimit, 0, len(c.Limits)+1)
+	for _, l := range c.Limits {
+		if l.Endpoint != limit.Endpoint {
+			limits = append(limits, l)
+		}
+	}
+	if !limit.IsUnlimited() {
+		limits = append(limits, limit)
+	}
+	c.Limits = limits
+}
+
+// Replication modes.
+const (
+	// ReplicationModeMajority replicates the data to the majority of the peers.
+	ReplicationModeMajority = "majority"
+	// ReplicationModeDRAutoSync replicates the data to the DR synchronously
+	// when the DR is available, and asynchronously when it is not.
+	ReplicationModeDRAutoSync = "dr-auto-sync"
+)
+
+// ReplicationModeConfig is the configuration for the replication mode.
+type ReplicationModeConfig struct {
+	ReplicationMode string                      `toml:"replication-mode" json:"replication-mode"`
+	DRAutoSync      DRAutoSyncReplicationConfig `toml:"dr-auto-sync" json:"dr-auto-sync"`
+}
+
+func (c *ReplicationModeConfig) adjust(meta *configMetaData) error {
+	adjustString(&c.ReplicationMode, ReplicationModeMajority)
+	switch c.ReplicationMode {
+	case ReplicationModeMajority:
+		return nil
+	case ReplicationModeDRAutoSync:
+		return c.DRAutoSync.adjust(meta.Child("dr-auto-sync"))
+	default:
+		return errors.Errorf("unknown replication mode: %s", c.ReplicationMode)
+	}
+}
+
+// DRAutoSyncReplicationConfig is the configuration for the dr-auto-sync mode.
+// The stores are placed in the primary DC or the DR DC by the label.
+type DRAutoSyncReplicationConfig struct {
+	LabelKey         string            `toml:"label-key" json:"label-key"`
+	Primary          string            `toml:"primary" json:"primary"`
+	DR               string            `toml:"dr" json:"dr"`
+	PrimaryReplicas  int               `toml:"primary-replicas" json:"primary-replicas"`
+	DRReplicas       int               `toml:"dr-replicas" json:"dr-replicas"`
+	WaitStoreTimeout typeutil.Duration `toml:"wait-store-timeout" json:"wait-store-timeout"`
+}
+
+func (c *DRAutoSyncReplicationConfig) adjust(meta *configMetaData) error {
+	if c.LabelKey == "" || c.Primary == "" || c.DR == "" {
+		return errors.New("label-key, primary and dr must be set in dr-auto-sync mode")
+	}
+	if c.Primary == c.DR {
+		return errors.New("primary and dr must be different in dr-auto-sync mode")
+	}
+	if c.PrimaryReplicas <= 0 || c.DRReplicas <= 0 {
+		return errors.New("primary-replicas and dr-replicas must be positive in dr-auto-sync mode")
+	}
+	adjustDuration(&c.WaitStoreTimeout, defaultDRWaitStoreTimeout)
+	return nil
+}
+
+// EtcdMaintenanceConfig is the configuration for the maintenance of the
+// embedded etcd, which is run by the PD leader.
+type EtcdMaintenanceConfig struct {
+	// CheckInterval is the interval to check the db size of the members.
+	CheckInterval typeutil.Duration `toml:"check-interval" json:"check-interval"`
+	// DefragWindows are the local time windows like "01:00-05:00" in which the
+	// members can be defragmented. Only the members close to the quota are
+	// defragmented automatically if it is empty.
+	DefragWindows []string `toml:"defrag-windows" json:"defrag-windows"`
+	// DefragRatio is the ratio of the free space in the db, above which the
+	// member is defragmented.
+	DefragRatio float64 `toml:"defrag-ratio" json:"defrag-ratio"`
+	// DefragMinDBSize is the db size below which the member is not defragmented.
+	DefragMinDBSize typeutil.ByteSize `toml:"defrag-min-db-size" json:"defrag-min-db-size"`
+	// QuotaAlertRatio is the ratio of the db size to the backend quota, above
+	// which an alert is raised and the fragmented member is defragmented.
+	QuotaAlertRatio float64 `toml:"quota-alert-ratio" json:"quota-alert-ratio"`
+
+	windows []timeWindow
+}
+
+func (c *EtcdMaintenanceConfig) adjust() error {
+	adjustDuration(&c.CheckInterval, defaultMaintenanceCheckInterval)
+	adjustFloat64(&c.DefragRatio, defaultDefragRatio)
+	adjustFloat64(&c.QuotaAlertRatio, defaultQuotaAlertRatio)
+	if c.DefragMinDBSize == 0 {
+		c.DefragMinDBSize = defaultDefragMinDBSize
+	}
+	if c.DefragRatio <= 0 || c.DefragRatio >= 1 {
+		return errors.Errorf("defrag-ratio should be in (0, 1), got %v", c.DefragRatio)
+	}
+	if c.QuotaAlertRatio <= 0 || c.QuotaAlertRatio > 1 {
+		return errors.Errorf("quota-alert-ratio should be in (0, 1], got %v", c.QuotaAlertRatio)
+	}
+	c.windows = c.windows[:0]
+	for _, w := range c.DefragWindows {
+		window, err := parseTimeWindow(w)
+		if err != nil {
+			return err
+		}
+		c.windows = append(c.windows, window)
+	}
+	return nil
+}
+
+// InDefragWindow checks if the time is in one of the defrag windows.
+func (c *EtcdMaintenanceConfig) InDefragWindow(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range c.windows {
+		if w.contains(minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// timeWindow is a range of the minutes in a day, it crosses midnight if the
+// start is larger than the end.
+type timeWindow struct {
+	start, end int
+}
+
+func (w timeWindow) contains(minute int) bool {
+	if w.start <= w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+func parseTimeWindow(s string) (timeWindow, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return timeWindow{}, errors.Errorf("invalid time window %q, it should be like 01:00-05:00", s)
+	}
+	var w timeWindow
+	for i, p := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(p))
+		if err != nil {
+			return timeWindow{}, errors.Errorf("invalid time window %q, it should be like 01:00-05:00", s)
+		}
+		if i == 0 {
+			w.start = t.Hour()*60 + t.Minute()
+		} else {
+			w.end = t.Hour()*60 + t.Minute()
+		}
+	}
+	return w, nil
+}
+
+// AuditConfig is the configuration for the audit log of the mutating API
+// requests.
+type AuditConfig struct {
+	Enable bool `toml:"enable" json:"enable"`
+	// MaxRecords is the number of the latest records kept in memory.
+	MaxRecords int `toml:"max-records" json:"max-records"`
+	// MaxBodySize is the max size of the request body to record, the rest is
+	// truncated.
+	MaxBodySize typeutil.ByteSize `toml:"max-body-size" json:"max-body-size"`
+	// File is the rotating file to write the records, it is disabled if the
+	// filename is empty.
+	File log.FileLogConfig `toml:"file" json:"file"`
+}
+
+func (c *AuditConfig) adjust(meta *configMetaData) {
+	if !meta.IsDefined("enable") {
+		c.Enable = defaultEnableAudit
+	}
+	if c.MaxRecords <= 0 {
+		c.MaxRecords = defaultAuditMaxRecords
+	}
+	if c.MaxBodySize == 0 {
+		c.MaxBodySize = defaultAuditMaxBodySize
+	}
+	if c.File.MaxSize == 0 {
+		c.File.MaxSize = defaultAuditFileMaxSize
+	}
+}
+
+// HeartbeatRecordConfig is the configuration for recording the region and
+// store heartbeats, which can be replayed by pd-simulator.
+type HeartbeatRecordConfig struct {
+	Enable bool `toml:"enable" json:"enable"`
+	// File is the rotating file to write the records.
+	File log.FileLogConfig `toml:"file" json:"file"`
+}
+
+func (c *HeartbeatRecordConfig) adjust() error {
+	if c.Enable && c.File.Filename == "" {
+		return errors.New("heartbeat-record.file.filename should be set if the heartbeat record is enabled")
+	}
+	if c.File.MaxSize == 0 {
+		c.File.MaxSize = defaultHeartbeatRecordFileMaxSize
+	}
+	return nil
+}
+
 // StoreLabel is the config item of LabelPropertyConfig.
 type StoreLabel struct {
 	Key   string `toml:"key" json:"key"`
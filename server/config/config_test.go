@@ -14,7 +14,15 @@
 package config
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"math/big"
 	"os"
 	"path"
 	"strings"
@@ -45,6 +53,42 @@ func (s *testConfigSuite) TestTLS(c *C) {
 	c.Assert(tls, IsNil)
 }
 
+func (s *testConfigSuite) TestMemberCertAllowedCN(c *C) {
+	cfg := NewConfig()
+	cns, err := cfg.Security.GetMemberCertAllowedCN()
+	c.Assert(err, IsNil)
+	c.Assert(cns, HasLen, 0)
+
+	// The common name of the certificate is used by default.
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pd-server"},
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	c.Assert(err, IsNil)
+	f, err := ioutil.TempFile("", "pd_cert")
+	c.Assert(err, IsNil)
+	defer os.Remove(f.Name())
+	c.Assert(pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: der}), IsNil)
+	c.Assert(f.Close(), IsNil)
+	cfg.Security.CertPath = f.Name()
+	cns, err = cfg.Security.GetMemberCertAllowedCN()
+	c.Assert(err, IsNil)
+	c.Assert(cns, DeepEquals, []string{"pd-server"})
+
+	cfg.Security.MemberCertAllowedCN = []string{"pd-1", "pd-2"}
+	cns, err = cfg.Security.GetMemberCertAllowedCN()
+	c.Assert(err, IsNil)
+	c.Assert(cns, DeepEquals, []string{"pd-1", "pd-2"})
+	cfg.Security.MemberCertAllowedCN = nil
+	cfg.Security.CertPath = f.Name() + ".missing"
+	_, err = cfg.Security.GetMemberCertAllowedCN()
+	c.Assert(err, NotNil)
+}
+
 func (s *testConfigSuite) TestBadFormatJoinAddr(c *C) {
 	cfg := NewConfig()
 	cfg.Join = "127.0.0.1:2379" // Wrong join addr without scheme.
@@ -62,13 +106,13 @@ func (s *testConfigSuite) TestReloadConfig(c *C) {
 	c.Assert(opt.Persist(storage), IsNil)
 
 	// suppose we add a new default enable scheduler "adjacent-region"
-	defaultSchedulers := []string{"balance-region", "balance-leader", "hot-region", "label", "adjacent-region"}
+	defaultSchedulers := []string{"balance-region", "balance-leader", "hot-region", "label", "evict-slow-store", "adjacent-region"}
 	newOpt, err := newTestScheduleOption()
 	c.Assert(err, IsNil)
 	newOpt.AddSchedulerCfg("adjacent-region", []string{})
 	c.Assert(newOpt.Reload(storage), IsNil)
 	schedulers := newOpt.GetSchedulers()
-	c.Assert(schedulers, HasLen, 5)
+	c.Assert(schedulers, HasLen, 6)
 	c.Assert(newOpt.LoadPDServerConfig().UseRegionStorage, IsTrue)
 	for i, s := range schedulers {
 		c.Assert(s.Type, Equals, defaultSchedulers[i])
@@ -196,3 +240,64 @@ func newTestScheduleOption() (*ScheduleOption, error) {
 	opt := NewScheduleOption(cfg)
 	return opt, nil
 }
+
+func (s *testConfigSuite) TestReplicationMode(c *C) {
+	cfg := NewConfig()
+	meta, err := toml.Decode("", &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta), IsNil)
+	c.Assert(cfg.ReplicationMode.ReplicationMode, Equals, ReplicationModeMajority)
+
+	cfgData := `
+[replication-mode]
+replication-mode = "dr-auto-sync"
+[replication-mode.dr-auto-sync]
+label-key = "zone"
+primary = "z1"
+dr = "z2"
+primary-replicas = 2
+dr-replicas = 1
+`
+	cfg = NewConfig()
+	meta, err = toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta), IsNil)
+	c.Assert(cfg.ReplicationMode.DRAutoSync.DR, Equals, "z2")
+	c.Assert(cfg.ReplicationMode.DRAutoSync.WaitStoreTimeout.Duration, Equals, defaultDRWaitStoreTimeout)
+
+	cfg = NewConfig()
+	meta, err = toml.Decode(`
+[replication-mode]
+replication-mode = "dr-auto-sync"
+`, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta), NotNil)
+}
+
+func (s *testConfigSuite) TestEtcdMaintenance(c *C) {
+	cfg := NewConfig()
+	meta, err := toml.Decode(`
+[etcd-maintenance]
+defrag-windows = ["01:00-05:00", "23:30-00:30"]
+`, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta), IsNil)
+	c.Assert(cfg.EtcdMaintenance.DefragRatio, Equals, defaultDefragRatio)
+	c.Assert(cfg.EtcdMaintenance.QuotaAlertRatio, Equals, defaultQuotaAlertRatio)
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2019, 10, 1, hour, minute, 0, 0, time.Local)
+	}
+	c.Assert(cfg.EtcdMaintenance.InDefragWindow(at(1, 0)), IsTrue)
+	c.Assert(cfg.EtcdMaintenance.InDefragWindow(at(4, 59)), IsTrue)
+	c.Assert(cfg.EtcdMaintenance.InDefragWindow(at(5, 0)), IsFalse)
+	c.Assert(cfg.EtcdMaintenance.InDefragWindow(at(23, 45)), IsTrue)
+	c.Assert(cfg.EtcdMaintenance.InDefragWindow(at(0, 15)), IsTrue)
+	c.Assert(cfg.EtcdMaintenance.InDefragWindow(at(12, 0)), IsFalse)
+
+	for _, window := range []string{"01:00", "1-5", "25:00-26:00"} {
+		cfg = NewConfig()
+		cfg.EtcdMaintenance.DefragWindows = []string{window}
+		c.Assert(cfg.Adjust(nil), NotNil)
+	}
+}
@@ -14,6 +14,7 @@
 package config
 
 import (
+	"encoding/json"
 	"reflect"
 	"sync"
 	"sync/atomic"
@@ -25,6 +26,7 @@ import (
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/kv"
 	"github.com/pingcap/pd/server/schedule"
+	"github.com/pkg/errors"
 )
 
 // ScheduleOption is a wrapper to access the configuration safely.
@@ -35,6 +37,7 @@ type ScheduleOption struct {
 	labelProperty  atomic.Value
 	clusterVersion unsafe.Pointer
 	pdServerConfig atomic.Value
+	rateLimit      atomic.Value
 }
 
 // NewScheduleOption creates a new ScheduleOption.
@@ -48,6 +51,7 @@ func NewScheduleOption(cfg *Config) *ScheduleOption {
 	}
 	o.replication = newReplication(&cfg.Replication)
 	o.pdServerConfig.Store(&cfg.PDServerCfg)
+	o.rateLimit.Store(&cfg.RateLimit)
 	o.labelProperty.Store(cfg.LabelProperty)
 	o.SetClusterVersion(&cfg.ClusterVersion)
 	return o
@@ -373,13 +377,31 @@ func (o *ScheduleOption) LoadClusterVersion() *semver.Version {
 	return (*semver.Version)(atomic.LoadPointer(&o.clusterVersion))
 }
 
+// SetRateLimitConfig sets the rate limit configurations.
+func (o *ScheduleOption) SetRateLimitConfig(cfg *RateLimitConfig) {
+	o.rateLimit.Store(cfg)
+}
+
+// LoadRateLimitConfig returns the rate limit configurations.
+func (o *ScheduleOption) LoadRateLimitConfig() *RateLimitConfig {
+	return o.rateLimit.Load().(*RateLimitConfig)
+}
+
 // LoadPDServerConfig returns PD server configurations.
 func (o *ScheduleOption) LoadPDServerConfig() *PDServerConfig {
 	return o.pdServerConfig.Load().(*PDServerConfig)
 }
 
-// Persist saves the configuration to the storage.
-func (o *ScheduleOption) Persist(storage *core.Storage) error {
+// Persist saves the configuration changed by PD itself to the storage. The
+// extra operations are applied in the same transaction.
+func (o *ScheduleOption) Persist(storage *core.Storage, ops ...kv.Op) error {
+	return o.PersistWithSource(storage, core.ConfigSourcePD, ops...)
+}
+
+// PersistWithSource saves the configuration to the storage as a new version
+// changed by the source. The extra operations are applied in the same
+// transaction.
+func (o *ScheduleOption) PersistWithSource(storage *core.Storage, source string, ops ...kv.Op) error {
 	namespaces := o.LoadNSConfig()
 
 	cfg := &Config{
@@ -389,11 +411,80 @@ func (o *ScheduleOption) Persist(storage *core.Storage) error {
 		LabelProperty:  o.LoadLabelPropertyConfig(),
 		ClusterVersion: *o.LoadClusterVersion(),
 		PDServerCfg:    *o.LoadPDServerConfig(),
+		RateLimit:      *o.LoadRateLimitConfig(),
 	}
-	err := storage.SaveConfig(cfg)
+	err := storage.SaveConfig(cfg, source, ops...)
 	return err
 }
 
+// clone returns a copy of the persisted configuration.
+func (o *ScheduleOption) clone() *Config {
+	return &Config{
+		Schedule:       *o.Load().Clone(),
+		Replication:    *o.replication.Load(),
+		Namespace:      o.LoadNSConfig(),
+		LabelProperty:  o.LoadLabelPropertyConfig().Clone(),
+		ClusterVersion: *o.LoadClusterVersion(),
+		PDServerCfg:    *o.LoadPDServerConfig(),
+		RateLimit:      *o.LoadRateLimitConfig().Clone(),
+	}
+}
+
+// ParseConfigVersion returns the configuration of a version in the history.
+// The sections missing in the version, which are added by the later releases,
+// are the current ones.
+func (o *ScheduleOption) ParseConfigVersion(v *core.ConfigVersion) (*Config, error) {
+	cfg := o.clone()
+	// The maps are replaced instead of merged.
+	cfg.Namespace, cfg.LabelProperty = nil, LabelPropertyConfig{}
+	if err := json.Unmarshal(v.Config, cfg); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := cfg.Schedule.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Replication.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.RateLimit.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Rollback replaces the configuration with cfg except the cluster version,
+// which is only upgraded with the stores, and persists it as a new version.
+// The configuration is restored if it fails to persist.
+func (o *ScheduleOption) Rollback(storage *core.Storage, cfg *Config) error {
+	old := o.clone()
+	cfg.ClusterVersion = old.ClusterVersion
+	o.restore(cfg)
+	if err := o.PersistWithSource(storage, core.ConfigSourceRollback); err != nil {
+		o.restore(old)
+		return err
+	}
+	return nil
+}
+
+func (o *ScheduleOption) restore(cfg *Config) {
+	o.Store(&cfg.Schedule)
+	o.replication.Store(&cfg.Replication)
+	o.ns.Range(func(name, _ interface{}) bool {
+		if _, ok := cfg.Namespace[name.(string)]; !ok {
+			o.ns.Delete(name)
+		}
+		return true
+	})
+	for name, nsCfg := range cfg.Namespace {
+		nsCfg := nsCfg
+		o.ns.Store(name, NewNamespaceOption(&nsCfg))
+	}
+	o.labelProperty.Store(cfg.LabelProperty)
+	o.SetClusterVersion(&cfg.ClusterVersion)
+	o.pdServerConfig.Store(&cfg.PDServerCfg)
+	o.rateLimit.Store(&cfg.RateLimit)
+}
+
 // Reload reloads the configuration from the storage.
 func (o *ScheduleOption) Reload(storage *core.Storage) error {
 	namespaces := o.LoadNSConfig()
@@ -405,6 +496,7 @@ func (o *ScheduleOption) Reload(storage *core.Storage) error {
 		LabelProperty:  o.LoadLabelPropertyConfig().Clone(),
 		ClusterVersion: *o.LoadClusterVersion(),
 		PDServerCfg:    *o.LoadPDServerConfig(),
+		RateLimit:      *o.LoadRateLimitConfig().Clone(),
 	}
 	isExist, err := storage.LoadConfig(cfg)
 	if err != nil {
@@ -421,6 +513,7 @@ func (o *ScheduleOption) Reload(storage *core.Storage) error {
 		o.labelProperty.Store(cfg.LabelProperty)
 		o.SetClusterVersion(&cfg.ClusterVersion)
 		o.pdServerConfig.Store(&cfg.PDServerCfg)
+		o.rateLimit.Store(&cfg.RateLimit)
 	}
 	return nil
 }
@@ -20,8 +20,11 @@ import (
 	"time"
 
 	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/pkg/logutil"
 	"github.com/pingcap/pd/server/config"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/kv"
 	"github.com/pingcap/pd/server/namespace"
 	"github.com/pingcap/pd/server/schedule"
 	"github.com/pingcap/pd/server/schedule/operator"
@@ -83,17 +86,17 @@ func newCoordinator(cluster *RaftCluster, hbStreams *heartbeatStreams, classifie
 
 // patrolRegions is used to scan regions.
 // The checkers will check these regions to decide if they need to do some operations.
-func (c *coordinator) patrolRegions() {
+func (c *coordinator) patrolRegions(timer *clock.Timer) {
 	defer logutil.LogPanic()
 
 	defer c.wg.Done()
-	timer := time.NewTimer(c.cluster.GetPatrolRegionInterval())
 	defer timer.Stop()
 
 	log.Info("coordinator starts patrol regions")
 	start := time.Now()
 	var key []byte
 	for {
+		timer.Done()
 		select {
 		case <-timer.C:
 			timer.Reset(c.cluster.GetPatrolRegionInterval())
@@ -131,14 +134,14 @@ func (c *coordinator) patrolRegions() {
 }
 
 // drivePushOperator is used to push the unfinished operator to the excutor.
-func (c *coordinator) drivePushOperator() {
+func (c *coordinator) drivePushOperator(ticker *clock.Ticker) {
 	defer logutil.LogPanic()
 
 	defer c.wg.Done()
 	log.Info("coordinator begins to actively drive push operator")
-	ticker := time.NewTicker(schedule.PushOperatorTickInterval)
 	defer ticker.Stop()
 	for {
+		ticker.Done()
 		select {
 		case <-c.ctx.Done():
 			log.Info("drive push operator has been stopped")
@@ -150,7 +153,7 @@ func (c *coordinator) drivePushOperator() {
 }
 
 func (c *coordinator) run() {
-	ticker := time.NewTicker(runSchedulerCheckInterval)
+	ticker := clock.NewTicker(runSchedulerCheckInterval)
 	defer ticker.Stop()
 	log.Info("coordinator starts to collect cluster information")
 	for {
@@ -158,6 +161,7 @@ func (c *coordinator) run() {
 			log.Info("coordinator has finished cluster information preparation")
 			break
 		}
+		ticker.Done()
 		select {
 		case <-ticker.C:
 		case <-c.ctx.Done():
@@ -208,13 +212,14 @@ func (c *coordinator) run() {
 			continue
 		}
 		log.Info("create scheduler with independent configuration", zap.String("scheduler-name", s.GetName()))
-		if err = c.addScheduler(s); err != nil {
+		if err = c.startScheduler(s); err != nil {
 			log.Error("can not add scheduler with independent configuration", zap.String("scheduler-name", s.GetName()), zap.Error(err))
 		}
 	}
 
 	// The old way to create the scheduler.
 	k := 0
+	var ops []kv.Op
 	for _, schedulerCfg := range scheduleCfg.Schedulers {
 		if schedulerCfg.Disable {
 			scheduleCfg.Schedulers[k] = schedulerCfg
@@ -230,26 +235,36 @@ func (c *coordinator) run() {
 		}
 
 		log.Info("create scheduler", zap.String("scheduler-name", s.GetName()))
-		if err = c.addScheduler(s, schedulerCfg.Args...); err != nil && err != errSchedulerExisted {
+		if err = c.startScheduler(s, schedulerCfg.Args...); err != nil && err != errSchedulerExisted {
 			log.Error("can not add scheduler", zap.String("scheduler-name", s.GetName()), zap.Error(err))
-		} else {
-			// Only records the valid scheduler config.
-			scheduleCfg.Schedulers[k] = schedulerCfg
-			k++
+			continue
+		}
+		if err == nil {
+			data, err := s.EncodeConfig()
+			if err != nil {
+				log.Error("can not encode scheduler config", zap.String("scheduler-name", s.GetName()), zap.Error(err))
+			} else {
+				ops = append(ops, c.cluster.storage.SaveScheduleConfigOp(s.GetName(), data))
+			}
 		}
+		// Only records the valid scheduler config.
+		scheduleCfg.Schedulers[k] = schedulerCfg
+		k++
 	}
 
-	// Removes the invalid scheduler config and persist.
+	// Removes the invalid scheduler config and persist it together with the
+	// config of the created schedulers.
 	scheduleCfg.Schedulers = scheduleCfg.Schedulers[:k]
 	c.cluster.opt.Store(scheduleCfg)
-	if err := c.cluster.opt.Persist(c.cluster.storage); err != nil {
+	if err := c.cluster.opt.Persist(c.cluster.storage, ops...); err != nil {
 		log.Error("cannot persist schedule config", zap.Error(err))
 	}
 
 	c.wg.Add(2)
-	// Starts to patrol regions.
-	go c.patrolRegions()
-	go c.drivePushOperator()
+	// Starts to patrol regions. The timers are created before the goroutines to
+	// make the virtual clock fire them in a fixed order.
+	go c.patrolRegions(clock.NewTimer(c.cluster.GetPatrolRegionInterval()))
+	go c.drivePushOperator(clock.NewTicker(schedule.PushOperatorTickInterval))
 }
 
 func (c *coordinator) stop() {
@@ -377,6 +392,28 @@ func (c *coordinator) shouldRun() bool {
 }
 
 func (c *coordinator) addScheduler(scheduler schedule.Scheduler, args ...string) error {
+	return c.addSchedulerWithSource(scheduler, core.ConfigSourceAPI, args...)
+}
+
+// addSchedulerWithSource adds the scheduler and persists its config together
+// with the configuration as a new version changed by the source.
+func (c *coordinator) addSchedulerWithSource(scheduler schedule.Scheduler, source string, args ...string) error {
+	data, err := scheduler.EncodeConfig()
+	if err != nil {
+		return err
+	}
+	if err = c.startScheduler(scheduler, args...); err != nil {
+		return err
+	}
+	storage := c.cluster.storage
+	if err = c.cluster.opt.PersistWithSource(storage, source, storage.SaveScheduleConfigOp(scheduler.GetName(), data)); err != nil {
+		log.Error("the option can not persist scheduler config", zap.Error(err))
+	}
+	return err
+}
+
+// startScheduler runs the scheduler without persisting anything.
+func (c *coordinator) startScheduler(scheduler schedule.Scheduler, args ...string) error {
 	c.Lock()
 	defer c.Unlock()
 
@@ -390,7 +427,7 @@ func (c *coordinator) addScheduler(scheduler schedule.Scheduler, args ...string)
 	}
 
 	c.wg.Add(1)
-	go c.runScheduler(s)
+	go c.runScheduler(s, clock.NewTimer(s.GetInterval()))
 	c.schedulers[s.GetName()] = s
 	c.cluster.opt.AddSchedulerCfg(s.GetType(), args)
 
@@ -398,6 +435,12 @@ func (c *coordinator) addScheduler(scheduler schedule.Scheduler, args ...string)
 }
 
 func (c *coordinator) removeScheduler(name string) error {
+	return c.removeSchedulerWithSource(name, core.ConfigSourceAPI)
+}
+
+// removeSchedulerWithSource removes the scheduler and persists the
+// configuration as a new version changed by the source.
+func (c *coordinator) removeSchedulerWithSource(name, source string) error {
 	c.Lock()
 	defer c.Unlock()
 	if c.cluster == nil {
@@ -416,26 +459,20 @@ func (c *coordinator) removeScheduler(name string) error {
 	opt := c.cluster.opt
 	if err = opt.RemoveSchedulerCfg(name); err != nil {
 		log.Error("can not remove scheduler", zap.String("scheduler-name", name), zap.Error(err))
-	} else if err = opt.Persist(c.cluster.storage); err != nil {
+	} else if err = opt.PersistWithSource(c.cluster.storage, source, c.cluster.storage.RemoveScheduleConfigOp(name)); err != nil {
 		log.Error("the option can not persist scheduler config", zap.Error(err))
-	} else {
-		err = c.cluster.storage.RemoveScheduleConfig(name)
-		if err != nil {
-			log.Error("can not remove the scheduler config", zap.Error(err))
-		}
 	}
 	return err
 }
 
-func (c *coordinator) runScheduler(s *scheduleController) {
+func (c *coordinator) runScheduler(s *scheduleController, timer *clock.Timer) {
 	defer logutil.LogPanic()
 	defer c.wg.Done()
 	defer s.Cleanup(c.cluster)
-
-	timer := time.NewTimer(s.GetInterval())
 	defer timer.Stop()
 
 	for {
+		timer.Done()
 		select {
 		case <-timer.C:
 			timer.Reset(s.GetInterval())
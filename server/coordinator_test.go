@@ -569,11 +569,12 @@ func (s *testCoordinatorSuite) TestAddScheduler(c *C) {
 	defer co.wg.Wait()
 	defer co.stop()
 
-	c.Assert(co.schedulers, HasLen, 4)
+	c.Assert(co.schedulers, HasLen, 5)
 	c.Assert(co.removeScheduler("balance-leader-scheduler"), IsNil)
 	c.Assert(co.removeScheduler("balance-region-scheduler"), IsNil)
 	c.Assert(co.removeScheduler("balance-hot-region-scheduler"), IsNil)
 	c.Assert(co.removeScheduler("label-scheduler"), IsNil)
+	c.Assert(co.removeScheduler("evict-slow-store-scheduler"), IsNil)
 	c.Assert(co.schedulers, HasLen, 0)
 
 	stream := mockhbstream.NewHeartbeatStream()
@@ -632,7 +633,7 @@ func (s *testCoordinatorSuite) TestPersistScheduler(c *C) {
 	c.Assert(tc.addLeaderStore(1, 1), IsNil)
 	c.Assert(tc.addLeaderStore(2, 1), IsNil)
 
-	c.Assert(co.schedulers, HasLen, 4)
+	c.Assert(co.schedulers, HasLen, 5)
 	oc := co.opController
 	storage := tc.RaftCluster.storage
 
@@ -642,14 +643,15 @@ func (s *testCoordinatorSuite) TestPersistScheduler(c *C) {
 	gls2, err := schedule.CreateScheduler("grant-leader", oc, storage, schedule.ConfigSliceDecoder("grant-leader", []string{"2"}))
 	c.Assert(err, IsNil)
 	c.Assert(co.addScheduler(gls2, "2"), IsNil)
-	c.Assert(co.schedulers, HasLen, 6)
+	c.Assert(co.schedulers, HasLen, 7)
 	sches, _, err := storage.LoadAllScheduleConfig()
 	c.Assert(err, IsNil)
-	c.Assert(sches, HasLen, 6)
+	c.Assert(sches, HasLen, 7)
 	c.Assert(co.removeScheduler("balance-leader-scheduler"), IsNil)
 	c.Assert(co.removeScheduler("balance-region-scheduler"), IsNil)
 	c.Assert(co.removeScheduler("balance-hot-region-scheduler"), IsNil)
 	c.Assert(co.removeScheduler("label-scheduler"), IsNil)
+	c.Assert(co.removeScheduler("evict-slow-store-scheduler"), IsNil)
 	c.Assert(co.schedulers, HasLen, 2)
 	c.Assert(co.cluster.opt.Persist(storage), IsNil)
 	co.stop()
@@ -658,19 +660,22 @@ func (s *testCoordinatorSuite) TestPersistScheduler(c *C) {
 	// whether the schedulers added or removed in dynamic way are recorded in opt
 	_, newOpt, err := newTestScheduleConfig()
 	c.Assert(err, IsNil)
-	_, err = schedule.CreateScheduler("adjacent-region", oc, storage, schedule.ConfigJSONDecoder([]byte("null")))
+	ars, err := schedule.CreateScheduler("adjacent-region", oc, storage, schedule.ConfigJSONDecoder([]byte("null")))
+	c.Assert(err, IsNil)
+	data, err := ars.EncodeConfig()
 	c.Assert(err, IsNil)
+	c.Assert(storage.SaveScheduleConfig(ars.GetName(), data), IsNil)
 	// suppose we add a new default enable scheduler
 	newOpt.AddSchedulerCfg("adjacent-region", []string{})
-	c.Assert(newOpt.GetSchedulers(), HasLen, 5)
+	c.Assert(newOpt.GetSchedulers(), HasLen, 6)
 	c.Assert(newOpt.Reload(storage), IsNil)
 	// only remains 3 items with independent config.
 	sches, _, err = storage.LoadAllScheduleConfig()
 	c.Assert(err, IsNil)
 	c.Assert(sches, HasLen, 3)
 
-	// option have 7 items because the default scheduler do not remove.
-	c.Assert(newOpt.GetSchedulers(), HasLen, 7)
+	// option have 8 items because the default scheduler do not remove.
+	c.Assert(newOpt.GetSchedulers(), HasLen, 8)
 	c.Assert(newOpt.Persist(storage), IsNil)
 	tc.RaftCluster.opt = newOpt
 
@@ -695,12 +700,12 @@ func (s *testCoordinatorSuite) TestPersistScheduler(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(co.addScheduler(brs), IsNil)
 	c.Assert(co.schedulers, HasLen, 5)
-	// the scheduler option should contain 7 items
-	// the `hot scheduler` and `label scheduler` are disabled
-	c.Assert(co.cluster.opt.GetSchedulers(), HasLen, 7)
+	// the scheduler option should contain 8 items
+	// the `hot scheduler`, `label scheduler` and `evict-slow-store scheduler` are disabled
+	c.Assert(co.cluster.opt.GetSchedulers(), HasLen, 8)
 	c.Assert(co.removeScheduler("grant-leader-scheduler-1"), IsNil)
 	// the scheduler that is not enable by default will be completely deleted
-	c.Assert(co.cluster.opt.GetSchedulers(), HasLen, 6)
+	c.Assert(co.cluster.opt.GetSchedulers(), HasLen, 7)
 	c.Assert(co.schedulers, HasLen, 4)
 	c.Assert(co.cluster.opt.Persist(co.cluster.storage), IsNil)
 	co.stop()
@@ -737,23 +742,24 @@ func (s *testCoordinatorSuite) TestRemoveScheduler(c *C) {
 	c.Assert(tc.addLeaderStore(1, 1), IsNil)
 	c.Assert(tc.addLeaderStore(2, 1), IsNil)
 
-	c.Assert(co.schedulers, HasLen, 4)
+	c.Assert(co.schedulers, HasLen, 5)
 	oc := co.opController
 	storage := tc.RaftCluster.storage
 
 	gls1, err := schedule.CreateScheduler("grant-leader", oc, storage, schedule.ConfigSliceDecoder("grant-leader", []string{"1"}))
 	c.Assert(err, IsNil)
 	c.Assert(co.addScheduler(gls1, "1"), IsNil)
-	c.Assert(co.schedulers, HasLen, 5)
+	c.Assert(co.schedulers, HasLen, 6)
 	sches, _, err := storage.LoadAllScheduleConfig()
 	c.Assert(err, IsNil)
-	c.Assert(sches, HasLen, 5)
+	c.Assert(sches, HasLen, 6)
 
 	// remove all schedulers
 	c.Assert(co.removeScheduler("balance-leader-scheduler"), IsNil)
 	c.Assert(co.removeScheduler("balance-region-scheduler"), IsNil)
 	c.Assert(co.removeScheduler("balance-hot-region-scheduler"), IsNil)
 	c.Assert(co.removeScheduler("label-scheduler"), IsNil)
+	c.Assert(co.removeScheduler("evict-slow-store-scheduler"), IsNil)
 	c.Assert(co.removeScheduler("grant-leader-scheduler-1"), IsNil)
 	// all removed
 	sches, _, err = storage.LoadAllScheduleConfig()
@@ -773,7 +779,7 @@ func (s *testCoordinatorSuite) TestRemoveScheduler(c *C) {
 	co.run()
 	c.Assert(co.schedulers, HasLen, 0)
 	// the option remains default scheduler
-	c.Assert(co.cluster.opt.GetSchedulers(), HasLen, 4)
+	c.Assert(co.cluster.opt.GetSchedulers(), HasLen, 5)
 	co.stop()
 	co.wg.Wait()
 }
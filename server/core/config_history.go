@@ -0,0 +1,324 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/pd/server/kv"
+	"github.com/pkg/errors"
+)
+
+const (
+	configHistoryPath = "config_history"
+	// configVersionPath is the path to save the latest version of the
+	// configuration.
+	configVersionPath = "config_version"
+	// maxConfigHistory is the number of the latest versions kept in the
+	// history, the older ones are removed.
+	maxConfigHistory = 1000
+)
+
+// Sources of the configuration changes.
+const (
+	// ConfigSourceAPI means the configuration is changed by the HTTP API.
+	ConfigSourceAPI = "api"
+	// ConfigSourcePD means the configuration is changed by PD itself, such as
+	// the cluster version is upgraded with the stores.
+	ConfigSourcePD = "pd"
+	// ConfigSourceRollback means the configuration is rolled back to a
+	// version.
+	ConfigSourceRollback = "rollback"
+)
+
+// ConfigVersion is a version of the persisted configuration. Each change of
+// the configuration is saved as a new version.
+type ConfigVersion struct {
+	Version uint64    `json:"version"`
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	// Config is the persisted configuration, it is omitted in the history.
+	Config json.RawMessage `json:"config,omitempty"`
+	// SchedulerConfigs are the configurations of the schedulers by their
+	// names, they are omitted in the history.
+	SchedulerConfigs map[string]json.RawMessage `json:"scheduler-configs,omitempty"`
+}
+
+// ConfigChange is an item changed between two versions of the configuration.
+// The key is the dot-separated path of the item, Old or New is nil if the
+// item is added or removed.
+type ConfigChange struct {
+	Key string      `json:"key"`
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+func configVersionPathOf(version uint64) string {
+	return path.Join(configHistoryPath, fmt.Sprintf("%020d", version))
+}
+
+// saveConfigVersion saves the configuration and the operations, and records
+// them as a new version in the same transaction. The configuration is not
+// changed if it is empty. The scheduler configurations of the version are the
+// ones after applying the operations. No version is recorded if nothing is
+// changed since the latest version, such as persisting the configuration when
+// PD starts.
+func (s *Storage) saveConfigVersion(config string, source string, ops []kv.Op) error {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	latest, err := s.Load(configVersionPath)
+	if err != nil {
+		return err
+	}
+	var version uint64
+	if latest != "" {
+		if version, err = strconv.ParseUint(latest, 10, 64); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	version++
+
+	if config == "" {
+		if config, err = s.Load(configPath); err != nil {
+			return err
+		}
+	} else {
+		ops = append([]kv.Op{kv.SaveOp(configPath, config)}, ops...)
+	}
+	names, configs, err := s.LoadAllScheduleConfig()
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]string, len(names))
+	schedulerConfigs := make(map[string]json.RawMessage, len(names))
+	for i, name := range names {
+		stored[name] = configs[i]
+		schedulerConfigs[name] = json.RawMessage(configs[i])
+	}
+	for _, op := range ops {
+		if !strings.HasPrefix(op.Key, customScheduleConfigPath+"/") {
+			continue
+		}
+		name := strings.TrimPrefix(op.Key, customScheduleConfigPath+"/")
+		if op.Type == kv.OpRemove {
+			delete(schedulerConfigs, name)
+		} else {
+			schedulerConfigs[name] = json.RawMessage(op.Value)
+		}
+	}
+	for name, data := range schedulerConfigs {
+		if !json.Valid(data) {
+			delete(schedulerConfigs, name)
+		}
+	}
+
+	v := &ConfigVersion{
+		Version:          version,
+		Time:             time.Now(),
+		Source:           source,
+		SchedulerConfigs: schedulerConfigs,
+	}
+	if config != "" {
+		v.Config = json.RawMessage(config)
+	}
+	if version > 1 {
+		last, ok, err := s.LoadConfigVersion(version - 1)
+		if err != nil {
+			return err
+		}
+		if ok {
+			changes, err := DiffConfigVersions(last, v)
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 {
+				return s.saveUnchangedConfig(latest, stored, ops)
+			}
+		}
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ops = append(ops,
+		kv.SaveOp(configVersionPathOf(version), string(value)),
+		kv.SaveOp(configVersionPath, strconv.FormatUint(version, 10)))
+	if version > maxConfigHistory {
+		ops = append(ops, kv.RemoveOp(configVersionPathOf(version-maxConfigHistory)))
+	}
+	// The condition fails if another PD has saved a version at the same time.
+	return s.Txn([]kv.Cmp{kv.ValueEqual(configVersionPath, latest)}, ops...)
+}
+
+// saveUnchangedConfig applies the operations which change the storage when
+// the configuration is the same as the latest version. The configuration is
+// always saved with a version, but the scheduler configurations saved without
+// a version may be different.
+func (s *Storage) saveUnchangedConfig(latest string, stored map[string]string, ops []kv.Op) error {
+	var changed []kv.Op
+	for _, op := range ops {
+		if op.Key == configPath {
+			continue
+		}
+		if strings.HasPrefix(op.Key, customScheduleConfigPath+"/") {
+			data, ok := stored[strings.TrimPrefix(op.Key, customScheduleConfigPath+"/")]
+			if (op.Type == kv.OpRemove && !ok) || (op.Type != kv.OpRemove && ok && data == op.Value) {
+				continue
+			}
+		}
+		changed = append(changed, op)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return s.Txn([]kv.Cmp{kv.ValueEqual(configVersionPath, latest)}, changed...)
+}
+
+// SaveScheduleConfigVersion saves the config of the scheduler which is changed
+// after the scheduler is created, and records it as a new version.
+func (s *Storage) SaveScheduleConfigVersion(scheduleName string, data []byte, source string) error {
+	return s.saveConfigVersion("", source, []kv.Op{kv.SaveOp(path.Join(customScheduleConfigPath, scheduleName), string(data))})
+}
+
+// LoadLatestConfigVersion returns the latest version of the configuration, it
+// is 0 if the configuration has not been saved.
+func (s *Storage) LoadLatestConfigVersion() (uint64, error) {
+	value, err := s.Load(configVersionPath)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	version, err := strconv.ParseUint(value, 10, 64)
+	return version, errors.WithStack(err)
+}
+
+// LoadConfigVersion loads a version of the configuration.
+func (s *Storage) LoadConfigVersion(version uint64) (*ConfigVersion, bool, error) {
+	value, err := s.Load(configVersionPathOf(version))
+	if err != nil || value == "" {
+		return nil, false, err
+	}
+	v := &ConfigVersion{}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return v, true, nil
+}
+
+// LoadConfigHistory loads at most limit versions from startVersion, the
+// configurations of the versions are omitted.
+func (s *Storage) LoadConfigHistory(startVersion uint64, limit int) ([]*ConfigVersion, error) {
+	_, values, err := s.LoadRange(configVersionPathOf(startVersion), configVersionPathOf(math.MaxUint64), limit)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*ConfigVersion, 0, len(values))
+	for _, value := range values {
+		v := &ConfigVersion{}
+		if err := json.Unmarshal([]byte(value), v); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		v.Config, v.SchedulerConfigs = nil, nil
+		history = append(history, v)
+	}
+	return history, nil
+}
+
+// DiffConfigVersions returns the items changed from the old version to the
+// new one, sorted by the keys. The old version can be nil, then all items are
+// added. The scheduler configurations are prefixed by "scheduler-configs".
+func DiffConfigVersions(old, new *ConfigVersion) ([]*ConfigChange, error) {
+	oldItems, err := flattenConfigVersion(old)
+	if err != nil {
+		return nil, err
+	}
+	newItems, err := flattenConfigVersion(new)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for k := range oldItems {
+		keys = append(keys, k)
+	}
+	for k := range newItems {
+		if _, ok := oldItems[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	changes := make([]*ConfigChange, 0)
+	for _, k := range keys {
+		o, inOld := oldItems[k]
+		n, inNew := newItems[k]
+		if inOld != inNew || !reflect.DeepEqual(o, n) {
+			changes = append(changes, &ConfigChange{Key: k, Old: o, New: n})
+		}
+	}
+	return changes, nil
+}
+
+func flattenConfigVersion(v *ConfigVersion) (map[string]interface{}, error) {
+	items := make(map[string]interface{})
+	if v == nil {
+		return items, nil
+	}
+	if len(v.Config) > 0 {
+		if err := flattenJSON(v.Config, "", items); err != nil {
+			return nil, err
+		}
+	}
+	for name, data := range v.SchedulerConfigs {
+		if err := flattenJSON(data, "scheduler-configs."+name, items); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// flattenJSON puts the values of the JSON object to items by their
+// dot-separated paths, the arrays and the empty objects are not flattened.
+func flattenJSON(data []byte, prefix string, items map[string]interface{}) error {
+	d := json.NewDecoder(strings.NewReader(string(data)))
+	d.UseNumber()
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return errors.WithStack(err)
+	}
+	flattenValue(value, prefix, items)
+	return nil
+}
+
+func flattenValue(value interface{}, prefix string, items map[string]interface{}) {
+	m, ok := value.(map[string]interface{})
+	// The empty objects are kept to show the added or removed items.
+	if !ok || (len(m) == 0 && prefix != "") {
+		items[prefix] = value
+		return
+	}
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		flattenValue(v, key, items)
+	}
+}
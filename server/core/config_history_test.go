@@ -0,0 +1,155 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server/kv"
+)
+
+var _ = Suite(&testConfigHistorySuite{})
+
+type testConfigHistorySuite struct{}
+
+type testConfig struct {
+	Schedule map[string]interface{} `json:"schedule"`
+	Labels   []string               `json:"labels"`
+}
+
+func (s *testConfigHistorySuite) TestConfigHistory(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+	version, err := storage.LoadLatestConfigVersion()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, uint64(0))
+
+	cfg := &testConfig{Schedule: map[string]interface{}{"limit": 4, "enable": true}, Labels: []string{"zone"}}
+	c.Assert(storage.SaveConfig(cfg, ConfigSourcePD), IsNil)
+	c.Assert(storage.SaveScheduleConfig("scatter-range-a", []byte(`{"start-key":"a"}`)), IsNil)
+	cfg.Schedule["limit"] = 8
+	cfg.Labels = []string{"zone", "host"}
+	c.Assert(storage.SaveConfig(cfg, ConfigSourceAPI), IsNil)
+	c.Assert(storage.SaveScheduleConfigVersion("scatter-range-a", []byte(`{"start-key":"b"}`), ConfigSourceAPI), IsNil)
+	c.Assert(storage.SaveConfig(cfg, ConfigSourceAPI, storage.RemoveScheduleConfigOp("scatter-range-a")), IsNil)
+
+	// The saved config is the latest one.
+	loaded := &testConfig{}
+	ok, err := storage.LoadConfig(loaded)
+	c.Assert(ok, IsTrue)
+	c.Assert(err, IsNil)
+	c.Assert(loaded.Labels, DeepEquals, cfg.Labels)
+
+	version, err = storage.LoadLatestConfigVersion()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, uint64(4))
+	history, err := storage.LoadConfigHistory(2, 10)
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 3)
+	c.Assert(history[0].Version, Equals, uint64(2))
+	c.Assert(history[0].Source, Equals, ConfigSourceAPI)
+	c.Assert(history[0].Config, IsNil)
+
+	v1, ok, err := storage.LoadConfigVersion(1)
+	c.Assert(ok, IsTrue)
+	c.Assert(err, IsNil)
+	c.Assert(v1.Source, Equals, ConfigSourcePD)
+	c.Assert(v1.SchedulerConfigs, HasLen, 0)
+	v2, _, err := storage.LoadConfigVersion(2)
+	c.Assert(err, IsNil)
+	c.Assert(string(v2.SchedulerConfigs["scatter-range-a"]), Equals, `{"start-key":"a"}`)
+	v3, _, err := storage.LoadConfigVersion(3)
+	c.Assert(err, IsNil)
+	// The config is not changed by the scheduler config.
+	c.Assert(string(v3.Config), Equals, string(v2.Config))
+	v4, _, err := storage.LoadConfigVersion(4)
+	c.Assert(err, IsNil)
+	c.Assert(v4.SchedulerConfigs, HasLen, 0)
+	_, ok, err = storage.LoadConfigVersion(5)
+	c.Assert(ok, IsFalse)
+	c.Assert(err, IsNil)
+
+	changes, err := DiffConfigVersions(v1, v2)
+	c.Assert(err, IsNil)
+	c.Assert(changes, DeepEquals, []*ConfigChange{
+		{Key: "labels", Old: []interface{}{"zone"}, New: []interface{}{"zone", "host"}},
+		{Key: "schedule.limit", Old: json.Number("4"), New: json.Number("8")},
+		{Key: "scheduler-configs.scatter-range-a.start-key", Old: nil, New: "a"},
+	})
+	changes, err = DiffConfigVersions(v2, v3)
+	c.Assert(err, IsNil)
+	c.Assert(changes, DeepEquals, []*ConfigChange{
+		{Key: "scheduler-configs.scatter-range-a.start-key", Old: "a", New: "b"},
+	})
+	changes, err = DiffConfigVersions(nil, v1)
+	c.Assert(err, IsNil)
+	c.Assert(changes, HasLen, 3)
+	changes, err = DiffConfigVersions(v4, v4)
+	c.Assert(err, IsNil)
+	c.Assert(changes, HasLen, 0)
+}
+
+func (s *testConfigHistorySuite) TestConfigHistoryLimit(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+	for i := 0; i < maxConfigHistory+2; i++ {
+		c.Assert(storage.SaveConfig(&testConfig{Labels: []string{fmt.Sprint(i)}}, ConfigSourceAPI), IsNil)
+	}
+	_, ok, err := storage.LoadConfigVersion(2)
+	c.Assert(ok, IsFalse)
+	c.Assert(err, IsNil)
+	history, err := storage.LoadConfigHistory(0, maxConfigHistory+2)
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, maxConfigHistory)
+	c.Assert(history[0].Version, Equals, uint64(3))
+}
+
+func (s *testConfigHistorySuite) TestConfigHistoryUnchanged(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+	cfg := &testConfig{Labels: []string{"zone"}}
+	c.Assert(storage.SaveConfig(cfg, ConfigSourceAPI, storage.SaveScheduleConfigOp("scatter-range-a", []byte(`{"start-key":"a"}`))), IsNil)
+
+	// Persisting the same config and scheduler configs records no version.
+	c.Assert(storage.SaveConfig(cfg, ConfigSourcePD, storage.SaveScheduleConfigOp("scatter-range-a", []byte(`{"start-key":"a"}`))), IsNil)
+	c.Assert(storage.SaveScheduleConfigVersion("scatter-range-a", []byte(`{"start-key":"a"}`), ConfigSourceAPI), IsNil)
+	version, err := storage.LoadLatestConfigVersion()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, uint64(1))
+
+	// The other operations are still applied.
+	c.Assert(storage.SaveConfig(cfg, ConfigSourceAPI, kv.SaveOp("other", "value")), IsNil)
+	value, err := storage.Load("other")
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, "value")
+	version, err = storage.LoadLatestConfigVersion()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, uint64(1))
+
+	// The scheduler configs saved without a version are restored.
+	c.Assert(storage.SaveScheduleConfig("scatter-range-a", []byte(`{"start-key":"b"}`)), IsNil)
+	c.Assert(storage.SaveConfig(cfg, ConfigSourcePD, storage.SaveScheduleConfigOp("scatter-range-a", []byte(`{"start-key":"a"}`))), IsNil)
+	value, err = storage.Load(path.Join(customScheduleConfigPath, "scatter-range-a"))
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, `{"start-key":"a"}`)
+	version, err = storage.LoadLatestConfigVersion()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, uint64(1))
+
+	cfg.Labels = []string{"zone", "host"}
+	c.Assert(storage.SaveConfig(cfg, ConfigSourceAPI), IsNil)
+	version, err = storage.LoadLatestConfigVersion()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, uint64(2))
+}
@@ -0,0 +1,228 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"math"
+	"sort"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/kv"
+	"github.com/pkg/errors"
+)
+
+// Types of the region storage issues.
+const (
+	// RegionIssueOverlap means two stored regions overlap with each other.
+	RegionIssueOverlap = "overlap"
+	// RegionIssueHole means no stored region covers the key range.
+	RegionIssueHole = "hole"
+	// RegionIssueStaleEpoch means the stored region is older than the region
+	// in memory.
+	RegionIssueStaleEpoch = "stale-epoch"
+)
+
+// RegionIssue is an inconsistency found in the stored regions.
+type RegionIssue struct {
+	Type string `json:"type"`
+	// RegionID is the stored region, it is 0 for a hole.
+	RegionID uint64 `json:"region_id,omitempty"`
+	// OtherRegionID is the region which overlaps with or is newer than the
+	// stored region.
+	OtherRegionID uint64 `json:"other_region_id,omitempty"`
+	// StartKey and EndKey are the range of the issue in hex.
+	StartKey string `json:"start_key"`
+	EndKey   string `json:"end_key"`
+	// Stale means the stored region is stale and can be removed.
+	Stale   bool `json:"stale"`
+	Removed bool `json:"removed"`
+}
+
+// RegionStorageReport is the result of checking the regions in a storage.
+type RegionStorageReport struct {
+	Source       string         `json:"source"`
+	RegionCount  int            `json:"region_count"`
+	StaleCount   int            `json:"stale_count"`
+	RemovedCount int            `json:"removed_count"`
+	Issues       []*RegionIssue `json:"issues"`
+}
+
+// RegionLookup is used to find the regions in memory to compare with the
+// stored regions. Both RegionsInfo and BasicCluster implement it.
+type RegionLookup interface {
+	GetRegion(regionID uint64) *RegionInfo
+	GetOverlaps(region *RegionInfo) []*RegionInfo
+}
+
+// CheckRegions scans the regions saved in the kv, and finds the overlaps, the
+// holes and the regions which are staler than the regions in memory. The
+// memory is not compared if it is nil. If repair is true, the stale regions
+// are removed from the kv.
+func CheckRegions(source string, base kv.Base, memory RegionLookup, repair bool) (*RegionStorageReport, error) {
+	regions, err := scanRegions(base)
+	if err != nil {
+		return nil, err
+	}
+	report := &RegionStorageReport{Source: source, RegionCount: len(regions)}
+	stale := make(map[uint64]bool)
+
+	// Compare with the regions in memory.
+	if memory != nil {
+		for _, region := range regions {
+			if other := staleByMemory(region, memory); other != nil {
+				stale[region.GetId()] = true
+				report.Issues = append(report.Issues, newRegionIssue(RegionIssueStaleEpoch, region, other.GetID(), true))
+			}
+		}
+	}
+
+	// Find the overlaps, the staler one of each pair is stale.
+	sort.Slice(regions, func(i, j int) bool {
+		return bytes.Compare(regions[i].GetStartKey(), regions[j].GetStartKey()) < 0
+	})
+	var prev *metapb.Region
+	for _, region := range regions {
+		if stale[region.GetId()] {
+			continue
+		}
+		if prev == nil || !overlapped(prev, region) {
+			prev = region
+			continue
+		}
+		staler, newer := region, prev
+		if isStalerEpoch(prev.GetRegionEpoch(), region.GetRegionEpoch()) {
+			staler, newer = prev, region
+		}
+		isStale := isStalerEpoch(staler.GetRegionEpoch(), newer.GetRegionEpoch())
+		if isStale {
+			stale[staler.GetId()] = true
+		}
+		report.Issues = append(report.Issues, newRegionIssue(RegionIssueOverlap, staler, newer.GetId(), isStale))
+		if !isStale || staler == prev {
+			prev = region
+		}
+	}
+
+	// Find the holes among the regions which are not stale.
+	var lastEnd []byte
+	for _, region := range regions {
+		if stale[region.GetId()] {
+			continue
+		}
+		if bytes.Compare(lastEnd, region.GetStartKey()) < 0 {
+			report.Issues = append(report.Issues, &RegionIssue{
+				Type:     RegionIssueHole,
+				StartKey: string(HexRegionKey(lastEnd)),
+				EndKey:   string(HexRegionKey(region.GetStartKey())),
+			})
+		}
+		end := region.GetEndKey()
+		if len(end) == 0 {
+			lastEnd = nil
+			break
+		}
+		if bytes.Compare(end, lastEnd) > 0 {
+			lastEnd = end
+		}
+	}
+	if len(lastEnd) > 0 {
+		report.Issues = append(report.Issues, &RegionIssue{
+			Type:     RegionIssueHole,
+			StartKey: string(HexRegionKey(lastEnd)),
+		})
+	}
+
+	report.StaleCount = len(stale)
+	if !repair {
+		return report, nil
+	}
+	for _, issue := range report.Issues {
+		if !issue.Stale || issue.Removed {
+			continue
+		}
+		if err := base.Remove(regionPath(issue.RegionID)); err != nil {
+			return report, err
+		}
+		issue.Removed = true
+		report.RemovedCount++
+	}
+	return report, nil
+}
+
+// scanRegions loads all regions in the kv without resolving the overlaps.
+func scanRegions(base kv.Base) ([]*metapb.Region, error) {
+	var regions []*metapb.Region
+	nextID := uint64(0)
+	endKey := regionPath(math.MaxUint64)
+	for {
+		_, res, err := base.LoadRange(regionPath(nextID), endKey, minKVRangeLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range res {
+			region := &metapb.Region{}
+			if err := region.Unmarshal([]byte(s)); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			nextID = region.GetId() + 1
+			regions = append(regions, region)
+		}
+		if len(res) < minKVRangeLimit {
+			return regions, nil
+		}
+	}
+}
+
+// staleByMemory returns the region in memory which makes the stored region
+// stale, or nil if the stored region is not stale.
+func staleByMemory(region *metapb.Region, memory RegionLookup) *RegionInfo {
+	if origin := memory.GetRegion(region.GetId()); origin != nil {
+		if isStalerEpoch(region.GetRegionEpoch(), origin.GetRegionEpoch()) {
+			return origin
+		}
+		return nil
+	}
+	// The region is not in memory, it is stale if a region covering its range
+	// is newer, e.g. it has been merged.
+	for _, other := range memory.GetOverlaps(NewRegionInfo(region, nil)) {
+		if region.GetRegionEpoch().GetVersion() < other.GetRegionEpoch().GetVersion() {
+			return other
+		}
+	}
+	return nil
+}
+
+func overlapped(a, b *metapb.Region) bool {
+	return (len(a.GetEndKey()) == 0 || bytes.Compare(b.GetStartKey(), a.GetEndKey()) < 0) &&
+		(len(b.GetEndKey()) == 0 || bytes.Compare(a.GetStartKey(), b.GetEndKey()) < 0)
+}
+
+func isStalerEpoch(a, b *metapb.RegionEpoch) bool {
+	if a.GetVersion() != b.GetVersion() {
+		return a.GetVersion() < b.GetVersion()
+	}
+	return a.GetConfVer() < b.GetConfVer()
+}
+
+func newRegionIssue(typ string, region *metapb.Region, other uint64, stale bool) *RegionIssue {
+	return &RegionIssue{
+		Type:          typ,
+		RegionID:      region.GetId(),
+		OtherRegionID: other,
+		StartKey:      string(HexRegionKey(region.GetStartKey())),
+		EndKey:        string(HexRegionKey(region.GetEndKey())),
+		Stale:         stale,
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/kv"
+)
+
+var _ = Suite(&testRegionCheckSuite{})
+
+type testRegionCheckSuite struct{}
+
+func newCheckRegion(id uint64, start, end string, version uint64) *metapb.Region {
+	return &metapb.Region{
+		Id:          id,
+		StartKey:    []byte(start),
+		EndKey:      []byte(end),
+		RegionEpoch: &metapb.RegionEpoch{Version: version, ConfVer: 1},
+	}
+}
+
+func (s *testRegionCheckSuite) TestCheckRegions(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+	for _, region := range []*metapb.Region{
+		newCheckRegion(1, "", "b", 2),
+		// 2 is merged into 3, but not removed.
+		newCheckRegion(2, "b", "c", 1),
+		newCheckRegion(3, "b", "d", 2),
+		// 4 is stale in memory.
+		newCheckRegion(4, "d", "e", 1),
+		// No region covers [e, f).
+		newCheckRegion(5, "f", "", 1),
+	} {
+		c.Assert(storage.SaveRegion(region), IsNil)
+	}
+
+	// Check without the memory.
+	report, err := CheckRegions("etcd", storage.Base, nil, false)
+	c.Assert(err, IsNil)
+	c.Assert(report.RegionCount, Equals, 5)
+	c.Assert(report.StaleCount, Equals, 1)
+	c.Assert(report.Issues, HasLen, 2)
+	c.Assert(report.Issues[0].Type, Equals, RegionIssueOverlap)
+	c.Assert(report.Issues[0].RegionID, Equals, uint64(2))
+	c.Assert(report.Issues[0].OtherRegionID, Equals, uint64(3))
+	c.Assert(report.Issues[0].Stale, IsTrue)
+	c.Assert(report.Issues[1].Type, Equals, RegionIssueHole)
+	c.Assert(report.Issues[1].StartKey, Equals, "65")
+	c.Assert(report.Issues[1].EndKey, Equals, "66")
+
+	// Check with the memory and repair.
+	memory := NewRegionsInfo()
+	memory.SetRegion(NewRegionInfo(newCheckRegion(4, "d", "f", 2), nil))
+	report, err = CheckRegions("etcd", storage.Base, memory, true)
+	c.Assert(err, IsNil)
+	c.Assert(report.StaleCount, Equals, 2)
+	c.Assert(report.RemovedCount, Equals, 2)
+	c.Assert(report.Issues[0].Type, Equals, RegionIssueStaleEpoch)
+	c.Assert(report.Issues[0].RegionID, Equals, uint64(4))
+	c.Assert(report.Issues[0].Removed, IsTrue)
+
+	// Only the hole of [d, f) is left.
+	report, err = CheckRegions("etcd", storage.Base, nil, false)
+	c.Assert(err, IsNil)
+	c.Assert(report.RegionCount, Equals, 3)
+	c.Assert(report.StaleCount, Equals, 0)
+	c.Assert(report.Issues, HasLen, 1)
+	c.Assert(report.Issues[0].Type, Equals, RegionIssueHole)
+	c.Assert(report.Issues[0].StartKey, Equals, "64")
+}
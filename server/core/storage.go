@@ -20,10 +20,13 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/typeutil"
 	"github.com/pingcap/pd/server/kv"
 	"github.com/pkg/errors"
 	"go.etcd.io/etcd/clientv3"
@@ -34,6 +37,14 @@ const (
 	configPath   = "config"
 	schedulePath = "schedule"
 	gcPath       = "gc"
+	// replicationPath is the path to save the status of the replication mode.
+	replicationPath = "replication_mode"
+	// rbacPath is the path to save the access control of the HTTP API.
+	rbacPath = "rbac"
+	// componentConfigPath is the path to save the configs of the components,
+	// and componentConfigVersionPath is the latest version of them.
+	componentConfigPath        = "component_config"
+	componentConfigVersionPath = "component_config_version"
 
 	customScheduleConfigPath = "scheduler_config"
 )
@@ -47,7 +58,10 @@ const (
 type Storage struct {
 	kv.Base
 	regionStorage    *RegionStorage
+	trendStorage     *TrendStorage
 	useRegionStorage int32
+	// configMu serializes the versions of the configuration.
+	configMu sync.Mutex
 }
 
 // NewStorage creates Storage instance with Base.
@@ -68,6 +82,17 @@ func (s *Storage) GetRegionStorage() *RegionStorage {
 	return s.regionStorage
 }
 
+// SetTrendStorage sets the trend storage.
+func (s *Storage) SetTrendStorage(trendStorage *TrendStorage) *Storage {
+	s.trendStorage = trendStorage
+	return s
+}
+
+// GetTrendStorage gets the trend storage.
+func (s *Storage) GetTrendStorage() *TrendStorage {
+	return s.trendStorage
+}
+
 // SwitchToRegionStorage switches to the region storage.
 func (s *Storage) SwitchToRegionStorage() {
 	atomic.StoreInt32(&s.useRegionStorage, 1)
@@ -105,12 +130,24 @@ func (s *Storage) SaveScheduleConfig(scheduleName string, data []byte) error {
 	return s.Save(configPath, string(data))
 }
 
+// SaveScheduleConfigOp returns the operation to save the config of scheduler
+// in a transaction.
+func (s *Storage) SaveScheduleConfigOp(scheduleName string, data []byte) kv.Op {
+	return kv.SaveOp(path.Join(customScheduleConfigPath, scheduleName), string(data))
+}
+
 // RemoveScheduleConfig remvoes the config of scheduler.
 func (s *Storage) RemoveScheduleConfig(scheduleName string) error {
 	configPath := path.Join(customScheduleConfigPath, scheduleName)
 	return s.Remove(configPath)
 }
 
+// RemoveScheduleConfigOp returns the operation to remove the config of
+// scheduler in a transaction.
+func (s *Storage) RemoveScheduleConfigOp(scheduleName string) kv.Op {
+	return kv.RemoveOp(path.Join(customScheduleConfigPath, scheduleName))
+}
+
 // LoadScheduleConfig loads the config of scheduler.
 func (s *Storage) LoadScheduleConfig(scheduleName string) (string, error) {
 	configPath := path.Join(customScheduleConfigPath, scheduleName)
@@ -127,14 +164,46 @@ func (s *Storage) SaveMeta(meta *metapb.Cluster) error {
 	return saveProto(s.Base, clusterPath, meta)
 }
 
+// Bootstrap saves the cluster meta, the bootstrap time, the first store and
+// the first region in a transaction. It returns kv.ErrTxnConditionFailed if
+// the cluster is already bootstrapped.
+func (s *Storage) Bootstrap(meta *metapb.Cluster, store *metapb.Store, region *metapb.Region, bootstrapTime time.Time) error {
+	ops := make([]kv.Op, 0, 4)
+	for _, item := range []struct {
+		key string
+		msg proto.Message
+	}{
+		{clusterPath, meta},
+		{s.storePath(store.GetId()), store},
+		{regionPath(region.GetId()), region},
+	} {
+		op, err := saveProtoOp(item.key, item.msg)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, op)
+	}
+	timeData := typeutil.Uint64ToBytes(uint64(bootstrapTime.UnixNano()))
+	ops = append(ops, kv.SaveOp(s.ClusterStatePath("raft_bootstrap_time"), string(timeData)))
+	return s.Txn([]kv.Cmp{kv.NotExist(clusterPath)}, ops...)
+}
+
 // LoadStore loads one store from storage.
 func (s *Storage) LoadStore(storeID uint64, store *metapb.Store) (bool, error) {
 	return loadProto(s.Base, s.storePath(storeID), store)
 }
 
 // SaveStore saves one store to storage.
-func (s *Storage) SaveStore(store *metapb.Store) error {
-	return saveProto(s.Base, s.storePath(store.GetId()), store)
+// The extra operations are applied in the same transaction.
+func (s *Storage) SaveStore(store *metapb.Store, ops ...kv.Op) error {
+	if len(ops) == 0 {
+		return saveProto(s.Base, s.storePath(store.GetId()), store)
+	}
+	op, err := saveProtoOp(s.storePath(store.GetId()), store)
+	if err != nil {
+		return err
+	}
+	return s.Txn(nil, append([]kv.Op{op}, ops...)...)
 }
 
 // DeleteStore deletes one store from storage.
@@ -174,13 +243,15 @@ func (s *Storage) DeleteRegion(region *metapb.Region) error {
 	return deleteRegion(s.Base, region)
 }
 
-// SaveConfig stores marshalable cfg to the configPath.
-func (s *Storage) SaveConfig(cfg interface{}) error {
+// SaveConfig stores marshalable cfg to the configPath and records it as a new
+// version changed by the source. The extra operations are applied in the same
+// transaction.
+func (s *Storage) SaveConfig(cfg interface{}, source string, ops ...kv.Op) error {
 	value, err := json.Marshal(cfg)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	return s.Save(configPath, string(value))
+	return s.saveConfigVersion(string(value), source, ops)
 }
 
 // LoadConfig loads config from configPath then unmarshal it to cfg.
@@ -235,12 +306,18 @@ func (s *Storage) LoadStores(f func(store *StoreInfo)) error {
 
 // SaveStoreWeight saves a store's leader and region weight to storage.
 func (s *Storage) SaveStoreWeight(storeID uint64, leader, region float64) error {
+	return s.Txn(nil, s.SaveStoreWeightOps(storeID, leader, region)...)
+}
+
+// SaveStoreWeightOps returns the operations to save a store's leader and
+// region weight in a transaction.
+func (s *Storage) SaveStoreWeightOps(storeID uint64, leader, region float64) []kv.Op {
 	leaderValue := strconv.FormatFloat(leader, 'f', -1, 64)
-	if err := s.Save(s.storeLeaderWeightPath(storeID), leaderValue); err != nil {
-		return err
-	}
 	regionValue := strconv.FormatFloat(region, 'f', -1, 64)
-	return s.Save(s.storeRegionWeightPath(storeID), regionValue)
+	return []kv.Op{
+		kv.SaveOp(s.storeLeaderWeightPath(storeID), leaderValue),
+		kv.SaveOp(s.storeRegionWeightPath(storeID), regionValue),
+	}
 }
 
 func (s *Storage) loadFloatWithDefaultValue(path string, def float64) (float64, error) {
@@ -268,12 +345,110 @@ func (s *Storage) Flush() error {
 
 // Close closes the s.
 func (s *Storage) Close() error {
+	if s.trendStorage != nil {
+		if err := s.trendStorage.Close(); err != nil {
+			return err
+		}
+	}
 	if s.regionStorage != nil {
 		return s.regionStorage.Close()
 	}
 	return nil
 }
 
+// SaveReplicationStatus stores the status of the replication mode.
+func (s *Storage) SaveReplicationStatus(mode string, status interface{}) error {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return s.Save(path.Join(replicationPath, mode), string(value))
+}
+
+// LoadReplicationStatus loads the status of the replication mode.
+func (s *Storage) LoadReplicationStatus(mode string, status interface{}) (bool, error) {
+	value, err := s.Load(path.Join(replicationPath, mode))
+	if err != nil {
+		return false, err
+	}
+	if value == "" {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(value), status); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, nil
+}
+
+// SaveRBACConfig stores the access control config of the HTTP API.
+func (s *Storage) SaveRBACConfig(cfg interface{}) error {
+	value, err := json.Marshal(cfg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return s.Save(rbacPath, string(value))
+}
+
+// LoadRBACConfig loads the access control config of the HTTP API.
+func (s *Storage) LoadRBACConfig(cfg interface{}) (bool, error) {
+	value, err := s.Load(rbacPath)
+	if err != nil {
+		return false, err
+	}
+	if value == "" {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(value), cfg); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, nil
+}
+
+// SaveComponentConfig stores a layer of the component configs by its key and
+// the version of it. It fails if the latest version is not the previous one,
+// which means another PD has saved the configs.
+func (s *Storage) SaveComponentConfig(key string, cfg interface{}, version uint64) error {
+	value, err := json.Marshal(cfg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var prev string
+	if version > 1 {
+		prev = strconv.FormatUint(version-1, 10)
+	}
+	return s.Txn([]kv.Cmp{kv.ValueEqual(componentConfigVersionPath, prev)},
+		kv.SaveOp(path.Join(componentConfigPath, key), string(value)),
+		kv.SaveOp(componentConfigVersionPath, strconv.FormatUint(version, 10)))
+}
+
+// LoadComponentConfigs loads all layers of the component configs by their
+// keys, and the latest version of them.
+func (s *Storage) LoadComponentConfigs(f func(key, value string) error) (uint64, error) {
+	prefix := componentConfigPath + "/"
+	nextKey, endKey := prefix, clientv3.GetPrefixRangeEnd(prefix)
+	for {
+		keys, values, err := s.LoadRange(nextKey, endKey, minKVRangeLimit)
+		if err != nil {
+			return 0, err
+		}
+		for i, key := range keys {
+			if err := f(strings.TrimPrefix(key, prefix), values[i]); err != nil {
+				return 0, err
+			}
+		}
+		if len(keys) < minKVRangeLimit {
+			break
+		}
+		nextKey = keys[len(keys)-1] + "\x00"
+	}
+	value, err := s.Load(componentConfigVersionPath)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	version, err := strconv.ParseUint(value, 10, 64)
+	return version, errors.WithStack(err)
+}
+
 // SaveGCSafePoint saves new GC safe point to storage.
 func (s *Storage) SaveGCSafePoint(safePoint uint64) error {
 	key := path.Join(gcPath, "safe_point")
@@ -326,3 +501,11 @@ func saveProto(s kv.Base, key string, msg proto.Message) error {
 	}
 	return s.Save(key, string(value))
 }
+
+func saveProtoOp(key string, msg proto.Message) (kv.Op, error) {
+	value, err := proto.Marshal(msg)
+	if err != nil {
+		return kv.Op{}, errors.WithStack(err)
+	}
+	return kv.SaveOp(key, string(value)), nil
+}
@@ -14,8 +14,12 @@
 package core
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"os"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -132,6 +136,36 @@ func mustSaveRegions(c *C, s *Storage, n int) []*metapb.Region {
 	return regions
 }
 
+func (s *testKVSuite) TestBootstrap(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+	meta := &metapb.Cluster{Id: 1, MaxPeerCount: 3}
+	store := &metapb.Store{Id: 2}
+	region := &metapb.Region{Id: 3, Peers: []*metapb.Peer{{Id: 4, StoreId: 2}}}
+	c.Assert(storage.Bootstrap(meta, store, region, time.Now()), IsNil)
+
+	newMeta := &metapb.Cluster{}
+	ok, err := storage.LoadMeta(newMeta)
+	c.Assert(ok, IsTrue)
+	c.Assert(err, IsNil)
+	c.Assert(newMeta, DeepEquals, meta)
+	ok, err = storage.LoadStore(2, &metapb.Store{})
+	c.Assert(ok, IsTrue)
+	c.Assert(err, IsNil)
+	ok, err = storage.LoadRegion(3, &metapb.Region{})
+	c.Assert(ok, IsTrue)
+	c.Assert(err, IsNil)
+	v, err := storage.Load(storage.ClusterStatePath("raft_bootstrap_time"))
+	c.Assert(err, IsNil)
+	c.Assert(v, Not(Equals), "")
+
+	// It cannot be bootstrapped twice.
+	err = storage.Bootstrap(meta, &metapb.Store{Id: 5}, region, time.Now())
+	c.Assert(errors.Cause(err), Equals, kv.ErrTxnConditionFailed)
+	ok, err = storage.LoadStore(5, &metapb.Store{})
+	c.Assert(ok, IsFalse)
+	c.Assert(err, IsNil)
+}
+
 func (s *testKVSuite) TestLoadRegions(c *C) {
 	storage := NewStorage(kv.NewMemoryKV())
 	cache := NewRegionsInfo()
@@ -194,3 +228,51 @@ func newTestRegionMeta(regionID uint64) *metapb.Region {
 		EndKey:   []byte(fmt.Sprintf("%20d", regionID+1)),
 	}
 }
+
+func (s *testKVSuite) TestTrendStorage(c *C) {
+	dir, err := ioutil.TempDir("/tmp", "store-trend")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	storage, err := NewTrendStorage(dir)
+	c.Assert(err, IsNil)
+	defer storage.Close()
+
+	now := time.Now().Unix()
+	base := now - 30*60
+	base -= base % (10 * 60)
+	for i := int64(0); i < 25; i++ {
+		p := &StoreTrendPoint{Timestamp: base + i*60, RegionCount: int(i)}
+		c.Assert(storage.AppendStoreTrend(1, p), IsNil)
+	}
+
+	points, err := storage.LoadStoreTrend(1, time.Unix(base, 0), time.Unix(now, 0))
+	c.Assert(err, IsNil)
+	c.Assert(points, HasLen, 25)
+	c.Assert(points[24].RegionCount, Equals, 24)
+	points, err = storage.LoadStoreTrend(1, time.Unix(base+5*60, 0), time.Unix(base+10*60, 0))
+	c.Assert(err, IsNil)
+	c.Assert(points, HasLen, 5)
+	points, err = storage.LoadStoreTrend(2, time.Unix(base, 0), time.Unix(now, 0))
+	c.Assert(err, IsNil)
+	c.Assert(points, HasLen, 0)
+
+	// The first two windows of 10 minutes are downsampled.
+	_, values, err := storage.LoadRange(trendLevelPrefix("10m", 1), trendLevelPrefix("10m", 2), 100)
+	c.Assert(err, IsNil)
+	c.Assert(values, HasLen, 2)
+	p := &StoreTrendPoint{}
+	c.Assert(json.Unmarshal([]byte(values[1]), p), IsNil)
+	c.Assert(p.Timestamp, Equals, base+10*60)
+	c.Assert(p.RegionCount, Equals, 14)
+
+	// The expired samples are removed.
+	c.Assert(storage.AppendStoreTrend(1, &StoreTrendPoint{Timestamp: base + 48*3600}), IsNil)
+	keys, _, err := storage.LoadRange(trendLevelPrefix("1m", 1), trendLevelPrefix("1m", 2), 100)
+	c.Assert(err, IsNil)
+	c.Assert(keys, HasLen, 1)
+
+	c.Assert(storage.DeleteStoreTrend(1), IsNil)
+	keys, _, err = storage.LoadRange(trendPath, trendPath+"0", 100)
+	c.Assert(err, IsNil)
+	c.Assert(keys, HasLen, 0)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/clock"
 	"go.uber.org/zap"
 )
 
@@ -41,7 +42,9 @@ type StoreInfo struct {
 	lastHeartbeatTS  time.Time
 	leaderWeight     float64
 	regionWeight     float64
-	available        func() bool
+	// slowScore indicates how much slower the store is than the others.
+	slowScore float64
+	available func() bool
 }
 
 // NewStoreInfo creates StoreInfo with meta data.
@@ -73,6 +76,7 @@ func (s *StoreInfo) Clone(opts ...StoreCreateOption) *StoreInfo {
 		lastHeartbeatTS:  s.lastHeartbeatTS,
 		leaderWeight:     s.leaderWeight,
 		regionWeight:     s.regionWeight,
+		slowScore:        s.slowScore,
 		available:        s.available,
 	}
 
@@ -87,6 +91,11 @@ func (s *StoreInfo) IsBlocked() bool {
 	return s.blocked
 }
 
+// GetSlowScore returns the slow score of the store.
+func (s *StoreInfo) GetSlowScore() float64 {
+	return s.slowScore
+}
+
 // IsAvailable returns if the store bucket of limitation is available
 func (s *StoreInfo) IsAvailable() bool {
 	if s.available == nil {
@@ -112,7 +121,7 @@ func (s *StoreInfo) IsTombstone() bool {
 
 // DownTime returns the time elapsed since last heartbeat.
 func (s *StoreInfo) DownTime() time.Duration {
-	return time.Since(s.GetLastHeartbeatTS())
+	return clock.Since(s.GetLastHeartbeatTS())
 }
 
 // GetMeta returns the meta information of the store.
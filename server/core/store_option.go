@@ -117,6 +117,13 @@ func SetLeaderWeight(leaderWeight float64) StoreCreateOption {
 	}
 }
 
+// SetSlowScore sets the slow score for the store.
+func SetSlowScore(slowScore float64) StoreCreateOption {
+	return func(store *StoreInfo) {
+		store.slowScore = slowScore
+	}
+}
+
 // SetRegionWeight sets the Region weight for the store.
 func SetRegionWeight(regionWeight float64) StoreCreateOption {
 	return func(store *StoreInfo) {
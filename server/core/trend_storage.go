@@ -0,0 +1,247 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pingcap/pd/server/kv"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const trendPath = "trend"
+
+// StoreTrendPoint is a sample of the store status saved in the trend storage.
+// The points of the downsampled levels hold the average values of the window.
+type StoreTrendPoint struct {
+	// Timestamp is the unix timestamp in seconds of the sample, or the start
+	// of the window for downsampled points.
+	Timestamp   int64  `json:"timestamp"`
+	Capacity    uint64 `json:"capacity"`
+	Available   uint64 `json:"available"`
+	UsedSize    uint64 `json:"used_size"`
+	RegionCount int    `json:"region_count"`
+	LeaderCount int    `json:"leader_count"`
+
+	BytesWriteRate float64 `json:"bytes_write_rate"`
+	BytesReadRate  float64 `json:"bytes_read_rate"`
+	KeysWriteRate  float64 `json:"keys_write_rate"`
+	KeysReadRate   float64 `json:"keys_read_rate"`
+
+	// HeartbeatLatency is the time in milliseconds between the end of the
+	// reported interval and the time PD received the store heartbeat.
+	HeartbeatLatency float64 `json:"heartbeat_latency"`
+}
+
+// trendLevel is a resolution of the saved time series.
+type trendLevel struct {
+	name      string
+	step      time.Duration
+	retention time.Duration
+}
+
+// trendLevels are sorted from the finest resolution to the coarsest one. Each
+// sample is saved in the first level, and the others are downsampled from it.
+var trendLevels = []trendLevel{
+	{name: "1m", step: time.Minute, retention: 24 * time.Hour},
+	{name: "10m", step: 10 * time.Minute, retention: 7 * 24 * time.Hour},
+	{name: "1h", step: time.Hour, retention: 30 * 24 * time.Hour},
+}
+
+// trendAccumulator sums up the samples of a window for downsampling.
+type trendAccumulator struct {
+	window int64
+	count  int
+	sum    StoreTrendPoint
+}
+
+func (a *trendAccumulator) add(p *StoreTrendPoint) {
+	a.count++
+	a.sum.Capacity += p.Capacity
+	a.sum.Available += p.Available
+	a.sum.UsedSize += p.UsedSize
+	a.sum.RegionCount += p.RegionCount
+	a.sum.LeaderCount += p.LeaderCount
+	a.sum.BytesWriteRate += p.BytesWriteRate
+	a.sum.BytesReadRate += p.BytesReadRate
+	a.sum.KeysWriteRate += p.KeysWriteRate
+	a.sum.KeysReadRate += p.KeysReadRate
+	a.sum.HeartbeatLatency += p.HeartbeatLatency
+}
+
+func (a *trendAccumulator) average() *StoreTrendPoint {
+	n := a.count
+	return &StoreTrendPoint{
+		Timestamp:        a.window,
+		Capacity:         a.sum.Capacity / uint64(n),
+		Available:        a.sum.Available / uint64(n),
+		UsedSize:         a.sum.UsedSize / uint64(n),
+		RegionCount:      a.sum.RegionCount / n,
+		LeaderCount:      a.sum.LeaderCount / n,
+		BytesWriteRate:   a.sum.BytesWriteRate / float64(n),
+		BytesReadRate:    a.sum.BytesReadRate / float64(n),
+		KeysWriteRate:    a.sum.KeysWriteRate / float64(n),
+		KeysReadRate:     a.sum.KeysReadRate / float64(n),
+		HeartbeatLatency: a.sum.HeartbeatLatency / float64(n),
+	}
+}
+
+// TrendStorage is used to save the time series of the store status.
+type TrendStorage struct {
+	*kv.LeveldbKV
+	mu sync.Mutex
+	// storeID -> level index -> accumulator, the first level is not used.
+	accumulators map[uint64][]*trendAccumulator
+}
+
+// NewTrendStorage returns a trend storage that is used to save the store trend.
+func NewTrendStorage(path string) (*TrendStorage, error) {
+	levelDB, err := kv.NewLeveldbKV(path)
+	if err != nil {
+		return nil, err
+	}
+	return &TrendStorage{
+		LeveldbKV:    levelDB,
+		accumulators: make(map[uint64][]*trendAccumulator),
+	}, nil
+}
+
+func trendLevelPrefix(level string, storeID uint64) string {
+	return path.Join(trendPath, level, fmt.Sprintf("%020d", storeID))
+}
+
+func trendPointPath(level string, storeID uint64, ts int64) string {
+	return path.Join(trendLevelPrefix(level, storeID), fmt.Sprintf("%020d", ts))
+}
+
+// AppendStoreTrend saves a sample of the store, and downsamples the samples
+// into the coarser levels when their windows are completed.
+func (s *TrendStorage) AppendStoreTrend(storeID uint64, p *StoreTrendPoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+	if err := s.putPoint(batch, trendLevels[0], storeID, p); err != nil {
+		return err
+	}
+
+	accs, ok := s.accumulators[storeID]
+	if !ok {
+		accs = make([]*trendAccumulator, len(trendLevels))
+		s.accumulators[storeID] = accs
+	}
+	for i := 1; i < len(trendLevels); i++ {
+		level := trendLevels[i]
+		window := p.Timestamp - p.Timestamp%int64(level.step/time.Second)
+		acc := accs[i]
+		if acc != nil && acc.window != window {
+			if err := s.putPoint(batch, level, storeID, acc.average()); err != nil {
+				return err
+			}
+			acc = nil
+		}
+		if acc == nil {
+			acc = &trendAccumulator{window: window}
+			accs[i] = acc
+		}
+		acc.add(p)
+	}
+	return errors.WithStack(s.Write(batch, nil))
+}
+
+// putPoint adds the point and removes the expired points of the level.
+func (s *TrendStorage) putPoint(batch *leveldb.Batch, level trendLevel, storeID uint64, p *StoreTrendPoint) error {
+	value, err := json.Marshal(p)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	batch.Put([]byte(trendPointPath(level.name, storeID, p.Timestamp)), value)
+
+	expired := p.Timestamp - int64(level.retention/time.Second)
+	if expired <= 0 {
+		return nil
+	}
+	iter := s.NewIterator(&util.Range{
+		Start: []byte(trendPointPath(level.name, storeID, 0)),
+		Limit: []byte(trendPointPath(level.name, storeID, expired)),
+	}, nil)
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+	iter.Release()
+	return errors.WithStack(iter.Error())
+}
+
+// LoadStoreTrend loads the time series of the store in [start, end). It uses
+// the finest level which still keeps the samples at the start time.
+func (s *TrendStorage) LoadStoreTrend(storeID uint64, start, end time.Time) ([]*StoreTrendPoint, error) {
+	level := trendLevels[len(trendLevels)-1]
+	for _, l := range trendLevels {
+		if time.Since(start) <= l.retention {
+			level = l
+			break
+		}
+	}
+	startTS, endTS := start.Unix(), end.Unix()
+	if startTS < 0 {
+		startTS = 0
+	}
+
+	iter := s.NewIterator(&util.Range{
+		Start: []byte(trendPointPath(level.name, storeID, startTS)),
+		Limit: []byte(trendPointPath(level.name, storeID, endTS)),
+	}, nil)
+	defer iter.Release()
+	var points []*StoreTrendPoint
+	for iter.Next() {
+		p := &StoreTrendPoint{}
+		if err := json.Unmarshal(iter.Value(), p); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		points = append(points, p)
+	}
+	return points, errors.WithStack(iter.Error())
+}
+
+// DeleteStoreTrend removes all the saved samples of the store.
+func (s *TrendStorage) DeleteStoreTrend(storeID uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.accumulators, storeID)
+
+	batch := new(leveldb.Batch)
+	for _, level := range trendLevels {
+		prefix := trendLevelPrefix(level.name, storeID) + "/"
+		iter := s.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+		for iter.Next() {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return errors.WithStack(s.Write(batch, nil))
+}
+
+// Close closes the kv.
+func (s *TrendStorage) Close() error {
+	return errors.WithStack(s.LeveldbKV.Close())
+}
@@ -0,0 +1,410 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/logutil"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule/operator"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Stages of removing the failed stores.
+const (
+	RemovalIdle       = "idle"
+	RemovalCollecting = "collecting"
+	RemovalExecuting  = "executing"
+	RemovalFinished   = "finished"
+	RemovalFailed     = "failed"
+)
+
+// States of the removal plans.
+const (
+	PlanPending  = "pending"
+	PlanRunning  = "running"
+	PlanFinished = "finished"
+)
+
+const defaultRemoveFailedStoresTimeout = 10 * time.Minute
+
+// RemovalPlan is the plan to remove the failed peers of a region which still
+// has a quorum.
+type RemovalPlan struct {
+	RegionID    uint64         `json:"region_id"`
+	State       string         `json:"state"`
+	FailedPeers []*metapb.Peer `json:"failed_peers"`
+}
+
+// LostKeyRange is the key range of a region whose peers are all on the failed
+// stores, the keys are encoded in hex.
+type LostKeyRange struct {
+	RegionID uint64 `json:"region_id"`
+	StartKey string `json:"start_key"`
+	EndKey   string `json:"end_key"`
+}
+
+// RemoveFailedStoresStatus is the status and the report of removing the
+// failed stores.
+type RemoveFailedStoresStatus struct {
+	Stage        string    `json:"stage"`
+	FailedStores []uint64  `json:"failed_stores,omitempty"`
+	StartTime    time.Time `json:"start_time,omitempty"`
+	Deadline     time.Time `json:"deadline,omitempty"`
+	FinishTime   time.Time `json:"finish_time,omitempty"`
+	// ReportedStores are the surviving stores which have sent heartbeats since
+	// the removal started, PendingStores are the ones waited for.
+	ReportedStores []uint64       `json:"reported_stores,omitempty"`
+	PendingStores  []uint64       `json:"pending_stores,omitempty"`
+	Plans          []*RemovalPlan `json:"plans,omitempty"`
+	FinishedPlans  int            `json:"finished_plans"`
+	// QuorumLostRegions are the regions whose surviving voters are not a
+	// quorum. PD cannot remove the failed peers from them, so the removal is
+	// rejected before any change if there is such a region.
+	QuorumLostRegions []uint64        `json:"quorum_lost_regions,omitempty"`
+	LostKeyRanges     []*LostKeyRange `json:"lost_key_ranges,omitempty"`
+	Error             string          `json:"error,omitempty"`
+}
+
+// failedStoresRemover removes the peers on the failed stores from the regions
+// which still have a quorum. It waits for the heartbeats of the surviving
+// stores to make sure the region information is up to date, then plans the
+// removal for every region which has peers on the failed stores and runs the
+// plans by operators.
+type failedStoresRemover struct {
+	sync.RWMutex
+	cluster      *RaftCluster
+	tickInterval time.Duration
+	status       *RemoveFailedStoresStatus
+	failedStores map[uint64]struct{}
+
+	// reportMu protects reported, it is updated in the store heartbeats with
+	// the lock of the cluster held, so it is separated from the other fields.
+	reportMu sync.Mutex
+	reported map[uint64]struct{}
+}
+
+func newFailedStoresRemover(cluster *RaftCluster) *failedStoresRemover {
+	return &failedStoresRemover{
+		cluster:      cluster,
+		tickInterval: time.Second,
+		status:       &RemoveFailedStoresStatus{Stage: RemovalIdle},
+	}
+}
+
+func (u *failedStoresRemover) isRunning() bool {
+	return u.status.Stage == RemovalCollecting || u.status.Stage == RemovalExecuting
+}
+
+// removeFailedStores starts the removal, and it runs until finished, timeout or quit.
+func (u *failedStoresRemover) removeFailedStores(storeIDs []uint64, timeout time.Duration, quit <-chan struct{}) error {
+	u.Lock()
+	defer u.Unlock()
+	if u.isRunning() {
+		return errors.New("removing failed stores is already running")
+	}
+	if len(storeIDs) == 0 {
+		return errors.New("no failed store is specified")
+	}
+	failedStores := make(map[uint64]struct{}, len(storeIDs))
+	for _, id := range storeIDs {
+		store := u.cluster.GetStore(id)
+		if store == nil {
+			return core.NewStoreNotFoundErr(id)
+		}
+		if store.IsTombstone() {
+			return errors.Errorf("store %d is tombstone", id)
+		}
+		failedStores[id] = struct{}{}
+	}
+	var surviving int
+	for _, store := range u.cluster.GetStores() {
+		if _, ok := failedStores[store.GetID()]; !ok && !store.IsTombstone() {
+			surviving++
+		}
+	}
+	if surviving == 0 {
+		return errors.New("no surviving store")
+	}
+	if timeout <= 0 {
+		timeout = defaultRemoveFailedStoresTimeout
+	}
+
+	for id := range failedStores {
+		if err := u.cluster.BlockStore(id); err != nil {
+			log.Warn("failed to block the failed store", zap.Uint64("store-id", id), zap.Error(err))
+		}
+	}
+	now := time.Now()
+	u.failedStores = failedStores
+	u.status = &RemoveFailedStoresStatus{
+		Stage:        RemovalCollecting,
+		FailedStores: sortedIDs(failedStores),
+		StartTime:    now,
+		Deadline:     now.Add(timeout),
+	}
+	u.reportMu.Lock()
+	u.reported = make(map[uint64]struct{})
+	u.reportMu.Unlock()
+	log.Info("start to remove failed stores", zap.Uint64s("failed-stores", u.status.FailedStores), zap.Duration("timeout", timeout))
+
+	go u.run(quit)
+	return nil
+}
+
+// onStoreHeartbeat records the store which has reported since the removal started.
+func (u *failedStoresRemover) onStoreHeartbeat(storeID uint64) {
+	u.reportMu.Lock()
+	defer u.reportMu.Unlock()
+	if u.reported != nil {
+		u.reported[storeID] = struct{}{}
+	}
+}
+
+func (u *failedStoresRemover) run(quit <-chan struct{}) {
+	defer logutil.LogPanic()
+
+	ticker := time.NewTicker(u.tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-quit:
+			u.Lock()
+			u.finishLocked(errors.New("cluster is stopped"))
+			u.Unlock()
+			return
+		case <-ticker.C:
+			if !u.step() {
+				return
+			}
+		}
+	}
+}
+
+// step moves the removal forward, it returns false if the removal is done.
+func (u *failedStoresRemover) step() bool {
+	u.Lock()
+	defer u.Unlock()
+
+	switch u.status.Stage {
+	case RemovalCollecting:
+		if time.Now().After(u.status.Deadline) {
+			u.finishLocked(errors.New("timeout while waiting for the surviving stores"))
+			return false
+		}
+		if u.collectLocked() {
+			u.planLocked()
+			if n := len(u.status.QuorumLostRegions); n > 0 {
+				u.finishLocked(errors.Errorf("%d regions have lost the quorum, recover them with `tikv-ctl unsafe-recover remove-fail-stores` on the surviving stores first", n))
+				return false
+			}
+			u.status.Stage = RemovalExecuting
+		}
+		return true
+	case RemovalExecuting:
+		if time.Now().After(u.status.Deadline) {
+			u.finishLocked(errors.New("timeout while executing the plans"))
+			return false
+		}
+		if u.executeLocked() {
+			u.finishLocked(nil)
+			return false
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// affectedRegions returns the regions which have peers on the failed stores.
+func (u *failedStoresRemover) affectedRegions() []*core.RegionInfo {
+	var regions []*core.RegionInfo
+	for _, region := range u.cluster.GetRegions() {
+		for _, peer := range region.GetPeers() {
+			if _, ok := u.failedStores[peer.GetStoreId()]; ok {
+				regions = append(regions, region)
+				break
+			}
+		}
+	}
+	return regions
+}
+
+// collectLocked checks whether all surviving stores which hold the peers of the
+// affected regions have sent their heartbeats.
+func (u *failedStoresRemover) collectLocked() bool {
+	required := make(map[uint64]struct{})
+	for _, region := range u.affectedRegions() {
+		for _, peer := range region.GetPeers() {
+			if _, ok := u.failedStores[peer.GetStoreId()]; !ok {
+				required[peer.GetStoreId()] = struct{}{}
+			}
+		}
+	}
+	reported := make(map[uint64]struct{})
+	pending := make(map[uint64]struct{})
+	u.reportMu.Lock()
+	for id := range required {
+		if _, ok := u.reported[id]; ok {
+			reported[id] = struct{}{}
+		} else {
+			pending[id] = struct{}{}
+		}
+	}
+	u.reportMu.Unlock()
+	u.status.ReportedStores = sortedIDs(reported)
+	u.status.PendingStores = sortedIDs(pending)
+	return len(pending) == 0
+}
+
+func (u *failedStoresRemover) planLocked() {
+	for _, region := range u.affectedRegions() {
+		var failedPeers []*metapb.Peer
+		var aliveVoters []*metapb.Peer
+		for _, peer := range region.GetPeers() {
+			if _, ok := u.failedStores[peer.GetStoreId()]; ok {
+				failedPeers = append(failedPeers, peer)
+			} else if !peer.GetIsLearner() {
+				aliveVoters = append(aliveVoters, peer)
+			}
+		}
+		voters := len(region.GetVoters())
+		switch {
+		case len(aliveVoters) == 0:
+			u.status.LostKeyRanges = append(u.status.LostKeyRanges, &LostKeyRange{
+				RegionID: region.GetID(),
+				StartKey: string(core.HexRegionKey(region.GetStartKey())),
+				EndKey:   string(core.HexRegionKey(region.GetEndKey())),
+			})
+		case len(aliveVoters)*2 > voters:
+			u.status.Plans = append(u.status.Plans, &RemovalPlan{
+				RegionID:    region.GetID(),
+				State:       PlanPending,
+				FailedPeers: failedPeers,
+			})
+		default:
+			u.status.QuorumLostRegions = append(u.status.QuorumLostRegions, region.GetID())
+		}
+	}
+	log.Info("failed stores removal plans are generated",
+		zap.Int("plans", len(u.status.Plans)),
+		zap.Int("quorum-lost-regions", len(u.status.QuorumLostRegions)),
+		zap.Int("lost-key-ranges", len(u.status.LostKeyRanges)))
+}
+
+// executeLocked creates the operators to remove the failed peers, one peer at
+// a time for every region. It returns true if all plans are finished.
+func (u *failedStoresRemover) executeLocked() bool {
+	opController := u.cluster.GetOperatorController()
+	done := true
+	finished := 0
+	for _, plan := range u.status.Plans {
+		if plan.State == PlanFinished {
+			finished++
+			continue
+		}
+		region := u.cluster.GetRegion(plan.RegionID)
+		failedStore, hasFailedPeer := u.findFailedPeer(region)
+		if region == nil || !hasFailedPeer {
+			plan.State = PlanFinished
+			finished++
+			continue
+		}
+		done = false
+		if opController.GetOperator(plan.RegionID) != nil {
+			continue
+		}
+		// Wait for the region to elect a new leader.
+		if region.GetLeader() == nil {
+			continue
+		}
+		if _, ok := u.failedStores[region.GetLeader().GetStoreId()]; ok {
+			continue
+		}
+		op, err := operator.CreateRemovePeerOperator("remove-failed-stores", u.cluster, operator.OpAdmin, region, failedStore)
+		if err != nil {
+			log.Warn("failed to create the operator to remove the failed peer", zap.Uint64("region-id", plan.RegionID), zap.Error(err))
+			continue
+		}
+		op.SetPriorityLevel(core.HighPriority)
+		if opController.AddOperator(op) {
+			plan.State = PlanRunning
+		}
+	}
+	u.status.FinishedPlans = finished
+	return done
+}
+
+func (u *failedStoresRemover) findFailedPeer(region *core.RegionInfo) (uint64, bool) {
+	if region == nil {
+		return 0, false
+	}
+	for _, peer := range region.GetPeers() {
+		if _, ok := u.failedStores[peer.GetStoreId()]; ok {
+			return peer.GetStoreId(), true
+		}
+	}
+	return 0, false
+}
+
+func (u *failedStoresRemover) finishLocked(err error) {
+	if !u.isRunning() {
+		return
+	}
+	for id := range u.failedStores {
+		u.cluster.UnblockStore(id)
+	}
+	u.status.FinishTime = time.Now()
+	if err != nil {
+		u.status.Stage = RemovalFailed
+		u.status.Error = err.Error()
+		log.Warn("failed to remove failed stores", zap.Error(err))
+	} else {
+		u.status.Stage = RemovalFinished
+		log.Info("failed stores are removed",
+			zap.Int("finished-plans", u.status.FinishedPlans),
+			zap.Int("lost-key-ranges", len(u.status.LostKeyRanges)))
+	}
+	u.reportMu.Lock()
+	u.reported = nil
+	u.reportMu.Unlock()
+}
+
+func (u *failedStoresRemover) getStatus() *RemoveFailedStoresStatus {
+	u.RLock()
+	defer u.RUnlock()
+	status := *u.status
+	plans := make([]*RemovalPlan, 0, len(status.Plans))
+	for _, plan := range status.Plans {
+		p := *plan
+		plans = append(plans, &p)
+	}
+	status.Plans = plans
+	return &status
+}
+
+func sortedIDs(ids map[uint64]struct{}) []uint64 {
+	res := make([]uint64, 0, len(ids))
+	for id := range ids {
+		res = append(res, id)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
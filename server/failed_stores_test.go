@@ -0,0 +1,132 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/namespace"
+)
+
+var _ = Suite(&testFailedStoresSuite{})
+
+type testFailedStoresSuite struct{}
+
+func (s *testFailedStoresSuite) TestRemoveFailedStores(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	tc := newTestCluster(opt)
+	hbStreams, cleanup := getHeartBeatStreams(c, tc)
+	defer cleanup()
+	defer hbStreams.Close()
+	tc.coordinator = newCoordinator(tc.RaftCluster, hbStreams, namespace.DefaultClassifier)
+	oc := tc.coordinator.opController
+
+	for id := uint64(1); id <= 5; id++ {
+		c.Assert(tc.addRegionStore(id, 1), IsNil)
+	}
+	// Region 1 still has a quorum and region 3 has lost all peers.
+	c.Assert(tc.addLeaderRegion(1, 1, 2, 3), IsNil)
+	c.Assert(tc.addLeaderRegion(3, 3, 4, 5), IsNil)
+
+	u := tc.failedStores
+	u.tickInterval = time.Hour
+	quit := make(chan struct{})
+	defer close(quit)
+	c.Assert(tc.RemoveFailedStores(nil, 0), NotNil)
+	c.Assert(tc.RemoveFailedStores([]uint64{1, 2, 3, 4, 5}, 0), NotNil)
+	c.Assert(u.removeFailedStores([]uint64{3, 4, 5}, time.Minute, quit), IsNil)
+	c.Assert(u.removeFailedStores([]uint64{3}, time.Minute, quit), NotNil)
+	c.Assert(tc.GetStore(3).IsBlocked(), IsTrue)
+
+	// Wait for the surviving stores to report.
+	c.Assert(u.step(), IsTrue)
+	status := tc.GetRemoveFailedStoresStatus()
+	c.Assert(status.Stage, Equals, RemovalCollecting)
+	c.Assert(status.PendingStores, DeepEquals, []uint64{1, 2})
+	u.onStoreHeartbeat(1)
+	u.onStoreHeartbeat(2)
+	c.Assert(u.step(), IsTrue)
+	status = tc.GetRemoveFailedStoresStatus()
+	c.Assert(status.Stage, Equals, RemovalExecuting)
+	c.Assert(status.Plans, HasLen, 1)
+	c.Assert(status.LostKeyRanges, HasLen, 1)
+	c.Assert(status.LostKeyRanges[0].RegionID, Equals, uint64(3))
+
+	// The failed peer of region 1 is removed by an operator.
+	c.Assert(u.step(), IsTrue)
+	op := oc.GetOperator(1)
+	c.Assert(op, NotNil)
+	c.Assert(tc.GetRemoveFailedStoresStatus().Plans[0].State, Equals, PlanRunning)
+	c.Assert(oc.RemoveOperator(op), IsTrue)
+	region := tc.GetRegion(1).Clone(core.WithRemoveStorePeer(3), core.WithIncConfVer())
+	c.Assert(tc.putRegion(region), IsNil)
+
+	c.Assert(u.step(), IsFalse)
+	status = tc.GetRemoveFailedStoresStatus()
+	c.Assert(status.Stage, Equals, RemovalFinished)
+	c.Assert(status.FinishedPlans, Equals, 1)
+	c.Assert(tc.GetStore(3).IsBlocked(), IsFalse)
+}
+
+func (s *testFailedStoresSuite) TestLostQuorum(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	tc := newTestCluster(opt)
+	for id := uint64(1); id <= 3; id++ {
+		c.Assert(tc.addRegionStore(id, 1), IsNil)
+	}
+	c.Assert(tc.addLeaderRegion(1, 1, 2, 3), IsNil)
+	c.Assert(tc.addLeaderRegion(2, 2, 1, 3), IsNil)
+
+	u := tc.failedStores
+	u.tickInterval = time.Hour
+	quit := make(chan struct{})
+	defer close(quit)
+	c.Assert(u.removeFailedStores([]uint64{2, 3}, time.Minute, quit), IsNil)
+	u.onStoreHeartbeat(1)
+
+	// The removal is rejected before any change.
+	c.Assert(u.step(), IsFalse)
+	status := tc.GetRemoveFailedStoresStatus()
+	c.Assert(status.Stage, Equals, RemovalFailed)
+	c.Assert(status.Error, Matches, "2 regions have lost the quorum.*")
+	c.Assert(status.QuorumLostRegions, DeepEquals, []uint64{1, 2})
+	c.Assert(status.Plans, HasLen, 0)
+	c.Assert(tc.GetStore(2).IsBlocked(), IsFalse)
+}
+
+func (s *testFailedStoresSuite) TestTimeout(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	tc := newTestCluster(opt)
+	for id := uint64(1); id <= 3; id++ {
+		c.Assert(tc.addRegionStore(id, 1), IsNil)
+	}
+	c.Assert(tc.addLeaderRegion(1, 1, 2, 3), IsNil)
+
+	u := tc.failedStores
+	u.tickInterval = time.Hour
+	quit := make(chan struct{})
+	defer close(quit)
+	c.Assert(u.removeFailedStores([]uint64{3}, time.Millisecond, quit), IsNil)
+	time.Sleep(10 * time.Millisecond)
+	c.Assert(u.step(), IsFalse)
+	status := tc.GetRemoveFailedStoresStatus()
+	c.Assert(status.Stage, Equals, RemovalFailed)
+	c.Assert(status.Error, Matches, "timeout.*")
+	c.Assert(tc.GetStore(3).IsBlocked(), IsFalse)
+}
@@ -262,6 +262,7 @@ func (s *Server) StoreHeartbeat(ctx context.Context, request *pdpb.StoreHeartbea
 	if request.GetStats() == nil {
 		return nil, errors.Errorf("invalid store heartbeat command, but %v", request)
 	}
+	s.recorder.RecordStoreHeartbeat(request)
 	cluster := s.GetRaftCluster()
 	if cluster == nil {
 		return &pdpb.StoreHeartbeatResponse{Header: s.notBootstrappedHeader()}, nil
@@ -279,7 +280,8 @@ func (s *Server) StoreHeartbeat(ctx context.Context, request *pdpb.StoreHeartbea
 	}
 
 	return &pdpb.StoreHeartbeatResponse{
-		Header: s.header(),
+		Header:            s.header(),
+		ReplicationStatus: cluster.GetReplicationMode().HeartbeatStatus(),
 	}, nil
 }
 
@@ -327,12 +329,17 @@ func (s *heartbeatServer) Recv() (*pdpb.RegionHeartbeatRequest, error) {
 // RegionHeartbeat implements gRPC PDServer.
 func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
 	server := &heartbeatServer{stream: stream}
+	works := newSimulationWorks()
 	cluster := s.GetRaftCluster()
 	if cluster == nil {
 		resp := &pdpb.RegionHeartbeatResponse{
 			Header: s.notBootstrappedHeader(),
 		}
+		works.start()
 		err := server.Send(resp)
+		if err != nil {
+			works.end()
+		}
 		return errors.WithStack(err)
 	}
 
@@ -346,48 +353,58 @@ func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
 			return errors.WithStack(err)
 		}
 
-		if err = s.validateRequest(request.GetHeader()); err != nil {
+		err = s.handleRegionHeartbeat(cluster, server, request, &lastBind)
+		// The simulator waits for the heartbeat to be handled on the virtual clock.
+		works.end()
+		if err != nil {
 			return err
 		}
+	}
+}
 
-		storeID := request.GetLeader().GetStoreId()
-		storeLabel := strconv.FormatUint(storeID, 10)
-		store := cluster.GetStore(storeID)
-		if store == nil {
-			return errors.Errorf("invalid store ID %d, not found", storeID)
-		}
-		storeAddress := store.GetAddress()
+func (s *Server) handleRegionHeartbeat(cluster *RaftCluster, server *heartbeatServer, request *pdpb.RegionHeartbeatRequest, lastBind *time.Time) error {
+	if err := s.validateRequest(request.GetHeader()); err != nil {
+		return err
+	}
+	s.recorder.RecordRegionHeartbeat(request)
 
-		regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "report", "recv").Inc()
-		regionHeartbeatLatency.WithLabelValues(storeAddress, storeLabel).Observe(float64(time.Now().Unix()) - float64(request.GetInterval().GetEndTimestamp()))
+	storeID := request.GetLeader().GetStoreId()
+	storeLabel := strconv.FormatUint(storeID, 10)
+	store := cluster.GetStore(storeID)
+	if store == nil {
+		return errors.Errorf("invalid store ID %d, not found", storeID)
+	}
+	storeAddress := store.GetAddress()
 
-		hbStreams := cluster.GetHeartbeatStreams()
+	regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "report", "recv").Inc()
+	regionHeartbeatLatency.WithLabelValues(storeAddress, storeLabel).Observe(float64(time.Now().Unix()) - float64(request.GetInterval().GetEndTimestamp()))
 
-		if time.Since(lastBind) > s.cfg.HeartbeatStreamBindInterval.Duration {
-			regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "report", "bind").Inc()
-			hbStreams.bindStream(storeID, server)
-			lastBind = time.Now()
-		}
+	hbStreams := cluster.GetHeartbeatStreams()
 
-		region := core.RegionFromHeartbeat(request)
-		if region.GetLeader() == nil {
-			log.Error("invalid request, the leader is nil", zap.Reflect("reqeust", request))
-			continue
-		}
-		if region.GetID() == 0 {
-			msg := fmt.Sprintf("invalid request region, %v", request)
-			hbStreams.sendErr(pdpb.ErrorType_UNKNOWN, msg, request.GetLeader(), storeAddress, storeLabel)
-			continue
-		}
+	if time.Since(*lastBind) > s.cfg.HeartbeatStreamBindInterval.Duration {
+		regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "report", "bind").Inc()
+		hbStreams.bindStream(storeID, server)
+		*lastBind = time.Now()
+	}
 
-		err = cluster.HandleRegionHeartbeat(region)
-		if err != nil {
-			msg := err.Error()
-			hbStreams.sendErr(pdpb.ErrorType_UNKNOWN, msg, request.GetLeader(), storeAddress, storeLabel)
-		}
+	region := core.RegionFromHeartbeat(request)
+	if region.GetLeader() == nil {
+		log.Error("invalid request, the leader is nil", zap.Reflect("reqeust", request))
+		return nil
+	}
+	if region.GetID() == 0 {
+		msg := fmt.Sprintf("invalid request region, %v", request)
+		hbStreams.sendErr(pdpb.ErrorType_UNKNOWN, msg, request.GetLeader(), storeAddress, storeLabel)
+		return nil
+	}
 
-		regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "report", "ok").Inc()
+	if err := cluster.HandleRegionHeartbeat(region); err != nil {
+		msg := err.Error()
+		hbStreams.sendErr(pdpb.ErrorType_UNKNOWN, msg, request.GetLeader(), storeAddress, storeLabel)
 	}
+
+	regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "report", "ok").Inc()
+	return nil
 }
 
 // GetRegion implements gRPC PDServer.
@@ -395,6 +412,11 @@ func (s *Server) GetRegion(ctx context.Context, request *pdpb.GetRegionRequest)
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	release, err := s.limit("GetRegion")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	cluster := s.GetRaftCluster()
 	if cluster == nil {
@@ -413,6 +435,11 @@ func (s *Server) GetPrevRegion(ctx context.Context, request *pdpb.GetRegionReque
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	release, err := s.limit("GetPrevRegion")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	cluster := s.GetRaftCluster()
 	if cluster == nil {
@@ -432,6 +459,11 @@ func (s *Server) GetRegionByID(ctx context.Context, request *pdpb.GetRegionByIDR
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	release, err := s.limit("GetRegionByID")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	cluster := s.GetRaftCluster()
 	if cluster == nil {
@@ -451,6 +483,11 @@ func (s *Server) ScanRegions(ctx context.Context, request *pdpb.ScanRegionsReque
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	release, err := s.limit("ScanRegions")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	cluster := s.GetRaftCluster()
 	if cluster == nil {
@@ -575,6 +612,11 @@ func (s *Server) GetClusterConfig(ctx context.Context, request *pdpb.GetClusterC
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	release, err := s.limit("GetClusterConfig")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	cluster := s.GetRaftCluster()
 	if cluster == nil {
@@ -591,6 +633,11 @@ func (s *Server) PutClusterConfig(ctx context.Context, request *pdpb.PutClusterC
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	release, err := s.limit("PutClusterConfig")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	cluster := s.GetRaftCluster()
 	if cluster == nil {
@@ -613,6 +660,11 @@ func (s *Server) ScatterRegion(ctx context.Context, request *pdpb.ScatterRegionR
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	release, err := s.limit("ScatterRegion")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	cluster := s.GetRaftCluster()
 	if cluster == nil {
@@ -714,11 +766,22 @@ func (s *Server) UpdateGCSafePoint(ctx context.Context, request *pdpb.UpdateGCSa
 	}, nil
 }
 
+// UpdateServiceGCSafePoint implements gRPC PDServer. Service safe points are
+// not supported yet, so it answers the same as a server without the method.
+func (s *Server) UpdateServiceGCSafePoint(ctx context.Context, request *pdpb.UpdateServiceGCSafePointRequest) (*pdpb.UpdateServiceGCSafePointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "service gc safe point is not supported")
+}
+
 // GetOperator gets information about the operator belonging to the speicfy region.
 func (s *Server) GetOperator(ctx context.Context, request *pdpb.GetOperatorRequest) (*pdpb.GetOperatorResponse, error) {
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	release, err := s.limit("GetOperator")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	cluster := s.GetRaftCluster()
 	if cluster == nil {
@@ -757,6 +820,19 @@ func (s *Server) validateRequest(header *pdpb.RequestHeader) error {
 	return nil
 }
 
+// limit checks the rate limit of the gRPC method. The returned function should
+// be called after the request is handled. Only the expensive client-facing
+// methods, the region queries and the operator and config calls, are limited.
+// The heartbeats, TSO and ID allocation are never limited, because rejecting
+// them disturbs the whole cluster rather than a client.
+func (s *Server) limit(method string) (func(), error) {
+	release, err := s.limiter.Allow(method)
+	if err != nil {
+		return nil, status.Errorf(codes.ResourceExhausted, "%s: %s", method, err)
+	}
+	return release, nil
+}
+
 func (s *Server) header() *pdpb.ResponseHeader {
 	return &pdpb.ResponseHeader{ClusterId: s.clusterID}
 }
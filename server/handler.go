@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/pd/server/config"
 	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/kv"
 	"github.com/pingcap/pd/server/schedule"
 	"github.com/pingcap/pd/server/schedule/operator"
 	"github.com/pingcap/pd/server/statistics"
@@ -63,6 +64,11 @@ var (
 	ErrStoreNotFound = func(storeID uint64) error {
 		return errors.Errorf("store %v not found", storeID)
 	}
+	// ErrConfigVersionNotFound is error info for the version of the
+	// configuration not found.
+	ErrConfigVersionNotFound = func(version uint64) error {
+		return errors.Errorf("config version %v not found", version)
+	}
 )
 
 // Handler is a helper to export methods to handle API/RPC requests.
@@ -179,6 +185,10 @@ func (h *Handler) GetHotKeysReadStores() map[uint64]uint64 {
 
 // AddScheduler adds a scheduler.
 func (h *Handler) AddScheduler(name string, args ...string) error {
+	return h.addScheduler(core.ConfigSourceAPI, name, args...)
+}
+
+func (h *Handler) addScheduler(source, name string, args ...string) error {
 	c, err := h.getCoordinator()
 	if err != nil {
 		return err
@@ -189,26 +199,70 @@ func (h *Handler) AddScheduler(name string, args ...string) error {
 		return err
 	}
 	log.Info("create scheduler", zap.String("scheduler-name", s.GetName()))
-	if err = c.addScheduler(s, args...); err != nil {
+	if err = c.addSchedulerWithSource(s, source, args...); err != nil {
 		log.Error("can not add scheduler", zap.String("scheduler-name", s.GetName()), zap.Error(err))
-	} else if err = h.opt.Persist(c.cluster.storage); err != nil {
-		log.Error("can not persist scheduler config", zap.Error(err))
 	}
 	return err
 }
 
 // RemoveScheduler removes a scheduler by name.
 func (h *Handler) RemoveScheduler(name string) error {
+	return h.removeScheduler(core.ConfigSourceAPI, name)
+}
+
+func (h *Handler) removeScheduler(source, name string) error {
 	c, err := h.getCoordinator()
 	if err != nil {
 		return err
 	}
-	if err = c.removeScheduler(name); err != nil {
+	if err = c.removeSchedulerWithSource(name, source); err != nil {
 		log.Error("can not remove scheduler", zap.String("scheduler-name", name), zap.Error(err))
 	}
 	return err
 }
 
+// syncSchedulers adds and removes the schedulers to match the scheduler
+// configurations, the disabled ones are removed.
+func (h *Handler) syncSchedulers(cfgs config.SchedulerConfigs, source string) error {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return err
+	}
+	var names []string
+	wanted := make(map[string]config.SchedulerConfig)
+	for _, cfg := range cfgs {
+		if cfg.Disable {
+			continue
+		}
+		// To create a temporary scheduler is just used to get scheduler's name.
+		tmp, err := schedule.CreateScheduler(cfg.Type, schedule.NewOperatorController(nil, nil), core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(cfg.Type, cfg.Args))
+		if err != nil {
+			return err
+		}
+		names = append(names, tmp.GetName())
+		wanted[tmp.GetName()] = cfg
+	}
+	running := make(map[string]struct{})
+	for _, name := range c.getSchedulers() {
+		running[name] = struct{}{}
+		if _, ok := wanted[name]; !ok {
+			if err := h.removeScheduler(source, name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range names {
+		if _, ok := running[name]; ok {
+			continue
+		}
+		cfg := wanted[name]
+		if err := h.addScheduler(source, cfg.Type, cfg.Args...); err != nil && errors.Cause(err) != errSchedulerExisted {
+			return err
+		}
+	}
+	return nil
+}
+
 // AddBalanceLeaderScheduler adds a balance-leader-scheduler.
 func (h *Handler) AddBalanceLeaderScheduler() error {
 	return h.AddScheduler("balance-leader")
@@ -249,6 +303,11 @@ func (h *Handler) AddEvictLeaderScheduler(storeID uint64) error {
 	return h.AddScheduler("evict-leader", strconv.FormatUint(storeID, 10))
 }
 
+// AddEvictSlowStoreScheduler adds an evict-slow-store-scheduler.
+func (h *Handler) AddEvictSlowStoreScheduler() error {
+	return h.AddScheduler("evict-slow-store")
+}
+
 // AddShuffleLeaderScheduler adds a shuffle-leader-scheduler.
 func (h *Handler) AddShuffleLeaderScheduler() error {
 	return h.AddScheduler("shuffle-leader")
@@ -363,6 +422,15 @@ func (h *Handler) GetOperatorsOfKind(mask operator.OpKind) ([]*operator.Operator
 	return results, nil
 }
 
+// GetStoreTrend returns the saved time series of the store in [start, end).
+func (h *Handler) GetStoreTrend(storeID uint64, start, end time.Time) ([]*core.StoreTrendPoint, error) {
+	cluster := h.s.GetRaftCluster()
+	if cluster == nil {
+		return nil, errors.WithStack(ErrNotBootstrapped)
+	}
+	return cluster.GetStoreTrend(storeID, start, end)
+}
+
 // GetHistory returns finished operators' history since start.
 func (h *Handler) GetHistory(start time.Time) ([]operator.OpHistory, error) {
 	c, err := h.getCoordinator()
@@ -22,6 +22,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/pkg/logutil"
 	"github.com/pingcap/pd/server/core"
 	"go.uber.org/zap"
@@ -33,6 +34,28 @@ type heartbeatStream interface {
 	Send(*pdpb.RegionHeartbeatResponse) error
 }
 
+// simulationWorks counts the messages between PD and the simulated stores as
+// the works of the virtual clock, so that the clock of the simulator is
+// settled after they are handled. It is only enabled on the virtual clock, and
+// does nothing in production.
+type simulationWorks bool
+
+func newSimulationWorks() simulationWorks {
+	return clock.GetVirtual() != nil
+}
+
+func (w simulationWorks) start() {
+	if w {
+		clock.StartWork()
+	}
+}
+
+func (w simulationWorks) end() {
+	if w {
+		clock.EndWork()
+	}
+}
+
 type streamUpdate struct {
 	storeID uint64
 	stream  heartbeatStream
@@ -47,6 +70,7 @@ type heartbeatStreams struct {
 	msgCh     chan *pdpb.RegionHeartbeatResponse
 	streamCh  chan streamUpdate
 	cluster   *RaftCluster
+	works     simulationWorks
 }
 
 func newHeartbeatStreams(clusterID uint64, cluster *RaftCluster) *heartbeatStreams {
@@ -59,6 +83,7 @@ func newHeartbeatStreams(clusterID uint64, cluster *RaftCluster) *heartbeatStrea
 		msgCh:     make(chan *pdpb.RegionHeartbeatResponse, regionheartbeatSendChanCap),
 		streamCh:  make(chan streamUpdate, 1),
 		cluster:   cluster,
+		works:     newSimulationWorks(),
 	}
 	hs.wg.Add(1)
 	go hs.run()
@@ -88,6 +113,7 @@ func (s *heartbeatStreams) run() {
 					zap.Uint64("region-id", msg.RegionId),
 					zap.Uint64("store-id", storeID))
 				delete(s.streams, storeID)
+				s.works.end()
 				continue
 			}
 			storeAddress := store.GetAddress()
@@ -97,6 +123,7 @@ func (s *heartbeatStreams) run() {
 						zap.Uint64("region-id", msg.RegionId), zap.Error(err))
 					delete(s.streams, storeID)
 					regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "push", "err").Inc()
+					s.works.end()
 				} else {
 					regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "push", "ok").Inc()
 				}
@@ -105,6 +132,7 @@ func (s *heartbeatStreams) run() {
 					zap.Uint64("region-id", msg.RegionId),
 					zap.Uint64("store-id", storeID))
 				regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "push", "skip").Inc()
+				s.works.end()
 			}
 		case <-keepAliveTicker.C:
 			for storeID, stream := range s.streams {
@@ -116,12 +144,14 @@ func (s *heartbeatStreams) run() {
 				}
 				storeAddress := store.GetAddress()
 				storeLabel := strconv.FormatUint(storeID, 10)
+				s.works.start()
 				if err := stream.Send(keepAlive); err != nil {
 					log.Error("send keepalive message fail",
 						zap.Uint64("target-store-id", storeID),
 						zap.Error(err))
 					delete(s.streams, storeID)
 					regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "keepalive", "err").Inc()
+					s.works.end()
 				} else {
 					regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "keepalive", "ok").Inc()
 				}
@@ -158,9 +188,12 @@ func (s *heartbeatStreams) SendMsg(region *core.RegionInfo, msg *pdpb.RegionHear
 	msg.RegionEpoch = region.GetRegionEpoch()
 	msg.TargetPeer = region.GetLeader()
 
+	// The message is a work until the store handles it on the virtual clock.
+	s.works.start()
 	select {
 	case s.msgCh <- msg:
 	case <-s.ctx.Done():
+		s.works.end()
 	}
 }
 
@@ -178,8 +211,10 @@ func (s *heartbeatStreams) sendErr(errType pdpb.ErrorType, errMsg string, target
 		TargetPeer: targetPeer,
 	}
 
+	s.works.start()
 	select {
 	case s.msgCh <- msg:
 	case <-s.ctx.Done():
+		s.works.end()
 	}
 }
@@ -66,14 +66,23 @@ func (kv *etcdKVBase) Load(key string) (string, error) {
 }
 
 func (kv *etcdKVBase) LoadRange(key, endKey string, limit int) ([]string, []string, error) {
+	keys, values, _, err := kv.LoadRangeAtRevision(key, endKey, limit, 0)
+	return keys, values, err
+}
+
+// LoadRangeAtRevision loads the range at the given revision, or the latest
+// revision if it is 0. It also returns the revision of the read, so that a
+// large range can be loaded consistently by the pages read at the revision.
+func (kv *etcdKVBase) LoadRangeAtRevision(key, endKey string, limit int, revision int64) ([]string, []string, int64, error) {
 	key = path.Join(kv.rootPath, key)
 	endKey = path.Join(kv.rootPath, endKey)
 
 	withRange := clientv3.WithRange(endKey)
 	withLimit := clientv3.WithLimit(int64(limit))
-	resp, err := etcdutil.EtcdKVGet(kv.client, key, withRange, withLimit)
+	withRev := clientv3.WithRev(revision)
+	resp, err := etcdutil.EtcdKVGet(kv.client, key, withRange, withLimit, withRev)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
 	}
 	keys := make([]string, 0, len(resp.Kvs))
 	values := make([]string, 0, len(resp.Kvs))
@@ -81,7 +90,11 @@ func (kv *etcdKVBase) LoadRange(key, endKey string, limit int) ([]string, []stri
 		keys = append(keys, strings.TrimPrefix(strings.TrimPrefix(string(item.Key), kv.rootPath), "/"))
 		values = append(values, string(item.Value))
 	}
-	return keys, values, nil
+	// The revision of the header is the latest one rather than the read one.
+	if revision == 0 {
+		revision = resp.Header.GetRevision()
+	}
+	return keys, values, revision, nil
 }
 
 func (kv *etcdKVBase) Save(key, value string) error {
@@ -114,6 +127,39 @@ func (kv *etcdKVBase) Remove(key string) error {
 	return nil
 }
 
+func (kv *etcdKVBase) Txn(conds []Cmp, ops ...Op) error {
+	cmps := make([]clientv3.Cmp, 0, len(conds))
+	for _, cond := range conds {
+		key := path.Join(kv.rootPath, cond.Key)
+		if cond.Value == "" {
+			cmps = append(cmps, clientv3.Compare(clientv3.CreateRevision(key), "=", 0))
+		} else {
+			cmps = append(cmps, clientv3.Compare(clientv3.Value(key), "=", cond.Value))
+		}
+	}
+	etcdOps := make([]clientv3.Op, 0, len(ops))
+	for _, op := range ops {
+		key := path.Join(kv.rootPath, op.Key)
+		switch op.Type {
+		case OpSave:
+			etcdOps = append(etcdOps, clientv3.OpPut(key, op.Value))
+		case OpRemove:
+			etcdOps = append(etcdOps, clientv3.OpDelete(key))
+		}
+	}
+
+	txn := NewSlowLogTxn(kv.client)
+	resp, err := txn.If(cmps...).Then(etcdOps...).Commit()
+	if err != nil {
+		log.Error("commit txn to etcd meet error", zap.Error(err))
+		return errors.WithStack(err)
+	}
+	if !resp.Succeeded {
+		return errors.WithStack(ErrTxnConditionFailed)
+	}
+	return nil
+}
+
 // SlowLogTxn wraps etcd transaction and log slow one.
 type SlowLogTxn struct {
 	clientv3.Txn
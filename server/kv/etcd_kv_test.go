@@ -87,6 +87,19 @@ func (s *testEtcdKVSuite) TestEtcdKV(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(v, Equals, "")
 
+	// The later reads at the revision are not affected by the changes.
+	ks, vs, rev, err := kv.LoadRangeAtRevision(keys[0], "test/zzz", 2, 0)
+	c.Assert(err, IsNil)
+	c.Assert(ks, DeepEquals, []string{keys[0], keys[2]})
+	c.Assert(kv.Save(keys[3], "val6"), IsNil)
+	ks, vs, rev2, err := kv.LoadRangeAtRevision(keys[3], "test/zzz", 2, rev)
+	c.Assert(err, IsNil)
+	c.Assert(rev2, Equals, rev)
+	c.Assert(ks, DeepEquals, keys[3:])
+	c.Assert(vs, DeepEquals, vals[3:])
+
+	testTxn(c, kv)
+
 	etcd.Close()
 	cleanConfig(cfg)
 }
@@ -13,10 +13,62 @@
 
 package kv
 
+import "github.com/pkg/errors"
+
+// ErrTxnConditionFailed is returned when the conditions of a transaction are
+// not satisfied.
+var ErrTxnConditionFailed = errors.New("the conditions of the transaction are not satisfied")
+
 // Base is an abstract interface for load/save pd cluster data.
 type Base interface {
 	Load(key string) (string, error)
 	LoadRange(key, endKey string, limit int) (keys []string, values []string, err error)
 	Save(key, value string) error
 	Remove(key string) error
+	// Txn applies the operations atomically if all the conditions are
+	// satisfied, otherwise it returns ErrTxnConditionFailed.
+	Txn(conds []Cmp, ops ...Op) error
+}
+
+// OpType is the type of an operation in a transaction.
+type OpType int
+
+// Types of the operations.
+const (
+	OpSave OpType = iota
+	OpRemove
+)
+
+// Op is an operation in a transaction.
+type Op struct {
+	Type  OpType
+	Key   string
+	Value string
+}
+
+// SaveOp returns an operation to save a key-value pair.
+func SaveOp(key, value string) Op {
+	return Op{Type: OpSave, Key: key, Value: value}
+}
+
+// RemoveOp returns an operation to remove a key.
+func RemoveOp(key string) Op {
+	return Op{Type: OpRemove, Key: key}
+}
+
+// Cmp is a condition of a transaction, it is satisfied if the value of the key
+// equals the value. As Load does, an empty value means the key does not exist.
+type Cmp struct {
+	Key   string
+	Value string
+}
+
+// ValueEqual returns a condition that the value of the key equals the value.
+func ValueEqual(key, value string) Cmp {
+	return Cmp{Key: key, Value: value}
+}
+
+// NotExist returns a condition that the key does not exist.
+func NotExist(key string) Cmp {
+	return Cmp{Key: key}
 }
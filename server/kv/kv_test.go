@@ -0,0 +1,64 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/pingcap/check"
+	"github.com/pkg/errors"
+)
+
+type testKVSuite struct{}
+
+var _ = Suite(&testKVSuite{})
+
+func (s *testKVSuite) TestMemoryKVTxn(c *C) {
+	testTxn(c, NewMemoryKV())
+}
+
+func (s *testKVSuite) TestLeveldbKVTxn(c *C) {
+	dir, err := ioutil.TempDir("", "leveldb_kv")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	kv, err := NewLeveldbKV(dir)
+	c.Assert(err, IsNil)
+	defer kv.Close()
+	testTxn(c, kv)
+}
+
+func testTxn(c *C, kv Base) {
+	// The operations are applied without the conditions.
+	c.Assert(kv.Txn(nil, SaveOp("txn/a", "1"), SaveOp("txn/b", "2")), IsNil)
+	ks, vs, err := kv.LoadRange("txn/", "txn/z", 100)
+	c.Assert(err, IsNil)
+	c.Assert(ks, DeepEquals, []string{"txn/a", "txn/b"})
+	c.Assert(vs, DeepEquals, []string{"1", "2"})
+
+	// Nothing is applied if any condition is not satisfied.
+	err = kv.Txn([]Cmp{ValueEqual("txn/a", "1"), NotExist("txn/b")}, RemoveOp("txn/a"), SaveOp("txn/c", "3"))
+	c.Assert(errors.Cause(err), Equals, ErrTxnConditionFailed)
+	err = kv.Txn([]Cmp{ValueEqual("txn/a", "2")}, RemoveOp("txn/a"))
+	c.Assert(errors.Cause(err), Equals, ErrTxnConditionFailed)
+	ks, _, err = kv.LoadRange("txn/", "txn/z", 100)
+	c.Assert(err, IsNil)
+	c.Assert(ks, DeepEquals, []string{"txn/a", "txn/b"})
+
+	c.Assert(kv.Txn([]Cmp{ValueEqual("txn/a", "1"), NotExist("txn/c")}, RemoveOp("txn/a"), SaveOp("txn/c", "3")), IsNil)
+	ks, vs, err = kv.LoadRange("txn/", "txn/z", 100)
+	c.Assert(err, IsNil)
+	c.Assert(ks, DeepEquals, []string{"txn/b", "txn/c"})
+	c.Assert(vs, DeepEquals, []string{"2", "3"})
+}
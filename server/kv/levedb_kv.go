@@ -72,6 +72,44 @@ func (kv *LeveldbKV) Remove(key string) error {
 	return errors.WithStack(kv.Delete([]byte(key), nil))
 }
 
+// Txn applies the operations in a batch. The writes to the db are blocked when
+// the conditions are checked, so they are atomic with respect to all writes.
+func (kv *LeveldbKV) Txn(conds []Cmp, ops ...Op) error {
+	batch := new(leveldb.Batch)
+	for _, op := range ops {
+		switch op.Type {
+		case OpSave:
+			batch.Put([]byte(op.Key), []byte(op.Value))
+		case OpRemove:
+			batch.Delete([]byte(op.Key))
+		}
+	}
+	if len(conds) == 0 {
+		return errors.WithStack(kv.Write(batch, nil))
+	}
+
+	tr, err := kv.OpenTransaction()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, cond := range conds {
+		value, err := tr.Get([]byte(cond.Key), nil)
+		if err != nil && err != leveldb.ErrNotFound {
+			tr.Discard()
+			return errors.WithStack(err)
+		}
+		if string(value) != cond.Value {
+			tr.Discard()
+			return errors.WithStack(ErrTxnConditionFailed)
+		}
+	}
+	if err := tr.Write(batch, nil); err != nil {
+		tr.Discard()
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(tr.Commit())
+}
+
 // SaveRegions stores some regions.
 func (kv *LeveldbKV) SaveRegions(regions map[string]*metapb.Region) error {
 	batch := new(leveldb.Batch)
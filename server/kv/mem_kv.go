@@ -17,6 +17,7 @@ import (
 	"sync"
 
 	"github.com/google/btree"
+	"github.com/pkg/errors"
 )
 
 type memoryKV struct {
@@ -76,3 +77,27 @@ func (kv *memoryKV) Remove(key string) error {
 	kv.tree.Delete(memoryKVItem{key, ""})
 	return nil
 }
+
+func (kv *memoryKV) Txn(conds []Cmp, ops ...Op) error {
+	kv.Lock()
+	defer kv.Unlock()
+
+	for _, cond := range conds {
+		var value string
+		if item := kv.tree.Get(memoryKVItem{cond.Key, ""}); item != nil {
+			value = item.(memoryKVItem).value
+		}
+		if value != cond.Value {
+			return errors.WithStack(ErrTxnConditionFailed)
+		}
+	}
+	for _, op := range ops {
+		switch op.Type {
+		case OpSave:
+			kv.tree.ReplaceOrInsert(memoryKVItem{op.Key, op.Value})
+		case OpRemove:
+			kv.tree.Delete(memoryKVItem{op.Key, ""})
+		}
+	}
+	return nil
+}
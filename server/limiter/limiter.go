@@ -0,0 +1,138 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package limiter
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/juju/ratelimit"
+	"github.com/pingcap/pd/server/config"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrQPSExceeded is returned when the QPS of an endpoint exceeds the limit.
+	ErrQPSExceeded = errors.New("qps exceeds the limit")
+	// ErrConcurrencyExceeded is returned when the requests in progress of an
+	// endpoint exceed the limit.
+	ErrConcurrencyExceeded = errors.New("concurrency exceeds the limit")
+)
+
+type endpointLimit struct {
+	cfg      config.EndpointLimit
+	bucket   *ratelimit.Bucket
+	inflight int64
+}
+
+func newEndpointLimit(cfg config.EndpointLimit) *endpointLimit {
+	l := &endpointLimit{cfg: cfg}
+	if cfg.QPS > 0 {
+		burst := cfg.Burst
+		if burst == 0 {
+			burst = int64(math.Ceil(cfg.QPS))
+		}
+		l.bucket = ratelimit.NewBucketWithRate(cfg.QPS, burst)
+	}
+	return l
+}
+
+func (l *endpointLimit) match(endpoint string) bool {
+	if strings.HasSuffix(l.cfg.Endpoint, "*") {
+		return strings.HasPrefix(endpoint, strings.TrimSuffix(l.cfg.Endpoint, "*"))
+	}
+	return l.cfg.Endpoint == endpoint
+}
+
+// Limiter limits the QPS and the concurrency of the endpoints.
+type Limiter struct {
+	mu     sync.RWMutex
+	limits []*endpointLimit
+}
+
+// NewLimiter creates a Limiter.
+func NewLimiter(cfg *config.RateLimitConfig) *Limiter {
+	l := &Limiter{}
+	l.Update(cfg)
+	return l
+}
+
+// Update replaces the limits. The endpoints whose limits are not changed keep
+// the states, so the requests in progress are still counted.
+func (l *Limiter) Update(cfg *config.RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := make(map[config.EndpointLimit]*endpointLimit, len(l.limits))
+	for _, limit := range l.limits {
+		old[limit.cfg] = limit
+	}
+	limits := make([]*endpointLimit, 0, len(cfg.Limits))
+	for _, c := range cfg.Limits {
+		if limit, ok := old[c]; ok {
+			limits = append(limits, limit)
+		} else {
+			limits = append(limits, newEndpointLimit(c))
+		}
+	}
+	l.limits = limits
+}
+
+// find returns the limit of the endpoint. The exact one is preferred, then the
+// pattern with the longest prefix.
+func (l *Limiter) find(endpoint string) *endpointLimit {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var found *endpointLimit
+	for _, limit := range l.limits {
+		if !limit.match(endpoint) {
+			continue
+		}
+		if limit.cfg.Endpoint == endpoint {
+			return limit
+		}
+		if found == nil || len(limit.cfg.Endpoint) > len(found.cfg.Endpoint) {
+			found = limit
+		}
+	}
+	return found
+}
+
+// Allow checks if a request of the endpoint can be handled now. The returned
+// function should be called after the request is handled if it is allowed.
+func (l *Limiter) Allow(endpoint string) (func(), error) {
+	limit := l.find(endpoint)
+	if limit == nil {
+		return func() {}, nil
+	}
+	if limit.cfg.Concurrency > 0 {
+		if atomic.AddInt64(&limit.inflight, 1) > limit.cfg.Concurrency {
+			atomic.AddInt64(&limit.inflight, -1)
+			rejectedCounter.WithLabelValues(endpoint, "concurrency").Inc()
+			return nil, ErrConcurrencyExceeded
+		}
+	}
+	release := func() {
+		if limit.cfg.Concurrency > 0 {
+			atomic.AddInt64(&limit.inflight, -1)
+		}
+	}
+	if limit.bucket != nil && limit.bucket.TakeAvailable(1) == 0 {
+		release()
+		rejectedCounter.WithLabelValues(endpoint, "qps").Inc()
+		return nil, ErrQPSExceeded
+	}
+	return release, nil
+}
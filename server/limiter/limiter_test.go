@@ -0,0 +1,112 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package limiter
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server/config"
+)
+
+func TestLimiter(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testLimiterSuite{})
+
+type testLimiterSuite struct{}
+
+func (s *testLimiterSuite) TestConcurrency(c *C) {
+	l := NewLimiter(&config.RateLimitConfig{Limits: []config.EndpointLimit{
+		{Endpoint: "/api/v1/regions/check/*", Concurrency: 2},
+		{Endpoint: "/api/v1/regions/check/down-peer", Concurrency: 1},
+	}})
+
+	// The endpoints matching the pattern share the limit.
+	release1, err := l.Allow("/api/v1/regions/check/miss-peer")
+	c.Assert(err, IsNil)
+	release2, err := l.Allow("/api/v1/regions/check/pending-peer")
+	c.Assert(err, IsNil)
+	_, err = l.Allow("/api/v1/regions/check/miss-peer")
+	c.Assert(err, Equals, ErrConcurrencyExceeded)
+	// The exact one is preferred.
+	release3, err := l.Allow("/api/v1/regions/check/down-peer")
+	c.Assert(err, IsNil)
+	_, err = l.Allow("/api/v1/regions/check/down-peer")
+	c.Assert(err, Equals, ErrConcurrencyExceeded)
+	// Other endpoints are not limited.
+	_, err = l.Allow("/api/v1/regions")
+	c.Assert(err, IsNil)
+
+	release1()
+	release3()
+	_, err = l.Allow("/api/v1/regions/check/miss-peer")
+	c.Assert(err, IsNil)
+	_, err = l.Allow("/api/v1/regions/check/down-peer")
+	c.Assert(err, IsNil)
+
+	// The requests in progress are still counted if the limit is not changed.
+	l.Update(&config.RateLimitConfig{Limits: []config.EndpointLimit{
+		{Endpoint: "/api/v1/regions/check/*", Concurrency: 2},
+	}})
+	_, err = l.Allow("/api/v1/regions/check/down-peer")
+	c.Assert(err, Equals, ErrConcurrencyExceeded)
+	release2()
+	_, err = l.Allow("/api/v1/regions/check/down-peer")
+	c.Assert(err, IsNil)
+}
+
+func (s *testLimiterSuite) TestQPS(c *C) {
+	l := NewLimiter(&config.RateLimitConfig{Limits: []config.EndpointLimit{
+		{Endpoint: "ScanRegions", QPS: 0.01, Burst: 2},
+		{Endpoint: "GetRegion", QPS: 0.01, Concurrency: 5},
+	}})
+	for i := 0; i < 2; i++ {
+		release, err := l.Allow("ScanRegions")
+		c.Assert(err, IsNil)
+		release()
+	}
+	_, err := l.Allow("ScanRegions")
+	c.Assert(err, Equals, ErrQPSExceeded)
+
+	// The burst is the QPS rounded up by default, and the rejected request
+	// does not take the concurrency.
+	_, err = l.Allow("GetRegion")
+	c.Assert(err, IsNil)
+	for i := 0; i < 5; i++ {
+		_, err = l.Allow("GetRegion")
+		c.Assert(err, Equals, ErrQPSExceeded)
+	}
+	c.Assert(l.find("GetRegion").inflight, Equals, int64(1))
+}
+
+func (s *testLimiterSuite) TestConfig(c *C) {
+	cfg := &config.RateLimitConfig{}
+	cfg.SetLimit(config.EndpointLimit{Endpoint: "ScanRegions", QPS: 10})
+	cfg.SetLimit(config.EndpointLimit{Endpoint: "GetRegion", Concurrency: 10})
+	cfg.SetLimit(config.EndpointLimit{Endpoint: "ScanRegions", QPS: 20})
+	c.Assert(cfg.Limits, HasLen, 2)
+	c.Assert(cfg.Limits[1].QPS, Equals, 20.0)
+	c.Assert(cfg.Validate(), IsNil)
+	cfg.SetLimit(config.EndpointLimit{Endpoint: "ScanRegions"})
+	c.Assert(cfg.Limits, HasLen, 1)
+
+	cfg.Limits = append(cfg.Limits, cfg.Limits[0])
+	c.Assert(cfg.Validate(), NotNil)
+	cfg.Limits = []config.EndpointLimit{{Endpoint: "GetRegion", QPS: -1}}
+	c.Assert(cfg.Validate(), NotNil)
+	cfg.Limits = []config.EndpointLimit{{QPS: 1}}
+	c.Assert(cfg.Validate(), NotNil)
+}
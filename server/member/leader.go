@@ -87,6 +87,18 @@ func (m *Member) Etcd() *embed.Etcd {
 	return m.etcd
 }
 
+// HasMember checks whether the name is a member of the cluster. The member
+// list is cached by the embedded etcd, which updates it when the membership
+// change is applied, so no request is sent to etcd.
+func (m *Member) HasMember(name string) bool {
+	for _, member := range m.etcd.Server.Cluster().Members() {
+		if member.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // IsLeader returns whether the server is leader or not.
 func (m *Member) IsLeader() bool {
 	// If server is not started. Both leaderID and ID could be 0.
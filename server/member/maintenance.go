@@ -0,0 +1,245 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/etcdutil"
+	"github.com/pingcap/pd/pkg/logutil"
+	"github.com/pingcap/pd/server/config"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver"
+	"go.uber.org/zap"
+)
+
+// defragTimeout is the timeout of defragmenting a member, the member is
+// blocked during the defragmentation.
+const defragTimeout = 5 * time.Minute
+
+// DBStatus is the status of the backend db of an etcd member.
+type DBStatus struct {
+	MemberID    uint64 `json:"member_id"`
+	Name        string `json:"name"`
+	DBSize      int64  `json:"db_size"`
+	DBSizeInUse int64  `json:"db_size_in_use"`
+	// Fragmentation is the ratio of the free space in the db.
+	Fragmentation float64 `json:"fragmentation"`
+	// QuotaUsage is the ratio of the db size to the backend quota.
+	QuotaUsage     float64   `json:"quota_usage"`
+	LastDefragTime time.Time `json:"last_defrag_time,omitempty"`
+	Error          string    `json:"error,omitempty"`
+
+	clientURL string
+}
+
+// Maintainer watches the db size of the etcd members. It raises alerts when
+// the db is close to the backend quota, and defragments the fragmented members
+// one at a time in the defrag windows. The history is left to the auto
+// compaction of etcd, which keeps the recent revisions for the watchers. It
+// should only run on the PD leader.
+type Maintainer struct {
+	client *clientv3.Client
+	cfg    *config.EtcdMaintenanceConfig
+	quota  int64
+	selfID uint64
+
+	mu         sync.RWMutex
+	statuses   []*DBStatus
+	lastDefrag map[uint64]time.Time
+}
+
+// NewMaintainer creates a Maintainer. The default quota of etcd is used if the
+// quota is 0. selfID is the ID of the local member.
+func NewMaintainer(client *clientv3.Client, cfg *config.EtcdMaintenanceConfig, quota int64, selfID uint64) *Maintainer {
+	if quota <= 0 {
+		quota = etcdserver.DefaultQuotaBytes
+	}
+	return &Maintainer{
+		client:     client,
+		cfg:        cfg,
+		quota:      quota,
+		selfID:     selfID,
+		lastDefrag: make(map[uint64]time.Time),
+	}
+}
+
+// Run checks the members periodically until the context is done.
+func (m *Maintainer) Run(ctx context.Context) {
+	defer logutil.LogPanic()
+
+	ticker := time.NewTicker(m.cfg.CheckInterval.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.check(ctx, time.Now())
+		case <-ctx.Done():
+			log.Info("etcd maintenance is stopped")
+			return
+		}
+	}
+}
+
+func (m *Maintainer) check(ctx context.Context, now time.Time) {
+	listResp, err := etcdutil.ListEtcdMembers(m.client)
+	if err != nil {
+		log.Error("failed to list the etcd members", zap.Error(err))
+		return
+	}
+	var statuses []*DBStatus
+	for _, member := range listResp.Members {
+		if len(member.ClientURLs) == 0 {
+			continue
+		}
+		status := &DBStatus{MemberID: member.ID, Name: member.Name, clientURL: member.ClientURLs[0]}
+		statuses = append(statuses, status)
+		resp, err := m.status(ctx, status.clientURL)
+		if err != nil {
+			status.Error = err.Error()
+			continue
+		}
+		m.fillStatus(status, resp.DbSize, resp.DbSizeInUse)
+	}
+	m.setStatuses(statuses)
+
+	if alerts := m.quotaAlerts(statuses); len(alerts) > 0 {
+		for _, status := range alerts {
+			log.Warn("the etcd db is close to the backend quota",
+				zap.String("member", status.Name),
+				zap.Int64("db-size", status.DBSize),
+				zap.Int64("quota", m.quota))
+		}
+		// The auto compaction only frees the pages, the db is shrunk by the
+		// defragmentation. The members close to the quota are defragmented
+		// regardless of the windows once their free space is large enough.
+		if status := pickDefragMember(alerts, m.cfg, m.selfID); status != nil {
+			m.defrag(ctx, status, now)
+			return
+		}
+	}
+
+	if !m.cfg.InDefragWindow(now) {
+		return
+	}
+	if status := pickDefragMember(statuses, m.cfg, m.selfID); status != nil {
+		m.defrag(ctx, status, now)
+	}
+}
+
+func (m *Maintainer) status(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdutil.DefaultRequestTimeout)
+	defer cancel()
+	return m.client.Status(ctx, endpoint)
+}
+
+func (m *Maintainer) fillStatus(status *DBStatus, dbSize, dbSizeInUse int64) {
+	status.DBSize, status.DBSizeInUse = dbSize, dbSizeInUse
+	if dbSize > 0 && dbSizeInUse > 0 {
+		status.Fragmentation = float64(dbSize-dbSizeInUse) / float64(dbSize)
+	}
+	status.QuotaUsage = float64(dbSize) / float64(m.quota)
+}
+
+func (m *Maintainer) defrag(ctx context.Context, status *DBStatus, now time.Time) {
+	log.Info("start to defragment the etcd member",
+		zap.String("member", status.Name),
+		zap.Int64("db-size", status.DBSize),
+		zap.Float64("fragmentation", status.Fragmentation))
+	ctx, cancel := context.WithTimeout(ctx, defragTimeout)
+	defer cancel()
+	if _, err := m.client.Defragment(ctx, status.clientURL); err != nil {
+		etcdMaintenanceCounter.WithLabelValues("defrag", "failed").Inc()
+		log.Error("failed to defragment the etcd member", zap.String("member", status.Name), zap.Error(err))
+		return
+	}
+	etcdMaintenanceCounter.WithLabelValues("defrag", "success").Inc()
+	log.Info("defragment the etcd member", zap.String("member", status.Name), zap.Duration("cost", time.Since(now)))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastDefrag[status.MemberID] = now
+	status.LastDefragTime = now
+}
+
+// pickDefragMember returns the most fragmented member which needs to be
+// defragmented, or nil if there is none. The local member is never picked,
+// because defragmenting it blocks the leader. It is defragmented after the
+// leadership is moved to another member.
+func pickDefragMember(statuses []*DBStatus, cfg *config.EtcdMaintenanceConfig, selfID uint64) *DBStatus {
+	var picked *DBStatus
+	for _, status := range statuses {
+		if status.MemberID == selfID || status.Error != "" || status.DBSize < int64(cfg.DefragMinDBSize) || status.Fragmentation < cfg.DefragRatio {
+			continue
+		}
+		if picked == nil || status.Fragmentation > picked.Fragmentation {
+			picked = status
+		}
+	}
+	return picked
+}
+
+func (m *Maintainer) setStatuses(statuses []*DBStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].MemberID < statuses[j].MemberID })
+	etcdDBGauge.Reset()
+	for _, status := range statuses {
+		status.LastDefragTime = m.lastDefrag[status.MemberID]
+		if status.Error != "" {
+			continue
+		}
+		id := strconv.FormatUint(status.MemberID, 10)
+		etcdDBGauge.WithLabelValues(id, "db_size").Set(float64(status.DBSize))
+		etcdDBGauge.WithLabelValues(id, "db_size_in_use").Set(float64(status.DBSizeInUse))
+		etcdDBGauge.WithLabelValues(id, "fragmentation").Set(status.Fragmentation)
+		etcdDBGauge.WithLabelValues(id, "quota_usage").Set(status.QuotaUsage)
+		if !status.LastDefragTime.IsZero() {
+			etcdDBGauge.WithLabelValues(id, "last_defrag_time").Set(float64(status.LastDefragTime.Unix()))
+		}
+	}
+	m.statuses = statuses
+}
+
+// GetDBStatus returns the db status of the members collected in the last check.
+func (m *Maintainer) GetDBStatus() []*DBStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	res := make([]*DBStatus, 0, len(m.statuses))
+	for _, status := range m.statuses {
+		clone := *status
+		res = append(res, &clone)
+	}
+	return res
+}
+
+// GetQuotaAlerts returns the members whose db size is close to the quota.
+func (m *Maintainer) GetQuotaAlerts() []*DBStatus {
+	return m.quotaAlerts(m.GetDBStatus())
+}
+
+func (m *Maintainer) quotaAlerts(statuses []*DBStatus) []*DBStatus {
+	var alerts []*DBStatus
+	for _, status := range statuses {
+		if status.Error == "" && status.QuotaUsage >= m.cfg.QuotaAlertRatio {
+			alerts = append(alerts, status)
+		}
+	}
+	return alerts
+}
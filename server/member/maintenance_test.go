@@ -0,0 +1,70 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server/config"
+)
+
+func TestMember(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testMaintenanceSuite{})
+
+type testMaintenanceSuite struct{}
+
+func (s *testMaintenanceSuite) TestMaintainer(c *C) {
+	cfg := &config.EtcdMaintenanceConfig{
+		DefragRatio:     0.5,
+		DefragMinDBSize: 100,
+		QuotaAlertRatio: 0.8,
+	}
+	m := NewMaintainer(nil, cfg, 1000, 1)
+
+	newStatus := func(id uint64, dbSize, dbSizeInUse int64) *DBStatus {
+		status := &DBStatus{MemberID: id}
+		m.fillStatus(status, dbSize, dbSizeInUse)
+		return status
+	}
+	statuses := []*DBStatus{
+		// The local member is the most fragmented.
+		newStatus(1, 900, 100),
+		newStatus(3, 800, 300),
+		// It is too small to defragment.
+		newStatus(2, 90, 10),
+	}
+	c.Assert(statuses[0].Fragmentation, Equals, 800.0/900)
+	c.Assert(statuses[0].QuotaUsage, Equals, 0.9)
+
+	m.setStatuses(statuses)
+	dbStatus := m.GetDBStatus()
+	c.Assert(dbStatus, HasLen, 3)
+	c.Assert(dbStatus[1].MemberID, Equals, uint64(2))
+	alerts := m.GetQuotaAlerts()
+	c.Assert(alerts, HasLen, 2)
+	c.Assert(alerts[0].MemberID, Equals, uint64(1))
+	c.Assert(alerts[1].MemberID, Equals, uint64(3))
+
+	// The local member is not defragmented.
+	c.Assert(pickDefragMember(statuses, cfg, 1).MemberID, Equals, uint64(3))
+	c.Assert(pickDefragMember(statuses, cfg, 3).MemberID, Equals, uint64(1))
+	c.Assert(pickDefragMember(statuses[:1], cfg, 1), IsNil)
+	statuses[2].Error = "timeout"
+	c.Assert(pickDefragMember(statuses[2:], cfg, 1), IsNil)
+	c.Assert(pickDefragMember([]*DBStatus{newStatus(4, 1000, 600)}, cfg, 1), IsNil)
+}
@@ -0,0 +1,39 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	etcdDBGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "etcd_db",
+			Help:      "The db size, fragmentation, quota usage and last defrag time of the etcd members.",
+		}, []string{"member", "type"})
+
+	etcdMaintenanceCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "etcd_maintenance_total",
+			Help:      "Counter of the etcd maintenance operations.",
+		}, []string{"type", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(etcdDBGauge)
+	prometheus.MustRegister(etcdMaintenanceCounter)
+}
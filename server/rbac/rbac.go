@@ -0,0 +1,296 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/logutil"
+	"github.com/pingcap/pd/server/config"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// The builtin roles.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// reloadInterval is the interval to reload the config saved by the leader.
+const reloadInterval = 10 * time.Second
+
+// redactedToken replaces the tokens in the config returned to the clients.
+const redactedToken = "******"
+
+var builtinRoles = []config.RBACRole{
+	{
+		Name: RoleViewer,
+		Permissions: []config.RBACPermission{
+			{Methods: []string{http.MethodGet}, Path: "*"},
+		},
+	},
+	{
+		Name: RoleOperator,
+		Permissions: []config.RBACPermission{
+			{Methods: []string{http.MethodGet}, Path: "*"},
+			{Methods: []string{http.MethodPost, http.MethodDelete}, Path: "/pd/api/v1/operators*"},
+			{Methods: []string{http.MethodPost, http.MethodDelete}, Path: "/pd/api/v1/schedulers*"},
+		},
+	},
+	{
+		Name: RoleAdmin,
+		Permissions: []config.RBACPermission{
+			{Path: "*"},
+		},
+	},
+}
+
+// Manager checks the HTTP requests against the RBAC config. The config from
+// the config file is used until one is saved to the storage, and the saved
+// config is reloaded periodically, so all PD servers enforce the same config.
+type Manager struct {
+	mu      sync.RWMutex
+	storage *core.Storage
+	cfg     *config.RBACConfig
+	roles   map[string]*config.RBACRole
+	users   map[string]*config.RBACUser
+	tokens  map[string]*config.RBACUser
+}
+
+// NewManager creates a Manager with the config from the config file.
+func NewManager(cfg *config.RBACConfig) (*Manager, error) {
+	m := &Manager{}
+	if err := m.apply(cfg); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SetStorage sets the storage to save the config.
+func (m *Manager) SetStorage(storage *core.Storage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.storage = storage
+}
+
+// Run reloads the config periodically until the context is done.
+func (m *Manager) Run(ctx context.Context) {
+	defer logutil.LogPanic()
+
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		if err := m.Reload(); err != nil {
+			log.Error("failed to reload the rbac config", zap.Error(err))
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Reload loads the config from the storage if there is one.
+func (m *Manager) Reload() error {
+	m.mu.RLock()
+	storage := m.storage
+	m.mu.RUnlock()
+	if storage == nil {
+		return nil
+	}
+	cfg := &config.RBACConfig{}
+	ok, err := storage.LoadRBACConfig(cfg)
+	if err != nil || !ok {
+		return err
+	}
+	return m.apply(cfg)
+}
+
+// GetConfig returns the config in use, the tokens are redacted.
+func (m *Manager) GetConfig() *config.RBACConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cfg := m.cfg.Clone()
+	for i := range cfg.Users {
+		if cfg.Users[i].Token != "" {
+			cfg.Users[i].Token = redactedToken
+		}
+	}
+	return cfg
+}
+
+// SetConfig saves the config to the storage and uses it. The redacted tokens
+// are kept unchanged.
+func (m *Manager) SetConfig(cfg *config.RBACConfig) error {
+	m.mu.RLock()
+	storage, old := m.storage, m.users
+	m.mu.RUnlock()
+	if storage == nil {
+		return errors.New("storage is not ready")
+	}
+	cfg = cfg.Clone()
+	for i, user := range cfg.Users {
+		if user.Token != redactedToken {
+			continue
+		}
+		if oldUser, ok := old[user.Name]; ok && oldUser.Token != "" {
+			cfg.Users[i].Token = oldUser.Token
+		} else {
+			return errors.Errorf("user %s has no token to keep", user.Name)
+		}
+	}
+	if _, _, _, err := build(cfg); err != nil {
+		return err
+	}
+	if err := storage.SaveRBACConfig(cfg); err != nil {
+		return err
+	}
+	log.Info("rbac config is updated", zap.Bool("enable", cfg.Enable), zap.Int("users", len(cfg.Users)))
+	return m.apply(cfg)
+}
+
+func (m *Manager) apply(cfg *config.RBACConfig) error {
+	cfg = cfg.Clone()
+	roles, users, tokens, err := build(cfg)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cfg, m.roles, m.users, m.tokens = cfg, roles, users, tokens
+	return nil
+}
+
+// build validates the config and indexes the roles and the users.
+func build(cfg *config.RBACConfig) (map[string]*config.RBACRole, map[string]*config.RBACUser, map[string]*config.RBACUser, error) {
+	roles := make(map[string]*config.RBACRole)
+	for i := range builtinRoles {
+		roles[builtinRoles[i].Name] = &builtinRoles[i]
+	}
+	for i := range cfg.Roles {
+		role := &cfg.Roles[i]
+		if role.Name == "" {
+			return nil, nil, nil, errors.New("role name is empty")
+		}
+		if _, ok := roles[role.Name]; ok {
+			return nil, nil, nil, errors.Errorf("role %s is duplicated or builtin", role.Name)
+		}
+		for _, p := range role.Permissions {
+			if p.Path == "" {
+				return nil, nil, nil, errors.Errorf("role %s has a permission without path", role.Name)
+			}
+		}
+		roles[role.Name] = role
+	}
+	if _, ok := roles[cfg.DefaultRole]; cfg.DefaultRole != "" && !ok {
+		return nil, nil, nil, errors.Errorf("default role %s is not found", cfg.DefaultRole)
+	}
+	users := make(map[string]*config.RBACUser)
+	tokens := make(map[string]*config.RBACUser)
+	for i := range cfg.Users {
+		user := &cfg.Users[i]
+		if user.Name == "" {
+			return nil, nil, nil, errors.New("user name is empty")
+		}
+		if _, ok := users[user.Name]; ok {
+			return nil, nil, nil, errors.Errorf("user %s is duplicated", user.Name)
+		}
+		if _, ok := roles[user.Role]; !ok {
+			return nil, nil, nil, errors.Errorf("role %s of user %s is not found", user.Role, user.Name)
+		}
+		users[user.Name] = user
+		if user.Token == "" {
+			continue
+		}
+		if user.Token == redactedToken {
+			return nil, nil, nil, errors.Errorf("token of user %s is invalid", user.Name)
+		}
+		if _, ok := tokens[user.Token]; ok {
+			return nil, nil, nil, errors.Errorf("token of user %s is duplicated", user.Name)
+		}
+		tokens[user.Token] = user
+	}
+	return roles, users, tokens, nil
+}
+
+// Authorize checks if the request is allowed. It returns the name of the user
+// and the reason if the request is denied.
+//
+// The user is identified by the bearer token in the Authorization header, or
+// the common name of the TLS client certificate.
+func (m *Manager) Authorize(r *http.Request) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.cfg.Enable {
+		return "", nil
+	}
+
+	var name string
+	var user *config.RBACUser
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if user = m.tokens[token]; user == nil {
+			deniedCounter.WithLabelValues("invalid_token").Inc()
+			return "", errors.New("invalid token")
+		}
+		name = user.Name
+	} else if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		name = r.TLS.PeerCertificates[0].Subject.CommonName
+		user = m.users[name]
+	}
+
+	roleName := m.cfg.DefaultRole
+	if user != nil {
+		roleName = user.Role
+	}
+	if roleName == "" {
+		deniedCounter.WithLabelValues("unknown_user").Inc()
+		return name, errors.Errorf("user %q is not allowed to access PD", name)
+	}
+	if !allow(m.roles[roleName], r.Method, r.URL.Path) {
+		deniedCounter.WithLabelValues("no_permission").Inc()
+		return name, errors.Errorf("user %q with role %s is not allowed to %s %s", name, roleName, r.Method, r.URL.Path)
+	}
+	return name, nil
+}
+
+func allow(role *config.RBACRole, method, path string) bool {
+	for _, p := range role.Permissions {
+		if len(p.Methods) > 0 && !containsMethod(p.Methods, method) {
+			continue
+		}
+		if p.Path == path || (strings.HasSuffix(p.Path, "*") && strings.HasPrefix(path, strings.TrimSuffix(p.Path, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server/config"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/kv"
+)
+
+func TestRBAC(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testRBACSuite{})
+
+type testRBACSuite struct{}
+
+func newRequest(method, path, cn, token string) *http.Request {
+	r := httptest.NewRequest(method, path, nil)
+	if cn != "" {
+		r.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: cn}}},
+		}
+	}
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func (s *testRBACSuite) TestAuthorize(c *C) {
+	m, err := NewManager(&config.RBACConfig{})
+	c.Assert(err, IsNil)
+	// All requests are allowed if it is disabled.
+	_, err = m.Authorize(newRequest("DELETE", "/pd/api/v1/store/1", "", ""))
+	c.Assert(err, IsNil)
+
+	m, err = NewManager(&config.RBACConfig{
+		Enable: true,
+		Users: []config.RBACUser{
+			{Name: "admin", Role: RoleAdmin},
+			{Name: "ops", Role: RoleOperator},
+			{Name: "dashboard", Token: "secret", Role: RoleViewer},
+			{Name: "store", Role: "store-admin"},
+		},
+		Roles: []config.RBACRole{
+			{Name: "store-admin", Permissions: []config.RBACPermission{
+				{Methods: []string{"post", "delete"}, Path: "/pd/api/v1/store/*"},
+			}},
+		},
+	})
+	c.Assert(err, IsNil)
+
+	testCases := []struct {
+		method, path, cn, token string
+		user                    string
+		allowed                 bool
+	}{
+		{"DELETE", "/pd/api/v1/store/1", "admin", "", "admin", true},
+		{"GET", "/pd/api/v1/stores", "ops", "", "ops", true},
+		{"POST", "/pd/api/v1/operators", "ops", "", "ops", true},
+		{"DELETE", "/pd/api/v1/schedulers/balance-leader-scheduler", "ops", "", "ops", true},
+		{"DELETE", "/pd/api/v1/store/1", "ops", "", "ops", false},
+		{"GET", "/pd/api/v1/stores", "", "secret", "dashboard", true},
+		{"POST", "/pd/api/v1/config", "", "secret", "dashboard", false},
+		// The token takes precedence over the certificate.
+		{"POST", "/pd/api/v1/config", "admin", "secret", "dashboard", false},
+		{"GET", "/pd/api/v1/stores", "", "unknown", "", false},
+		{"DELETE", "/pd/api/v1/store/1", "store", "", "store", true},
+		{"GET", "/pd/api/v1/store/1", "store", "", "store", false},
+		{"DELETE", "/pd/api/v1/stores", "store", "", "store", false},
+		// The unknown users get the default role.
+		{"GET", "/pd/api/v1/stores", "unknown", "", "unknown", false},
+		{"GET", "/pd/api/v1/stores", "", "", "", false},
+	}
+	for _, t := range testCases {
+		user, err := m.Authorize(newRequest(t.method, t.path, t.cn, t.token))
+		c.Assert(user, Equals, t.user, Commentf("%+v", t))
+		c.Assert(err == nil, Equals, t.allowed, Commentf("%+v", t))
+	}
+
+	cfg := m.GetConfig()
+	cfg.DefaultRole = RoleViewer
+	c.Assert(cfg.Users[2].Token, Equals, redactedToken)
+	c.Assert(m.apply(cfg), NotNil)
+}
+
+func (s *testRBACSuite) TestConfig(c *C) {
+	for _, cfg := range []*config.RBACConfig{
+		{DefaultRole: "unknown"},
+		{Users: []config.RBACUser{{Name: "a", Role: "unknown"}}},
+		{Users: []config.RBACUser{{Name: "a", Role: RoleAdmin}, {Name: "a", Role: RoleViewer}}},
+		{Users: []config.RBACUser{{Name: "a", Token: "t", Role: RoleAdmin}, {Name: "b", Token: "t", Role: RoleViewer}}},
+		{Roles: []config.RBACRole{{Name: RoleAdmin}}},
+		{Roles: []config.RBACRole{{Name: "r", Permissions: []config.RBACPermission{{Methods: []string{"GET"}}}}}},
+	} {
+		_, err := NewManager(cfg)
+		c.Assert(err, NotNil)
+	}
+
+	storage := core.NewStorage(kv.NewMemoryKV())
+	m, err := NewManager(&config.RBACConfig{})
+	c.Assert(err, IsNil)
+	c.Assert(m.SetConfig(&config.RBACConfig{Enable: true}), NotNil)
+	m.SetStorage(storage)
+	c.Assert(m.Reload(), IsNil)
+	c.Assert(m.GetConfig().Enable, IsFalse)
+
+	cfg := &config.RBACConfig{
+		Enable:      true,
+		DefaultRole: RoleViewer,
+		Users:       []config.RBACUser{{Name: "dashboard", Token: "secret", Role: RoleAdmin}},
+	}
+	c.Assert(m.SetConfig(cfg), IsNil)
+	_, err = m.Authorize(newRequest("GET", "/pd/api/v1/stores", "", ""))
+	c.Assert(err, IsNil)
+
+	// The redacted token is kept.
+	cfg = m.GetConfig()
+	cfg.DefaultRole = ""
+	c.Assert(m.SetConfig(cfg), IsNil)
+	_, err = m.Authorize(newRequest("GET", "/pd/api/v1/stores", "", ""))
+	c.Assert(err, NotNil)
+	_, err = m.Authorize(newRequest("POST", "/pd/api/v1/config", "", "secret"))
+	c.Assert(err, IsNil)
+
+	// Another server loads the saved config.
+	m2, err := NewManager(&config.RBACConfig{})
+	c.Assert(err, IsNil)
+	m2.SetStorage(storage)
+	c.Assert(m2.Reload(), IsNil)
+	_, err = m2.Authorize(newRequest("POST", "/pd/api/v1/config", "", "secret"))
+	c.Assert(err, IsNil)
+	_, err = m2.Authorize(newRequest("POST", "/pd/api/v1/config", "", ""))
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pkg/errors"
+)
+
+// The types of the records.
+const (
+	typeRegionHeartbeat byte = 1
+	typeStoreHeartbeat  byte = 2
+)
+
+// maxRecordSize is the max size of a record to read, the larger ones are
+// treated as corrupted.
+const maxRecordSize = 64 * 1024 * 1024
+
+// Record is a recorded heartbeat, only one of the heartbeats is set.
+type Record struct {
+	Time            time.Time
+	RegionHeartbeat *pdpb.RegionHeartbeatRequest
+	StoreHeartbeat  *pdpb.StoreHeartbeatRequest
+}
+
+type marshaler interface {
+	Size() int
+	MarshalTo([]byte) (int, error)
+}
+
+// encode appends a record to buf. A record consists of the type in 1 byte,
+// the time in unix nanoseconds in 8 bytes, the size of the request in uvarint
+// and the request in protobuf.
+func encode(buf []byte, typ byte, t time.Time, msg marshaler) ([]byte, error) {
+	var header [1 + 8 + binary.MaxVarintLen64]byte
+	header[0] = typ
+	binary.BigEndian.PutUint64(header[1:9], uint64(t.UnixNano()))
+	size := msg.Size()
+	n := 9 + binary.PutUvarint(header[9:], uint64(size))
+	buf = append(buf, header[:n]...)
+	start := len(buf)
+	buf = append(buf, make([]byte, size)...)
+	if _, err := msg.MarshalTo(buf[start:]); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf, nil
+}
+
+// Reader reads the records written by the Recorder.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader creates a Reader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next record. It returns io.EOF if there are no more
+// records, and io.ErrUnexpectedEOF if the last record is incomplete.
+func (r *Reader) Next() (*Record, error) {
+	typ, err := r.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	var ts [8]byte
+	if _, err = io.ReadFull(r.r, ts[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	size, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if size > maxRecordSize {
+		return nil, errors.Errorf("record size %d is too large", size)
+	}
+	data := make([]byte, size)
+	if _, err = io.ReadFull(r.r, data); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	record := &Record{Time: time.Unix(0, int64(binary.BigEndian.Uint64(ts[:])))}
+	switch typ {
+	case typeRegionHeartbeat:
+		record.RegionHeartbeat = &pdpb.RegionHeartbeatRequest{}
+		err = record.RegionHeartbeat.Unmarshal(data)
+	case typeStoreHeartbeat:
+		record.StoreHeartbeat = &pdpb.StoreHeartbeatRequest{}
+		err = record.StoreHeartbeat.Unmarshal(data)
+	default:
+		return nil, errors.Errorf("unknown record type %d", typ)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return record, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
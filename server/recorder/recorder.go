@@ -0,0 +1,149 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/logutil"
+	"github.com/pingcap/pd/server/config"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+)
+
+// recordChanCap is the number of the records waiting to be written, the
+// records are dropped if the writing falls behind.
+const recordChanCap = 4096
+
+// Recorder writes the heartbeats to the rotating file in the background, so
+// the heartbeats are not blocked by the disk.
+type Recorder struct {
+	mu     sync.RWMutex
+	ch     chan []byte
+	closed bool
+	file   io.WriteCloser
+	wg     sync.WaitGroup
+}
+
+// NewRecorder creates a Recorder, it returns nil if the heartbeat record is
+// disabled.
+func NewRecorder(cfg config.HeartbeatRecordConfig) (*Recorder, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+	if st, err := os.Stat(cfg.File.Filename); err == nil && st.IsDir() {
+		return nil, errors.New("can't use directory as heartbeat record file name")
+	}
+	r := &Recorder{
+		ch: make(chan []byte, recordChanCap),
+		file: &lumberjack.Logger{
+			Filename:   cfg.File.Filename,
+			MaxSize:    cfg.File.MaxSize,
+			MaxBackups: cfg.File.MaxBackups,
+			MaxAge:     cfg.File.MaxDays,
+			LocalTime:  true,
+		},
+	}
+	r.wg.Add(1)
+	go r.run()
+	return r, nil
+}
+
+// IsEnabled returns if the recorder is enabled.
+func (r *Recorder) IsEnabled() bool {
+	return r != nil
+}
+
+// RecordRegionHeartbeat records a region heartbeat.
+func (r *Recorder) RecordRegionHeartbeat(request *pdpb.RegionHeartbeatRequest) {
+	r.record(typeRegionHeartbeat, "region", request)
+}
+
+// RecordStoreHeartbeat records a store heartbeat.
+func (r *Recorder) RecordStoreHeartbeat(request *pdpb.StoreHeartbeatRequest) {
+	r.record(typeStoreHeartbeat, "store", request)
+}
+
+func (r *Recorder) record(typ byte, typeLabel string, msg marshaler) {
+	if !r.IsEnabled() {
+		return
+	}
+	data, err := encode(nil, typ, time.Now(), msg)
+	if err != nil {
+		recordCounter.WithLabelValues(typeLabel, "error").Inc()
+		return
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.ch <- data:
+		recordCounter.WithLabelValues(typeLabel, "ok").Inc()
+	default:
+		recordCounter.WithLabelValues(typeLabel, "dropped").Inc()
+	}
+}
+
+func (r *Recorder) run() {
+	defer logutil.LogPanic()
+	defer r.wg.Done()
+
+	var buf []byte
+	for data := range r.ch {
+		// Write the records in batches, and every write only contains whole
+		// records, so a record is never split by the rotation.
+		buf = append(buf[:0], data...)
+	batch:
+		for {
+			select {
+			case data, ok := <-r.ch:
+				if !ok {
+					break batch
+				}
+				buf = append(buf, data...)
+			default:
+				break batch
+			}
+		}
+		if _, err := r.file.Write(buf); err != nil {
+			log.Error("failed to write the heartbeat records", zap.Error(err))
+		}
+	}
+}
+
+// Close writes the pending records and closes the file.
+func (r *Recorder) Close() error {
+	if !r.IsEnabled() {
+		return nil
+	}
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.ch)
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	return errors.WithStack(r.file.Close())
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/server/config"
+)
+
+func TestRecorder(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testRecorderSuite{})
+
+type testRecorderSuite struct{}
+
+func (s *testRecorderSuite) TestDisabled(c *C) {
+	r, err := NewRecorder(config.HeartbeatRecordConfig{})
+	c.Assert(err, IsNil)
+	c.Assert(r.IsEnabled(), IsFalse)
+	r.RecordStoreHeartbeat(&pdpb.StoreHeartbeatRequest{})
+	c.Assert(r.Close(), IsNil)
+}
+
+func (s *testRecorderSuite) TestRecordAndRead(c *C) {
+	dir, err := ioutil.TempDir("", "heartbeat_record")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+
+	cfg := config.HeartbeatRecordConfig{Enable: true}
+	cfg.File.Filename = filepath.Join(dir, "heartbeat.record")
+	r, err := NewRecorder(cfg)
+	c.Assert(err, IsNil)
+	c.Assert(r.IsEnabled(), IsTrue)
+
+	region := &pdpb.RegionHeartbeatRequest{
+		Region: &metapb.Region{Id: 2, StartKey: []byte("a"), EndKey: []byte("b")},
+		Leader: &metapb.Peer{Id: 3, StoreId: 1},
+	}
+	store := &pdpb.StoreHeartbeatRequest{
+		Stats: &pdpb.StoreStats{StoreId: 1, RegionCount: 1},
+	}
+	r.RecordRegionHeartbeat(region)
+	r.RecordStoreHeartbeat(store)
+	c.Assert(r.Close(), IsNil)
+	// Records after closing are ignored.
+	r.RecordStoreHeartbeat(store)
+
+	f, err := os.Open(cfg.File.Filename)
+	c.Assert(err, IsNil)
+	defer f.Close()
+	reader := NewReader(f)
+	record, err := reader.Next()
+	c.Assert(err, IsNil)
+	c.Assert(record.RegionHeartbeat, DeepEquals, region)
+	c.Assert(record.StoreHeartbeat, IsNil)
+	c.Assert(time.Since(record.Time), Less, time.Minute)
+	record, err = reader.Next()
+	c.Assert(err, IsNil)
+	c.Assert(record.StoreHeartbeat, DeepEquals, store)
+	_, err = reader.Next()
+	c.Assert(err, Equals, io.EOF)
+}
+
+func (s *testRecorderSuite) TestCorrupted(c *C) {
+	data, err := encode(nil, typeStoreHeartbeat, time.Now(), &pdpb.StoreHeartbeatRequest{
+		Stats: &pdpb.StoreStats{StoreId: 1},
+	})
+	c.Assert(err, IsNil)
+
+	_, err = NewReader(bytes.NewReader(data[:len(data)-1])).Next()
+	c.Assert(err, Equals, io.ErrUnexpectedEOF)
+
+	data[0] = 0
+	_, err = NewReader(bytes.NewReader(data)).Next()
+	c.Assert(err, NotNil)
+}
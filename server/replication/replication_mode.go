@@ -0,0 +1,327 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replication
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/replication_modepb"
+	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/logutil"
+	"github.com/pingcap/pd/server/config"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/id"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// States of the dr-auto-sync mode.
+const (
+	// DRStateSync means the data is replicated to the DR synchronously.
+	DRStateSync = "sync"
+	// DRStateAsync means the DR is unavailable and the data is replicated to
+	// it asynchronously.
+	DRStateAsync = "async"
+	// DRStateSyncRecover means the DR is available again and is catching up
+	// the data written in the async state.
+	DRStateSyncRecover = "sync-recover"
+)
+
+// drTransitions are the states which can be switched to from a state. The
+// sync state can only be entered from the sync-recover state, otherwise the
+// data written in the async state is regarded as durable in the DR.
+var drTransitions = map[string][]string{
+	DRStateSync:        {DRStateAsync},
+	DRStateAsync:       {DRStateSyncRecover},
+	DRStateSyncRecover: {DRStateSync, DRStateAsync},
+}
+
+var tickInterval = 10 * time.Second
+
+// Cluster is the cluster information used by the replication mode.
+type Cluster interface {
+	GetStores() []*core.StoreInfo
+	GetRegions() []*core.RegionInfo
+}
+
+// DRAutoSyncStatus is the status of the dr-auto-sync mode.
+type DRAutoSyncStatus struct {
+	State string `json:"state"`
+	// StateID is allocated when the state changes.
+	StateID uint64 `json:"state_id"`
+	// DurableStateID is the ID of the last sync state. The data written before
+	// leaving it has been replicated to the DR, so it must be durable in the DR
+	// before failing over to the DR.
+	DurableStateID   uint64    `json:"durable_state_id"`
+	RecoverStartTime time.Time `json:"recover_start_time,omitempty"`
+	// RecoverProgress is the ratio of the regions which have caught up in the
+	// DR during the sync-recover state.
+	RecoverProgress float64 `json:"recover_progress,omitempty"`
+	PrimaryUpStores int     `json:"primary_up_stores"`
+	DRUpStores      int     `json:"dr_up_stores"`
+}
+
+// Status is the status of the replication mode.
+type Status struct {
+	Mode       string            `json:"mode"`
+	DRAutoSync *DRAutoSyncStatus `json:"dr-auto-sync,omitempty"`
+}
+
+// ModeManager is used to manage the replication mode of the cluster. In the
+// dr-auto-sync mode, it switches the state by the number of the up stores in
+// the DR.
+type ModeManager struct {
+	sync.RWMutex
+	config  config.ReplicationModeConfig
+	storage *core.Storage
+	cluster Cluster
+	idAlloc id.Allocator
+
+	drStatus DRAutoSyncStatus
+}
+
+// NewReplicationModeManager creates the replication mode manager, and loads
+// the persisted status.
+func NewReplicationModeManager(cfg config.ReplicationModeConfig, storage *core.Storage, cluster Cluster, idAlloc id.Allocator) (*ModeManager, error) {
+	m := &ModeManager{
+		config:  cfg,
+		storage: storage,
+		cluster: cluster,
+		idAlloc: idAlloc,
+	}
+	if cfg.ReplicationMode != config.ReplicationModeDRAutoSync {
+		return m, nil
+	}
+	ok, err := storage.LoadReplicationStatus(cfg.ReplicationMode, &m.drStatus)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := m.switchStateLocked(DRStateSync); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// GetReplicationStatus returns the status of the replication mode.
+func (m *ModeManager) GetReplicationStatus() *Status {
+	m.RLock()
+	defer m.RUnlock()
+	status := &Status{Mode: m.config.ReplicationMode}
+	if m.config.ReplicationMode == config.ReplicationModeDRAutoSync {
+		drStatus := m.drStatus
+		status.DRAutoSync = &drStatus
+	}
+	return status
+}
+
+// ForceState switches the state of the dr-auto-sync mode manually.
+func (m *ModeManager) ForceState(state string) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.config.ReplicationMode != config.ReplicationModeDRAutoSync {
+		return errors.Errorf("the replication mode is %s", m.config.ReplicationMode)
+	}
+	switch state {
+	case DRStateSync, DRStateAsync, DRStateSyncRecover:
+	default:
+		return errors.Errorf("unknown state: %s", state)
+	}
+	if !isLegalTransition(m.drStatus.State, state) {
+		return errors.Errorf("cannot switch the state from %s to %s", m.drStatus.State, state)
+	}
+	log.Info("force to switch the dr-auto-sync state", zap.String("from", m.drStatus.State), zap.String("to", state))
+	return m.switchStateLocked(state)
+}
+
+func isLegalTransition(from, to string) bool {
+	for _, state := range drTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// switchStateLocked allocates a new state ID and persists the new state.
+func (m *ModeManager) switchStateLocked(state string) error {
+	stateID, err := m.idAlloc.Alloc()
+	if err != nil {
+		return err
+	}
+	status := m.drStatus
+	if status.State == DRStateSync && state != DRStateSync {
+		status.DurableStateID = status.StateID
+	}
+	status.State = state
+	status.StateID = stateID
+	status.RecoverStartTime = time.Time{}
+	status.RecoverProgress = 0
+	if state == DRStateSync {
+		status.DurableStateID = stateID
+	}
+	if state == DRStateSyncRecover {
+		status.RecoverStartTime = time.Now()
+	}
+	if err := m.storage.SaveReplicationStatus(config.ReplicationModeDRAutoSync, status); err != nil {
+		return err
+	}
+	m.drStatus = status
+	return nil
+}
+
+// Run checks the stores and switches the state periodically until quit.
+func (m *ModeManager) Run(quit <-chan struct{}) {
+	defer logutil.LogPanic()
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			m.tickDR()
+		}
+	}
+}
+
+func (m *ModeManager) tickDR() {
+	if m.config.ReplicationMode != config.ReplicationModeDRAutoSync {
+		return
+	}
+	cfg := m.config.DRAutoSync
+	drStores := make(map[uint64]struct{})
+	var primaryUp, drUp int
+	for _, store := range m.cluster.GetStores() {
+		if store.IsTombstone() {
+			continue
+		}
+		isUp := store.DownTime() < cfg.WaitStoreTimeout.Duration
+		switch store.GetLabelValue(cfg.LabelKey) {
+		case cfg.Primary:
+			if isUp {
+				primaryUp++
+			}
+		case cfg.DR:
+			drStores[store.GetID()] = struct{}{}
+			if isUp {
+				drUp++
+			}
+		}
+	}
+	drAvailable := drUp >= cfg.DRReplicas
+
+	m.Lock()
+	defer m.Unlock()
+	m.drStatus.PrimaryUpStores, m.drStatus.DRUpStores = primaryUp, drUp
+
+	var next string
+	switch m.drStatus.State {
+	case DRStateSync:
+		if !drAvailable {
+			next = DRStateAsync
+		}
+	case DRStateAsync:
+		if drAvailable {
+			next = DRStateSyncRecover
+		}
+	case DRStateSyncRecover:
+		if !drAvailable {
+			next = DRStateAsync
+			break
+		}
+		m.drStatus.RecoverProgress = m.recoverProgress(drStores)
+		if m.drStatus.RecoverProgress >= 1 {
+			next = DRStateSync
+		}
+	}
+	if next == "" {
+		return
+	}
+	from := m.drStatus.State
+	if err := m.switchStateLocked(next); err != nil {
+		log.Error("failed to switch the dr-auto-sync state", zap.String("from", from), zap.String("to", next), zap.Error(err))
+		return
+	}
+	log.Info("switch the dr-auto-sync state",
+		zap.String("from", from),
+		zap.String("to", next),
+		zap.Uint64("state-id", m.drStatus.StateID),
+		zap.Int("primary-up-stores", primaryUp),
+		zap.Int("dr-up-stores", drUp))
+}
+
+// recoverProgress returns the ratio of the regions whose peers in the DR are
+// neither pending nor down.
+func (m *ModeManager) recoverProgress(drStores map[uint64]struct{}) float64 {
+	var total, synced int
+	for _, region := range m.cluster.GetRegions() {
+		inDR, lagging := false, false
+		for _, peer := range region.GetPeers() {
+			if _, ok := drStores[peer.GetStoreId()]; ok {
+				inDR = true
+				break
+			}
+		}
+		if !inDR {
+			continue
+		}
+		for _, peer := range region.GetPendingPeers() {
+			if _, ok := drStores[peer.GetStoreId()]; ok {
+				lagging = true
+			}
+		}
+		for _, stats := range region.GetDownPeers() {
+			if _, ok := drStores[stats.GetPeer().GetStoreId()]; ok {
+				lagging = true
+			}
+		}
+		total++
+		if !lagging {
+			synced++
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(synced) / float64(total)
+}
+
+var drStateValues = map[string]replication_modepb.DRAutoSyncState{
+	DRStateSync:        replication_modepb.DRAutoSyncState_SYNC,
+	DRStateAsync:       replication_modepb.DRAutoSyncState_ASYNC,
+	DRStateSyncRecover: replication_modepb.DRAutoSyncState_SYNC_RECOVER,
+}
+
+// HeartbeatStatus returns the replication status to be sent to the stores by
+// the store heartbeat responses, so that TiKV replicates the data by the state.
+// It returns nil in the majority mode.
+func (m *ModeManager) HeartbeatStatus() *replication_modepb.ReplicationStatus {
+	m.RLock()
+	defer m.RUnlock()
+	if m.config.ReplicationMode != config.ReplicationModeDRAutoSync {
+		return nil
+	}
+	return &replication_modepb.ReplicationStatus{
+		Mode: replication_modepb.ReplicationMode_DR_AUTO_SYNC,
+		DrAutoSync: &replication_modepb.DRAutoSync{
+			LabelKey: m.config.DRAutoSync.LabelKey,
+			State:    drStateValues[m.drStatus.State],
+			StateId:  m.drStatus.StateID,
+		},
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replication
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/replication_modepb"
+	"github.com/pingcap/pd/pkg/mock/mockcluster"
+	"github.com/pingcap/pd/pkg/mock/mockid"
+	"github.com/pingcap/pd/pkg/mock/mockoption"
+	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server/config"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/kv"
+)
+
+func TestReplicationMode(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testReplicationMode{})
+
+type testReplicationMode struct{}
+
+func (s *testReplicationMode) TestMajority(c *C) {
+	storage := core.NewStorage(kv.NewMemoryKV())
+	cfg := config.ReplicationModeConfig{ReplicationMode: config.ReplicationModeMajority}
+	m, err := NewReplicationModeManager(cfg, storage, mockcluster.NewCluster(mockoption.NewScheduleOptions()), mockid.NewIDAllocator())
+	c.Assert(err, IsNil)
+	c.Assert(m.GetReplicationStatus().Mode, Equals, config.ReplicationModeMajority)
+	c.Assert(m.GetReplicationStatus().DRAutoSync, IsNil)
+	c.Assert(m.ForceState(DRStateAsync), NotNil)
+	c.Assert(m.HeartbeatStatus(), IsNil)
+}
+
+func (s *testReplicationMode) TestDRAutoSync(c *C) {
+	storage := core.NewStorage(kv.NewMemoryKV())
+	cfg := config.ReplicationModeConfig{
+		ReplicationMode: config.ReplicationModeDRAutoSync,
+		DRAutoSync: config.DRAutoSyncReplicationConfig{
+			LabelKey:         "zone",
+			Primary:          "z1",
+			DR:               "z2",
+			PrimaryReplicas:  2,
+			DRReplicas:       1,
+			WaitStoreTimeout: typeutil.NewDuration(time.Minute),
+		},
+	}
+	tc := mockcluster.NewCluster(mockoption.NewScheduleOptions())
+	tc.AddLabelsStore(1, 1, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(2, 1, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(3, 1, map[string]string{"zone": "z2"})
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	idAlloc := mockid.NewIDAllocator()
+	m, err := NewReplicationModeManager(cfg, storage, tc, idAlloc)
+	c.Assert(err, IsNil)
+	status := m.GetReplicationStatus().DRAutoSync
+	c.Assert(status.State, Equals, DRStateSync)
+	syncID := status.StateID
+	c.Assert(status.DurableStateID, Equals, syncID)
+
+	m.tickDR()
+	c.Assert(m.GetReplicationStatus().DRAutoSync.State, Equals, DRStateSync)
+	c.Assert(m.GetReplicationStatus().DRAutoSync.DRUpStores, Equals, 1)
+
+	// The DR is down.
+	tc.SetStoreDown(3)
+	m.tickDR()
+	status = m.GetReplicationStatus().DRAutoSync
+	c.Assert(status.State, Equals, DRStateAsync)
+	c.Assert(status.StateID, Greater, syncID)
+	c.Assert(status.DurableStateID, Equals, syncID)
+
+	// The DR is up, but the region is still pending.
+	tc.SetStoreUp(3)
+	region := tc.GetRegion(1)
+	tc.PutRegion(region.Clone(core.WithPendingPeers([]*metapb.Peer{region.GetStorePeer(3)})))
+	m.tickDR()
+	c.Assert(m.GetReplicationStatus().DRAutoSync.State, Equals, DRStateSyncRecover)
+	m.tickDR()
+	status = m.GetReplicationStatus().DRAutoSync
+	c.Assert(status.State, Equals, DRStateSyncRecover)
+	c.Assert(status.RecoverProgress, Equals, 0.0)
+	tc.PutRegion(region)
+	m.tickDR()
+	status = m.GetReplicationStatus().DRAutoSync
+	c.Assert(status.State, Equals, DRStateSync)
+	c.Assert(status.DurableStateID, Equals, status.StateID)
+
+	// Force the state, and the status is persisted.
+	c.Assert(m.ForceState("unknown"), NotNil)
+	c.Assert(m.ForceState(DRStateSyncRecover), NotNil)
+	c.Assert(m.ForceState(DRStateAsync), IsNil)
+	m, err = NewReplicationModeManager(cfg, storage, tc, idAlloc)
+	c.Assert(err, IsNil)
+	c.Assert(m.GetReplicationStatus().DRAutoSync.State, Equals, DRStateAsync)
+	// The sync state is not entered without recovering.
+	c.Assert(m.ForceState(DRStateSync), NotNil)
+	c.Assert(m.ForceState(DRStateAsync), NotNil)
+}
+
+func (s *testReplicationMode) TestHeartbeatStatus(c *C) {
+	cfg := config.ReplicationModeConfig{
+		ReplicationMode: config.ReplicationModeDRAutoSync,
+		DRAutoSync:      config.DRAutoSyncReplicationConfig{LabelKey: "zone"},
+	}
+	m, err := NewReplicationModeManager(cfg, core.NewStorage(kv.NewMemoryKV()), mockcluster.NewCluster(mockoption.NewScheduleOptions()), mockid.NewIDAllocator())
+	c.Assert(err, IsNil)
+	c.Assert(m.ForceState(DRStateAsync), IsNil)
+
+	status := m.HeartbeatStatus()
+	c.Assert(status.GetMode(), Equals, replication_modepb.ReplicationMode_DR_AUTO_SYNC)
+	c.Assert(status.GetDrAutoSync().GetLabelKey(), Equals, "zone")
+	c.Assert(status.GetDrAutoSync().GetState(), Equals, replication_modepb.DRAutoSyncState_ASYNC)
+	c.Assert(status.GetDrAutoSync().GetStateId(), Equals, m.GetReplicationStatus().DRAutoSync.StateID)
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/schedule/opt"
 	"go.uber.org/zap"
@@ -482,8 +483,8 @@ func NewOperator(desc, brief string, regionID uint64, regionEpoch *metapb.Region
 		regionEpoch: regionEpoch,
 		kind:        kind,
 		steps:       steps,
-		createTime:  time.Now(),
-		stepTime:    time.Now().UnixNano(),
+		createTime:  clock.Now(),
+		stepTime:    clock.Now().UnixNano(),
 		level:       level,
 	}
 }
@@ -540,12 +541,12 @@ func (o *Operator) Kind() OpKind {
 
 // ElapsedTime returns duration since it was created.
 func (o *Operator) ElapsedTime() time.Duration {
-	return time.Since(o.createTime)
+	return clock.Since(o.createTime)
 }
 
 // RunningTime returns duration since it was promoted.
 func (o *Operator) RunningTime() time.Duration {
-	return time.Since(o.startTime)
+	return clock.Since(o.startTime)
 }
 
 // SetStartTime sets the start time for operator.
@@ -577,9 +578,9 @@ func (o *Operator) Check(region *core.RegionInfo) OpStep {
 	for step := atomic.LoadInt32(&o.currentStep); int(step) < len(o.steps); step++ {
 		if o.steps[int(step)].IsFinish(region) {
 			operatorStepDuration.WithLabelValues(reflect.TypeOf(o.steps[int(step)]).Name()).
-				Observe(time.Since(time.Unix(0, atomic.LoadInt64(&o.stepTime))).Seconds())
+				Observe(clock.Since(time.Unix(0, atomic.LoadInt64(&o.stepTime))).Seconds())
 			atomic.StoreInt32(&o.currentStep, step+1)
-			atomic.StoreInt64(&o.stepTime, time.Now().UnixNano())
+			atomic.StoreInt64(&o.stepTime, clock.Now().UnixNano())
 		} else {
 			return o.steps[int(step)]
 		}
@@ -628,9 +629,9 @@ func (o *Operator) IsTimeout() bool {
 		return false
 	}
 	if o.kind&OpRegion != 0 {
-		timeout = time.Since(o.startTime) > RegionOperatorWaitTime
+		timeout = clock.Since(o.startTime) > RegionOperatorWaitTime
 	} else {
-		timeout = time.Since(o.startTime) > LeaderOperatorWaitTime
+		timeout = clock.Since(o.startTime) > LeaderOperatorWaitTime
 	}
 	if timeout {
 		return true
@@ -663,7 +664,7 @@ type OpHistory struct {
 
 // History transfers the operator's steps to operator histories.
 func (o *Operator) History() []OpHistory {
-	now := time.Now()
+	now := clock.Now()
 	var histories []OpHistory
 	var addPeerStores, removePeerStores []uint64
 	for _, step := range o.steps {
@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
 	"github.com/pingcap/pd/pkg/cache"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/schedule/operator"
 	"github.com/pingcap/pd/server/schedule/opt"
@@ -177,7 +178,7 @@ func (oc *OperatorController) pollNeedDispatchRegion() (r *core.RegionInfo, next
 	if step == nil {
 		return r, true
 	}
-	now := time.Now()
+	now := clock.Now()
 	if now.Before(item.time) {
 		heap.Push(&oc.opNotifierQueue, item)
 		return nil, false
@@ -326,7 +327,7 @@ func (oc *OperatorController) addOperatorLocked(op *operator.Operator) bool {
 	}
 
 	oc.operators[regionID] = op
-	op.SetStartTime(time.Now())
+	op.SetStartTime(clock.Now())
 	operatorCounter.WithLabelValues(op.Desc(), "start").Inc()
 	operatorWaitDuration.WithLabelValues(op.Desc()).Observe(op.ElapsedTime().Seconds())
 	opInfluence := NewTotalOpInfluence([]*operator.Operator{op}, oc.cluster)
@@ -347,7 +348,7 @@ func (oc *OperatorController) addOperatorLocked(op *operator.Operator) bool {
 		}
 	}
 
-	heap.Push(&oc.opNotifierQueue, &operatorWithTime{op: op, time: oc.getNextPushOperatorTime(step, time.Now())})
+	heap.Push(&oc.opNotifierQueue, &operatorWithTime{op: op, time: oc.getNextPushOperatorTime(step, clock.Now())})
 	operatorCounter.WithLabelValues(op.Desc(), "create").Inc()
 	return true
 }
@@ -539,7 +540,7 @@ func (oc *OperatorController) PruneHistory() {
 	oc.Lock()
 	defer oc.Unlock()
 	p := oc.histories.Back()
-	for p != nil && time.Since(p.Value.(operator.OpHistory).FinishTime) > historyKeepTime {
+	for p != nil && clock.Since(p.Value.(operator.OpHistory).FinishTime) > historyKeepTime {
 		prev := p.Prev()
 		oc.histories.Remove(p)
 		p = prev
@@ -728,7 +729,7 @@ func (oc *OperatorController) newStoreLimit(storeID uint64, rate float64) {
 		capacity = int64(rate * float64(operator.RegionInfluence))
 	}
 	rate *= float64(operator.RegionInfluence)
-	oc.storesLimit[storeID] = ratelimit.NewBucketWithRate(rate, capacity)
+	oc.storesLimit[storeID] = ratelimit.NewBucketWithRateAndClock(rate, capacity, clock.Source{})
 }
 
 // getOrCreateStoreLimit is used to get or create the limit of a store.
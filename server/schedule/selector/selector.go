@@ -16,6 +16,7 @@ package selector
 import (
 	"math/rand"
 
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/schedule/filter"
 	"github.com/pingcap/pd/server/schedule/opt"
@@ -46,9 +47,7 @@ func (s *BalanceSelector) SelectSource(opt opt.Options, stores []*core.StoreInfo
 		if filter.Source(opt, store, filters) {
 			continue
 		}
-		if result == nil ||
-			result.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) <
-				store.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) {
+		if result == nil || s.compare(opt, store, result) > 0 {
 			result = store
 		}
 	}
@@ -65,15 +64,35 @@ func (s *BalanceSelector) SelectTarget(opt opt.Options, stores []*core.StoreInfo
 		if filter.Target(opt, store, filters) {
 			continue
 		}
-		if result == nil ||
-			result.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) >
-				store.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) {
+		if result == nil || s.compare(opt, store, result) < 0 {
 			result = store
 		}
 	}
 	return result
 }
 
+// compare compares the resource scores of the stores. A tie is left to the
+// order of the stores, except on the virtual clock of the simulator, where the
+// store with the larger ID is considered larger so that the simulation is
+// reproducible.
+func (s *BalanceSelector) compare(opt opt.Options, storeA, storeB *core.StoreInfo) int {
+	scoreA := storeA.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0)
+	scoreB := storeB.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0)
+	switch {
+	case scoreA > scoreB:
+		return 1
+	case scoreA < scoreB:
+		return -1
+	case clock.GetVirtual() == nil:
+		return 0
+	case storeA.GetID() > storeB.GetID():
+		return 1
+	case storeA.GetID() < storeB.GetID():
+		return -1
+	}
+	return 0
+}
+
 func (s *BalanceSelector) updateConfig(opt opt.Options) {
 	if s.kind.Resource == core.LeaderKind {
 		s.kind.Strategy = opt.GetLeaderScheduleStrategy()
@@ -15,8 +15,10 @@ package selector
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/pkg/mock/mockcluster"
 	"github.com/pingcap/pd/pkg/mock/mockoption"
 	"github.com/pingcap/pd/server/core"
@@ -52,6 +54,27 @@ func (s *testSelectorSuite) TestCompareStoreScore(c *C) {
 	c.Assert(compareStoreScore(s.tc, store1, 1, store3, 2), Equals, -1)
 }
 
+func (s *testSelectorSuite) TestTie(c *C) {
+	selector := NewBalanceSelector(core.ScheduleKind{
+		Resource: core.LeaderKind,
+		Strategy: core.ByCount,
+	}, nil)
+	stores := []*core.StoreInfo{
+		core.NewStoreInfoWithSizeCount(2, 2, 10, 10, 5),
+		core.NewStoreInfoWithSizeCount(1, 2, 10, 10, 5),
+		core.NewStoreInfoWithSizeCount(3, 2, 10, 10, 5),
+	}
+	// The first store wins a tie on the wall clock.
+	c.Assert(selector.SelectSource(s.tc, stores).GetID(), Equals, uint64(2))
+	c.Assert(selector.SelectTarget(s.tc, stores).GetID(), Equals, uint64(2))
+
+	// The store ID decides a tie on the virtual clock.
+	clock.SetVirtual(clock.NewVirtual(time.Now()))
+	defer clock.SetVirtual(nil)
+	c.Assert(selector.SelectSource(s.tc, stores).GetID(), Equals, uint64(3))
+	c.Assert(selector.SelectTarget(s.tc, stores).GetID(), Equals, uint64(1))
+}
+
 func (s *testSelectorSuite) TestScheduleConfig(c *C) {
 	filters := make([]filter.Filter, 0)
 	testScheduleConfig := func(selector *BalanceSelector, stores []*core.StoreInfo, expectSourceID, expectTargetID uint64) {
@@ -20,6 +20,7 @@ import (
 
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/schedule"
 	"github.com/pingcap/pd/server/schedule/checker"
@@ -274,10 +275,10 @@ func (h *hitsStoreBuilder) filter(source, target *core.StoreInfo) bool {
 		return false
 	}
 	if item, ok := h.hits[key]; ok {
-		if time.Since(item.lastTime) > h.ttl {
+		if clock.Since(item.lastTime) > h.ttl {
 			delete(h.hits, key)
 		}
-		if time.Since(item.lastTime) <= h.ttl && item.count >= h.threshold {
+		if clock.Since(item.lastTime) <= h.ttl && item.count >= h.threshold {
 			log.Debug("skip the the store", zap.String("scheduler", balanceRegionName), zap.String("filter-key", key))
 			return true
 		}
@@ -298,14 +299,14 @@ func (h *hitsStoreBuilder) put(source, target *core.StoreInfo) {
 		return
 	}
 	if item, ok := h.hits[key]; ok {
-		if time.Since(item.lastTime) >= h.ttl {
+		if clock.Since(item.lastTime) >= h.ttl {
 			item.count = 0
 		} else {
 			item.count++
 		}
-		item.lastTime = time.Now()
+		item.lastTime = clock.Now()
 	} else {
-		item := &record{lastTime: time.Now()}
+		item := &record{lastTime: clock.Now()}
 		h.hits[key] = item
 	}
 }
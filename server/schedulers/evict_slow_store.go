@@ -0,0 +1,199 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sync"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/pingcap/pd/server/schedule/filter"
+	"github.com/pingcap/pd/server/schedule/operator"
+	"github.com/pingcap/pd/server/schedule/opt"
+	"github.com/pingcap/pd/server/schedule/selector"
+	"go.uber.org/zap"
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder("evict-slow-store", func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			return nil
+		}
+	})
+
+	schedule.RegisterScheduler("evict-slow-store", func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &evictSlowStoreSchedulerConfig{storage: storage}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newEvictSlowStoreScheduler(opController, conf), nil
+	})
+}
+
+const (
+	evictSlowStoreName = "evict-slow-store-scheduler"
+	// slowStoreEvictThreshold is the slow score to start evicting the leaders
+	// of a store.
+	slowStoreEvictThreshold = 3.0
+	// slowStoreRecoverThreshold is the slow score to consider the evicted
+	// store is recovered.
+	slowStoreRecoverThreshold = 1.5
+)
+
+type evictSlowStoreSchedulerConfig struct {
+	mu      sync.RWMutex
+	storage *core.Storage
+	// EvictedStore is the store whose leaders are being evicted, at most one
+	// store is evicted at the same time.
+	EvictedStore uint64 `json:"evicted-store"`
+}
+
+func (conf *evictSlowStoreSchedulerConfig) getEvictedStore() uint64 {
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	return conf.EvictedStore
+}
+
+func (conf *evictSlowStoreSchedulerConfig) setEvictedStore(storeID uint64) error {
+	conf.mu.Lock()
+	defer conf.mu.Unlock()
+	conf.EvictedStore = storeID
+	data, err := schedule.EncodeConfig(conf)
+	if err != nil {
+		return err
+	}
+	return conf.storage.SaveScheduleConfig(evictSlowStoreName, data)
+}
+
+type evictSlowStoreScheduler struct {
+	*baseScheduler
+	conf     *evictSlowStoreSchedulerConfig
+	selector *selector.RandomSelector
+}
+
+// newEvictSlowStoreScheduler creates a scheduler that detects the store which
+// is much slower than the others and transfers its leaders out until the store
+// recovers.
+func newEvictSlowStoreScheduler(opController *schedule.OperatorController, conf *evictSlowStoreSchedulerConfig) schedule.Scheduler {
+	filters := []filter.Filter{
+		filter.StoreStateFilter{ActionScope: evictSlowStoreName, TransferLeader: true},
+	}
+	base := newBaseScheduler(opController)
+	return &evictSlowStoreScheduler{
+		baseScheduler: base,
+		conf:          conf,
+		selector:      selector.NewRandomSelector(filters),
+	}
+}
+
+func (s *evictSlowStoreScheduler) GetName() string {
+	return evictSlowStoreName
+}
+
+func (s *evictSlowStoreScheduler) GetType() string {
+	return "evict-slow-store"
+}
+
+func (s *evictSlowStoreScheduler) EncodeConfig() ([]byte, error) {
+	s.conf.mu.RLock()
+	defer s.conf.mu.RUnlock()
+	return schedule.EncodeConfig(s.conf)
+}
+
+func (s *evictSlowStoreScheduler) Prepare(cluster opt.Cluster) error {
+	if storeID := s.conf.getEvictedStore(); storeID != 0 {
+		return cluster.BlockStore(storeID)
+	}
+	return nil
+}
+
+func (s *evictSlowStoreScheduler) Cleanup(cluster opt.Cluster) {
+	if storeID := s.conf.getEvictedStore(); storeID != 0 {
+		cluster.UnblockStore(storeID)
+	}
+}
+
+func (s *evictSlowStoreScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	return s.opController.OperatorCount(operator.OpLeader) < cluster.GetLeaderScheduleLimit()
+}
+
+func (s *evictSlowStoreScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+
+	storeID := s.conf.getEvictedStore()
+	if storeID != 0 {
+		store := cluster.GetStore(storeID)
+		if store == nil || store.IsTombstone() || store.GetSlowScore() <= slowStoreRecoverThreshold {
+			s.recover(cluster, storeID)
+			return nil
+		}
+		return s.evictLeader(cluster, storeID)
+	}
+
+	var slowStore *core.StoreInfo
+	for _, store := range cluster.GetStores() {
+		if !store.IsUp() || store.GetSlowScore() < slowStoreEvictThreshold {
+			continue
+		}
+		if slowStore == nil || store.GetSlowScore() > slowStore.GetSlowScore() {
+			slowStore = store
+		}
+	}
+	if slowStore == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-slow-store").Inc()
+		return nil
+	}
+	if err := cluster.BlockStore(slowStore.GetID()); err != nil {
+		log.Warn("failed to block the slow store", zap.Uint64("store-id", slowStore.GetID()), zap.Error(err))
+		schedulerCounter.WithLabelValues(s.GetName(), "block-store-fail").Inc()
+		return nil
+	}
+	if err := s.conf.setEvictedStore(slowStore.GetID()); err != nil {
+		log.Error("failed to persist the evicted store", zap.Uint64("store-id", slowStore.GetID()), zap.Error(err))
+		cluster.UnblockStore(slowStore.GetID())
+		return nil
+	}
+	log.Info("detected a slow store, start to evict its leaders",
+		zap.Uint64("store-id", slowStore.GetID()),
+		zap.Float64("slow-score", slowStore.GetSlowScore()))
+	return s.evictLeader(cluster, slowStore.GetID())
+}
+
+func (s *evictSlowStoreScheduler) recover(cluster opt.Cluster, storeID uint64) {
+	if err := s.conf.setEvictedStore(0); err != nil {
+		log.Error("failed to persist the evicted store", zap.Uint64("store-id", storeID), zap.Error(err))
+		return
+	}
+	cluster.UnblockStore(storeID)
+	log.Info("the slow store has recovered, stop evicting its leaders", zap.Uint64("store-id", storeID))
+	schedulerCounter.WithLabelValues(s.GetName(), "recover-store").Inc()
+}
+
+func (s *evictSlowStoreScheduler) evictLeader(cluster opt.Cluster, storeID uint64) []*operator.Operator {
+	region := cluster.RandLeaderRegion(storeID, core.HealthRegion())
+	if region == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-leader").Inc()
+		return nil
+	}
+	target := s.selector.SelectTarget(cluster, cluster.GetFollowerStores(region))
+	if target == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-target-store").Inc()
+		return nil
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "new-operator").Inc()
+	op := operator.CreateTransferLeaderOperator("evict-slow-store", region, storeID, target.GetID(), operator.OpLeader)
+	op.SetPriorityLevel(core.HighPriority)
+	return []*operator.Operator{op}
+}
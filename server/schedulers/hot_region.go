@@ -17,7 +17,6 @@ import (
 	"math"
 	"math/rand"
 	"sync"
-	"time"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/log"
@@ -100,7 +99,7 @@ func newBalanceHotRegionsScheduler(opController *schedule.OperatorController) *b
 		peerLimit:     1,
 		stats:         newStoreStaticstics(),
 		types:         []BalanceType{hotWriteRegionBalance, hotReadRegionBalance},
-		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		r:             rand.New(rand.NewSource(rand.Int63())),
 	}
 }
 
@@ -112,7 +111,7 @@ func newBalanceHotReadRegionsScheduler(opController *schedule.OperatorController
 		peerLimit:     1,
 		stats:         newStoreStaticstics(),
 		types:         []BalanceType{hotReadRegionBalance},
-		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		r:             rand.New(rand.NewSource(rand.Int63())),
 	}
 }
 
@@ -124,7 +123,7 @@ func newBalanceHotWriteRegionsScheduler(opController *schedule.OperatorControlle
 		peerLimit:     1,
 		stats:         newStoreStaticstics(),
 		types:         []BalanceType{hotWriteRegionBalance},
-		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		r:             rand.New(rand.NewSource(rand.Int63())),
 	}
 }
 
@@ -104,8 +104,7 @@ func (conf *scatterRangeSchedulerConfig) Persist() error {
 	if err != nil {
 		return err
 	}
-	conf.storage.SaveScheduleConfig(name, data)
-	return nil
+	return conf.storage.SaveScheduleConfigVersion(name, data, core.ConfigSourceAPI)
 }
 
 func (conf *scatterRangeSchedulerConfig) GetRangeName() string {
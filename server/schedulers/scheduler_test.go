@@ -416,6 +416,51 @@ func (s *testEvictLeaderSuite) TestEvictLeader(c *C) {
 	testutil.CheckTransferLeader(c, op[0], operator.OpLeader, 1, 2)
 }
 
+var _ = Suite(&testEvictSlowStoreSuite{})
+
+type testEvictSlowStoreSuite struct{}
+
+func (s *testEvictSlowStoreSuite) TestEvictSlowStore(c *C) {
+	opt := mockoption.NewScheduleOptions()
+	tc := mockcluster.NewCluster(opt)
+
+	// Add stores 1, 2, 3
+	tc.AddLeaderStore(1, 0)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderStore(3, 0)
+	// Add regions 1, 2 with leaders in store 1
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	tc.AddLeaderRegion(2, 1, 2, 3)
+
+	storage := core.NewStorage(kv.NewMemoryKV())
+	es, err := schedule.CreateScheduler("evict-slow-store", schedule.NewOperatorController(nil, nil), storage, schedule.ConfigSliceDecoder("evict-slow-store", nil))
+	c.Assert(err, IsNil)
+	c.Assert(es.IsScheduleAllowed(tc), IsTrue)
+	c.Assert(es.Schedule(tc), IsNil)
+
+	// Store 1 becomes slow, its leaders are evicted.
+	tc.PutStore(tc.GetStore(1).Clone(core.SetSlowScore(5)))
+	op := es.Schedule(tc)
+	testutil.CheckTransferLeaderFrom(c, op[0], operator.OpLeader, 1)
+	c.Assert(tc.GetStore(1).IsBlocked(), IsTrue)
+	data, err := storage.LoadScheduleConfig(es.GetName())
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, `{"evicted-store":1}`)
+
+	// Only one store is evicted at the same time.
+	tc.PutStore(tc.GetStore(2).Clone(core.SetSlowScore(10)))
+	op = es.Schedule(tc)
+	testutil.CheckTransferLeaderFrom(c, op[0], operator.OpLeader, 1)
+	c.Assert(tc.GetStore(2).IsBlocked(), IsFalse)
+
+	// Store 1 recovers.
+	tc.PutStore(tc.GetStore(1).Clone(core.SetSlowScore(1)))
+	tc.PutStore(tc.GetStore(2).Clone(core.SetSlowScore(1)))
+	c.Assert(es.Schedule(tc), IsNil)
+	c.Assert(tc.GetStore(1).IsBlocked(), IsFalse)
+	c.Assert(es.Schedule(tc), IsNil)
+}
+
 var _ = Suite(&testShuffleRegionSuite{})
 
 type testShuffleRegionSuite struct{}
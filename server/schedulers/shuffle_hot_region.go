@@ -16,7 +16,6 @@ package schedulers
 import (
 	"math/rand"
 	"strconv"
-	"time"
 
 	"github.com/pingcap/log"
 	"github.com/pingcap/pd/server/core"
@@ -78,7 +77,7 @@ func newShuffleHotRegionScheduler(opController *schedule.OperatorController, con
 		conf:          conf,
 		stats:         newStoreStaticstics(),
 		types:         []BalanceType{hotReadRegionBalance, hotWriteRegionBalance},
-		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		r:             rand.New(rand.NewSource(rand.Int63())),
 	}
 }
 
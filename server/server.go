@@ -16,7 +16,6 @@ package server
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"net/http"
 	"path"
 	"path/filepath"
@@ -32,15 +31,21 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/configpb"
 	"github.com/pingcap/pd/pkg/etcdutil"
 	"github.com/pingcap/pd/pkg/logutil"
 	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server/audit"
+	"github.com/pingcap/pd/server/componentconfig"
 	"github.com/pingcap/pd/server/config"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/id"
 	"github.com/pingcap/pd/server/kv"
+	"github.com/pingcap/pd/server/limiter"
 	"github.com/pingcap/pd/server/member"
 	"github.com/pingcap/pd/server/namespace"
+	"github.com/pingcap/pd/server/rbac"
+	"github.com/pingcap/pd/server/recorder"
 	"github.com/pingcap/pd/server/tso"
 	"github.com/pkg/errors"
 	"go.etcd.io/etcd/clientv3"
@@ -99,6 +104,20 @@ type Server struct {
 	cluster *RaftCluster
 	// For async region heartbeat.
 	hbStreams *heartbeatStreams
+	// for the maintenance of etcd, it runs on the leader.
+	etcdMaintainer *member.Maintainer
+	// for the audit log of the API requests.
+	auditor *audit.Auditor
+	// for recording the heartbeats to replay.
+	recorder *recorder.Recorder
+	// for the access control of the API requests.
+	rbac *rbac.Manager
+	// the common names of the TLS certificates of the members.
+	memberCertCN map[string]struct{}
+	// for the configs of the components, such as TiKV and TiDB.
+	componentConfig *componentconfig.Manager
+	// for the rate limit of the expensive endpoints.
+	limiter *limiter.Limiter
 	// Zap logger
 	lg       *zap.Logger
 	logProps *log.ZapProperties
@@ -107,7 +126,6 @@ type Server struct {
 // CreateServer creates the UNINITIALIZED pd server with given configuration.
 func CreateServer(cfg *config.Config, apiRegister func(*Server) http.Handler) (*Server, error) {
 	log.Info("PD Config", zap.Reflect("config", cfg))
-	rand.Seed(time.Now().UnixNano())
 
 	s := &Server{
 		cfg:         cfg,
@@ -115,6 +133,29 @@ func CreateServer(cfg *config.Config, apiRegister func(*Server) http.Handler) (*
 		member:      &member.Member{},
 	}
 	s.handler = newHandler(s)
+	s.componentConfig = componentconfig.NewManager()
+	componentconfig.RegisterBuiltinValidators(s.componentConfig)
+	s.limiter = limiter.NewLimiter(s.scheduleOpt.LoadRateLimitConfig())
+
+	auditor, err := audit.NewAuditor(cfg.Audit)
+	if err != nil {
+		return nil, err
+	}
+	s.auditor = auditor
+	if s.recorder, err = recorder.NewRecorder(cfg.HeartbeatRecord); err != nil {
+		return nil, err
+	}
+	if s.rbac, err = rbac.NewManager(&cfg.Security.RBAC); err != nil {
+		return nil, err
+	}
+	memberCertCN, err := cfg.Security.GetMemberCertAllowedCN()
+	if err != nil {
+		return nil, err
+	}
+	s.memberCertCN = make(map[string]struct{}, len(memberCertCN))
+	for _, cn := range memberCertCN {
+		s.memberCertCN[cn] = struct{}{}
+	}
 
 	// Adjust etcd config.
 	etcdCfg, err := s.cfg.GenEmbedEtcdConfig()
@@ -126,7 +167,10 @@ func CreateServer(cfg *config.Config, apiRegister func(*Server) http.Handler) (*
 			pdAPIPrefix: apiRegister(s),
 		}
 	}
-	etcdCfg.ServiceRegister = func(gs *grpc.Server) { pdpb.RegisterPDServer(gs, s) }
+	etcdCfg.ServiceRegister = func(gs *grpc.Server) {
+		pdpb.RegisterPDServer(gs, s)
+		configpb.RegisterConfigServer(gs, s)
+	}
 	s.etcdCfg = etcdCfg
 	if EnableZap {
 		// The etcd master version has removed embed.Config.SetupLogging.
@@ -228,9 +272,16 @@ func (s *Server) startServer() error {
 	if err != nil {
 		return err
 	}
-	s.storage = core.NewStorage(kvBase).SetRegionStorage(regionStorage)
+	trendStorage, err := core.NewTrendStorage(filepath.Join(s.cfg.DataDir, "store-trend"))
+	if err != nil {
+		return err
+	}
+	s.storage = core.NewStorage(kvBase).SetRegionStorage(regionStorage).SetTrendStorage(trendStorage)
 	s.cluster = newRaftCluster(s, s.clusterID)
 	s.hbStreams = newHeartbeatStreams(s.clusterID, s.cluster)
+	s.rbac.SetStorage(s.storage)
+	s.componentConfig.SetStorage(s.storage)
+	s.etcdMaintainer = member.NewMaintainer(s.client, &s.cfg.EtcdMaintenance, int64(s.cfg.QuotaBackendBytes), s.member.ID())
 	if s.classifier, err = namespace.CreateClassifier(s.cfg.NamespaceClassifier, s.storage, s.idAllocator); err != nil {
 		return err
 	}
@@ -280,6 +331,12 @@ func (s *Server) Close() {
 	if err := s.storage.Close(); err != nil {
 		log.Error("close storage meet error", zap.Error(err))
 	}
+	if err := s.auditor.Close(); err != nil {
+		log.Error("close auditor meet error", zap.Error(err))
+	}
+	if err := s.recorder.Close(); err != nil {
+		log.Error("close heartbeat recorder meet error", zap.Error(err))
+	}
 
 	log.Info("close server")
 }
@@ -320,10 +377,16 @@ func (s *Server) Context() context.Context {
 
 func (s *Server) startServerLoop() {
 	s.serverLoopCtx, s.serverLoopCancel = context.WithCancel(context.Background())
-	s.serverLoopWg.Add(3)
+	s.serverLoopWg.Add(4)
 	go s.leaderLoop()
 	go s.etcdLeaderLoop()
 	go s.serverMetricsLoop()
+	go s.rbacLoop()
+}
+
+func (s *Server) rbacLoop() {
+	defer s.serverLoopWg.Done()
+	s.rbac.Run(s.serverLoopCtx)
 }
 
 func (s *Server) stopServerLoop() {
@@ -370,51 +433,15 @@ func (s *Server) bootstrapCluster(req *pdpb.BootstrapRequest) (*pdpb.BootstrapRe
 		MaxPeerCount: uint32(s.scheduleOpt.GetReplication().GetMaxReplicas()),
 	}
 
-	// Set cluster meta
-	clusterValue, err := clusterMeta.Marshal()
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-	clusterRootPath := s.getClusterRootPath()
-
-	var ops []clientv3.Op
-	ops = append(ops, clientv3.OpPut(clusterRootPath, string(clusterValue)))
-
-	// Set bootstrap time
-	bootstrapKey := makeBootstrapTimeKey(clusterRootPath)
-	nano := time.Now().UnixNano()
-
-	timeData := typeutil.Uint64ToBytes(uint64(nano))
-	ops = append(ops, clientv3.OpPut(bootstrapKey, string(timeData)))
-
-	// Set store meta
-	storeMeta := req.GetStore()
-	storePath := makeStoreKey(clusterRootPath, storeMeta.GetId())
-	storeValue, err := storeMeta.Marshal()
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-	ops = append(ops, clientv3.OpPut(storePath, string(storeValue)))
-
-	regionValue, err := req.GetRegion().Marshal()
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-
-	// Set region meta with region id.
-	regionPath := makeRegionKey(clusterRootPath, req.GetRegion().GetId())
-	ops = append(ops, clientv3.OpPut(regionPath, string(regionValue)))
-
 	// TODO: we must figure out a better way to handle bootstrap failed, maybe intervene manually.
-	bootstrapCmp := clientv3.Compare(clientv3.CreateRevision(clusterRootPath), "=", 0)
-	resp, err := kv.NewSlowLogTxn(s.client).If(bootstrapCmp).Then(ops...).Commit()
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-	if !resp.Succeeded {
+	err := s.storage.Bootstrap(&clusterMeta, req.GetStore(), req.GetRegion(), time.Now())
+	if errors.Cause(err) == kv.ErrTxnConditionFailed {
 		log.Warn("cluster already bootstrapped", zap.Uint64("cluster-id", clusterID))
 		return nil, errors.Errorf("cluster %d already bootstrapped", clusterID)
 	}
+	if err != nil {
+		return nil, err
+	}
 
 	log.Info("bootstrap cluster ok", zap.Uint64("cluster-id", clusterID))
 	err = s.storage.SaveRegion(req.GetRegion())
@@ -479,6 +506,21 @@ func (s *Server) GetMember() *member.Member {
 	return s.member
 }
 
+// GetAuditor returns the auditor of server.
+func (s *Server) GetAuditor() *audit.Auditor {
+	return s.auditor
+}
+
+// GetRecorder returns the heartbeat recorder of server.
+func (s *Server) GetRecorder() *recorder.Recorder {
+	return s.recorder
+}
+
+// GetEtcdMaintainer returns the etcd maintainer of server.
+func (s *Server) GetEtcdMaintainer() *member.Maintainer {
+	return s.etcdMaintainer
+}
+
 // GetStorage returns the backend storage of server.
 func (s *Server) GetStorage() *core.Storage {
 	return s.storage
@@ -515,6 +557,8 @@ func (s *Server) GetConfig() *config.Config {
 	cfg.LabelProperty = s.scheduleOpt.LoadLabelPropertyConfig().Clone()
 	cfg.ClusterVersion = *s.scheduleOpt.LoadClusterVersion()
 	cfg.PDServerCfg = *s.scheduleOpt.LoadPDServerConfig()
+	cfg.RateLimit = *s.scheduleOpt.LoadRateLimitConfig()
+	cfg.Security.RBAC = *s.rbac.GetConfig()
 	storage := s.GetStorage()
 	if storage == nil {
 		return cfg
@@ -548,7 +592,7 @@ func (s *Server) SetScheduleConfig(cfg config.ScheduleConfig) error {
 	}
 	old := s.scheduleOpt.Load()
 	s.scheduleOpt.Store(&cfg)
-	if err := s.scheduleOpt.Persist(s.storage); err != nil {
+	if err := s.scheduleOpt.PersistWithSource(s.storage, core.ConfigSourceAPI); err != nil {
 		s.scheduleOpt.Store(old)
 		log.Error("failed to update schedule config",
 			zap.Reflect("new", cfg),
@@ -574,7 +618,7 @@ func (s *Server) SetReplicationConfig(cfg config.ReplicationConfig) error {
 	}
 	old := s.scheduleOpt.GetReplication().Load()
 	s.scheduleOpt.GetReplication().Store(&cfg)
-	if err := s.scheduleOpt.Persist(s.storage); err != nil {
+	if err := s.scheduleOpt.PersistWithSource(s.storage, core.ConfigSourceAPI); err != nil {
 		s.scheduleOpt.GetReplication().Store(old)
 		log.Error("failed to update replication config",
 			zap.Reflect("new", cfg),
@@ -590,7 +634,7 @@ func (s *Server) SetReplicationConfig(cfg config.ReplicationConfig) error {
 func (s *Server) SetPDServerConfig(cfg config.PDServerConfig) error {
 	old := s.scheduleOpt.LoadPDServerConfig()
 	s.scheduleOpt.SetPDServerConfig(&cfg)
-	if err := s.scheduleOpt.Persist(s.storage); err != nil {
+	if err := s.scheduleOpt.PersistWithSource(s.storage, core.ConfigSourceAPI); err != nil {
 		s.scheduleOpt.SetPDServerConfig(old)
 		log.Error("failed to update PDServer config",
 			zap.Reflect("new", cfg),
@@ -602,6 +646,39 @@ func (s *Server) SetPDServerConfig(cfg config.PDServerConfig) error {
 	return nil
 }
 
+// GetRateLimitConfig gets the rate limit config.
+func (s *Server) GetRateLimitConfig() *config.RateLimitConfig {
+	return s.scheduleOpt.LoadRateLimitConfig().Clone()
+}
+
+// SetRateLimit sets the rate limit of an endpoint, the limit is removed if it
+// is unlimited.
+func (s *Server) SetRateLimit(limit config.EndpointLimit) error {
+	old := s.scheduleOpt.LoadRateLimitConfig()
+	cfg := old.Clone()
+	cfg.SetLimit(limit)
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	s.scheduleOpt.SetRateLimitConfig(cfg)
+	if err := s.scheduleOpt.PersistWithSource(s.storage, core.ConfigSourceAPI); err != nil {
+		s.scheduleOpt.SetRateLimitConfig(old)
+		log.Error("failed to update rate limit config",
+			zap.Reflect("new", limit),
+			zap.Reflect("old", old),
+			zap.Error(err))
+		return err
+	}
+	s.limiter.Update(cfg)
+	log.Info("rate limit config is updated", zap.Reflect("new", limit), zap.Reflect("old", old))
+	return nil
+}
+
+// GetLimiter returns the rate limiter of the expensive endpoints.
+func (s *Server) GetLimiter() *limiter.Limiter {
+	return s.limiter
+}
+
 // GetNamespaceConfig get the namespace config.
 func (s *Server) GetNamespaceConfig(name string) *config.NamespaceConfig {
 	if _, ok := s.scheduleOpt.GetNS(name); !ok {
@@ -632,7 +709,7 @@ func (s *Server) SetNamespaceConfig(name string, cfg config.NamespaceConfig) err
 	if n, ok := s.scheduleOpt.GetNS(name); ok {
 		old := n.Load()
 		n.Store(&cfg)
-		if err := s.scheduleOpt.Persist(s.storage); err != nil {
+		if err := s.scheduleOpt.PersistWithSource(s.storage, core.ConfigSourceAPI); err != nil {
 			s.scheduleOpt.SetNS(name, config.NewNamespaceOption(old))
 			log.Error("failed to update namespace config",
 				zap.String("name", name),
@@ -644,7 +721,7 @@ func (s *Server) SetNamespaceConfig(name string, cfg config.NamespaceConfig) err
 		log.Info("namespace config is updated", zap.String("name", name), zap.Reflect("new", cfg), zap.Reflect("old", old))
 	} else {
 		s.scheduleOpt.SetNS(name, config.NewNamespaceOption(&cfg))
-		if err := s.scheduleOpt.Persist(s.storage); err != nil {
+		if err := s.scheduleOpt.PersistWithSource(s.storage, core.ConfigSourceAPI); err != nil {
 			s.scheduleOpt.DeleteNS(name)
 			log.Error("failed to add namespace config",
 				zap.String("name", name),
@@ -662,7 +739,7 @@ func (s *Server) DeleteNamespaceConfig(name string) error {
 	if n, ok := s.scheduleOpt.GetNS(name); ok {
 		cfg := n.Load()
 		s.scheduleOpt.DeleteNS(name)
-		if err := s.scheduleOpt.Persist(s.storage); err != nil {
+		if err := s.scheduleOpt.PersistWithSource(s.storage, core.ConfigSourceAPI); err != nil {
 			s.scheduleOpt.SetNS(name, config.NewNamespaceOption(cfg))
 			log.Error("failed to delete namespace config",
 				zap.String("name", name),
@@ -677,7 +754,7 @@ func (s *Server) DeleteNamespaceConfig(name string) error {
 // SetLabelProperty inserts a label property config.
 func (s *Server) SetLabelProperty(typ, labelKey, labelValue string) error {
 	s.scheduleOpt.SetLabelProperty(typ, labelKey, labelValue)
-	err := s.scheduleOpt.Persist(s.storage)
+	err := s.scheduleOpt.PersistWithSource(s.storage, core.ConfigSourceAPI)
 	if err != nil {
 		s.scheduleOpt.DeleteLabelProperty(typ, labelKey, labelValue)
 		log.Error("failed to update label property config",
@@ -695,7 +772,7 @@ func (s *Server) SetLabelProperty(typ, labelKey, labelValue string) error {
 // DeleteLabelProperty deletes a label property config.
 func (s *Server) DeleteLabelProperty(typ, labelKey, labelValue string) error {
 	s.scheduleOpt.DeleteLabelProperty(typ, labelKey, labelValue)
-	err := s.scheduleOpt.Persist(s.storage)
+	err := s.scheduleOpt.PersistWithSource(s.storage, core.ConfigSourceAPI)
 	if err != nil {
 		s.scheduleOpt.SetLabelProperty(typ, labelKey, labelValue)
 		log.Error("failed to delete label property config",
@@ -723,7 +800,7 @@ func (s *Server) SetClusterVersion(v string) error {
 	}
 	old := s.scheduleOpt.LoadClusterVersion()
 	s.scheduleOpt.SetClusterVersion(version)
-	err = s.scheduleOpt.Persist(s.storage)
+	err = s.scheduleOpt.PersistWithSource(s.storage, core.ConfigSourceAPI)
 	if err != nil {
 		s.scheduleOpt.SetClusterVersion(old)
 		log.Error("failed to update cluster version",
@@ -741,6 +818,81 @@ func (s *Server) GetClusterVersion() semver.Version {
 	return *s.scheduleOpt.LoadClusterVersion()
 }
 
+// GetConfigHistory returns at most limit versions of the configuration up to
+// endVersion, the latest one is the first. endVersion 0 means the latest
+// version.
+func (s *Server) GetConfigHistory(endVersion uint64, limit int) ([]*core.ConfigVersion, error) {
+	if endVersion == 0 {
+		var err error
+		if endVersion, err = s.storage.LoadLatestConfigVersion(); err != nil {
+			return nil, err
+		}
+	}
+	if endVersion == 0 || limit <= 0 {
+		return []*core.ConfigVersion{}, nil
+	}
+	startVersion := uint64(1)
+	if endVersion > uint64(limit) {
+		startVersion = endVersion - uint64(limit) + 1
+	}
+	history, err := s.storage.LoadConfigHistory(startVersion, int(endVersion-startVersion+1))
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history, nil
+}
+
+// GetConfigVersion returns a version of the configuration, it is nil if the
+// version does not exist.
+func (s *Server) GetConfigVersion(version uint64) (*core.ConfigVersion, error) {
+	v, _, err := s.storage.LoadConfigVersion(version)
+	return v, err
+}
+
+// RollbackConfig rolls back the configuration to a version, and saves it as a
+// new version. The schedulers are added or removed to match the version, the
+// cluster version is not rolled back.
+func (s *Server) RollbackConfig(v *core.ConfigVersion) error {
+	cfg, err := s.scheduleOpt.ParseConfigVersion(v)
+	if err != nil {
+		return err
+	}
+	if s.GetRaftCluster() != nil {
+		if err := s.handler.syncSchedulers(cfg.Schedule.Schedulers, core.ConfigSourceRollback); err != nil {
+			log.Error("failed to roll back schedulers", zap.Uint64("version", v.Version), zap.Error(err))
+			return err
+		}
+		cfg.Schedule.Schedulers = append(config.SchedulerConfigs(nil), s.scheduleOpt.GetSchedulers()...)
+	}
+	if err := s.scheduleOpt.Rollback(s.storage, cfg); err != nil {
+		log.Error("failed to roll back config", zap.Uint64("version", v.Version), zap.Error(err))
+		return err
+	}
+	s.limiter.Update(s.scheduleOpt.LoadRateLimitConfig())
+	log.Info("config is rolled back", zap.Uint64("version", v.Version))
+	return nil
+}
+
+// GetRBACManager returns the access control manager of the API requests.
+func (s *Server) GetRBACManager() *rbac.Manager {
+	return s.rbac
+}
+
+// IsMemberCertCN checks whether the common name is the one of the TLS
+// certificates of the members.
+func (s *Server) IsMemberCertCN(cn string) bool {
+	_, ok := s.memberCertCN[cn]
+	return ok
+}
+
+// GetComponentConfigManager returns the manager of the component configs.
+func (s *Server) GetComponentConfigManager() *componentconfig.Manager {
+	return s.componentConfig
+}
+
 // GetSecurityConfig get the security config.
 func (s *Server) GetSecurityConfig() *config.SecurityConfig {
 	return &s.cfg.Security
@@ -899,6 +1051,8 @@ func (s *Server) campaignLeader() {
 	s.member.EnableLeader()
 	defer s.member.DisableLeader()
 
+	go s.etcdMaintainer.Run(ctx)
+
 	CheckPDVersion(s.scheduleOpt)
 	log.Info("PD cluster leader is ready to serve", zap.String("leader-name", s.Name()))
 
@@ -954,6 +1108,10 @@ func (s *Server) reloadConfigFromKV() error {
 	if err != nil {
 		return err
 	}
+	s.limiter.Update(s.scheduleOpt.LoadRateLimitConfig())
+	if err = s.componentConfig.Reload(); err != nil {
+		return err
+	}
 	if s.scheduleOpt.LoadPDServerConfig().UseRegionStorage {
 		s.storage.SwitchToRegionStorage()
 		log.Info("server enable region storage")
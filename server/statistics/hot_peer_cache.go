@@ -14,10 +14,9 @@
 package statistics
 
 import (
-	"time"
-
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/pkg/cache"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/server/core"
 )
 
@@ -96,7 +95,7 @@ func (f *hotPeerCache) CheckRegionFlow(region *core.RegionInfo, stats *StoresSta
 
 		// This is used for the simulator.
 		if oldItem != nil && Denoising {
-			interval := time.Since(oldItem.LastUpdateTime).Seconds()
+			interval := clock.Since(oldItem.LastUpdateTime).Seconds()
 			// ignore if report too fast
 			if interval < hotRegionReportMinInterval && !isExpired {
 				continue
@@ -111,7 +110,7 @@ func (f *hotPeerCache) CheckRegionFlow(region *core.RegionInfo, stats *StoresSta
 			Kind:           f.kind,
 			BytesRate:      bytesPerSec,
 			KeysRate:       keysPerSec,
-			LastUpdateTime: time.Now(),
+			LastUpdateTime: clock.Now(),
 			Version:        region.GetMeta().GetRegionEpoch().GetVersion(),
 			needDelete:     isExpired,
 			isLeader:       region.GetLeader().GetStoreId() == storeID,
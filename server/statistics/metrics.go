@@ -64,6 +64,22 @@ var (
 			Help:      "Status of the scheduling configurations.",
 		}, []string{"type", "namespace"})
 
+	storeLabelStatusGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "cluster",
+			Name:      "store_label_status",
+			Help:      "Status of the stores grouped by label.",
+		}, []string{"key", "value", "type"})
+
+	storeProgressGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "cluster",
+			Name:      "store_progress",
+			Help:      "Progress of the stores being removed or prepared.",
+		}, []string{"store", "address", "action", "type"})
+
 	regionLabelLevelGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "pd",
@@ -81,4 +97,6 @@ func init() {
 	prometheus.MustRegister(placementStatusGauge)
 	prometheus.MustRegister(configStatusGauge)
 	prometheus.MustRegister(regionLabelLevelGauge)
+	prometheus.MustRegister(storeLabelStatusGauge)
+	prometheus.MustRegister(storeProgressGauge)
 }
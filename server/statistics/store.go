@@ -14,6 +14,7 @@
 package statistics
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/pingcap/kvproto/pkg/pdpb"
@@ -23,6 +24,9 @@ import (
 const (
 	// StoreHeartBeatReportInterval is the heartbeat report interval of a store.
 	StoreHeartBeatReportInterval = 10
+	// minSlowScoreStoreCount is the minimum number of stores to compare with
+	// when calculating the slow score.
+	minSlowScoreStoreCount = 3
 )
 
 // StoresStats is a cache hold hot regions.
@@ -97,6 +101,40 @@ func (s *StoresStats) TotalBytesReadRate() float64 {
 	return s.bytesReadRate
 }
 
+// ObserveHeartbeatGap records the time in seconds between the two latest
+// heartbeats of the store.
+func (s *StoresStats) ObserveHeartbeatGap(storeID uint64, gap float64) {
+	s.RLock()
+	defer s.RUnlock()
+	if r, ok := s.rollingStoresStats[storeID]; ok {
+		r.ObserveHeartbeatGap(gap)
+	}
+}
+
+// GetSlowScore returns the ratio of the heartbeat gap of the store to the
+// median heartbeat gap of all stores, a store which is much slower than the
+// others has a high score. It returns 0 if there are not enough stores.
+func (s *StoresStats) GetSlowScore(storeID uint64) float64 {
+	s.RLock()
+	defer s.RUnlock()
+	r, ok := s.rollingStoresStats[storeID]
+	if !ok {
+		return 0
+	}
+	gaps := make([]float64, 0, len(s.rollingStoresStats))
+	for _, stats := range s.rollingStoresStats {
+		if gap := stats.GetHeartbeatGap(); gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) < minSlowScoreStoreCount {
+		return 0
+	}
+	sort.Float64s(gaps)
+	median := gaps[len(gaps)/2]
+	return r.GetHeartbeatGap() / median
+}
+
 // GetStoreBytesRate returns the bytes write stat of the specified store.
 func (s *StoresStats) GetStoreBytesRate(storeID uint64) (writeRate float64, readRate float64) {
 	s.RLock()
@@ -160,6 +198,7 @@ type RollingStoreStats struct {
 	bytesReadRate  *RollingStats
 	keysWriteRate  *RollingStats
 	keysReadRate   *RollingStats
+	heartbeatGap   *RollingStats
 }
 
 const storeStatsRollingWindows = 3
@@ -171,6 +210,7 @@ func newRollingStoreStats() *RollingStoreStats {
 		bytesReadRate:  NewRollingStats(storeStatsRollingWindows),
 		keysWriteRate:  NewRollingStats(storeStatsRollingWindows),
 		keysReadRate:   NewRollingStats(storeStatsRollingWindows),
+		heartbeatGap:   NewRollingStats(storeStatsRollingWindows),
 	}
 }
 
@@ -209,3 +249,17 @@ func (r *RollingStoreStats) GetKeysReadRate() float64 {
 	defer r.RUnlock()
 	return r.keysReadRate.Median()
 }
+
+// ObserveHeartbeatGap records the gap between two heartbeats.
+func (r *RollingStoreStats) ObserveHeartbeatGap(gap float64) {
+	r.Lock()
+	defer r.Unlock()
+	r.heartbeatGap.Add(gap)
+}
+
+// GetHeartbeatGap returns the heartbeat gap.
+func (r *RollingStoreStats) GetHeartbeatGap() float64 {
+	r.RLock()
+	defer r.RUnlock()
+	return r.heartbeatGap.Median()
+}
@@ -201,6 +201,7 @@ type storeStatisticsMap struct {
 	opt        ScheduleOptions
 	classifier namespace.Classifier
 	stats      map[string]*storeStatistics
+	labelStats *storeLabelStatistics
 }
 
 // NewStoreStatisticsMap creates a new storeStatisticsMap.
@@ -209,6 +210,7 @@ func NewStoreStatisticsMap(opt ScheduleOptions, classifier namespace.Classifier)
 		opt:        opt,
 		classifier: classifier,
 		stats:      make(map[string]*storeStatistics),
+		labelStats: newStoreLabelStatistics(),
 	}
 }
 
@@ -220,15 +222,18 @@ func (m *storeStatisticsMap) Observe(store *core.StoreInfo, stats *StoresStats)
 		m.stats[namespace] = stat
 	}
 	stat.Observe(store, stats)
+	m.labelStats.Observe(store, stats)
 }
 
 func (m *storeStatisticsMap) Collect() {
 	for _, s := range m.stats {
 		s.Collect()
 	}
+	m.labelStats.Collect()
 }
 
 func (m *storeStatisticsMap) Reset() {
 	storeStatusGauge.Reset()
 	clusterStatusGauge.Reset()
+	storeLabelStatusGauge.Reset()
 }
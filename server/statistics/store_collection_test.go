@@ -74,3 +74,102 @@ func (t *testStoreStatisticsSuite) TestStoreStatistics(c *C) {
 	c.Assert(stats.LabelCounter["host:h2"], Equals, 4)
 	c.Assert(stats.LabelCounter["zone:unknown"], Equals, 2)
 }
+
+func (t *testStoreStatisticsSuite) TestStoreLabelStats(c *C) {
+	metaStores := []*metapb.Store{
+		{Id: 1, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}, {Key: "host", Value: "h1"}}},
+		{Id: 2, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}, {Key: "host", Value: "h2"}}},
+		{Id: 3, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z2"}, {Key: "host", Value: "h1"}}},
+		{Id: 4, Labels: []*metapb.StoreLabel{{Key: "host", Value: "h2"}}},
+		{Id: 5, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z2"}}, State: metapb.StoreState_Tombstone},
+	}
+	storesStats := NewStoresStats()
+	var stores []*core.StoreInfo
+	for _, m := range metaStores {
+		s := core.NewStoreInfo(m,
+			core.SetRegionCount(10),
+			core.SetLeaderCount(int(m.GetId())),
+		)
+		storesStats.CreateRollingStoreStats(m.GetId())
+		stores = append(stores, s)
+	}
+
+	stats := GetStoreLabelStats(stores, storesStats, "zone")
+	c.Assert(stats, HasLen, 3)
+	c.Assert(stats[0].Value, Equals, "unknown")
+	c.Assert(stats[0].StoreCount, Equals, 1)
+	c.Assert(stats[0].LeaderCount, Equals, 4)
+	c.Assert(stats[1].Value, Equals, "z1")
+	c.Assert(stats[1].StoreCount, Equals, 2)
+	c.Assert(stats[1].RegionCount, Equals, 20)
+	c.Assert(stats[1].LeaderCount, Equals, 3)
+	c.Assert(stats[2].Value, Equals, "z2")
+	c.Assert(stats[2].StoreCount, Equals, 1)
+	c.Assert(stats[2].LeaderCount, Equals, 3)
+
+	stats = GetStoreLabelStats(stores, storesStats, "host")
+	c.Assert(stats, HasLen, 2)
+	c.Assert(stats[0].Value, Equals, "h1")
+	c.Assert(stats[0].StoreCount, Equals, 2)
+	c.Assert(stats[1].Value, Equals, "h2")
+	c.Assert(stats[1].StoreCount, Equals, 2)
+}
+
+func (t *testStoreStatisticsSuite) TestSlowScore(c *C) {
+	storesStats := NewStoresStats()
+	for i := uint64(1); i <= 4; i++ {
+		storesStats.CreateRollingStoreStats(i)
+	}
+	storesStats.ObserveHeartbeatGap(1, 10)
+	storesStats.ObserveHeartbeatGap(2, 10)
+	c.Assert(storesStats.GetSlowScore(1), Equals, 0.0)
+
+	storesStats.ObserveHeartbeatGap(3, 10)
+	storesStats.ObserveHeartbeatGap(4, 40)
+	c.Assert(storesStats.GetSlowScore(1), Equals, 1.0)
+	c.Assert(storesStats.GetSlowScore(4), Equals, 4.0)
+	c.Assert(storesStats.GetSlowScore(5), Equals, 0.0)
+}
+
+func (t *testStoreStatisticsSuite) TestStoreProgress(c *C) {
+	newStores := func(offlineCount, newCount int) []*core.StoreInfo {
+		stores := make([]*core.StoreInfo, 0, 5)
+		for id := uint64(1); id <= 3; id++ {
+			stores = append(stores, core.NewStoreInfo(&metapb.Store{Id: id}, core.SetRegionCount(100)))
+		}
+		if offlineCount >= 0 {
+			stores = append(stores, core.NewStoreInfo(&metapb.Store{Id: 4, State: metapb.StoreState_Offline}, core.SetRegionCount(offlineCount)))
+		}
+		stores = append(stores, core.NewStoreInfo(&metapb.Store{Id: 5}, core.SetRegionCount(newCount)))
+		return stores
+	}
+
+	m := NewStoreProgressManager()
+	start := time.Now()
+	m.Observe(newStores(100, 0), start)
+	progresses := m.GetProgresses()
+	c.Assert(progresses, HasLen, 2)
+	c.Assert(progresses[0].Action, Equals, RemovingAction)
+	c.Assert(progresses[0].Progress, Equals, 0.0)
+	c.Assert(progresses[0].LeftSeconds, Equals, -1.0)
+	c.Assert(progresses[1].Action, Equals, PreparingAction)
+	c.Assert(progresses[1].TargetRegionCount, Equals, 75)
+
+	m.Observe(newStores(70, 20), start.Add(time.Minute))
+	progresses = m.GetProgresses()
+	c.Assert(progresses, HasLen, 2)
+	c.Assert(progresses[0].StoreID, Equals, uint64(4))
+	c.Assert(progresses[0].MovedRegionCount, Equals, 30)
+	c.Assert(progresses[0].Progress, Equals, 0.3)
+	c.Assert(progresses[0].CurrentSpeed, Equals, 0.5)
+	c.Assert(progresses[0].LeftSeconds, Equals, 140.0)
+	c.Assert(progresses[1].StoreID, Equals, uint64(5))
+	c.Assert(progresses[1].TargetRegionCount, Equals, 80)
+	c.Assert(progresses[1].MovedRegionCount, Equals, 20)
+	c.Assert(progresses[1].Progress, Equals, 0.25)
+	c.Assert(progresses[1].LeftSeconds, Equals, 180.0)
+
+	// The offline store becomes tombstone and the new store is prepared.
+	m.Observe(newStores(-1, 90), start.Add(2*time.Minute))
+	c.Assert(m.GetProgresses(), HasLen, 0)
+}
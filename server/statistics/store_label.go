@@ -0,0 +1,140 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sort"
+
+	"github.com/pingcap/pd/server/core"
+)
+
+// StoreLabelStats records the aggregated statistics of the stores which have
+// the same value of a label key.
+type StoreLabelStats struct {
+	Value          string  `json:"value"`
+	StoreCount     int     `json:"store_count"`
+	Capacity       uint64  `json:"capacity"`
+	Available      uint64  `json:"available"`
+	UsedSize       uint64  `json:"used_size"`
+	RegionCount    int     `json:"region_count"`
+	LeaderCount    int     `json:"leader_count"`
+	BytesWriteRate float64 `json:"bytes_write_rate"`
+	BytesReadRate  float64 `json:"bytes_read_rate"`
+	KeysWriteRate  float64 `json:"keys_write_rate"`
+	KeysReadRate   float64 `json:"keys_read_rate"`
+}
+
+// Observe adds a store's statistics into StoreLabelStats.
+func (s *StoreLabelStats) Observe(store *core.StoreInfo, stats *StoresStats) {
+	s.StoreCount++
+	s.Capacity += store.GetCapacity()
+	s.Available += store.GetAvailable()
+	s.UsedSize += store.GetUsedSize()
+	s.RegionCount += store.GetRegionCount()
+	s.LeaderCount += store.GetLeaderCount()
+
+	if stats == nil {
+		return
+	}
+	rolling := stats.GetRollingStoreStats(store.GetID())
+	if rolling == nil {
+		return
+	}
+	writeRate, readRate := rolling.GetBytesRate()
+	s.BytesWriteRate += writeRate
+	s.BytesReadRate += readRate
+	s.KeysWriteRate += rolling.GetKeysWriteRate()
+	s.KeysReadRate += rolling.GetKeysReadRate()
+}
+
+// GetStoreLabelStats groups the stores by the value of the label key and sums
+// up their statistics. The stores without the label are grouped as "unknown",
+// and the tombstone stores are ignored.
+func GetStoreLabelStats(stores []*core.StoreInfo, stats *StoresStats, key string) []*StoreLabelStats {
+	groups := make(map[string]*StoreLabelStats)
+	for _, store := range stores {
+		if store.IsTombstone() {
+			continue
+		}
+		value := store.GetLabelValue(key)
+		if value == "" {
+			value = unknown
+		}
+		group, ok := groups[value]
+		if !ok {
+			group = &StoreLabelStats{Value: value}
+			groups[value] = group
+		}
+		group.Observe(store, stats)
+	}
+
+	res := make([]*StoreLabelStats, 0, len(groups))
+	for _, group := range groups {
+		res = append(res, group)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Value < res[j].Value })
+	return res
+}
+
+// storeLabelStatistics collects the label statistics of all label keys
+// reported by the stores for the metrics.
+type storeLabelStatistics struct {
+	stats map[string]map[string]*StoreLabelStats // label key -> label value -> stats
+}
+
+func newStoreLabelStatistics() *storeLabelStatistics {
+	return &storeLabelStatistics{
+		stats: make(map[string]map[string]*StoreLabelStats),
+	}
+}
+
+func (l *storeLabelStatistics) Observe(store *core.StoreInfo, stats *StoresStats) {
+	if store.IsTombstone() {
+		return
+	}
+	for _, label := range store.GetLabels() {
+		values, ok := l.stats[label.GetKey()]
+		if !ok {
+			values = make(map[string]*StoreLabelStats)
+			l.stats[label.GetKey()] = values
+		}
+		group, ok := values[label.GetValue()]
+		if !ok {
+			group = &StoreLabelStats{Value: label.GetValue()}
+			values[label.GetValue()] = group
+		}
+		group.Observe(store, stats)
+	}
+}
+
+func (l *storeLabelStatistics) Collect() {
+	for key, values := range l.stats {
+		for value, s := range values {
+			metrics := make(map[string]float64)
+			metrics["store_count"] = float64(s.StoreCount)
+			metrics["capacity"] = float64(s.Capacity)
+			metrics["available"] = float64(s.Available)
+			metrics["used_size"] = float64(s.UsedSize)
+			metrics["region_count"] = float64(s.RegionCount)
+			metrics["leader_count"] = float64(s.LeaderCount)
+			metrics["bytes_write_rate"] = s.BytesWriteRate
+			metrics["bytes_read_rate"] = s.BytesReadRate
+			metrics["keys_write_rate"] = s.KeysWriteRate
+			metrics["keys_read_rate"] = s.KeysReadRate
+			for typ, v := range metrics {
+				storeLabelStatusGauge.WithLabelValues(key, value, typ).Set(v)
+			}
+		}
+	}
+}
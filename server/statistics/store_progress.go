@@ -0,0 +1,234 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+)
+
+// Actions of the store progress.
+const (
+	// RemovingAction means the regions are moved out of an offline store.
+	RemovingAction = "removing"
+	// PreparingAction means the regions are moved into a new store.
+	PreparingAction = "preparing"
+)
+
+const (
+	// progressWindowSize is the number of samples used to calculate the speed.
+	progressWindowSize = 10
+	// preparingStartRatio is the ratio of the region count of a store to the
+	// average region count, below which the store is considered as new.
+	preparingStartRatio = 0.5
+	// preparingFinishRatio is the ratio of the region count of a store to the
+	// average region count, above which the store is considered as prepared.
+	preparingFinishRatio = 0.9
+)
+
+// StoreProgress shows the progress of the regions moving out of an offline
+// store or into a new store.
+type StoreProgress struct {
+	StoreID            uint64    `json:"store_id"`
+	Address            string    `json:"address"`
+	Action             string    `json:"action"`
+	StartTime          time.Time `json:"start_time"`
+	StartRegionCount   int       `json:"start_region_count"`
+	CurrentRegionCount int       `json:"current_region_count"`
+	TargetRegionCount  int       `json:"target_region_count"`
+	MovedRegionCount   int       `json:"moved_region_count"`
+	// Progress is in the range of [0, 1].
+	Progress float64 `json:"progress"`
+	// CurrentSpeed is the number of regions moved per second in the window.
+	CurrentSpeed float64 `json:"current_speed"`
+	// LeftSeconds is the estimated time left, it is -1 if the speed is 0.
+	LeftSeconds float64 `json:"left_seconds"`
+}
+
+type progressSample struct {
+	time        time.Time
+	regionCount int
+}
+
+type storeProgress struct {
+	StoreProgress
+	samples []progressSample
+}
+
+func (p *storeProgress) update(store *core.StoreInfo, target int, now time.Time) {
+	regionCount := store.GetRegionCount()
+	p.Address = store.GetAddress()
+	p.CurrentRegionCount = regionCount
+	p.TargetRegionCount = target
+	p.samples = append(p.samples, progressSample{time: now, regionCount: regionCount})
+	if len(p.samples) > progressWindowSize {
+		p.samples = p.samples[len(p.samples)-progressWindowSize:]
+	}
+
+	total, moved, left := p.StartRegionCount-target, p.StartRegionCount-regionCount, regionCount-target
+	if p.Action == PreparingAction {
+		total, moved, left = -total, -moved, -left
+	}
+	if moved < 0 {
+		moved = 0
+	}
+	if left < 0 {
+		left = 0
+	}
+	p.MovedRegionCount = moved
+	p.Progress = 1
+	if total > 0 && left > 0 {
+		p.Progress = float64(total-left) / float64(total)
+		if p.Progress < 0 {
+			p.Progress = 0
+		}
+	}
+
+	p.CurrentSpeed = 0
+	oldest, latest := p.samples[0], p.samples[len(p.samples)-1]
+	if d := latest.time.Sub(oldest.time).Seconds(); d > 0 {
+		diff := oldest.regionCount - latest.regionCount
+		if p.Action == PreparingAction {
+			diff = -diff
+		}
+		if diff > 0 {
+			p.CurrentSpeed = float64(diff) / d
+		}
+	}
+	p.LeftSeconds = -1
+	if left == 0 {
+		p.LeftSeconds = 0
+	} else if p.CurrentSpeed > 0 {
+		p.LeftSeconds = float64(left) / p.CurrentSpeed
+	}
+}
+
+// StoreProgressManager tracks the progress of the offline stores and the new
+// stores.
+type StoreProgressManager struct {
+	sync.RWMutex
+	progresses map[uint64]*storeProgress
+}
+
+// NewStoreProgressManager creates a StoreProgressManager.
+func NewStoreProgressManager() *StoreProgressManager {
+	return &StoreProgressManager{
+		progresses: make(map[uint64]*storeProgress),
+	}
+}
+
+// Observe updates the progresses with the current status of the stores.
+func (m *StoreProgressManager) Observe(stores []*core.StoreInfo, now time.Time) {
+	m.Lock()
+	defer m.Unlock()
+
+	var upCount, upRegionCount int
+	for _, store := range stores {
+		if store.IsUp() {
+			upCount++
+			upRegionCount += store.GetRegionCount()
+		}
+	}
+	var avg int
+	if upCount > 0 {
+		avg = upRegionCount / upCount
+	}
+
+	seen := make(map[uint64]struct{}, len(stores))
+	for _, store := range stores {
+		id := store.GetID()
+		seen[id] = struct{}{}
+		p, ok := m.progresses[id]
+
+		var action string
+		var target int
+		switch {
+		case store.IsOffline():
+			action = RemovingAction
+		case store.IsUp() && ok && p.Action == PreparingAction:
+			if float64(store.GetRegionCount()) < float64(avg)*preparingFinishRatio {
+				action, target = PreparingAction, avg
+			}
+		case store.IsUp():
+			if float64(store.GetRegionCount()) < float64(avg)*preparingStartRatio {
+				action, target = PreparingAction, avg
+			}
+		}
+
+		if action == "" {
+			if ok {
+				m.removeLocked(id)
+			}
+			continue
+		}
+		if !ok || p.Action != action {
+			p = &storeProgress{StoreProgress: StoreProgress{
+				StoreID:          id,
+				Action:           action,
+				StartTime:        now,
+				StartRegionCount: store.GetRegionCount(),
+			}}
+			m.progresses[id] = p
+		}
+		p.update(store, target, now)
+	}
+	for id := range m.progresses {
+		if _, ok := seen[id]; !ok {
+			m.removeLocked(id)
+		}
+	}
+}
+
+func (m *StoreProgressManager) removeLocked(storeID uint64) {
+	p := m.progresses[storeID]
+	store := strconv.FormatUint(storeID, 10)
+	for _, typ := range []string{"progress", "speed", "left_seconds"} {
+		storeProgressGauge.DeleteLabelValues(store, p.Address, p.Action, typ)
+	}
+	delete(m.progresses, storeID)
+}
+
+// GetProgresses returns the progresses sorted by the store ID.
+func (m *StoreProgressManager) GetProgresses() []*StoreProgress {
+	m.RLock()
+	defer m.RUnlock()
+	res := make([]*StoreProgress, 0, len(m.progresses))
+	for _, p := range m.progresses {
+		clone := p.StoreProgress
+		res = append(res, &clone)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].StoreID < res[j].StoreID })
+	return res
+}
+
+// Collect sets the metrics of the progresses.
+func (m *StoreProgressManager) Collect() {
+	m.RLock()
+	defer m.RUnlock()
+	for id, p := range m.progresses {
+		store := strconv.FormatUint(id, 10)
+		storeProgressGauge.WithLabelValues(store, p.Address, p.Action, "progress").Set(p.Progress)
+		storeProgressGauge.WithLabelValues(store, p.Address, p.Action, "speed").Set(p.CurrentSpeed)
+		storeProgressGauge.WithLabelValues(store, p.Address, p.Action, "left_seconds").Set(p.LeftSeconds)
+	}
+}
+
+// Reset resets the metrics of the progresses.
+func (m *StoreProgressManager) Reset() {
+	storeProgressGauge.Reset()
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/testutil"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/config"
+	"github.com/pingcap/pd/tests"
+	"github.com/pingcap/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&applyTestSuite{})
+
+type applyTestSuite struct{}
+
+func (s *applyTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+const clusterYAML = `
+schedule:
+  leader-schedule-limit: 8
+  max-store-down-time: 30m
+replication:
+  location-labels: [zone, host]
+schedulers:
+  - name: balance-leader-scheduler
+  - name: balance-region-scheduler
+  - name: evict-slow-store-scheduler
+  - name: evict-leader-scheduler
+    args:
+      store_id: 2
+stores:
+  - id: 1
+    labels:
+      zone: z1
+      host: h1
+    leader-weight: 2
+  - id: 2
+    labels:
+      zone: z2
+      host: h2
+    limit: 30
+label-property:
+  reject-leader:
+    - key: zone
+      value: z2
+`
+
+func execute(c *C, args ...string) string {
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	return strings.TrimSpace(string(output))
+}
+
+func (s *applyTestSuite) TestApply(c *C) {
+	cluster, err := tests.NewTestCluster(1, func(cfg *config.Config) {
+		cfg.Schedule.MaxStoreDownTime.Duration = 30 * time.Minute
+	})
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURLs()
+	defer cluster.Destroy()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	svr := leaderServer.GetServer()
+	pdctl.MustPutStore(c, svr, 1, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: "z1"}})
+	pdctl.MustPutStore(c, svr, 2, metapb.StoreState_Up, nil)
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte("a"), []byte("b"))
+	testutil.WaitUntil(c, func(c *C) bool {
+		return strings.Contains(execute(c, "-u", pdAddr, "scheduler", "show"), "balance-leader-scheduler")
+	})
+
+	dir, err := ioutil.TempDir("", "pdctl_apply")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "cluster.yaml")
+	c.Assert(ioutil.WriteFile(file, []byte(clusterYAML), 0644), IsNil)
+
+	// The changes are in order, max-store-down-time is not changed.
+	c.Assert(strings.Split(execute(c, "-u", pdAddr, "diff", "-f", file), "\n"), DeepEquals, []string{
+		"+ store 1 label host=h1",
+		"+ store 2 label host=h2",
+		"+ store 2 label zone=z2",
+		"+ label-property reject-leader zone=z2",
+		"~ replication.location-labels:  -> zone,host",
+		"~ schedule.leader-schedule-limit: 4 -> 8",
+		"~ store 1 weight: leader 1, region 1 -> leader 2, region 1",
+		"~ store 2 limit: 15 -> 30",
+		"+ scheduler evict-leader-scheduler-2",
+		"- scheduler balance-hot-region-scheduler",
+		"- scheduler label-scheduler",
+	})
+
+	output := execute(c, "-u", pdAddr, "apply", "-f", file)
+	c.Assert(strings.HasSuffix(output, "Applied 10 changes."), IsTrue, Commentf(output))
+	c.Assert(execute(c, "-u", pdAddr, "diff", "-f", file), Equals, "No changes.")
+
+	c.Assert(svr.GetScheduleConfig().LeaderScheduleLimit, Equals, uint64(8))
+	c.Assert([]string(svr.GetReplicationConfig().LocationLabels), DeepEquals, []string{"zone", "host"})
+	c.Assert(svr.GetLabelProperty()["reject-leader"], DeepEquals, []config.StoreLabel{{Key: "zone", Value: "z2"}})
+	store := svr.GetRaftCluster().GetStore(2)
+	c.Assert(store.GetLabelValue("zone"), Equals, "z2")
+	c.Assert(svr.GetRaftCluster().GetStore(1).GetLeaderWeight(), Equals, float64(2))
+	names, err := svr.GetHandler().GetSchedulers()
+	c.Assert(err, IsNil)
+	sort.Strings(names)
+	c.Assert(names, DeepEquals, []string{"balance-leader-scheduler", "balance-region-scheduler", "evict-leader-scheduler-2", "evict-slow-store-scheduler"})
+
+	// The config of the existing scheduler is updated, and the big store ID is
+	// not formatted as 1e+06.
+	schedulersYAML := `
+schedulers:
+  - name: balance-leader-scheduler
+  - name: evict-slow-store-scheduler
+  - name: scatter-range
+    args:
+      start_key: a
+      end_key: %s
+      range_name: r
+  - name: evict-leader-scheduler
+    args:
+      store_id: 2
+`
+	c.Assert(ioutil.WriteFile(file, []byte(fmt.Sprintf(schedulersYAML, "b")), 0644), IsNil)
+	output = execute(c, "-u", pdAddr, "apply", "-f", file)
+	c.Assert(strings.HasSuffix(output, "Applied 2 changes."), IsTrue, Commentf(output))
+	c.Assert(ioutil.WriteFile(file, []byte(fmt.Sprintf(schedulersYAML, "c%2F")), 0644), IsNil)
+	c.Assert(strings.Split(execute(c, "-u", pdAddr, "diff", "-f", file), "\n"), DeepEquals, []string{
+		"~ scheduler scatter-range-r end_key: b -> c/",
+	})
+	output = execute(c, "-u", pdAddr, "apply", "-f", file)
+	c.Assert(strings.HasSuffix(output, "Applied 1 changes."), IsTrue, Commentf(output))
+	c.Assert(execute(c, "-u", pdAddr, "diff", "-f", file), Equals, "No changes.")
+	c.Assert(ioutil.WriteFile(file, []byte(strings.Replace(fmt.Sprintf(schedulersYAML, "c%2F"), "store_id: 2", "store_id: 1000000", 1)), 0644), IsNil)
+	c.Assert(strings.Split(execute(c, "-u", pdAddr, "diff", "-f", file), "\n"), DeepEquals, []string{
+		"+ scheduler evict-leader-scheduler-1000000",
+		"- scheduler evict-leader-scheduler-2",
+	})
+
+	// The errors.
+	c.Assert(ioutil.WriteFile(file, []byte("schedule:\n  unknown-option: 1\n"), 0644), IsNil)
+	c.Assert(execute(c, "-u", pdAddr, "diff", "-f", file), Equals, "unknown schedule option unknown-option")
+	c.Assert(ioutil.WriteFile(file, []byte("schedulers: []\nunknown: 1\n"), 0644), IsNil)
+	c.Assert(strings.Contains(execute(c, "-u", pdAddr, "apply", "-f", file), "unknown field"), IsTrue)
+	c.Assert(execute(c, "-u", pdAddr, "apply"), Equals, "the file should be specified by -f")
+}
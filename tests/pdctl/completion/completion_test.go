@@ -0,0 +1,99 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package completion_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chzyer/readline"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/testutil"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/tests"
+	"github.com/pingcap/pd/tests/pdctl"
+	ctl "github.com/pingcap/pd/tools/pd-ctl/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&completionTestSuite{})
+
+type completionTestSuite struct{}
+
+func (s *completionTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+// complete returns the candidates to complete the last word of the line.
+func complete(completer readline.AutoCompleter, line string) []string {
+	candidates, offset := completer.Do([]rune(line), len(line))
+	prefix := line[len(line)-offset:]
+	words := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		words = append(words, strings.TrimSpace(prefix+string(candidate)))
+	}
+	return words
+}
+
+func (s *completionTestSuite) TestCompletion(c *C) {
+	cluster, err := tests.NewTestCluster(1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURLs()
+	defer cluster.Destroy()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	for _, id := range []uint64{11, 12} {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), id, metapb.StoreState_Up,
+			[]*metapb.StoreLabel{{Key: "zone", Value: "z1"}, {Key: "host", Value: "h1"}})
+	}
+	pdctl.MustPutRegion(c, cluster, 7, 11, []byte("a"), []byte("b"), core.SetPeers([]*metapb.Peer{
+		{Id: 1, StoreId: 11},
+	}))
+	cmd := pdctl.InitCommand()
+	_, _, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "operator", "add", "add-peer", "7", "12")
+	c.Assert(err, IsNil)
+
+	// The schedulers are added after the coordinator is running.
+	testutil.WaitUntil(c, func(c *C) bool {
+		_, output, err := pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "scheduler", "show")
+		return err == nil && strings.Contains(string(output), "balance-leader-scheduler")
+	})
+
+	completer, err := ctl.NewCompleter(ctl.CommandFlags{URL: pdAddr})
+	c.Assert(err, IsNil)
+
+	// The commands and the flags.
+	c.Assert(complete(completer, "sto"), DeepEquals, []string{"store", "stores"})
+	c.Assert(complete(completer, "store --gr"), DeepEquals, []string{"--group-by"})
+	// The arguments got from PD, store 1 is put by the bootstrap.
+	c.Assert(complete(completer, "store delete "), DeepEquals, []string{"addr", "1", "11", "12"})
+	c.Assert(complete(completer, "store label 1"), DeepEquals, []string{"1", "11", "12"})
+	c.Assert(complete(completer, "store label 11 "), DeepEquals, []string{"host", "zone"})
+	c.Assert(complete(completer, "label store "), DeepEquals, []string{"host", "zone"})
+	c.Assert(complete(completer, "scheduler remove balance-l"), DeepEquals, []string{"balance-leader-scheduler"})
+	c.Assert(complete(completer, "operator remove "), DeepEquals, []string{"7"})
+	c.Assert(complete(completer, "config set max-rep"), DeepEquals, []string{"max-replicas"})
+	c.Assert(complete(completer, "config set schedulers"), HasLen, 0)
+	// The commands to watch.
+	c.Assert(complete(completer, "watch store de"), DeepEquals, []string{"delete"})
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package componentconfig_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/componentconfig"
+	"github.com/pingcap/pd/tests"
+	"github.com/pingcap/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&componentConfigTestSuite{})
+
+type componentConfigTestSuite struct{}
+
+func (s *componentConfigTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+func (s *componentConfigTestSuite) TestComponentConfig(c *C) {
+	cluster, err := tests.NewTestCluster(1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURLs()
+	defer cluster.Destroy()
+
+	// The flags are kept in the command, so a new one is used each time.
+	execute := func(args ...string) string {
+		_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), append([]string{"-u", pdAddr, "component-config"}, args...)...)
+		c.Assert(err, IsNil)
+		return strings.TrimSpace(string(output))
+	}
+	c.Assert(execute("set", "log-level", "info"), Equals, "Success!")
+	c.Assert(execute("set", "raftstore.sync-log", "true", "--component=tikv"), Equals, "Success!")
+	c.Assert(execute("set", "raftstore.capacity", "18446744073709551615", "--component=tikv"), Equals, "Success!")
+	c.Assert(execute("set", "log-level", "debug", "--component=tikv", "--instance=127.0.0.1:20160"), Equals, "Success!")
+
+	e := &componentconfig.Entry{}
+	c.Assert(json.Unmarshal([]byte(execute("show", "--component=tikv", "--instance=127.0.0.1:20160", "--merged")), e), IsNil)
+	c.Assert(e.Version, Equals, uint64(4))
+	c.Assert(e.Config, DeepEquals, map[string]interface{}{
+		"log-level": "debug",
+		"raftstore": map[string]interface{}{"sync-log": true, "capacity": float64(18446744073709551615)},
+	})
+	c.Assert(execute("show", "--component=tikv"), Matches, `(?s).*"capacity": 18446744073709551615.*`)
+
+	c.Assert(execute("delete", "raftstore.sync-log", "--component=tikv"), Equals, "Success!")
+	c.Assert(execute("clear", "--component=tikv", "--instance=127.0.0.1:20160"), Equals, "Success!")
+	e = &componentconfig.Entry{}
+	c.Assert(json.Unmarshal([]byte(execute("show", "--component=tikv", "--instance=127.0.0.1:20160", "--merged")), e), IsNil)
+	c.Assert(e.Version, Equals, uint64(6))
+	c.Assert(e.Config, DeepEquals, map[string]interface{}{
+		"log-level": "info",
+		"raftstore": map[string]interface{}{"capacity": float64(18446744073709551615)},
+	})
+	all := &componentconfig.Configs{}
+	c.Assert(json.Unmarshal([]byte(execute("show")), all), IsNil)
+	c.Assert(all.Global.Config, DeepEquals, map[string]interface{}{"log-level": "info"})
+	c.Assert(all.Components, HasKey, "tikv")
+	c.Assert(all.Instances, HasLen, 0)
+
+	c.Assert(execute("set", "a..b", "1"), Matches, `Failed to set the component config: .*invalid key.*`)
+	c.Assert(execute("show", "--instance=127.0.0.1:20160"), Equals, "component should be set with instance or merged")
+	c.Assert(execute("clear", "--instance=127.0.0.1:20160"), Equals, "component should be set with instance")
+}
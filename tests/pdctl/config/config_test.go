@@ -15,6 +15,7 @@ package config_test
 
 import (
 	"encoding/json"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server"
 	"github.com/pingcap/pd/server/config"
+	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/tests"
 	"github.com/pingcap/pd/tests/pdctl"
 )
@@ -199,3 +201,61 @@ func (s *configTestSuite) TestConfig(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(strings.Contains(string(output), "config item not found"), IsTrue)
 }
+
+func (s *configTestSuite) TestConfigHistory(c *C) {
+	cluster, err := tests.NewTestCluster(1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURLs()
+	defer cluster.Destroy()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	svr := leaderServer.GetServer()
+	execute := func(args ...string) string {
+		_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), append([]string{"-u", pdAddr}, args...)...)
+		c.Assert(err, IsNil)
+		return strings.TrimSpace(string(output))
+	}
+	getHistory := func() []*core.ConfigVersion {
+		var history []*core.ConfigVersion
+		c.Assert(json.Unmarshal([]byte(execute("config", "history")), &history), IsNil)
+		return history
+	}
+
+	c.Assert(execute("config", "set", "leader-schedule-limit", "10"), Equals, "Success!")
+	base := getHistory()[0].Version
+	c.Assert(execute("config", "set", "leader-schedule-limit", "12"), Equals, "Success!")
+	history := getHistory()
+	c.Assert(history[0].Version, Equals, base+1)
+	c.Assert(history[0].Source, Equals, core.ConfigSourceAPI)
+	c.Assert(json.Unmarshal([]byte(execute("config", "history", "--limit", "1", "--end", strconv.FormatUint(base, 10))), &history), IsNil)
+	c.Assert(history, HasLen, 1)
+	c.Assert(history[0].Version, Equals, base)
+	output := execute("config", "history", "-o", "table")
+	c.Assert(strings.Fields(strings.Split(output, "\n")[0]), DeepEquals, []string{"VERSION", "TIME", "SOURCE"})
+
+	var v core.ConfigVersion
+	c.Assert(json.Unmarshal([]byte(execute("config", "history", "show", strconv.FormatUint(base, 10))), &v), IsNil)
+	c.Assert(v.Version, Equals, base)
+	c.Assert(v.Config, NotNil)
+
+	var changes []*core.ConfigChange
+	c.Assert(json.Unmarshal([]byte(execute("config", "history", "diff", strconv.FormatUint(base+1, 10))), &changes), IsNil)
+	c.Assert(changes, HasLen, 1)
+	c.Assert(changes[0].Key, Equals, "schedule.leader-schedule-limit")
+	c.Assert(changes[0].Old, Equals, float64(10))
+	c.Assert(changes[0].New, Equals, float64(12))
+	output = execute("config", "history", "diff", strconv.FormatUint(base+1, 10), "--base", strconv.FormatUint(base, 10), "-o", "table")
+	c.Assert(strings.Fields(strings.Split(output, "\n")[1]), DeepEquals, []string{"schedule.leader-schedule-limit", "10", "12"})
+
+	c.Assert(execute("config", "rollback", strconv.FormatUint(base, 10)), Equals, "Success!")
+	c.Assert(svr.GetScheduleConfig().LeaderScheduleLimit, Equals, uint64(10))
+	c.Assert(getHistory()[0].Source, Equals, core.ConfigSourceRollback)
+
+	c.Assert(execute("config", "rollback", "abc"), Equals, "version should be a number")
+	c.Assert(strings.Contains(execute("config", "rollback", "100000"), "config version 100000 not found"), IsTrue)
+	c.Assert(strings.Contains(execute("config", "history", "show", "100000"), "not found"), IsTrue)
+}
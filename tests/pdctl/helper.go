@@ -43,6 +43,7 @@ func InitCommand() *cobra.Command {
 	rootCmd.Flags().StringVar(&commandFlags.CAPath, "cacert", "", "")
 	rootCmd.Flags().StringVar(&commandFlags.CertPath, "cert", "", "")
 	rootCmd.Flags().StringVar(&commandFlags.KeyPath, "key", "", "")
+	command.AddOutputFlags(rootCmd)
 	rootCmd.AddCommand(
 		command.NewConfigCommand(),
 		command.NewRegionCommand(),
@@ -60,6 +61,9 @@ func InitCommand() *cobra.Command {
 		command.NewTableNamespaceCommand(),
 		command.NewHealthCommand(),
 		command.NewLogCommand(),
+		command.NewApplyCommand(),
+		command.NewDiffCommand(),
+		command.NewComponentConfigCommand(),
 	)
 	return rootCmd
 }
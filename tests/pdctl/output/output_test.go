@@ -0,0 +1,123 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/api"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/tests"
+	"github.com/pingcap/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&outputTestSuite{})
+
+type outputTestSuite struct{}
+
+func (s *outputTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+// execute runs the command with a new root command, the flags are not kept
+// between the commands.
+func execute(c *C, args ...string) string {
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	return strings.TrimSpace(string(output))
+}
+
+// tableRows returns the cells of the rows in the table, the header excluded.
+func tableRows(output string) [][]string {
+	var rows [][]string
+	for _, line := range strings.Split(output, "\n")[1:] {
+		rows = append(rows, strings.Fields(line))
+	}
+	return rows
+}
+
+func (s *outputTestSuite) TestOutput(c *C) {
+	cluster, err := tests.NewTestCluster(1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURLs()
+	defer cluster.Destroy()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	for id, zone := range map[uint64]string{1: "z1", 2: "z2", 3: "z1"} {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), id, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: zone}})
+	}
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte("a"), []byte("b"), core.SetApproximateSize(10),
+		core.SetPeers([]*metapb.Peer{{Id: 11, StoreId: 1}, {Id: 12, StoreId: 2}}))
+	pdctl.MustPutRegion(c, cluster, 2, 3, []byte("b"), []byte("c"), core.SetApproximateSize(30),
+		core.SetPeers([]*metapb.Peer{{Id: 21, StoreId: 3}}))
+
+	// The raw JSON is printed by default.
+	var stores api.StoresInfo
+	c.Assert(json.Unmarshal([]byte(execute(c, "-u", pdAddr, "store")), &stores), IsNil)
+	c.Assert(stores.Count, Equals, 3)
+
+	// Table.
+	output := execute(c, "-u", pdAddr, "store", "-o", "table", "--sort-by", "id")
+	c.Assert(strings.Fields(strings.Split(output, "\n")[0]), DeepEquals,
+		[]string{"ID", "ADDRESS", "STATE", "VERSION", "LABELS", "CAPACITY", "AVAILABLE", "LEADERS", "REGIONS"})
+	rows := tableRows(output)
+	c.Assert(rows, HasLen, 3)
+	c.Assert(rows[0][0], Equals, "1")
+	c.Assert(rows[0][2], Equals, "Down")
+	c.Assert(rows[1][4], Equals, "zone=z2")
+	output = execute(c, "-u", pdAddr, "store", "-o", "wide")
+	c.Assert(strings.Contains(strings.Split(output, "\n")[0], "LEADER_WEIGHT"), IsTrue)
+
+	// Filter and sort.
+	rows = tableRows(execute(c, "-u", pdAddr, "store", "-o", "table", "--filter", "labels=~z1,state==Down", "--sort-by", "-id"))
+	c.Assert(rows, HasLen, 2)
+	c.Assert(rows[0][0], Equals, "3")
+	c.Assert(rows[1][0], Equals, "1")
+	stores = api.StoresInfo{}
+	c.Assert(json.Unmarshal([]byte(execute(c, "-u", pdAddr, "store", "--filter", "store.id>1")), &stores), IsNil)
+	c.Assert(stores.Count, Equals, 2)
+	c.Assert(stores.Stores, HasLen, 2)
+	rows = tableRows(execute(c, "-u", pdAddr, "region", "-o", "table", "--sort-by", "-size"))
+	c.Assert(rows, HasLen, 2)
+	c.Assert(rows[0][0], Equals, "2")
+	c.Assert(rows[1][4], Equals, "1,2")
+	c.Assert(strings.Contains(execute(c, "-u", pdAddr, "store", "--filter", "id"), "bad filter expression"), IsTrue)
+
+	// YAML.
+	data, err := yaml.YAMLToJSON([]byte(execute(c, "-u", pdAddr, "region", "1", "-o", "yaml")))
+	c.Assert(err, IsNil)
+	var region api.RegionInfo
+	c.Assert(json.Unmarshal(data, &region), IsNil)
+	c.Assert(region.ID, Equals, uint64(1))
+	c.Assert(region.Peers, HasLen, 2)
+
+	// The commands without tables print JSON.
+	output = execute(c, "-u", pdAddr, "config", "show", "replication", "-o", "table")
+	c.Assert(strings.HasPrefix(output, "{"), IsTrue)
+	c.Assert(strings.Contains(execute(c, "-u", pdAddr, "store", "-o", "xml"), "Unknown output format"), IsTrue)
+}
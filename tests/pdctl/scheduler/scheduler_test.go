@@ -87,6 +87,7 @@ func (s *schedulerTestSuite) TestScheduler(c *C) {
 		"balance-leader-scheduler":     true,
 		"balance-hot-region-scheduler": true,
 		"label-scheduler":              true,
+		"evict-slow-store-scheduler":   true,
 	}
 	for _, scheduler := range schedulers {
 		c.Assert(expected[scheduler], Equals, true)
@@ -106,6 +107,7 @@ func (s *schedulerTestSuite) TestScheduler(c *C) {
 		"balance-leader-scheduler":     true,
 		"balance-hot-region-scheduler": true,
 		"label-scheduler":              true,
+		"evict-slow-store-scheduler":   true,
 		"grant-leader-scheduler-1":     true,
 	}
 	for _, scheduler := range schedulers {
@@ -125,6 +127,7 @@ func (s *schedulerTestSuite) TestScheduler(c *C) {
 		"balance-leader-scheduler":     true,
 		"balance-hot-region-scheduler": true,
 		"label-scheduler":              true,
+		"evict-slow-store-scheduler":   true,
 		"grant-leader-scheduler-1":     true,
 	}
 	for _, scheduler := range schedulers {
@@ -19,8 +19,12 @@ import (
 	"io/ioutil"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/chzyer/readline"
 	shellwords "github.com/mattn/go-shellwords"
@@ -38,6 +42,15 @@ var (
 	caPath   string
 	certPath string
 	keyPath  string
+
+	historyFile string
+)
+
+var (
+	// watching is set when a command is being watched, SIGINT stops the
+	// watch instead of exiting then.
+	watching  int32
+	stopWatch = make(chan struct{}, 1)
 )
 
 func init() {
@@ -48,6 +61,7 @@ func init() {
 	flag.StringVar(&caPath, "cacert", "", "The path of file that contains list of trusted SSL CAs.")
 	flag.StringVar(&certPath, "cert", "", "The path of file that contains X509 certificate in PEM format.")
 	flag.StringVar(&keyPath, "key", "", "The path of file that contains X509 key in PEM format.")
+	flag.StringVar(&historyFile, "history-file", defaultHistoryFile(), "The file to keep the command history of the interactive mode.")
 	flag.BoolVarP(&help, "help", "h", false, "Help message.")
 }
 
@@ -76,13 +90,21 @@ func main() {
 		syscall.SIGQUIT)
 
 	go func() {
-		sig := <-sc
-		fmt.Printf("\nGot signal [%v] to exit.\n", sig)
-		switch sig {
-		case syscall.SIGTERM:
-			os.Exit(0)
-		default:
-			os.Exit(1)
+		for sig := range sc {
+			if sig == syscall.SIGINT && atomic.LoadInt32(&watching) == 1 {
+				select {
+				case stopWatch <- struct{}{}:
+				default:
+				}
+				continue
+			}
+			fmt.Printf("\nGot signal [%v] to exit.\n", sig)
+			switch sig {
+			case syscall.SIGTERM:
+				os.Exit(0)
+			default:
+				os.Exit(1)
+			}
 		}
 	}()
 	var input []string
@@ -103,10 +125,29 @@ func main() {
 	pdctl.Start(append(os.Args[1:], input...))
 }
 
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "pd-ctl-history")
+	}
+	return filepath.Join(home, ".pd-ctl-history")
+}
+
 func loop() {
+	completer, err := pdctl.NewCompleter(pdctl.CommandFlags{
+		URL:      url,
+		CAPath:   caPath,
+		CertPath: certPath,
+		KeyPath:  keyPath,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	l, err := readline.NewEx(&readline.Config{
 		Prompt:            "\033[31m»\033[0m ",
-		HistoryFile:       "/tmp/readline.tmp",
+		HistoryFile:       historyFile,
+		AutoComplete:      completer,
 		InterruptPrompt:   "^C",
 		EOFPrompt:         "^D",
 		HistorySearchFold: true,
@@ -134,10 +175,62 @@ func loop() {
 			fmt.Printf("parse command err: %v\n", err)
 			continue
 		}
-		args = append(args, "-u", url)
-		if caPath != "" && certPath != "" && keyPath != "" {
-			args = append(args, "--cacert", caPath, "--cert", certPath, "--key", keyPath)
+		if len(args) > 0 && args[0] == "watch" {
+			watch(args[1:])
+			continue
 		}
-		pdctl.Start(args)
+		pdctl.Start(withGlobalFlags(args))
+	}
+}
+
+func withGlobalFlags(args []string) []string {
+	args = append(args, "-u", url)
+	if caPath != "" && certPath != "" && keyPath != "" {
+		args = append(args, "--cacert", caPath, "--cert", certPath, "--key", keyPath)
+	}
+	return args
+}
+
+// watch runs the command every interval until Ctrl-C is pressed, the last
+// argument is the interval, such as "5s" or "5" in seconds.
+func watch(args []string) {
+	if len(args) < 2 {
+		fmt.Println("Usage: watch <command> <interval>")
+		return
+	}
+	interval, err := parseInterval(args[len(args)-1])
+	if err != nil {
+		fmt.Printf("parse interval err: %v\n", err)
+		return
+	}
+	args = args[:len(args)-1]
+
+	atomic.StoreInt32(&watching, 1)
+	defer atomic.StoreInt32(&watching, 0)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		fmt.Printf("Every %v: %s\t%s\n", interval, strings.Join(args, " "), time.Now().Format(time.RFC3339))
+		pdctl.Start(withGlobalFlags(append([]string(nil), args...)))
+		select {
+		case <-ticker.C:
+		case <-stopWatch:
+			return
+		}
+	}
+}
+
+func parseInterval(s string) (time.Duration, error) {
+	interval, err := time.ParseDuration(s)
+	if err != nil {
+		seconds, err1 := strconv.ParseFloat(s, 64)
+		if err1 != nil {
+			return 0, err
+		}
+		interval = time.Duration(seconds * float64(time.Second))
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("interval %v should be positive", s)
 	}
+	return interval, nil
 }
@@ -0,0 +1,601 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// clusterSpec is the desired state of the cluster described by a file. The
+// sections which are not set are not managed.
+type clusterSpec struct {
+	Schedule    map[string]interface{} `json:"schedule,omitempty"`
+	Replication map[string]interface{} `json:"replication,omitempty"`
+	// Schedulers are all the schedulers of the cluster if it is set, the
+	// others are removed.
+	Schedulers *[]schedulerSpec `json:"schedulers,omitempty"`
+	Stores     []storeSpec      `json:"stores,omitempty"`
+	// LabelProperty is all the label properties if it is set, the others are
+	// deleted.
+	LabelProperty map[string][]labelSpec `json:"label-property,omitempty"`
+}
+
+// schedulerSpec is a scheduler, the args are the arguments to add it, such as
+// store_id of evict-leader-scheduler.
+type schedulerSpec struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// fullName returns the name of the scheduler shown by PD.
+func (s *schedulerSpec) fullName() string {
+	switch s.Name {
+	case "evict-leader-scheduler", "grant-leader-scheduler":
+		// The numbers in the file are float64, which are formatted as 1e+06.
+		if id, ok := s.Args["store_id"].(float64); ok {
+			return fmt.Sprintf("%s-%d", s.Name, uint64(id))
+		}
+		return fmt.Sprintf("%s-%v", s.Name, s.Args["store_id"])
+	case "scatter-range":
+		return fmt.Sprintf("%s-%v", s.Name, s.Args["range_name"])
+	}
+	return s.Name
+}
+
+type storeSpec struct {
+	ID           uint64            `json:"id"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	LeaderWeight *float64          `json:"leader-weight,omitempty"`
+	RegionWeight *float64          `json:"region-weight,omitempty"`
+	Limit        *float64          `json:"limit,omitempty"`
+}
+
+type labelSpec struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// change is a difference between the file and the cluster.
+type change struct {
+	desc  string
+	apply func(cmd *cobra.Command) error
+}
+
+// NewApplyCommand returns a command to apply the configuration file.
+func NewApplyCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "apply -f <file>",
+		Short: "apply the configuration of the cluster in a YAML or JSON file",
+		Run:   applyCommandFunc,
+	}
+	c.Flags().StringP("file", "f", "", "the configuration file")
+	return c
+}
+
+// NewDiffCommand returns a command to show the differences between the
+// configuration file and the cluster.
+func NewDiffCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "diff -f <file>",
+		Short: "show the changes to apply the configuration of the cluster in a YAML or JSON file",
+		Run:   diffCommandFunc,
+	}
+	c.Flags().StringP("file", "f", "", "the configuration file")
+	return c
+}
+
+func diffCommandFunc(cmd *cobra.Command, args []string) {
+	changes, err := loadChanges(cmd)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	if len(changes) == 0 {
+		cmd.Println("No changes.")
+		return
+	}
+	for _, c := range changes {
+		cmd.Println(c.desc)
+	}
+}
+
+func applyCommandFunc(cmd *cobra.Command, args []string) {
+	changes, err := loadChanges(cmd)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	if len(changes) == 0 {
+		cmd.Println("No changes.")
+		return
+	}
+	for _, c := range changes {
+		if err := c.apply(cmd); err != nil {
+			cmd.Printf("Failed to apply %s: %s\n", c.desc, err)
+			return
+		}
+		cmd.Println(c.desc)
+	}
+	cmd.Printf("Applied %d changes.\n", len(changes))
+}
+
+func loadChanges(cmd *cobra.Command) ([]*change, error) {
+	file, err := cmd.Flags().GetString("file")
+	if err != nil || file == "" {
+		return nil, errors.New("the file should be specified by -f")
+	}
+	spec, err := loadClusterSpec(file)
+	if err != nil {
+		return nil, err
+	}
+	return planChanges(cmd, spec)
+}
+
+func loadClusterSpec(file string) (*clusterSpec, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// The JSON format is a subset of YAML.
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", file)
+	}
+	spec := &clusterSpec{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(spec); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", file)
+	}
+	return spec, nil
+}
+
+// planChanges compares the file with the cluster. The changes are in a safe
+// order: the store labels are set before the label properties and the
+// replication config which refer to them, and the schedulers are added before
+// the old ones are removed.
+func planChanges(cmd *cobra.Command, spec *clusterSpec) ([]*change, error) {
+	var changes []*change
+	for _, plan := range []func(*cobra.Command, *clusterSpec) ([]*change, error){
+		planStoreLabels,
+		planLabelProperty,
+		func(cmd *cobra.Command, spec *clusterSpec) ([]*change, error) {
+			return planConfig(cmd, "replication", replicationPrefix, spec.Replication)
+		},
+		func(cmd *cobra.Command, spec *clusterSpec) ([]*change, error) {
+			return planConfig(cmd, "schedule", schedulePrefix, spec.Schedule)
+		},
+		planStoreWeightsAndLimits,
+		planSchedulers,
+	} {
+		c, err := plan(cmd, spec)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c...)
+	}
+	return changes, nil
+}
+
+func planConfig(cmd *cobra.Command, section, prefix string, desired map[string]interface{}) ([]*change, error) {
+	if len(desired) == 0 {
+		return nil, nil
+	}
+	current := make(map[string]interface{})
+	if err := getJSON(cmd, prefix, &current); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(desired))
+	for k := range desired {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var changes []*change
+	for _, k := range keys {
+		old, ok := current[k]
+		if !ok {
+			return nil, errors.Errorf("unknown %s option %s", section, k)
+		}
+		value := configValue(desired[k])
+		if equalConfigValues(configValue(old), value) {
+			continue
+		}
+		input := map[string]interface{}{k: value}
+		changes = append(changes, &change{
+			desc: fmt.Sprintf("~ %s.%s: %v -> %v", section, k, configValue(old), value),
+			apply: func(cmd *cobra.Command) error {
+				return postJSONBody(cmd, configPrefix, input)
+			},
+		})
+	}
+	return changes, nil
+}
+
+// configValue converts the value to the form accepted by PD, which is the
+// same as `config set`: the numbers are numbers, the lists are joined by comma
+// and the others are strings.
+func configValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			values = append(values, fmt.Sprint(e))
+		}
+		return strings.Join(values, ",")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func equalConfigValues(a, b interface{}) bool {
+	x, y := fmt.Sprint(a), fmt.Sprint(b)
+	if x == y {
+		return true
+	}
+	if d1, err := time.ParseDuration(x); err == nil {
+		if d2, err := time.ParseDuration(y); err == nil {
+			return d1 == d2
+		}
+	}
+	return false
+}
+
+type storeState struct {
+	Store struct {
+		Labels []labelSpec `json:"labels"`
+	} `json:"store"`
+	Status struct {
+		LeaderWeight float64 `json:"leader_weight"`
+		RegionWeight float64 `json:"region_weight"`
+	} `json:"status"`
+}
+
+func getStoreState(cmd *cobra.Command, id uint64) (*storeState, error) {
+	store := &storeState{}
+	if err := getJSON(cmd, fmt.Sprintf(storePrefix, id), store); err != nil {
+		return nil, errors.Wrapf(err, "failed to get store %d", id)
+	}
+	return store, nil
+}
+
+func planStoreLabels(cmd *cobra.Command, spec *clusterSpec) ([]*change, error) {
+	var changes []*change
+	for _, s := range spec.Stores {
+		if len(s.Labels) == 0 {
+			continue
+		}
+		store, err := getStoreState(cmd, s.ID)
+		if err != nil {
+			return nil, err
+		}
+		current := make(map[string]string)
+		for _, l := range store.Store.Labels {
+			current[l.Key] = l.Value
+		}
+		input := make(map[string]interface{})
+		var descs []string
+		for _, k := range sortedKeys(s.Labels) {
+			if old, ok := current[k]; !ok {
+				descs = append(descs, fmt.Sprintf("+ store %d label %s=%s", s.ID, k, s.Labels[k]))
+			} else if old != s.Labels[k] {
+				descs = append(descs, fmt.Sprintf("~ store %d label %s: %s -> %s", s.ID, k, old, s.Labels[k]))
+			} else {
+				continue
+			}
+			input[k] = s.Labels[k]
+		}
+		if len(input) == 0 {
+			continue
+		}
+		prefix := fmt.Sprintf(path.Join(storePrefix, "label"), s.ID)
+		changes = append(changes, &change{
+			desc: strings.Join(descs, "\n"),
+			apply: func(cmd *cobra.Command) error {
+				return postJSONBody(cmd, prefix, input)
+			},
+		})
+	}
+	return changes, nil
+}
+
+func planStoreWeightsAndLimits(cmd *cobra.Command, spec *clusterSpec) ([]*change, error) {
+	var limits map[string]struct {
+		Rate float64 `json:"rate"`
+	}
+	var changes []*change
+	for _, s := range spec.Stores {
+		if s.LeaderWeight != nil || s.RegionWeight != nil {
+			store, err := getStoreState(cmd, s.ID)
+			if err != nil {
+				return nil, err
+			}
+			leader, region := store.Status.LeaderWeight, store.Status.RegionWeight
+			if s.LeaderWeight != nil {
+				leader = *s.LeaderWeight
+			}
+			if s.RegionWeight != nil {
+				region = *s.RegionWeight
+			}
+			if leader != store.Status.LeaderWeight || region != store.Status.RegionWeight {
+				prefix := fmt.Sprintf(path.Join(storePrefix, "weight"), s.ID)
+				input := map[string]interface{}{"leader": leader, "region": region}
+				changes = append(changes, &change{
+					desc: fmt.Sprintf("~ store %d weight: leader %v, region %v -> leader %v, region %v",
+						s.ID, store.Status.LeaderWeight, store.Status.RegionWeight, leader, region),
+					apply: func(cmd *cobra.Command) error {
+						return postJSONBody(cmd, prefix, input)
+					},
+				})
+			}
+		}
+		if s.Limit != nil {
+			if limits == nil {
+				if err := getJSON(cmd, path.Join(storesPrefix, "limit"), &limits); err != nil {
+					return nil, err
+				}
+			}
+			old, ok := limits[strconv.FormatUint(s.ID, 10)]
+			if !ok {
+				// The limit is created with store-balance-rate when the store
+				// is scheduled for the first time.
+				var schedule struct {
+					StoreBalanceRate float64 `json:"store-balance-rate"`
+				}
+				if err := getJSON(cmd, schedulePrefix, &schedule); err != nil {
+					return nil, err
+				}
+				old.Rate = schedule.StoreBalanceRate
+			}
+			if old.Rate != *s.Limit {
+				prefix := fmt.Sprintf(path.Join(storePrefix, "limit"), s.ID)
+				input := map[string]interface{}{"rate": *s.Limit}
+				changes = append(changes, &change{
+					desc: fmt.Sprintf("~ store %d limit: %v -> %v", s.ID, old.Rate, *s.Limit),
+					apply: func(cmd *cobra.Command) error {
+						return postJSONBody(cmd, prefix, input)
+					},
+				})
+			}
+		}
+	}
+	return changes, nil
+}
+
+func planLabelProperty(cmd *cobra.Command, spec *clusterSpec) ([]*change, error) {
+	if spec.LabelProperty == nil {
+		return nil, nil
+	}
+	current := make(map[string][]labelSpec)
+	if err := getJSON(cmd, labelPropertyPrefix, &current); err != nil {
+		return nil, err
+	}
+	types := make(map[string]struct{})
+	for t := range current {
+		types[t] = struct{}{}
+	}
+	for t := range spec.LabelProperty {
+		types[t] = struct{}{}
+	}
+	var changes []*change
+	for _, t := range sortedKeys(types) {
+		desired := labelSet(spec.LabelProperty[t])
+		existing := labelSet(current[t])
+		for _, l := range sortedKeys(desired) {
+			if _, ok := existing[l]; !ok {
+				changes = append(changes, labelPropertyChange("+", "set", t, desired[l]))
+			}
+		}
+		for _, l := range sortedKeys(existing) {
+			if _, ok := desired[l]; !ok {
+				changes = append(changes, labelPropertyChange("-", "delete", t, existing[l]))
+			}
+		}
+	}
+	return changes, nil
+}
+
+func labelSet(labels []labelSpec) map[string]labelSpec {
+	set := make(map[string]labelSpec, len(labels))
+	for _, l := range labels {
+		set[l.Key+"="+l.Value] = l
+	}
+	return set
+}
+
+func labelPropertyChange(sign, action, typ string, l labelSpec) *change {
+	input := map[string]interface{}{
+		"type":        typ,
+		"action":      action,
+		"label-key":   l.Key,
+		"label-value": l.Value,
+	}
+	return &change{
+		desc: fmt.Sprintf("%s label-property %s %s=%s", sign, typ, l.Key, l.Value),
+		apply: func(cmd *cobra.Command) error {
+			return postJSONBody(cmd, labelPropertyPrefix, input)
+		},
+	}
+}
+
+func planSchedulers(cmd *cobra.Command, spec *clusterSpec) ([]*change, error) {
+	if spec.Schedulers == nil {
+		return nil, nil
+	}
+	var current []string
+	if err := getJSON(cmd, schedulersPrefix, &current); err != nil {
+		return nil, err
+	}
+	existing := make(map[string]struct{}, len(current))
+	for _, name := range current {
+		existing[name] = struct{}{}
+	}
+	desired := make(map[string]struct{})
+	var changes []*change
+	for _, s := range *spec.Schedulers {
+		name := s.fullName()
+		if _, ok := desired[name]; ok {
+			return nil, errors.Errorf("duplicated scheduler %s", name)
+		}
+		desired[name] = struct{}{}
+		if _, ok := existing[name]; ok {
+			c, err := planSchedulerConfig(cmd, name, s.Args)
+			if err != nil {
+				return nil, err
+			}
+			if c != nil {
+				changes = append(changes, c)
+			}
+			continue
+		}
+		input := map[string]interface{}{"name": s.Name}
+		for k, v := range s.Args {
+			input[k] = v
+		}
+		changes = append(changes, &change{
+			desc: fmt.Sprintf("+ scheduler %s", name),
+			apply: func(cmd *cobra.Command) error {
+				return postJSONBody(cmd, schedulersPrefix, input)
+			},
+		})
+	}
+	sort.Strings(current)
+	for _, name := range current {
+		if _, ok := desired[name]; ok {
+			continue
+		}
+		prefix := path.Join(schedulersPrefix, name)
+		changes = append(changes, &change{
+			desc: fmt.Sprintf("- scheduler %s", name),
+			apply: func(cmd *cobra.Command) error {
+				_, err := doRequest(cmd, prefix, http.MethodDelete)
+				return err
+			},
+		})
+	}
+	return changes, nil
+}
+
+// planSchedulerConfig compares the args with the config of the existing
+// scheduler, the arg a_b is the config item a-b. The schedulers without the
+// config handler are only compared by name, which contains their args.
+func planSchedulerConfig(cmd *cobra.Command, name string, args map[string]interface{}) (*change, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	r, err := doRequest(cmd, path.Join(schedulerConfigPrefix, name, "list"), http.MethodGet)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the config of scheduler %s", name)
+	}
+	if strings.TrimSpace(r) == "not implements" {
+		return nil, nil
+	}
+	current := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(r), &current); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	input := make(map[string]interface{}, len(current))
+	for k, v := range current {
+		input[k] = v
+	}
+	var descs []string
+	for _, k := range sortedKeys(args) {
+		item := strings.Replace(k, "_", "-", -1)
+		old, ok := current[item]
+		if !ok {
+			return nil, errors.Errorf("unknown arg %s of scheduler %s", k, name)
+		}
+		value := fmt.Sprint(args[k])
+		if k == "start_key" || k == "end_key" {
+			// The keys are escaped as the args of `scheduler add`, but the
+			// config keeps the raw keys.
+			if value, err = url.QueryUnescape(value); err != nil {
+				return nil, errors.Wrapf(err, "invalid arg %s of scheduler %s", k, name)
+			}
+		}
+		if fmt.Sprint(old) == value {
+			continue
+		}
+		descs = append(descs, fmt.Sprintf("%s: %v -> %v", k, old, value))
+		input[item] = value
+	}
+	if len(descs) == 0 {
+		return nil, nil
+	}
+	prefix := path.Join(schedulerConfigPrefix, name, "config")
+	return &change{
+		desc: fmt.Sprintf("~ scheduler %s %s", name, strings.Join(descs, ", ")),
+		apply: func(cmd *cobra.Command) error {
+			return postJSONBody(cmd, prefix, input)
+		},
+	}, nil
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]string:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]struct{}:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]labelSpec:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]interface{}:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func getJSON(cmd *cobra.Command, prefix string, v interface{}) error {
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		return err
+	}
+	return errors.WithStack(json.Unmarshal([]byte(r), v))
+}
+
+func postJSONBody(cmd *cobra.Command, prefix string, input map[string]interface{}) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = doRequest(cmd, prefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(data)))
+	return err
+}
@@ -37,5 +37,5 @@ func showClusterCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get the cluster information: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
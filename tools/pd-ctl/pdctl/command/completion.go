@@ -0,0 +1,115 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// The functions below get the candidates to complete the arguments in the
+// interactive mode, the cmd only needs the pd flag.
+
+// GetStoreIDs returns the IDs of the stores.
+func GetStoreIDs(cmd *cobra.Command) ([]string, error) {
+	r, err := doRequest(cmd, storesPrefix, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	var stores struct {
+		Stores []struct {
+			Store struct {
+				ID uint64 `json:"id"`
+			} `json:"store"`
+		} `json:"stores"`
+	}
+	if err := json.Unmarshal([]byte(r), &stores); err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, 0, len(stores.Stores))
+	for _, s := range stores.Stores {
+		ids = append(ids, s.Store.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, strconv.FormatUint(id, 10))
+	}
+	return names, nil
+}
+
+// GetSchedulerNames returns the names of the running schedulers.
+func GetSchedulerNames(cmd *cobra.Command) ([]string, error) {
+	r, err := doRequest(cmd, schedulersPrefix, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal([]byte(r), &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// operatorRegionRegexp matches the region ID in the description of an
+// operator, such as "region:2(1,1)".
+var operatorRegionRegexp = regexp.MustCompile(`region:(\d+)\(`)
+
+// GetOperatorRegionIDs returns the IDs of the regions having operators.
+func GetOperatorRegionIDs(cmd *cobra.Command) ([]string, error) {
+	r, err := doRequest(cmd, operatorsPrefix, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	var ops []string
+	if err := json.Unmarshal([]byte(r), &ops); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(ops))
+	for _, op := range ops {
+		if m := operatorRegionRegexp.FindStringSubmatch(op); m != nil {
+			ids = append(ids, m[1])
+		}
+	}
+	return ids, nil
+}
+
+// GetLabelKeys returns the label keys of the stores.
+func GetLabelKeys(cmd *cobra.Command) ([]string, error) {
+	r, err := doRequest(cmd, labelsPrefix, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	var labels []struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal([]byte(r), &labels); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(labels))
+	found := make(map[string]struct{})
+	for _, l := range labels {
+		if _, ok := found[l.Key]; !ok {
+			found[l.Key] = struct{}{}
+			keys = append(keys, l.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var componentConfigPrefix = "pd/api/v1/component-config"
+
+// NewComponentConfigCommand returns a component-config subcommand of rootCmd.
+func NewComponentConfigCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "component-config <subcommand>",
+		Short: "show or change the configs of the components, such as tikv and tidb",
+	}
+	c.PersistentFlags().String("component", "", "the component layer, the global layer is used if it is not set")
+	c.PersistentFlags().String("instance", "", "the instance layer of the component")
+	show := &cobra.Command{
+		Use:   "show [--component=<name> [--instance=<id>]] [--merged]",
+		Short: "show all the layers of the configs, or the layer of the component or the instance",
+		Run:   showComponentConfigCommandFunc,
+	}
+	show.Flags().Bool("merged", false, "show the merged config of the component or the instance")
+	c.AddCommand(show)
+	c.AddCommand(&cobra.Command{
+		Use:   "set <key> <value> [--component=<name> [--instance=<id>]]",
+		Short: "set the item of a layer, the key is separated by dots, such as raftstore.sync-log",
+		Run:   setComponentConfigCommandFunc,
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "delete <key> [--component=<name> [--instance=<id>]]",
+		Short: "delete the item of a layer, so that the item in the lower layer is used",
+		Run:   deleteComponentConfigCommandFunc,
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "clear [--component=<name> [--instance=<id>]]",
+		Short: "delete all the items of a layer",
+		Run:   clearComponentConfigCommandFunc,
+	})
+	return c
+}
+
+// componentConfigLayerPath returns the path of the layer by the flags.
+func componentConfigLayerPath(cmd *cobra.Command) (string, bool) {
+	component, _ := cmd.Flags().GetString("component")
+	instance, _ := cmd.Flags().GetString("instance")
+	switch {
+	case component == "" && instance != "":
+		cmd.Println("component should be set with instance")
+		return "", false
+	case component == "":
+		return path.Join(componentConfigPrefix, "global"), true
+	case instance == "":
+		return path.Join(componentConfigPrefix, "components", component), true
+	default:
+		return path.Join(componentConfigPrefix, "components", component, "instances", instance), true
+	}
+}
+
+func showComponentConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	prefix := componentConfigPrefix
+	component, _ := cmd.Flags().GetString("component")
+	instance, _ := cmd.Flags().GetString("instance")
+	merged, _ := cmd.Flags().GetBool("merged")
+	if component != "" || instance != "" || merged {
+		if component == "" {
+			cmd.Println("component should be set with instance or merged")
+			return
+		}
+		prefix, _ = componentConfigLayerPath(cmd)
+		if merged {
+			prefix = path.Join(prefix, "merged")
+		}
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get the component config: %s\n", err)
+		return
+	}
+	printResponse(cmd, r, nil)
+}
+
+func setComponentConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	// The value is a string if it is not valid JSON.
+	var value interface{} = args[1]
+	d := json.NewDecoder(strings.NewReader(args[1]))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err == nil && !d.More() {
+		value = v
+	}
+	patchComponentConfig(cmd, args[0], value)
+}
+
+func deleteComponentConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	patchComponentConfig(cmd, args[0], nil)
+}
+
+// patchComponentConfig posts the value of the dot-separated key as a JSON
+// merge patch, the item is deleted if the value is nil.
+func patchComponentConfig(cmd *cobra.Command, key string, value interface{}) {
+	prefix, ok := componentConfigLayerPath(cmd)
+	if !ok {
+		return
+	}
+	keys := strings.Split(key, ".")
+	patch := map[string]interface{}{keys[len(keys)-1]: value}
+	for i := len(keys) - 2; i >= 0; i-- {
+		patch = map[string]interface{}{keys[i]: patch}
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	_, err = doRequest(cmd, prefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(data)))
+	if err != nil {
+		cmd.Printf("Failed to set the component config: %s\n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
+
+func clearComponentConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	prefix, ok := componentConfigLayerPath(cmd)
+	if !ok {
+		return
+	}
+	_, err := doRequest(cmd, prefix, http.MethodDelete)
+	if err != nil {
+		cmd.Printf("Failed to clear the component config: %s\n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
@@ -16,6 +16,7 @@ package command
 import (
 	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"path"
 	"strconv"
@@ -30,6 +31,7 @@ var (
 	namespacePrefix      = "pd/api/v1/config/namespace"
 	labelPropertyPrefix  = "pd/api/v1/config/label-property"
 	clusterVersionPrefix = "pd/api/v1/config/cluster-version"
+	rbacPrefix           = "pd/api/v1/config/rbac"
 )
 
 // NewConfigCommand return a config subcommand of rootCmd
@@ -41,13 +43,15 @@ func NewConfigCommand() *cobra.Command {
 	conf.AddCommand(NewShowConfigCommand())
 	conf.AddCommand(NewSetConfigCommand())
 	conf.AddCommand(NewDeleteConfigCommand())
+	conf.AddCommand(NewConfigHistoryCommand())
+	conf.AddCommand(NewRollbackConfigCommand())
 	return conf
 }
 
 // NewShowConfigCommand return a show subcommand of configCmd
 func NewShowConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
-		Use:   "show [namespace|replication|label-property|all]",
+		Use:   "show [namespace|replication|label-property|rbac|all]",
 		Short: "show replication and schedule config of PD",
 		Run:   showConfigCommandFunc,
 	}
@@ -56,6 +60,7 @@ func NewShowConfigCommand() *cobra.Command {
 	sc.AddCommand(NewShowReplicationConfigCommand())
 	sc.AddCommand(NewShowLabelPropertyCommand())
 	sc.AddCommand(NewShowClusterVersionCommand())
+	sc.AddCommand(NewShowRBACConfigCommand())
 	return sc
 }
 
@@ -109,16 +114,27 @@ func NewShowClusterVersionCommand() *cobra.Command {
 	return sc
 }
 
+// NewShowRBACConfigCommand returns a rbac subcommand of show subcommand.
+func NewShowRBACConfigCommand() *cobra.Command {
+	sc := &cobra.Command{
+		Use:   "rbac",
+		Short: "show the access control config of the HTTP API",
+		Run:   showRBACConfigCommandFunc,
+	}
+	return sc
+}
+
 // NewSetConfigCommand return a set subcommand of configCmd
 func NewSetConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
-		Use:   "set <option> <value>, set namespace <name> <option> <value>, set label-property <type> <key> <value>, set cluster-version <version>",
+		Use:   "set <option> <value>, set namespace <name> <option> <value>, set label-property <type> <key> <value>, set cluster-version <version>, set rbac <file>",
 		Short: "set the option with value",
 		Run:   setConfigCommandFunc,
 	}
 	sc.AddCommand(NewSetNamespaceConfigCommand())
 	sc.AddCommand(NewSetLabelPropertyCommand())
 	sc.AddCommand(NewSetClusterVersionCommand())
+	sc.AddCommand(NewSetRBACConfigCommand())
 	return sc
 }
 
@@ -152,6 +168,16 @@ func NewSetClusterVersionCommand() *cobra.Command {
 	return sc
 }
 
+// NewSetRBACConfigCommand creates a set subcommand of set subcommand
+func NewSetRBACConfigCommand() *cobra.Command {
+	sc := &cobra.Command{
+		Use:   "rbac <file>",
+		Short: "replace the access control config with the JSON file",
+		Run:   setRBACConfigCommandFunc,
+	}
+	return sc
+}
+
 // NewDeleteConfigCommand a set subcommand of cfgCmd
 func NewDeleteConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
@@ -205,7 +231,7 @@ func showConfigCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to marshal config: %s\n", err)
 		return
 	}
-	cmd.Println(string(r))
+	printResponse(cmd, string(r), nil)
 }
 
 func showReplicationConfigCommandFunc(cmd *cobra.Command, args []string) {
@@ -214,7 +240,7 @@ func showReplicationConfigCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get config: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
 
 func showLabelPropertyConfigCommandFunc(cmd *cobra.Command, args []string) {
@@ -223,7 +249,7 @@ func showLabelPropertyConfigCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get config: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
 
 func showAllConfigCommandFunc(cmd *cobra.Command, args []string) {
@@ -232,7 +258,7 @@ func showAllConfigCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get config: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
 
 func showNamespaceConfigCommandFunc(cmd *cobra.Command, args []string) {
@@ -246,7 +272,7 @@ func showNamespaceConfigCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get config: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
 
 func showClusterVersionCommandFunc(cmd *cobra.Command, args []string) {
@@ -255,7 +281,16 @@ func showClusterVersionCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get cluster version: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
+}
+
+func showRBACConfigCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, rbacPrefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get config: %s\n", err)
+		return
+	}
+	printResponse(cmd, r, nil)
 }
 
 func postConfigDataWithPath(cmd *cobra.Command, key, value, path string) error {
@@ -367,3 +402,22 @@ func setClusterVersionCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	postJSON(cmd, clusterVersionPrefix, input)
 }
+
+func setRBACConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		cmd.Printf("Failed to read the file: %s\n", err)
+		return
+	}
+	_, err = doRequest(cmd, rbacPrefix, http.MethodPost,
+		WithBody("application/json", bytes.NewBuffer(data)))
+	if err != nil {
+		cmd.Printf("Failed to set config: %s\n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
@@ -0,0 +1,169 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configHistoryPrefix  = "pd/api/v1/config/history"
+	configRollbackPrefix = "pd/api/v1/config/rollback"
+)
+
+var configHistoryFormat = &tableFormat{
+	columns: []column{
+		{name: "VERSION", path: "version"},
+		{name: "TIME", path: "time"},
+		{name: "SOURCE", path: "source"},
+	},
+}
+
+var configChangeFormat = &tableFormat{
+	columns: []column{
+		{name: "KEY", path: "key"},
+		{name: "OLD", path: "old", def: "-"},
+		{name: "NEW", path: "new", def: "-"},
+	},
+}
+
+// NewConfigHistoryCommand returns a history subcommand of configCmd.
+func NewConfigHistoryCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "history [--limit=<count>] [--end=<version>]",
+		Short: "show the versions of the config, the latest one is the first",
+		Run:   showConfigHistoryCommandFunc,
+	}
+	c.Flags().Uint64("limit", 0, "the max number of the versions, 100 by default")
+	c.Flags().Uint64("end", 0, "the latest version to show, the latest version of the config by default")
+	c.AddCommand(NewShowConfigVersionCommand())
+	c.AddCommand(NewDiffConfigVersionCommand())
+	return c
+}
+
+// NewShowConfigVersionCommand returns a show subcommand of history subcommand.
+func NewShowConfigVersionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <version>",
+		Short: "show a version of the config",
+		Run:   showConfigVersionCommandFunc,
+	}
+}
+
+// NewDiffConfigVersionCommand returns a diff subcommand of history subcommand.
+func NewDiffConfigVersionCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "diff <version> [--base=<version>]",
+		Short: "show the changes of a version from the previous version or the base version",
+		Run:   diffConfigVersionCommandFunc,
+	}
+	c.Flags().String("base", "", "the base version, 0 means all the items are added")
+	return c
+}
+
+// NewRollbackConfigCommand returns a rollback subcommand of configCmd.
+func NewRollbackConfigCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback <version>",
+		Short: "roll back the config to a version, the schedulers are added or removed to match it",
+		Run:   rollbackConfigCommandFunc,
+	}
+}
+
+func showConfigHistoryCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	query := make(url.Values)
+	for _, name := range []string{"limit", "end"} {
+		if v, _ := cmd.Flags().GetUint64(name); v != 0 {
+			query.Set(name, strconv.FormatUint(v, 10))
+		}
+	}
+	prefix := configHistoryPrefix
+	if len(query) > 0 {
+		prefix += "?" + query.Encode()
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get the config history: %s\n", err)
+		return
+	}
+	printResponse(cmd, r, configHistoryFormat)
+}
+
+func showConfigVersionCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		cmd.Println("version should be a number")
+		return
+	}
+	r, err := doRequest(cmd, path.Join(configHistoryPrefix, args[0]), http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get the config version: %s\n", err)
+		return
+	}
+	printResponse(cmd, r, nil)
+}
+
+func diffConfigVersionCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		cmd.Println("version should be a number")
+		return
+	}
+	prefix := path.Join(configHistoryPrefix, args[0], "diff")
+	if base, _ := cmd.Flags().GetString("base"); base != "" {
+		if _, err := strconv.ParseUint(base, 10, 64); err != nil {
+			cmd.Println("base should be a number")
+			return
+		}
+		prefix += "?base=" + base
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get the config diff: %s\n", err)
+		return
+	}
+	printResponse(cmd, r, configChangeFormat)
+}
+
+func rollbackConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		cmd.Println("version should be a number")
+		return
+	}
+	_, err := doRequest(cmd, path.Join(configRollbackPrefix, args[0]), http.MethodPost)
+	if err != nil {
+		cmd.Printf("Failed to roll back the config: %s\n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
@@ -114,7 +114,7 @@ func dail(req *http.Request) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		return "", errors.Errorf("[%d] %s", resp.StatusCode, msg)
+		return "", responseError(resp.StatusCode, msg)
 	}
 
 	content, err := ioutil.ReadAll(resp.Body)
@@ -124,6 +124,16 @@ func dail(req *http.Request) (string, error) {
 	return string(content), nil
 }
 
+// responseError converts a failed response to an error. The requests denied by
+// the RBAC of PD get a hint about the identity of pd-ctl.
+func responseError(statusCode int, msg []byte) error {
+	if statusCode == http.StatusForbidden {
+		return errors.Errorf("[%d] permission denied: %s. pd-ctl is identified by the common name of the certificate set by --cert, check its role in the RBAC config of PD",
+			statusCode, strings.TrimSpace(string(msg)))
+	}
+	return errors.Errorf("[%d] %s", statusCode, msg)
+}
+
 // DoFunc receives an endpoint which you can issue request to
 type DoFunc func(endpoint string) error
 
@@ -195,7 +205,7 @@ func postJSON(cmd *cobra.Command, prefix string, input map[string]interface{}) {
 			if err != nil {
 				return err
 			}
-			return errors.Errorf("[%d] %s", r.StatusCode, msg)
+			return responseError(r.StatusCode, msg)
 		}
 		return nil
 	})
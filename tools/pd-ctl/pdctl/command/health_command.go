@@ -39,5 +39,5 @@ func showHealthCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Println(err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
@@ -15,6 +15,7 @@ package command
 
 import (
 	"net/http"
+	"sort"
 
 	"github.com/spf13/cobra"
 )
@@ -25,6 +26,52 @@ const (
 	hotStoresPrefix       = "pd/api/v1/hotspot/stores"
 )
 
+// hotRegionFormat shows the hot peers of the stores as a table, the role is
+// leader or peer.
+var hotRegionFormat = &tableFormat{
+	items: hotPeers,
+	columns: []column{
+		{name: "STORE", path: "store_id"},
+		{name: "ROLE", path: "role"},
+		{name: "REGION", path: "region_id"},
+		{name: "FLOW_BYTES", path: "flow_bytes", def: "0"},
+		{name: "FLOW_KEYS", path: "flow_keys", def: "0"},
+		{name: "HOT_DEGREE", path: "hot_degree", def: "0"},
+	},
+	wide: []column{
+		{name: "ANTI_COUNT", path: "AntiCount", def: "0"},
+		{name: "LAST_UPDATE", path: "last_update_time"},
+	},
+}
+
+func hotPeers(resp interface{}) []interface{} {
+	var peers []interface{}
+	for _, role := range []string{"leader", "peer"} {
+		stores, _ := lookup(resp, "as_"+role).(map[string]interface{})
+		ids := make([]string, 0, len(stores))
+		for id := range stores {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return compareValues(ids[i], ids[j]) < 0 })
+		for _, id := range ids {
+			stats, _ := lookup(stores[id], "statistics").([]interface{})
+			for _, stat := range stats {
+				m, ok := stat.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				peer := make(map[string]interface{}, len(m)+1)
+				for k, v := range m {
+					peer[k] = v
+				}
+				peer["role"] = role
+				peers = append(peers, peer)
+			}
+		}
+	}
+	return peers
+}
+
 // NewHotSpotCommand return a hot subcommand of rootCmd
 func NewHotSpotCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -53,7 +100,7 @@ func showHotWriteRegionsCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get hotspot: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, hotRegionFormat)
 }
 
 // NewHotReadRegionCommand return a hot read regions subcommand of hotSpotCmd
@@ -72,7 +119,7 @@ func showHotReadRegionsCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get hotspot: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, hotRegionFormat)
 }
 
 // NewHotStoreCommand return a hot stores subcommand of hotSpotCmd
@@ -91,5 +138,5 @@ func showHotStoresCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get hotspot: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
@@ -52,7 +52,7 @@ func showLabelsCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get labels: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
 
 func getValue(args []string, i int) string {
@@ -75,5 +75,5 @@ func showLabelListStoresCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get stores through label: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
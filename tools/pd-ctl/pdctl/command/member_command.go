@@ -27,6 +27,33 @@ var (
 	leaderMemberPrefix = "pd/api/v1/leader"
 )
 
+// memberFormat shows the members as a table.
+var memberFormat = &tableFormat{
+	listKey: "members",
+	fields: func(resp, item interface{}) interface{} {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return item
+		}
+		fields := make(map[string]interface{}, len(m)+1)
+		for k, v := range m {
+			fields[k] = v
+		}
+		fields["leader"] = formatValue(m["member_id"]) == formatValue(lookup(resp, "leader.member_id"))
+		return fields
+	},
+	columns: []column{
+		{name: "NAME", path: "name"},
+		{name: "ID", path: "member_id"},
+		{name: "CLIENT_URLS", path: "client_urls"},
+		{name: "LEADER", path: "leader"},
+	},
+	wide: []column{
+		{name: "PEER_URLS", path: "peer_urls"},
+		{name: "LEADER_PRIORITY", path: "leader_priority", def: "0"},
+	},
+}
+
 // NewMemberCommand return a member subcommand of rootCmd
 func NewMemberCommand() *cobra.Command {
 	m := &cobra.Command{
@@ -94,7 +121,7 @@ func showMemberCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get pd members: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, memberFormat)
 }
 
 func deleteMemberByNameCommandFunc(cmd *cobra.Command, args []string) {
@@ -131,7 +158,7 @@ func getLeaderMemberCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get the leader of pd members: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
 
 func resignLeaderCommandFunc(cmd *cobra.Command, args []string) {
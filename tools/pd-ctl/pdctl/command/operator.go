@@ -16,6 +16,7 @@ package command
 import (
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 
 	"github.com/pkg/errors"
@@ -26,6 +27,53 @@ var (
 	operatorsPrefix = "pd/api/v1/operators"
 )
 
+// operatorFormat shows the operators as a table.
+var operatorFormat = &tableFormat{
+	fields: operatorFields,
+	columns: []column{
+		{name: "REGION", path: "region_id"},
+		{name: "DESC", path: "desc"},
+		{name: "KIND", path: "kind"},
+		{name: "STEP", path: "current_step"},
+		{name: "STATUS", path: "status"},
+	},
+	wide: []column{
+		{name: "BRIEF", path: "brief"},
+		{name: "CREATE_AT", path: "create_at"},
+		{name: "START_AT", path: "start_at"},
+		{name: "STEPS", path: "steps"},
+	},
+}
+
+// operatorRegexp matches the description of an operator.
+var operatorRegexp = regexp.MustCompile(`^(\S*) \{(.*?)\} \(kind:(.*?), region:(\d+)\((\d+),(\d+)\), createAt:(.*?), startAt:(.*?), currentStep:(\d+), steps:\[(.*)\]\)( timeout)?( finished)?$`)
+
+// operatorFields parses the description of an operator to the fields.
+func operatorFields(resp, item interface{}) interface{} {
+	s, _ := item.(string)
+	m := operatorRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return map[string]interface{}{"desc": s}
+	}
+	status := "running"
+	if m[11] != "" {
+		status = "timeout"
+	} else if m[12] != "" {
+		status = "finished"
+	}
+	return map[string]interface{}{
+		"desc":         m[1],
+		"brief":        m[2],
+		"kind":         m[3],
+		"region_id":    m[4],
+		"create_at":    m[7],
+		"start_at":     m[8],
+		"current_step": m[9],
+		"steps":        m[10],
+		"status":       status,
+	}
+}
+
 // NewOperatorCommand returns a operator command.
 func NewOperatorCommand() *cobra.Command {
 	c := &cobra.Command{
@@ -75,7 +123,7 @@ func showOperatorCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Println(err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, operatorFormat)
 }
 
 func checkOperatorCommandFunc(cmd *cobra.Command, args []string) {
@@ -94,7 +142,7 @@ func checkOperatorCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Println(err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
 
 // NewAddOperatorCommand returns a command to add operators.
@@ -0,0 +1,429 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	units "github.com/docker/go-units"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+	outputWide  = "wide"
+)
+
+// AddOutputFlags adds the flags to format the output to the root command.
+func AddOutputFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringP("output", "o", outputJSON, "output format: table|json|yaml|wide")
+	cmd.PersistentFlags().String("sort-by", "", "sort the items by a column or a field, prefix '-' to sort in descending order")
+	cmd.PersistentFlags().String("filter", "", "filter the items by the expressions separated by comma, such as 'state==Up,regions>10'")
+}
+
+// column is a column of a table.
+type column struct {
+	name string
+	// path is the dot-separated keys of the field in the item.
+	path string
+	// value gets the value from the item instead of path if it is set.
+	value func(item interface{}) interface{}
+	// def is shown if the field is missing, the fields are omitted if they are
+	// empty.
+	def string
+}
+
+func (c *column) get(item interface{}) interface{} {
+	if c.value != nil {
+		return c.value(item)
+	}
+	return lookup(item, c.path)
+}
+
+// tableFormat describes how to show a kind of response as a table.
+type tableFormat struct {
+	// listKey is the key of the items in the response. If the response has no
+	// such key, it is a single item.
+	listKey string
+	// items gets the items from the response instead of listKey if it is set.
+	// The items are printed as a list in JSON and YAML after filtering.
+	items func(resp interface{}) []interface{}
+	// fields converts the item to the fields used by the columns, the filters
+	// and the sorting if it is set.
+	fields  func(resp, item interface{}) interface{}
+	columns []column
+	// wide is the extra columns of the wide output.
+	wide []column
+}
+
+// row is an item of the response, raw is printed in JSON and YAML and fields
+// is printed in tables.
+type row struct {
+	raw    interface{}
+	fields interface{}
+}
+
+// rows returns the rows of the response and a function to rebuild the
+// response with the rows.
+func (f *tableFormat) rows(resp interface{}) ([]*row, func([]*row) interface{}) {
+	var items []interface{}
+	var rebuild func([]*row) interface{}
+	if f != nil && f.items != nil {
+		items = f.items(resp)
+		rebuild = rebuildList
+	} else if list, ok := resp.([]interface{}); ok {
+		items = list
+		rebuild = rebuildList
+	} else if m, ok := resp.(map[string]interface{}); ok && f != nil && f.listKey != "" && m[f.listKey] != nil {
+		items, _ = m[f.listKey].([]interface{})
+		rebuild = func(rows []*row) interface{} {
+			n := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				n[k] = v
+			}
+			n[f.listKey] = rebuildList(rows)
+			if _, ok := n["count"]; ok {
+				n["count"] = len(rows)
+			}
+			return n
+		}
+	} else if resp != nil {
+		items = []interface{}{resp}
+		rebuild = func(rows []*row) interface{} {
+			if len(rows) == 0 {
+				return nil
+			}
+			return rows[0].raw
+		}
+	}
+	rows := make([]*row, 0, len(items))
+	for _, item := range items {
+		r := &row{raw: item, fields: item}
+		if f != nil && f.fields != nil {
+			r.fields = f.fields(resp, item)
+		}
+		rows = append(rows, r)
+	}
+	return rows, rebuild
+}
+
+func rebuildList(rows []*row) interface{} {
+	list := make([]interface{}, 0, len(rows))
+	for _, r := range rows {
+		list = append(list, r.raw)
+	}
+	return list
+}
+
+// resolve returns the column referred by the name of a column or a path.
+func (f *tableFormat) resolve(name string) *column {
+	if f != nil {
+		for _, columns := range [][]column{f.columns, f.wide} {
+			for i := range columns {
+				if strings.EqualFold(columns[i].name, name) {
+					return &columns[i]
+				}
+			}
+		}
+	}
+	return &column{name: name, path: strings.TrimPrefix(name, ".")}
+}
+
+// printResponse prints the JSON response in the format specified by the
+// output flags. The raw response is printed if the flags are not set.
+func printResponse(cmd *cobra.Command, r string, f *tableFormat) {
+	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
+		printWithJQFilter(r, flag.Value.String())
+		return
+	}
+	output, sortBy, filter := flagValue(cmd, "output"), flagValue(cmd, "sort-by"), flagValue(cmd, "filter")
+	if output == "" {
+		output = outputJSON
+	}
+	if output == outputJSON && sortBy == "" && filter == "" {
+		cmd.Println(r)
+		return
+	}
+	if output != outputJSON && output != outputYAML && output != outputTable && output != outputWide {
+		cmd.Printf("Unknown output format %s, it should be table, json, yaml or wide\n", output)
+		return
+	}
+	s, err := formatResponse(r, f, output, sortBy, filter)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	cmd.Println(s)
+}
+
+func flagValue(cmd *cobra.Command, name string) string {
+	if flag := cmd.Flag(name); flag != nil {
+		return flag.Value.String()
+	}
+	return ""
+}
+
+func formatResponse(r string, f *tableFormat, output, sortBy, filter string) (string, error) {
+	var resp interface{}
+	d := json.NewDecoder(strings.NewReader(r))
+	d.UseNumber()
+	if err := d.Decode(&resp); err != nil {
+		// The response is not JSON, such as a message.
+		return r, nil
+	}
+
+	rows, rebuild := f.rows(resp)
+	if filter != "" || sortBy != "" {
+		var err error
+		if rows, err = filterRows(rows, f, filter); err != nil {
+			return "", err
+		}
+		sortRows(rows, f, sortBy)
+		resp = rebuild(rows)
+	}
+
+	switch output {
+	case outputYAML:
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return "", err
+		}
+		data, err = yaml.JSONToYAML(data)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(data), "\n"), nil
+	case outputTable, outputWide:
+		if f != nil && len(f.columns) > 0 {
+			return formatTable(rows, f, output == outputWide), nil
+		}
+	}
+	data, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func formatTable(rows []*row, f *tableFormat, wide bool) string {
+	columns := f.columns
+	if wide {
+		columns = append(append([]column(nil), f.columns...), f.wide...)
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+	fmt.Fprintln(w, strings.Join(names, "\t"))
+	for _, r := range rows {
+		cells := make([]string, 0, len(columns))
+		for i := range columns {
+			cell := formatValue(columns[i].get(r.fields))
+			if cell == "" {
+				cell = columns[i].def
+			}
+			cells = append(cells, cell)
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// lookup returns the field at the dot-separated path of the item.
+func lookup(item interface{}, path string) interface{} {
+	if path == "" {
+		return item
+	}
+	for _, key := range strings.Split(path, ".") {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		item = m[key]
+	}
+	return item
+}
+
+// formatValue formats a field as a cell of a table. The labels are shown as
+// key=value.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			if m, ok := e.(map[string]interface{}); ok && len(m) == 2 && m["key"] != nil && m["value"] != nil {
+				values = append(values, fmt.Sprintf("%v=%v", m["key"], m["value"]))
+				continue
+			}
+			values = append(values, formatValue(e))
+		}
+		return strings.Join(values, ",")
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// compareValues compares the values as numbers, byte sizes such as "1GiB",
+// or strings.
+func compareValues(a, b string) int {
+	if x, y, ok := parseNumbers(a, b, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }); ok {
+		return compareFloats(x, y)
+	}
+	if x, y, ok := parseNumbers(a, b, func(s string) (float64, error) {
+		size, err := units.RAMInBytes(s)
+		return float64(size), err
+	}); ok {
+		return compareFloats(x, y)
+	}
+	return strings.Compare(a, b)
+}
+
+func parseNumbers(a, b string, parse func(string) (float64, error)) (float64, float64, bool) {
+	x, err := parse(a)
+	if err != nil {
+		return 0, 0, false
+	}
+	y, err := parse(b)
+	if err != nil {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+func compareFloats(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func sortRows(rows []*row, f *tableFormat, sortBy string) {
+	if sortBy == "" {
+		return
+	}
+	desc := strings.HasPrefix(sortBy, "-")
+	c := f.resolve(strings.TrimPrefix(sortBy, "-"))
+	sort.SliceStable(rows, func(i, j int) bool {
+		cmp := compareValues(cellValue(c, rows[i]), cellValue(c, rows[j]))
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
+
+func cellValue(c *column, r *row) string {
+	if v := formatValue(c.get(r.fields)); v != "" {
+		return v
+	}
+	return c.def
+}
+
+// filterRegexp matches a filter expression, such as "state==Up".
+var filterRegexp = regexp.MustCompile(`^\s*([\w.\-]+)\s*(==|!=|>=|<=|=~|>|<|=)\s*(.*?)\s*$`)
+
+type condition struct {
+	column *column
+	op     string
+	value  string
+	re     *regexp.Regexp
+}
+
+func (c *condition) match(r *row) bool {
+	v := cellValue(c.column, r)
+	if c.op == "=~" {
+		return c.re.MatchString(v)
+	}
+	cmp := compareValues(v, c.value)
+	switch c.op {
+	case "==", "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// filterRows returns the rows matching all the expressions, an expression
+// compares a column or a field with a value by ==, !=, >, >=, <, <=, or
+// matches it with a regular expression by =~.
+func filterRows(rows []*row, f *tableFormat, filter string) ([]*row, error) {
+	if strings.TrimSpace(filter) == "" {
+		return rows, nil
+	}
+	var conditions []*condition
+	for _, expr := range strings.Split(filter, ",") {
+		m := filterRegexp.FindStringSubmatch(expr)
+		if m == nil {
+			return nil, errors.Errorf("bad filter expression %q", expr)
+		}
+		c := &condition{column: f.resolve(m[1]), op: m[2], value: m[3]}
+		if c.op == "=~" {
+			re, err := regexp.Compile(c.value)
+			if err != nil {
+				return nil, errors.Errorf("bad regular expression %q: %v", c.value, err)
+			}
+			c.re = re
+		}
+		conditions = append(conditions, c)
+	}
+	filtered := rows[:0]
+	for _, r := range rows {
+		ok := true
+		for _, c := range conditions {
+			if !c.match(r) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
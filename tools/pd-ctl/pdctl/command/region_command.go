@@ -45,6 +45,42 @@ var (
 	regionKeyPrefix        = "pd/api/v1/region/key"
 )
 
+// regionFormat shows the regions as a table, the size is in MB.
+var regionFormat = &tableFormat{
+	listKey: "regions",
+	columns: []column{
+		{name: "ID", path: "id"},
+		{name: "START_KEY", path: "start_key"},
+		{name: "END_KEY", path: "end_key"},
+		{name: "LEADER", path: "leader.store_id"},
+		{name: "PEERS", value: peerStores("peers", "store_id")},
+		{name: "SIZE", path: "approximate_size", def: "0"},
+		{name: "KEYS", path: "approximate_keys", def: "0"},
+	},
+	wide: []column{
+		{name: "CONF_VER", path: "epoch.conf_ver", def: "0"},
+		{name: "VERSION", path: "epoch.version", def: "0"},
+		{name: "WRITTEN_BYTES", path: "written_bytes", def: "0"},
+		{name: "READ_BYTES", path: "read_bytes", def: "0"},
+		{name: "WRITTEN_KEYS", path: "written_keys", def: "0"},
+		{name: "READ_KEYS", path: "read_keys", def: "0"},
+		{name: "DOWN_PEERS", value: peerStores("down_peers", "peer.store_id")},
+		{name: "PENDING_PEERS", value: peerStores("pending_peers", "store_id")},
+	},
+}
+
+// peerStores returns a function to get the store IDs of the peers at the path.
+func peerStores(path, storeIDPath string) func(interface{}) interface{} {
+	return func(item interface{}) interface{} {
+		peers, _ := lookup(item, path).([]interface{})
+		ids := make([]interface{}, 0, len(peers))
+		for _, peer := range peers {
+			ids = append(ids, lookup(peer, storeIDPath))
+		}
+		return ids
+	}
+}
+
 // NewRegionCommand returns a region subcommand of rootCmd
 func NewRegionCommand() *cobra.Command {
 	r := &cobra.Command{
@@ -125,12 +161,7 @@ func showRegionCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get region: %s\n", err)
 		return
 	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
-	}
-
-	cmd.Println(r)
+	printResponse(cmd, r, regionFormat)
 }
 
 func scanRegionCommandFunc(cmd *cobra.Command, args []string) {
@@ -144,11 +175,7 @@ func scanRegionCommandFunc(cmd *cobra.Command, args []string) {
 			return
 		}
 
-		if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-			printWithJQFilter(r, flag.Value.String())
-		} else {
-			cmd.Println(r)
-		}
+		printResponse(cmd, r, regionFormat)
 
 		// Extract last region's endkey for next batch.
 		type regionsInfo struct {
@@ -193,11 +220,7 @@ func showRegionTopWriteCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get regions: %s\n", err)
 		return
 	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
-	}
-	cmd.Println(r)
+	printResponse(cmd, r, regionFormat)
 }
 
 func showRegionTopReadCommandFunc(cmd *cobra.Command, args []string) {
@@ -214,11 +237,7 @@ func showRegionTopReadCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get regions: %s\n", err)
 		return
 	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
-	}
-	cmd.Println(r)
+	printResponse(cmd, r, regionFormat)
 }
 
 func showRegionTopConfVerCommandFunc(cmd *cobra.Command, args []string) {
@@ -235,11 +254,7 @@ func showRegionTopConfVerCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get regions: %s\n", err)
 		return
 	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
-	}
-	cmd.Println(r)
+	printResponse(cmd, r, regionFormat)
 }
 
 func showRegionTopVersionCommandFunc(cmd *cobra.Command, args []string) {
@@ -256,11 +271,7 @@ func showRegionTopVersionCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get regions: %s\n", err)
 		return
 	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
-	}
-	cmd.Println(r)
+	printResponse(cmd, r, regionFormat)
 }
 
 func showRegionTopSizeCommandFunc(cmd *cobra.Command, args []string) {
@@ -277,11 +288,7 @@ func showRegionTopSizeCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get regions: %s\n", err)
 		return
 	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
-	}
-	cmd.Println(r)
+	printResponse(cmd, r, regionFormat)
 }
 
 // NewRegionWithKeyCommand return a region with key subcommand of regionCmd
@@ -312,7 +319,7 @@ func showRegionWithTableCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get region: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, regionFormat)
 }
 
 func parseKey(flags *pflag.FlagSet, key string) (string, error) {
@@ -408,7 +415,7 @@ func showRegionsFromStartKeyCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get region: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, regionFormat)
 }
 
 // NewRegionWithCheckCommand returns a region with check subcommand of regionCmd
@@ -433,7 +440,7 @@ func showRegionWithCheckCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get region: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, regionFormat)
 }
 
 // NewRegionWithSiblingCommand returns a region with sibling subcommand of regionCmd
@@ -458,7 +465,7 @@ func showRegionWithSiblingCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get region sibling: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, regionFormat)
 }
 
 // NewRegionWithStoreCommand returns regions with store subcommand of regionCmd
@@ -483,7 +490,7 @@ func showRegionWithStoreCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get regions with the given storeID: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, regionFormat)
 }
 
 func printWithJQFilter(data, filter string) {
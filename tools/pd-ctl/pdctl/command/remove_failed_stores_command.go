@@ -0,0 +1,78 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	removeFailedStoresPrefix = "pd/api/v1/admin/remove-failed-stores"
+)
+
+// NewRemoveFailedStoresCommand returns a remove-failed-stores subcommand of rootCmd.
+func NewRemoveFailedStoresCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "remove-failed-stores <store_id1,store_id2,...> [--timeout=<seconds>]",
+		Short: "remove the peers on the failed stores from the regions which still have a quorum",
+		Run:   removeFailedStoresCommandFunc,
+	}
+	c.Flags().Uint64("timeout", 0, "timeout in seconds of the removal, the default is 600")
+	c.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "show the progress and the report of removing the failed stores",
+		Run:   showRemoveFailedStoresCommandFunc,
+	})
+	return c
+}
+
+func removeFailedStoresCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	var stores []uint64
+	for _, s := range strings.Split(args[0], ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			cmd.Println("store_id should be a number")
+			return
+		}
+		stores = append(stores, id)
+	}
+	timeout, err := cmd.Flags().GetUint64("timeout")
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	input := map[string]interface{}{
+		"stores":  stores,
+		"timeout": timeout,
+	}
+	postJSON(cmd, removeFailedStoresPrefix, input)
+}
+
+func showRemoveFailedStoresCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, path.Join(removeFailedStoresPrefix, "show"), http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get the status of removing the failed stores: %s\n", err)
+		return
+	}
+	printResponse(cmd, r, nil)
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	replicationModeStatusPrefix = "pd/api/v1/replication_mode/status"
+	replicationModeStatePrefix  = "pd/api/v1/replication_mode/state"
+)
+
+// NewReplicationModeCommand returns a replication-mode subcommand of rootCmd.
+func NewReplicationModeCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "replication-mode [command]",
+		Short: "show or switch the state of the replication mode",
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "show the status of the replication mode",
+		Run:   showReplicationModeCommandFunc,
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "set-state <sync|async|sync-recover>",
+		Short: "switch the state of the dr-auto-sync mode manually",
+		Run:   setReplicationStateCommandFunc,
+	})
+	return c
+}
+
+func showReplicationModeCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, replicationModeStatusPrefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get the replication mode status: %s\n", err)
+		return
+	}
+	printResponse(cmd, r, nil)
+}
+
+func setReplicationStateCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	postJSON(cmd, replicationModeStatePrefix, map[string]interface{}{"state": args[0]})
+}
@@ -22,9 +22,20 @@ import (
 )
 
 var (
-	schedulersPrefix = "pd/api/v1/schedulers"
+	schedulersPrefix      = "pd/api/v1/schedulers"
+	schedulerConfigPrefix = "pd/api/v1/schedule-config"
 )
 
+// schedulerFormat shows the schedulers as a table.
+var schedulerFormat = &tableFormat{
+	fields: func(resp, item interface{}) interface{} {
+		return map[string]interface{}{"name": item}
+	},
+	columns: []column{
+		{name: "NAME", path: "name"},
+	},
+}
+
 // NewSchedulerCommand returns a scheduler command.
 func NewSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
@@ -58,7 +69,7 @@ func showSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Println(err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, schedulerFormat)
 }
 
 // NewAddSchedulerCommand returns a command to add scheduler.
@@ -69,6 +80,7 @@ func NewAddSchedulerCommand() *cobra.Command {
 	}
 	c.AddCommand(NewGrantLeaderSchedulerCommand())
 	c.AddCommand(NewEvictLeaderSchedulerCommand())
+	c.AddCommand(NewEvictSlowStoreSchedulerCommand())
 	c.AddCommand(NewShuffleLeaderSchedulerCommand())
 	c.AddCommand(NewShuffleRegionSchedulerCommand())
 	c.AddCommand(NewShuffleHotRegionSchedulerCommand())
@@ -120,6 +132,16 @@ func addSchedulerForStoreCommandFunc(cmd *cobra.Command, args []string) {
 	postJSON(cmd, schedulersPrefix, input)
 }
 
+// NewEvictSlowStoreSchedulerCommand returns a command to add an evict-slow-store-scheduler.
+func NewEvictSlowStoreSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "evict-slow-store-scheduler",
+		Short: "add a scheduler to detect and evict leaders from the slow store",
+		Run:   addSchedulerCommandFunc,
+	}
+	return c
+}
+
 // NewShuffleLeaderSchedulerCommand returns a command to add a shuffle-leader-scheduler.
 func NewShuffleLeaderSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
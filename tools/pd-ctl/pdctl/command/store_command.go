@@ -17,6 +17,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"path"
 	"strconv"
 
@@ -26,12 +27,40 @@ import (
 var (
 	storesPrefix = "pd/api/v1/stores"
 	storePrefix  = "pd/api/v1/store/%v"
+	// labelStatsPrefix is used to get the store statistics grouped by label.
+	labelStatsPrefix = "pd/api/v1/stats/label"
 )
 
+// storeFormat shows the stores as a table.
+var storeFormat = &tableFormat{
+	listKey: "stores",
+	columns: []column{
+		{name: "ID", path: "store.id"},
+		{name: "ADDRESS", path: "store.address"},
+		{name: "STATE", path: "store.state_name"},
+		{name: "VERSION", path: "store.version"},
+		{name: "LABELS", path: "store.labels"},
+		{name: "CAPACITY", path: "status.capacity"},
+		{name: "AVAILABLE", path: "status.available"},
+		{name: "LEADERS", path: "status.leader_count", def: "0"},
+		{name: "REGIONS", path: "status.region_count", def: "0"},
+	},
+	wide: []column{
+		{name: "LEADER_WEIGHT", path: "status.leader_weight", def: "0"},
+		{name: "REGION_WEIGHT", path: "status.region_weight", def: "0"},
+		{name: "LEADER_SCORE", path: "status.leader_score", def: "0"},
+		{name: "REGION_SCORE", path: "status.region_score", def: "0"},
+		{name: "LEADER_SIZE", path: "status.leader_size", def: "0"},
+		{name: "REGION_SIZE", path: "status.region_size", def: "0"},
+		{name: "LAST_HEARTBEAT", path: "status.last_heartbeat_ts"},
+		{name: "UPTIME", path: "status.uptime"},
+	},
+}
+
 // NewStoreCommand return a stores subcommand of rootCmd
 func NewStoreCommand() *cobra.Command {
 	s := &cobra.Command{
-		Use:   `store [delete|label|weight|limit] <store_id> [--jq="<query string>"]`,
+		Use:   `store [delete|label|weight|limit|progress] <store_id> [--jq="<query string>"] [--group-by=<label_key>]`,
 		Short: "show the store status",
 		Run:   showStoreCommandFunc,
 	}
@@ -39,7 +68,9 @@ func NewStoreCommand() *cobra.Command {
 	s.AddCommand(NewLabelStoreCommand())
 	s.AddCommand(NewSetStoreWeightCommand())
 	s.AddCommand(NewSetStoreLimitCommand())
+	s.AddCommand(NewStoreProgressCommand())
 	s.Flags().String("jq", "", "jq query")
+	s.Flags().String("group-by", "", "show the statistics of stores grouped by the label key")
 	return s
 }
 
@@ -92,6 +123,15 @@ func NewSetStoreLimitCommand() *cobra.Command {
 	}
 }
 
+// NewStoreProgressCommand returns a progress subcommand of storeCmd.
+func NewStoreProgressCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "progress",
+		Short: "show the progress of the stores being removed or prepared",
+		Run:   showStoreProgressCommandFunc,
+	}
+}
+
 // NewStoresCommand returns a store subcommand of rootCmd
 func NewStoresCommand() *cobra.Command {
 	s := &cobra.Command{
@@ -155,6 +195,14 @@ func NewSetAllLimitCommand() *cobra.Command {
 }
 
 func showStoreCommandFunc(cmd *cobra.Command, args []string) {
+	if flag := cmd.Flag("group-by"); flag != nil && flag.Value.String() != "" {
+		if len(args) != 0 {
+			cmd.Println("store_id should not be specified with --group-by")
+			return
+		}
+		showStoreLabelStats(cmd, flag.Value.String())
+		return
+	}
 	prefix := storesPrefix
 	if len(args) == 1 {
 		if _, err := strconv.Atoi(args[0]); err != nil {
@@ -168,11 +216,19 @@ func showStoreCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get store: %s\n", err)
 		return
 	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
+	printResponse(cmd, r, storeFormat)
+}
+
+func showStoreLabelStats(cmd *cobra.Command, key string) {
+	query := make(url.Values)
+	query.Set("key", key)
+	prefix := labelStatsPrefix + "?" + query.Encode()
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get the store statistics: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
 
 func deleteStoreCommandFunc(cmd *cobra.Command, args []string) {
@@ -302,11 +358,7 @@ func showStoresCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get store: %s\n", err)
 		return
 	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
-	}
-	cmd.Println(r)
+	printResponse(cmd, r, storeFormat)
 }
 
 func showAllLimitCommandFunc(cmd *cobra.Command, args []string) {
@@ -316,7 +368,17 @@ func showAllLimitCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get all limit: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
+}
+
+func showStoreProgressCommandFunc(cmd *cobra.Command, args []string) {
+	prefix := path.Join(storesPrefix, "progress")
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get the progress of stores: %s\n", err)
+		return
+	}
+	printResponse(cmd, r, nil)
 }
 
 func removeTombStoneCommandFunc(cmd *cobra.Command, args []string) {
@@ -81,7 +81,7 @@ func showNamespaceCommandFunc(cmd *cobra.Command, args []string) {
 		cmd.Printf("Failed to get the namespace information: %s\n", err)
 		return
 	}
-	cmd.Println(r)
+	printResponse(cmd, r, nil)
 }
 
 func createNamespaceCommandFunc(cmd *cobra.Command, args []string) {
@@ -0,0 +1,149 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdctl
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chzyer/readline"
+	"github.com/pingcap/pd/server/config"
+	"github.com/pingcap/pd/tools/pd-ctl/pdctl/command"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// candidateTTL is how long the candidates got from PD are reused, readline
+// asks for them several times to complete a line.
+const candidateTTL = 3 * time.Second
+
+type candidates struct {
+	values []string
+	expire time.Time
+}
+
+type completer struct {
+	// cmd is used to send requests to PD.
+	cmd *cobra.Command
+
+	mu    sync.Mutex
+	cache map[string]*candidates
+}
+
+// NewCompleter returns the completer of the interactive mode. Besides the
+// commands and the flags, it completes the store IDs, the scheduler names, the
+// region IDs of the operators and the label keys got from PD, and the config
+// options. The `watch` command is completed with the other commands.
+func NewCompleter(flags CommandFlags) (readline.AutoCompleter, error) {
+	if len(flags.CAPath) != 0 {
+		if err := command.InitHTTPSClient(flags.CAPath, flags.CertPath, flags.KeyPath); err != nil {
+			return nil, err
+		}
+	}
+	c := &completer{
+		cmd:   &cobra.Command{},
+		cache: make(map[string]*candidates),
+	}
+	c.cmd.Flags().String("pd", flags.URL, "")
+
+	items := genItems(getBasicCmd(), c.argItems())
+	items = append(items, readline.PcItem("watch", items...))
+	return readline.NewPrefixCompleter(items...), nil
+}
+
+// argItems returns the completion of the arguments of the commands, in the
+// order of the arguments.
+func (c *completer) argItems() map[string][]readline.DynamicCompleteFunc {
+	stores := c.dynamic("stores", command.GetStoreIDs)
+	schedulers := c.dynamic("schedulers", command.GetSchedulerNames)
+	operators := c.dynamic("operators", command.GetOperatorRegionIDs)
+	labels := c.dynamic("labels", command.GetLabelKeys)
+	options := func(string) []string { return configOptions() }
+	return map[string][]readline.DynamicCompleteFunc{
+		"store":                                {stores},
+		"store delete":                         {stores},
+		"store label":                          {stores, labels},
+		"store weight":                         {stores},
+		"store limit":                          {stores},
+		"region store":                         {stores},
+		"label store":                          {labels},
+		"scheduler remove":                     {schedulers},
+		"scheduler add grant-leader-scheduler": {stores},
+		"scheduler add evict-leader-scheduler": {stores},
+		"operator check":                       {operators},
+		"operator remove":                      {operators},
+		"config set":                           {options},
+	}
+}
+
+// genItems generates the completion of the subcommands of cmd.
+func genItems(cmd *cobra.Command, args map[string][]readline.DynamicCompleteFunc) []readline.PrefixCompleterInterface {
+	var items []readline.PrefixCompleterInterface
+	for _, sub := range cmd.Commands() {
+		children := genItems(sub, args)
+		sub.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			children = append(children, readline.PcItem("--"+f.Name))
+		})
+		path := strings.TrimPrefix(sub.CommandPath(), sub.Root().Name()+" ")
+		if fs := args[path]; len(fs) > 0 {
+			children = append(children, genArgItem(fs))
+		}
+		items = append(items, readline.PcItem(sub.Name(), children...))
+	}
+	return items
+}
+
+func genArgItem(fs []readline.DynamicCompleteFunc) readline.PrefixCompleterInterface {
+	if len(fs) == 1 {
+		return readline.PcItemDynamic(fs[0])
+	}
+	return readline.PcItemDynamic(fs[0], genArgItem(fs[1:]))
+}
+
+// dynamic returns a function to get the candidates from PD, they are cached
+// for candidateTTL. The errors are ignored, nothing is completed then.
+func (c *completer) dynamic(name string, get func(*cobra.Command) ([]string, error)) readline.DynamicCompleteFunc {
+	return func(string) []string {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if cached, ok := c.cache[name]; ok && time.Now().Before(cached.expire) {
+			return cached.values
+		}
+		values, _ := get(c.cmd)
+		c.cache[name] = &candidates{values: values, expire: time.Now().Add(candidateTTL)}
+		return values
+	}
+}
+
+// configOptions returns the options of `config set`, which are the JSON tags
+// of the schedule and replication configurations.
+func configOptions() []string {
+	var options []string
+	for _, t := range []reflect.Type{reflect.TypeOf(config.ScheduleConfig{}), reflect.TypeOf(config.ReplicationConfig{})} {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			// The schedulers are not set by `config set`.
+			kind := field.Type.Kind()
+			if kind == reflect.Map || (kind == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct) {
+				continue
+			}
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+				options = append(options, tag)
+			}
+		}
+	}
+	return options
+}
@@ -36,8 +36,8 @@ func init() {
 	cobra.EnablePrefixMatching = true
 }
 
-// Start run Command
-func Start(args []string) {
+// getBasicCmd returns the root command with all the subcommands.
+func getBasicCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "pdctl",
 		Short: "Placement Driver control",
@@ -46,6 +46,7 @@ func Start(args []string) {
 	rootCmd.Flags().StringVar(&commandFlags.CAPath, "cacert", "", "path of file that contains list of trusted SSL CAs.")
 	rootCmd.Flags().StringVar(&commandFlags.CertPath, "cert", "", "path of file that contains X509 certificate in PEM format.")
 	rootCmd.Flags().StringVar(&commandFlags.KeyPath, "key", "", "path of file that contains X509 key in PEM format.")
+	command.AddOutputFlags(rootCmd)
 	rootCmd.AddCommand(
 		command.NewConfigCommand(),
 		command.NewRegionCommand(),
@@ -63,8 +64,18 @@ func Start(args []string) {
 		command.NewTableNamespaceCommand(),
 		command.NewHealthCommand(),
 		command.NewLogCommand(),
+		command.NewRemoveFailedStoresCommand(),
+		command.NewReplicationModeCommand(),
+		command.NewApplyCommand(),
+		command.NewDiffCommand(),
+		command.NewComponentConfigCommand(),
 	)
+	return rootCmd
+}
 
+// Start run Command
+func Start(args []string) {
+	rootCmd := getBasicCmd()
 	rootCmd.SetArgs(args)
 	rootCmd.SilenceErrors = true
 	rootCmd.ParseFlags(args)
@@ -0,0 +1,299 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server/kv"
+	"github.com/pkg/errors"
+)
+
+// Version is the version of the archive format written by this tool.
+const Version = 1
+
+const (
+	rangeLimit   = 1000
+	allocIDKey   = "alloc_id"
+	clusterKey   = "raft"
+	storePrefix  = "raft/s/"
+	regionPrefix = "raft/r/"
+)
+
+// skippedKeys are the keys which are owned by the running PD leader, they are
+// neither backed up nor restored.
+var skippedKeys = map[string]struct{}{
+	"leader":    {},
+	"timestamp": {},
+}
+
+// Entry is a key-value pair in the archive.
+type Entry struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// Archive is a snapshot of the PD metadata of a cluster.
+type Archive struct {
+	Version    int       `json:"version"`
+	ClusterID  uint64    `json:"cluster_id"`
+	CreateTime time.Time `json:"create_time"`
+	// Checksum is the hex encoded SHA-256 of the cluster ID and the entries.
+	Checksum string `json:"checksum"`
+	// Etcd holds the keys under the root path of the cluster in etcd.
+	Etcd []Entry `json:"etcd"`
+	// Regions holds the keys in the region storage. It is empty if the region
+	// storage is not backed up.
+	Regions []Entry `json:"regions,omitempty"`
+}
+
+// IsBootstrapped checks whether the cluster meta is saved in the kv.
+func IsBootstrapped(etcdKV kv.Base) (bool, error) {
+	value, err := etcdKV.Load(clusterKey)
+	if err != nil {
+		return false, err
+	}
+	return value != "", nil
+}
+
+// Backup takes a snapshot of all keys of the cluster. etcdKV should use the
+// root path of the cluster, and regionKV is optional.
+func Backup(clusterID uint64, etcdKV kv.Base, regionKV kv.Base) (*Archive, error) {
+	a := &Archive{
+		Version:    Version,
+		ClusterID:  clusterID,
+		CreateTime: time.Now(),
+	}
+	var err error
+	if a.Etcd, err = loadAll(etcdKV); err != nil {
+		return nil, err
+	}
+	if regionKV != nil {
+		if a.Regions, err = loadAll(regionKV); err != nil {
+			return nil, err
+		}
+	}
+	a.Checksum = a.checksum()
+	return a, nil
+}
+
+// revisionLoader is implemented by the etcd kv, which can read the keys at a
+// revision.
+type revisionLoader interface {
+	LoadRangeAtRevision(key, endKey string, limit int, revision int64) ([]string, []string, int64, error)
+}
+
+func loadAll(base kv.Base) ([]Entry, error) {
+	var entries []Entry
+	nextKey := ""
+	// The end key is larger than all printable keys under the root path.
+	endKey := "\xff"
+	loadRange := func(key string) ([]string, []string, error) {
+		return base.LoadRange(key, endKey, rangeLimit)
+	}
+	if loader, ok := base.(revisionLoader); ok {
+		// All pages are read at the revision of the first page, so that the
+		// backup is a consistent snapshot even if PD is running.
+		var revision int64
+		loadRange = func(key string) ([]string, []string, error) {
+			keys, values, rev, err := loader.LoadRangeAtRevision(key, endKey, rangeLimit, revision)
+			revision = rev
+			return keys, values, err
+		}
+	}
+	for {
+		keys, values, err := loadRange(nextKey)
+		if err != nil {
+			return nil, err
+		}
+		for i, key := range keys {
+			if _, ok := skippedKeys[key]; ok {
+				continue
+			}
+			entries = append(entries, Entry{Key: key, Value: []byte(values[i])})
+		}
+		if len(keys) < rangeLimit {
+			return entries, nil
+		}
+		nextKey = keys[len(keys)-1] + "\x00"
+	}
+}
+
+func (a *Archive) checksum() string {
+	h := sha256.New()
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], a.ClusterID)
+	h.Write(buf[:])
+	write := func(b []byte) {
+		binary.BigEndian.PutUint64(buf[:], uint64(len(b)))
+		h.Write(buf[:])
+		h.Write(b)
+	}
+	for _, entries := range [][]Entry{a.Etcd, a.Regions} {
+		binary.BigEndian.PutUint64(buf[:], uint64(len(entries)))
+		h.Write(buf[:])
+		for _, e := range entries {
+			write([]byte(e.Key))
+			write(e.Value)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Write writes the archive as gzipped JSON.
+func (a *Archive) Write(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(a); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(zw.Close())
+}
+
+// Read reads an archive and verifies its version and checksum.
+func Read(r io.Reader) (*Archive, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer zr.Close()
+	a := &Archive{}
+	if err := json.NewDecoder(zr).Decode(a); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if a.Version <= 0 || a.Version > Version {
+		return nil, errors.Errorf("unsupported archive version %d", a.Version)
+	}
+	if checksum := a.checksum(); checksum != a.Checksum {
+		return nil, errors.Errorf("checksum mismatch: expect %s, got %s", a.Checksum, checksum)
+	}
+	return a, nil
+}
+
+// MaxID returns the largest ID found in the archive, including the saved
+// alloc ID, the store IDs, the region IDs and the peer IDs.
+func (a *Archive) MaxID() (uint64, error) {
+	var maxID uint64
+	observe := func(id uint64) {
+		if id > maxID {
+			maxID = id
+		}
+	}
+	for _, entries := range [][]Entry{a.Etcd, a.Regions} {
+		for _, e := range entries {
+			switch {
+			case e.Key == allocIDKey:
+				id, err := typeutil.BytesToUint64(e.Value)
+				if err != nil {
+					return 0, err
+				}
+				observe(id)
+			case strings.HasPrefix(e.Key, storePrefix):
+				store := &metapb.Store{}
+				if err := store.Unmarshal(e.Value); err != nil {
+					return 0, errors.WithStack(err)
+				}
+				observe(store.GetId())
+			case strings.HasPrefix(e.Key, regionPrefix):
+				region := &metapb.Region{}
+				if err := region.Unmarshal(e.Value); err != nil {
+					return 0, errors.WithStack(err)
+				}
+				observe(region.GetId())
+				for _, peer := range region.GetPeers() {
+					observe(peer.GetId())
+				}
+			}
+		}
+	}
+	return maxID, nil
+}
+
+// RestoreOptions are the options of Restore.
+type RestoreOptions struct {
+	// Force allows to overwrite a bootstrapped cluster, all existing keys of
+	// the cluster are removed before restoring.
+	Force bool
+	// AllocID is the minimum alloc ID to restore, the larger one of it and the
+	// max ID found in the archive is saved.
+	AllocID uint64
+}
+
+// Restore writes the archive into the kv. It returns the restored alloc ID.
+// The keys of each kv are replaced in one transaction, so a failed restore
+// leaves the cluster unchanged. Note that etcd limits the number of operations
+// in a transaction by --max-txn-ops.
+func Restore(a *Archive, etcdKV kv.Base, regionKV kv.Base, opts RestoreOptions) (uint64, error) {
+	cluster, err := etcdKV.Load(clusterKey)
+	if err != nil {
+		return 0, err
+	}
+	if cluster != "" && !opts.Force {
+		return 0, errors.New("the cluster is already bootstrapped, use force to overwrite it")
+	}
+	allocID, err := a.MaxID()
+	if err != nil {
+		return 0, err
+	}
+	if opts.AllocID > allocID {
+		allocID = opts.AllocID
+	}
+
+	entries := append(a.Etcd[:len(a.Etcd):len(a.Etcd)], Entry{Key: allocIDKey, Value: typeutil.Uint64ToBytes(allocID)})
+	// The cluster key is checked, so that the cluster is not bootstrapped or
+	// restored by others in the meantime.
+	if err := replaceAll(etcdKV, []kv.Cmp{kv.ValueEqual(clusterKey, cluster)}, entries); err != nil {
+		return 0, err
+	}
+	// The region storage is a cache of the regions, it is replaced after the
+	// cluster meta is restored.
+	if regionKV != nil && len(a.Regions) > 0 {
+		if err := replaceAll(regionKV, nil, a.Regions); err != nil {
+			return 0, err
+		}
+	}
+	return allocID, nil
+}
+
+// replaceAll removes all existing keys of the kv and saves the entries in one
+// transaction.
+func replaceAll(base kv.Base, conds []kv.Cmp, entries []Entry) error {
+	existing, err := loadAll(base)
+	if err != nil {
+		return err
+	}
+	saved := make(map[string]struct{}, len(entries))
+	ops := make([]kv.Op, 0, len(entries))
+	for _, e := range entries {
+		if _, ok := skippedKeys[e.Key]; ok {
+			continue
+		}
+		saved[e.Key] = struct{}{}
+		ops = append(ops, kv.SaveOp(e.Key, string(e.Value)))
+	}
+	for _, e := range existing {
+		if _, ok := saved[e.Key]; !ok {
+			ops = append(ops, kv.RemoveOp(e.Key))
+		}
+	}
+	return base.Txn(conds, ops...)
+}
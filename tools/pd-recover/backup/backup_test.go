@@ -0,0 +1,133 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/kv"
+	"github.com/pkg/errors"
+)
+
+func TestBackup(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testBackupSuite{})
+
+type testBackupSuite struct{}
+
+func (s *testBackupSuite) prepare(c *C) (kv.Base, kv.Base) {
+	etcdKV, regionKV := kv.NewMemoryKV(), kv.NewMemoryKV()
+	storage := core.NewStorage(etcdKV)
+	c.Assert(storage.SaveMeta(&metapb.Cluster{Id: 1, MaxPeerCount: 3}), IsNil)
+	c.Assert(storage.SaveStore(&metapb.Store{Id: 2000, Address: "s1"}), IsNil)
+	c.Assert(storage.SaveRegion(&metapb.Region{Id: 10, Peers: []*metapb.Peer{{Id: 11, StoreId: 2000}}}), IsNil)
+	c.Assert(storage.SaveGCSafePoint(100), IsNil)
+	c.Assert(etcdKV.Save(allocIDKey, string(typeutil.Uint64ToBytes(1000))), IsNil)
+	c.Assert(etcdKV.Save("leader", "member"), IsNil)
+
+	region := &metapb.Region{Id: 20, Peers: []*metapb.Peer{{Id: 3000, StoreId: 2000}}}
+	value, err := region.Marshal()
+	c.Assert(err, IsNil)
+	c.Assert(regionKV.Save("raft/r/00000000000000000020", string(value)), IsNil)
+	return etcdKV, regionKV
+}
+
+func (s *testBackupSuite) TestBackupAndRestore(c *C) {
+	etcdKV, regionKV := s.prepare(c)
+	archive, err := Backup(1, etcdKV, regionKV)
+	c.Assert(err, IsNil)
+	// The leader key is not backed up.
+	c.Assert(archive.Etcd, HasLen, 5)
+	c.Assert(archive.Regions, HasLen, 1)
+
+	var buf bytes.Buffer
+	c.Assert(archive.Write(&buf), IsNil)
+	archive, err = Read(bytes.NewReader(buf.Bytes()))
+	c.Assert(err, IsNil)
+	c.Assert(archive.ClusterID, Equals, uint64(1))
+	maxID, err := archive.MaxID()
+	c.Assert(err, IsNil)
+	c.Assert(maxID, Equals, uint64(3000))
+
+	// Restore to an empty cluster.
+	newEtcdKV, newRegionKV := kv.NewMemoryKV(), kv.NewMemoryKV()
+	allocID, err := Restore(archive, newEtcdKV, newRegionKV, RestoreOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(allocID, Equals, uint64(3000))
+	storage := core.NewStorage(newEtcdKV)
+	store := &metapb.Store{}
+	ok, err := storage.LoadStore(2000, store)
+	c.Assert(ok, IsTrue)
+	c.Assert(err, IsNil)
+	c.Assert(store.GetAddress(), Equals, "s1")
+	safePoint, err := storage.LoadGCSafePoint()
+	c.Assert(err, IsNil)
+	c.Assert(safePoint, Equals, uint64(100))
+	value, err := newEtcdKV.Load(allocIDKey)
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, string(typeutil.Uint64ToBytes(3000)))
+	value, err = newRegionKV.Load("raft/r/00000000000000000020")
+	c.Assert(err, IsNil)
+	c.Assert(value, Not(Equals), "")
+
+	// The bootstrapped cluster is not overwritten unless forced.
+	_, err = Restore(archive, newEtcdKV, newRegionKV, RestoreOptions{})
+	c.Assert(err, NotNil)
+	c.Assert(newEtcdKV.Save("stale", "value"), IsNil)
+	// A failed restore leaves the cluster unchanged.
+	_, err = Restore(archive, &failedTxnKV{newEtcdKV}, newRegionKV, RestoreOptions{Force: true})
+	c.Assert(err, NotNil)
+	value, err = newEtcdKV.Load("stale")
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, "value")
+	allocID, err = Restore(archive, newEtcdKV, newRegionKV, RestoreOptions{Force: true, AllocID: 5000})
+	c.Assert(err, IsNil)
+	c.Assert(allocID, Equals, uint64(5000))
+	value, err = newEtcdKV.Load("stale")
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, "")
+}
+
+type failedTxnKV struct {
+	kv.Base
+}
+
+func (kv *failedTxnKV) Txn(conds []kv.Cmp, ops ...kv.Op) error {
+	return errors.New("failed to commit transaction")
+}
+
+func (s *testBackupSuite) TestChecksum(c *C) {
+	etcdKV, regionKV := s.prepare(c)
+	archive, err := Backup(1, etcdKV, regionKV)
+	c.Assert(err, IsNil)
+	archive.Etcd[0].Value = []byte("corrupted")
+	var buf bytes.Buffer
+	c.Assert(archive.Write(&buf), IsNil)
+	_, err = Read(&buf)
+	c.Assert(err, ErrorMatches, "checksum mismatch.*")
+
+	archive.Version = Version + 1
+	archive.Checksum = archive.checksum()
+	buf.Reset()
+	c.Assert(archive.Write(&buf), IsNil)
+	_, err = Read(&buf)
+	c.Assert(err, ErrorMatches, "unsupported archive version.*")
+}
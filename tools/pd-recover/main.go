@@ -12,6 +12,11 @@ import (
 
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/kv"
+	"github.com/pingcap/pd/tools/pd-recover/backup"
+	"github.com/pingcap/pd/tools/pd-recover/rebuild"
+	"github.com/pkg/errors"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/pkg/transport"
 )
@@ -23,6 +28,16 @@ var (
 	caPath    = flag.String("cacert", "", "path of file that contains list of trusted SSL CAs.")
 	certPath  = flag.String("cert", "", "path of file that contains X509 certificate in PEM format..")
 	keyPath   = flag.String("key", "", "path of file that contains X509 key in PEM format.")
+
+	regionFiles = flag.String("region-files", "", "comma separated region files in the regions-dump or JSON format, used to rebuild the stores and regions")
+
+	// flags of the backup and restore subcommands.
+	filePath          = flag.String("file", "", "path of the backup archive")
+	regionStoragePath = flag.String("region-storage", "", "path of the region storage, it is <data-dir>/region-meta of a stopped PD")
+	force             = flag.Bool("force", false, "overwrite the cluster even if it is already bootstrapped")
+
+	// flags of the check subcommand.
+	repair = flag.Bool("repair", false, "remove the stale regions found by the check")
 )
 
 const (
@@ -39,11 +54,37 @@ func exitErr(err error) {
 }
 
 func main() {
-	flag.Parse()
+	var subcommand string
+	args := os.Args[1:]
+	if len(args) > 0 && (args[0] == "backup" || args[0] == "restore" || args[0] == "check") {
+		subcommand, args = args[0], args[1:]
+	}
+	// The flag set exits on errors.
+	_ = flag.CommandLine.Parse(args)
+
+	switch subcommand {
+	case "backup":
+		runBackup()
+		return
+	case "restore":
+		runRestore()
+		return
+	case "check":
+		runCheck()
+		return
+	}
+
 	if *clusterID == 0 {
 		fmt.Println("please specify safe cluster-id")
 		return
 	}
+	var rebuilt *rebuild.Output
+	if *regionFiles != "" {
+		rebuilt = rebuildMeta()
+		if rebuilt.AllocID > *allocID {
+			*allocID = rebuilt.AllocID
+		}
+	}
 	if *allocID == 0 {
 		fmt.Println("please specify safe alloc-id")
 		return
@@ -53,30 +94,30 @@ func main() {
 	clusterRootPath := path.Join(rootPath, "raft")
 	raftBootstrapTimeKey := path.Join(clusterRootPath, "status", "raft_bootstrap_time")
 
-	urls := strings.Split(*endpoints, ",")
-
-	tlsInfo := transport.TLSInfo{
-		CertFile:      *certPath,
-		KeyFile:       *keyPath,
-		TrustedCAFile: *caPath,
-	}
-	tlsConfig, err := tlsInfo.ClientConfig()
-	if err != nil {
-		fmt.Println("failed to connect: err")
-		return
-	}
-
-	client, err := clientv3.New(clientv3.Config{
-		Endpoints:   urls,
-		DialTimeout: etcdTimeout,
-		TLS:         tlsConfig,
-	})
-	if err != nil {
-		exitErr(err)
-	}
+	client := newClient()
 	ctx, cancel := context.WithTimeout(client.Ctx(), requestTimeout)
 	defer cancel()
 
+	if rebuilt != nil {
+		// The stores and regions are saved before bootstrapping, so check the
+		// bootstrap status first to avoid overwriting a bootstrapped cluster.
+		etcdKV := kv.NewEtcdKVBase(client, rootPath)
+		if bootstrapped, err := backup.IsBootstrapped(etcdKV); err != nil {
+			exitErr(err)
+		} else if bootstrapped {
+			fmt.Println("failed to recover: the cluster is already bootstrapped")
+			return
+		}
+		var regionKV kv.Base
+		if levelDB := openRegionStorage(); levelDB != nil {
+			defer levelDB.Close()
+			regionKV = levelDB
+		}
+		if err := rebuild.Save(rebuilt, etcdKV, regionKV); err != nil {
+			exitErr(err)
+		}
+	}
+
 	var ops []clientv3.Op
 	// recover cluster_id
 	ops = append(ops, clientv3.OpPut(pdClusterIDPath, string(typeutil.Uint64ToBytes(*clusterID))))
@@ -108,5 +149,182 @@ func main() {
 		fmt.Println("failed to recover: the cluster is already bootstrapped")
 		return
 	}
-	fmt.Println("recover success! please restart the PD cluster")
+	if rebuilt != nil {
+		fmt.Printf("rebuilt %d stores and %d regions, %d stale regions are dropped\n",
+			len(rebuilt.Stores), len(rebuilt.Regions), rebuilt.Conflicts)
+	}
+	fmt.Printf("recover success! the alloc ID is %d, please restart the PD cluster\n", *allocID)
+}
+
+func rebuildMeta() *rebuild.Output {
+	input := &rebuild.Input{}
+	for _, name := range strings.Split(*regionFiles, ",") {
+		f, err := os.Open(name)
+		if err != nil {
+			exitErr(err)
+		}
+		err = rebuild.Parse(f, input)
+		f.Close()
+		if err != nil {
+			exitErr(errors.Wrapf(err, "failed to parse %s", name))
+		}
+	}
+	return rebuild.Rebuild(input)
+}
+
+func newClient() *clientv3.Client {
+	urls := strings.Split(*endpoints, ",")
+
+	tlsInfo := transport.TLSInfo{
+		CertFile:      *certPath,
+		KeyFile:       *keyPath,
+		TrustedCAFile: *caPath,
+	}
+	tlsConfig, err := tlsInfo.ClientConfig()
+	if err != nil {
+		exitErr(err)
+	}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   urls,
+		DialTimeout: etcdTimeout,
+		TLS:         tlsConfig,
+	})
+	if err != nil {
+		exitErr(err)
+	}
+	return client
+}
+
+// loadClusterID returns the cluster ID saved in etcd, it is 0 if not found.
+func loadClusterID(client *clientv3.Client) uint64 {
+	ctx, cancel := context.WithTimeout(client.Ctx(), requestTimeout)
+	defer cancel()
+	resp, err := client.Get(ctx, pdClusterIDPath)
+	if err != nil {
+		exitErr(err)
+	}
+	if len(resp.Kvs) == 0 {
+		return 0
+	}
+	id, err := typeutil.BytesToUint64(resp.Kvs[0].Value)
+	if err != nil {
+		exitErr(err)
+	}
+	return id
+}
+
+func openRegionStorage() *kv.LeveldbKV {
+	if *regionStoragePath == "" {
+		return nil
+	}
+	regionKV, err := kv.NewLeveldbKV(*regionStoragePath)
+	if err != nil {
+		exitErr(err)
+	}
+	return regionKV
+}
+
+func runBackup() {
+	if *filePath == "" {
+		fmt.Println("please specify the backup file")
+		return
+	}
+	client := newClient()
+	id := *clusterID
+	if id == 0 {
+		if id = loadClusterID(client); id == 0 {
+			fmt.Println("cluster ID is not found, please specify cluster-id")
+			return
+		}
+	}
+	etcdKV := kv.NewEtcdKVBase(client, path.Join(pdRootPath, strconv.FormatUint(id, 10)))
+	var regionKV kv.Base
+	if levelDB := openRegionStorage(); levelDB != nil {
+		defer levelDB.Close()
+		regionKV = levelDB
+	}
+
+	archive, err := backup.Backup(id, etcdKV, regionKV)
+	if err != nil {
+		exitErr(err)
+	}
+	f, err := os.Create(*filePath)
+	if err != nil {
+		exitErr(err)
+	}
+	defer f.Close()
+	if err := archive.Write(f); err != nil {
+		exitErr(err)
+	}
+	fmt.Printf("backup success! %d keys and %d regions are saved\n", len(archive.Etcd), len(archive.Regions))
+}
+
+func runRestore() {
+	if *filePath == "" {
+		fmt.Println("please specify the backup file")
+		return
+	}
+	f, err := os.Open(*filePath)
+	if err != nil {
+		exitErr(err)
+	}
+	archive, err := backup.Read(f)
+	f.Close()
+	if err != nil {
+		exitErr(err)
+	}
+	if *clusterID != 0 && *clusterID != archive.ClusterID {
+		fmt.Printf("failed to restore: the cluster ID of the backup is %d\n", archive.ClusterID)
+		return
+	}
+
+	client := newClient()
+	if id := loadClusterID(client); id != 0 && id != archive.ClusterID {
+		fmt.Printf("failed to restore: the cluster ID of the PD cluster is %d, but the backup is %d\n", id, archive.ClusterID)
+		return
+	}
+	etcdKV := kv.NewEtcdKVBase(client, path.Join(pdRootPath, strconv.FormatUint(archive.ClusterID, 10)))
+	var regionKV kv.Base
+	if levelDB := openRegionStorage(); levelDB != nil {
+		defer levelDB.Close()
+		regionKV = levelDB
+	}
+
+	restoredID, err := backup.Restore(archive, etcdKV, regionKV, backup.RestoreOptions{Force: *force, AllocID: *allocID})
+	if err != nil {
+		fmt.Printf("failed to restore: %v\n", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(client.Ctx(), requestTimeout)
+	defer cancel()
+	if _, err := client.Put(ctx, pdClusterIDPath, string(typeutil.Uint64ToBytes(archive.ClusterID))); err != nil {
+		exitErr(err)
+	}
+	fmt.Printf("restore success! the alloc ID is %d, please restart the PD cluster\n", restoredID)
+}
+
+func runCheck() {
+	levelDB := openRegionStorage()
+	if levelDB == nil {
+		fmt.Println("please specify the region storage")
+		return
+	}
+	defer levelDB.Close()
+
+	report, err := core.CheckRegions("region-storage", levelDB, nil, *repair)
+	if err != nil {
+		exitErr(err)
+	}
+	for _, issue := range report.Issues {
+		switch issue.Type {
+		case core.RegionIssueHole:
+			fmt.Printf("hole: [%s, %s)\n", issue.StartKey, issue.EndKey)
+		default:
+			fmt.Printf("%s: region %d [%s, %s) with region %d, stale: %t, removed: %t\n",
+				issue.Type, issue.RegionID, issue.StartKey, issue.EndKey, issue.OtherRegionID, issue.Stale, issue.Removed)
+		}
+	}
+	fmt.Printf("checked %d regions, %d stale regions are found, %d are removed\n",
+		report.RegionCount, report.StaleCount, report.RemovedCount)
 }
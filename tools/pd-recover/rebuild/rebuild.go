@@ -0,0 +1,234 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebuild
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/kv"
+	"github.com/pkg/errors"
+)
+
+// jsonRegion is the region in the JSON file, it is compatible with the output
+// of `pd-ctl region`, the keys are encoded in hex.
+type jsonRegion struct {
+	ID          uint64              `json:"id"`
+	StartKey    string              `json:"start_key"`
+	EndKey      string              `json:"end_key"`
+	RegionEpoch *metapb.RegionEpoch `json:"epoch,omitempty"`
+	Peers       []*metapb.Peer      `json:"peers,omitempty"`
+}
+
+// jsonStore is the store in the JSON file. It is either the store meta, or the
+// output of `pd-ctl store` which holds the meta in the "store" field.
+type jsonStore struct {
+	metapb.Store
+	Meta *metapb.Store `json:"store,omitempty"`
+}
+
+// jsonFile is the format of the JSON file.
+type jsonFile struct {
+	Stores  []*jsonStore  `json:"stores"`
+	Regions []*jsonRegion `json:"regions"`
+}
+
+// Input is the metadata parsed from the files.
+type Input struct {
+	Stores  []*metapb.Store
+	Regions []*metapb.Region
+}
+
+// Parse parses a file in the regions-dump format or the JSON format, the format
+// is detected by the first non-space character.
+func Parse(r io.Reader, input *Input) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseJSON(trimmed, input)
+	}
+	return parseDump(trimmed, input)
+}
+
+// parseDump parses the output of regions-dump, each line is a region in the
+// compact text format with the hex encoded keys.
+func parseDump(data []byte, input *Input) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		region := &metapb.Region{}
+		if err := proto.UnmarshalText(text, region); err != nil {
+			return errors.Wrapf(err, "line %d", line)
+		}
+		var err error
+		if region.StartKey, err = decodeKey(string(region.StartKey)); err != nil {
+			return errors.Wrapf(err, "line %d", line)
+		}
+		if region.EndKey, err = decodeKey(string(region.EndKey)); err != nil {
+			return errors.Wrapf(err, "line %d", line)
+		}
+		input.Regions = append(input.Regions, region)
+	}
+	return errors.WithStack(scanner.Err())
+}
+
+func parseJSON(data []byte, input *Input) error {
+	f := &jsonFile{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, s := range f.Stores {
+		store := &s.Store
+		if s.Meta != nil {
+			store = s.Meta
+		}
+		input.Stores = append(input.Stores, store)
+	}
+	for _, r := range f.Regions {
+		region := &metapb.Region{
+			Id:          r.ID,
+			RegionEpoch: r.RegionEpoch,
+			Peers:       r.Peers,
+		}
+		var err error
+		if region.StartKey, err = decodeKey(r.StartKey); err != nil {
+			return errors.Wrapf(err, "region %d", r.ID)
+		}
+		if region.EndKey, err = decodeKey(r.EndKey); err != nil {
+			return errors.Wrapf(err, "region %d", r.ID)
+		}
+		input.Regions = append(input.Regions, region)
+	}
+	return nil
+}
+
+func decodeKey(key string) ([]byte, error) {
+	b, err := hex.DecodeString(key)
+	return b, errors.WithStack(err)
+}
+
+// Output is the rebuilt metadata.
+type Output struct {
+	Stores  []*metapb.Store
+	Regions []*metapb.Region
+	// AllocID is the largest store, region and peer ID.
+	AllocID uint64
+	// Conflicts is the number of regions dropped because of the stale epoch.
+	Conflicts int
+}
+
+// Rebuild resolves the conflicts of the regions and builds the stores. If a
+// region is reported more than once, or it overlaps with the other regions,
+// the one with the larger epoch wins. The stores which are only referred by
+// the peers are built as up stores with no address, and their addresses are
+// updated when the TiKVs start.
+func Rebuild(input *Input) *Output {
+	res := &Output{}
+	observe := func(id uint64) {
+		if id > res.AllocID {
+			res.AllocID = id
+		}
+	}
+
+	regions := make([]*metapb.Region, len(input.Regions))
+	copy(regions, input.Regions)
+	sort.SliceStable(regions, func(i, j int) bool {
+		return isOlder(regions[i].GetRegionEpoch(), regions[j].GetRegionEpoch())
+	})
+	regionsInfo := core.NewRegionsInfo()
+	for _, region := range regions {
+		if origin := regionsInfo.GetRegion(region.GetId()); origin != nil {
+			res.Conflicts++
+		}
+		res.Conflicts += len(regionsInfo.SetRegion(core.NewRegionInfo(region, nil)))
+	}
+
+	stores := make(map[uint64]*metapb.Store)
+	for _, store := range input.Stores {
+		stores[store.GetId()] = store
+	}
+	for _, region := range regionsInfo.GetRegions() {
+		meta := region.GetMeta()
+		res.Regions = append(res.Regions, meta)
+		observe(meta.GetId())
+		for _, peer := range meta.GetPeers() {
+			observe(peer.GetId())
+			if _, ok := stores[peer.GetStoreId()]; !ok {
+				stores[peer.GetStoreId()] = &metapb.Store{Id: peer.GetStoreId()}
+			}
+		}
+	}
+	for _, store := range stores {
+		res.Stores = append(res.Stores, store)
+		observe(store.GetId())
+	}
+	sort.Slice(res.Regions, func(i, j int) bool { return res.Regions[i].GetId() < res.Regions[j].GetId() })
+	sort.Slice(res.Stores, func(i, j int) bool { return res.Stores[i].GetId() < res.Stores[j].GetId() })
+	return res
+}
+
+func isOlder(a, b *metapb.RegionEpoch) bool {
+	if a.GetVersion() != b.GetVersion() {
+		return a.GetVersion() < b.GetVersion()
+	}
+	return a.GetConfVer() < b.GetConfVer()
+}
+
+// Save saves the stores and the regions into the kv under the root path of the
+// cluster. The regions are also saved into the region storage if it is not nil.
+func Save(res *Output, etcdKV kv.Base, regionKV kv.Base) error {
+	for _, store := range res.Stores {
+		if err := saveProto(etcdKV, path.Join("raft", "s", fmt.Sprintf("%020d", store.GetId())), store); err != nil {
+			return err
+		}
+	}
+	for _, region := range res.Regions {
+		key := path.Join("raft", "r", fmt.Sprintf("%020d", region.GetId()))
+		if err := saveProto(etcdKV, key, region); err != nil {
+			return err
+		}
+		if regionKV != nil {
+			if err := saveProto(regionKV, key, region); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func saveProto(base kv.Base, key string, msg proto.Message) error {
+	value, err := proto.Marshal(msg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return base.Save(key, string(value))
+}
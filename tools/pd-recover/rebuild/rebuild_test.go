@@ -0,0 +1,102 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebuild
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/kv"
+)
+
+func TestRebuild(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testRebuildSuite{})
+
+type testRebuildSuite struct{}
+
+func newRegion(id uint64, start, end string, version, confVer uint64, peers ...uint64) *metapb.Region {
+	region := &metapb.Region{
+		Id:          id,
+		StartKey:    []byte(start),
+		EndKey:      []byte(end),
+		RegionEpoch: &metapb.RegionEpoch{Version: version, ConfVer: confVer},
+	}
+	for i := 0; i+1 < len(peers); i += 2 {
+		region.Peers = append(region.Peers, &metapb.Peer{Id: peers[i], StoreId: peers[i+1]})
+	}
+	return region
+}
+
+func (s *testRebuildSuite) TestParse(c *C) {
+	region := newRegion(10, "a", "b", 2, 3, 11, 1, 12, 2)
+	var dump strings.Builder
+	fmt.Fprintln(&dump, core.RegionToHexMeta(region).Region)
+	input := &Input{}
+	c.Assert(Parse(strings.NewReader(dump.String()), input), IsNil)
+	c.Assert(input.Regions, HasLen, 1)
+	c.Assert(input.Regions[0], DeepEquals, region)
+
+	json := `{
+		"stores": [{"id": 1, "address": "tikv1"}, {"store": {"id": 2, "address": "tikv2"}}],
+		"regions": [{"id": 20, "start_key": "62", "end_key": "", "epoch": {"version": 1, "conf_ver": 1}, "peers": [{"id": 21, "store_id": 3}]}]
+	}`
+	c.Assert(Parse(strings.NewReader(json), input), IsNil)
+	c.Assert(input.Stores, HasLen, 2)
+	c.Assert(input.Stores[1].GetAddress(), Equals, "tikv2")
+	c.Assert(input.Regions, HasLen, 2)
+	c.Assert(input.Regions[1].GetStartKey(), DeepEquals, []byte("b"))
+
+	c.Assert(Parse(strings.NewReader("id:1 start_key:\"zz\""), input), NotNil)
+}
+
+func (s *testRebuildSuite) TestRebuild(c *C) {
+	input := &Input{
+		Stores: []*metapb.Store{{Id: 1, Address: "tikv1"}},
+		Regions: []*metapb.Region{
+			// Region 1 is reported by two stores, the one with larger conf version wins.
+			newRegion(1, "", "m", 5, 2, 2, 1, 3, 2),
+			newRegion(1, "", "m", 5, 3, 2, 1, 3, 2, 4, 3),
+			// Region 5 is split into region 5 and region 6 later.
+			newRegion(5, "m", "", 1, 1, 6, 1),
+			newRegion(5, "m", "t", 2, 1, 6, 1),
+			newRegion(7, "t", "", 2, 1, 100, 1),
+		},
+	}
+	res := Rebuild(input)
+	c.Assert(res.Conflicts, Equals, 2)
+	c.Assert(res.Regions, HasLen, 3)
+	c.Assert(res.Regions[0].GetPeers(), HasLen, 3)
+	c.Assert(res.Regions[1].GetEndKey(), DeepEquals, []byte("t"))
+	c.Assert(res.Stores, HasLen, 3)
+	c.Assert(res.Stores[0].GetAddress(), Equals, "tikv1")
+	c.Assert(res.AllocID, Equals, uint64(100))
+
+	etcdKV, regionKV := kv.NewMemoryKV(), kv.NewMemoryKV()
+	c.Assert(Save(res, etcdKV, regionKV), IsNil)
+	storage := core.NewStorage(etcdKV)
+	store := &metapb.Store{}
+	ok, err := storage.LoadStore(3, store)
+	c.Assert(ok, IsTrue)
+	c.Assert(err, IsNil)
+	keys, _, err := regionKV.LoadRange("", "\xff", 10)
+	c.Assert(err, IsNil)
+	c.Assert(keys, HasLen, 3)
+}
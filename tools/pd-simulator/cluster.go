@@ -0,0 +1,142 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/pd/pkg/tempurl"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/api"
+	"github.com/pingcap/pd/server/config"
+	"github.com/pingcap/pd/tools/pd-simulator/simulator"
+	"github.com/pingcap/pd/tools/pd-simulator/simulator/simutil"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// pdCluster is the PD servers started by the simulator.
+type pdCluster struct {
+	sync.Mutex
+	servers []*server.Server
+	configs []*config.Config
+	killed  []bool
+}
+
+// newPDCluster creates the PD servers, they share the configuration of the
+// simulator except the names, the addresses and the data directories.
+func newPDCluster(simConfig *simulator.SimConfig, count int) *pdCluster {
+	c := &pdCluster{killed: make([]bool, count)}
+	if count == 1 {
+		s, _ := NewSingleServer(simConfig)
+		c.servers = []*server.Server{s}
+		c.configs = []*config.Config{simConfig.ServerConfig}
+		return c
+	}
+
+	var members []string
+	for i := 0; i < count; i++ {
+		cfg := simConfig.ServerConfig.Clone()
+		cfg.Name = fmt.Sprintf("pd-%d", i+1)
+		cfg.ClientUrls = tempurl.Alloc()
+		cfg.PeerUrls = tempurl.Alloc()
+		cfg.AdvertiseClientUrls = cfg.ClientUrls
+		cfg.AdvertisePeerUrls = cfg.PeerUrls
+		cfg.DataDir, _ = ioutil.TempDir("/tmp", "test_pd")
+		members = append(members, fmt.Sprintf("%s=%s", cfg.Name, cfg.PeerUrls))
+		c.configs = append(c.configs, cfg)
+	}
+	// The data directory of the simulator configuration is not used.
+	cleanServer(simConfig.ServerConfig)
+	for _, cfg := range c.configs {
+		cfg.InitialCluster = strings.Join(members, ",")
+	}
+	for _, cfg := range c.configs {
+		setupServerLogger(cfg)
+		s, err := server.CreateServer(cfg, api.NewHandler)
+		if err != nil {
+			simutil.Logger.Fatal("create server failed", zap.Error(err))
+		}
+		c.servers = append(c.servers, s)
+	}
+	return c
+}
+
+// run starts the servers and waits for the leader.
+func (c *pdCluster) run() error {
+	// The servers wait for each other to start the embedded etcd.
+	errCh := make(chan error, len(c.servers))
+	for _, s := range c.servers {
+		go func(s *server.Server) {
+			errCh <- s.Run(context.Background())
+		}(s)
+	}
+	for range c.servers {
+		if err := <-errCh; err != nil {
+			return err
+		}
+	}
+	for c.leader() < 0 {
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil
+}
+
+// leader returns the index of the leader, it is -1 if there is no leader.
+func (c *pdCluster) leader() int {
+	c.Lock()
+	defer c.Unlock()
+	for i, s := range c.servers {
+		if !c.killed[i] && !s.IsClosed() && s.GetMember().IsLeader() {
+			return i
+		}
+	}
+	return -1
+}
+
+// addrs returns the client addresses of the servers separated by comma.
+func (c *pdCluster) addrs() string {
+	addrs := make([]string, 0, len(c.servers))
+	for _, s := range c.servers {
+		addrs = append(addrs, s.GetAddr())
+	}
+	return strings.Join(addrs, ",")
+}
+
+// KillLeader implements the simulator.PDCluster interface.
+func (c *pdCluster) KillLeader() error {
+	i := c.leader()
+	if i < 0 {
+		return errors.New("no PD leader to kill")
+	}
+	c.Lock()
+	c.killed[i] = true
+	c.Unlock()
+	simutil.Logger.Info("kill PD leader", zap.String("name", c.configs[i].Name))
+	c.servers[i].Close()
+	return nil
+}
+
+// clean closes the servers and removes the data.
+func (c *pdCluster) clean() {
+	for i, s := range c.servers {
+		s.Close()
+		cleanServer(c.configs[i])
+	}
+}
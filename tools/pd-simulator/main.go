@@ -17,13 +17,16 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"math/rand"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/pingcap/log"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/pkg/logutil"
 	"github.com/pingcap/pd/server"
 	"github.com/pingcap/pd/server/api"
@@ -45,11 +48,19 @@ var (
 	pdAddr                      = flag.String("pd", "", "pd address")
 	configFile                  = flag.String("config", "conf/simconfig.toml", "config file")
 	caseName                    = flag.String("case", "", "case name")
+	caseFile                    = flag.String("case-file", "", "case file in TOML or YAML format")
 	serverLogLevel              = flag.String("serverLog", "fatal", "pd server log level.")
 	simLogLevel                 = flag.String("simLog", "fatal", "simulator log level.")
 	regionNum                   = flag.Int("regionNum", 0, "regionNum of one store")
 	storeNum                    = flag.Int("storeNum", 0, "storeNum")
 	enableTransferRegionCounter = flag.Bool("enableTransferRegionCounter", false, "enableTransferRegionCounter")
+	virtualClock                = flag.Bool("virtual-clock", false, "run on the virtual clock as fast as possible")
+	seed                        = flag.Int64("seed", 0, "seed of the randomness, 0 means a random seed")
+	replay                      = flag.String("replay", "", "heartbeat record files to replay, separated by comma")
+	replaySpeed                 = flag.Float64("replay-speed", 1, "multiple of the recorded speed to replay, 0 means as fast as possible")
+	anonymize                   = flag.Bool("anonymize", false, "anonymize the region keys when replaying")
+	pdCount                     = flag.Int("pd-count", 1, "number of the PD servers started by the simulator")
+	reportDir                   = flag.String("report-dir", "", "directory to write the scheduling-quality reports in JSON and HTML")
 )
 
 func main() {
@@ -62,6 +73,20 @@ func main() {
 		analysis.GetTransferCounter().Init(simutil.CaseConfigure.StoreNum, simutil.CaseConfigure.RegionNum)
 	}
 
+	if *replay != "" {
+		runReplay(strings.Split(*replay, ","))
+		return
+	}
+
+	if *caseFile != "" {
+		f, err := cases.LoadCaseFile(*caseFile)
+		if err != nil {
+			simutil.Logger.Fatal("failed to load case file", zap.Error(err))
+		}
+		cases.CaseMap[f.Name] = f.NewCase
+		*caseName = f.Name
+	}
+
 	if *caseName == "" {
 		if *pdAddr != "" {
 			simutil.Logger.Fatal("need to specify one config name")
@@ -84,39 +109,79 @@ func run(simCase string) {
 	if err := simConfig.Adjust(); err != nil {
 		simutil.Logger.Fatal("failed to adjust simulator configuration", zap.Error(err))
 	}
+	if *virtualClock {
+		simConfig.VirtualClock = true
+	}
+	if *seed != 0 {
+		simConfig.Seed = *seed
+	}
+	if simConfig.VirtualClock && *pdAddr != "" {
+		simutil.Logger.Fatal("virtual clock needs the PD started by the simulator")
+	}
+	if *pdAddr == "" && *pdCount < 1 {
+		simutil.Logger.Fatal("invalid PD count", zap.Int("pd-count", *pdCount))
+	}
+	simStart(*pdAddr, *pdCount, simCase, simConfig)
+}
 
+func runReplay(files []string) {
 	if *pdAddr != "" {
-		simStart(*pdAddr, simCase, simConfig)
-	} else {
-		local, clean := NewSingleServer(simConfig)
-		err := local.Run(context.Background())
-		if err != nil {
-			simutil.Logger.Fatal("run server error", zap.Error(err))
+		simutil.Logger.Fatal("replay needs the PD started by the simulator")
+	}
+	simConfig := simulator.NewSimConfig(*serverLogLevel)
+	if *configFile != "" {
+		if _, err := toml.DecodeFile(*configFile, simConfig); err != nil {
+			simutil.Logger.Fatal("failed to decode file ", zap.Error(err))
 		}
-		for {
-			if !local.IsClosed() && local.GetMember().IsLeader() {
-				break
-			}
-			time.Sleep(100 * time.Millisecond)
+	}
+	if err := simConfig.Adjust(); err != nil {
+		simutil.Logger.Fatal("failed to adjust simulator configuration", zap.Error(err))
+	}
+
+	local, clean := NewSingleServer(simConfig)
+	defer clean()
+	if err := local.Run(context.Background()); err != nil {
+		simutil.Logger.Fatal("run server error", zap.Error(err))
+	}
+	for {
+		if !local.IsClosed() && local.GetMember().IsLeader() {
+			break
 		}
-		simStart(local.GetAddr(), simCase, simConfig, clean)
+		time.Sleep(100 * time.Millisecond)
 	}
-}
 
-// NewSingleServer creates a pd server for simulator.
-func NewSingleServer(simConfig *simulator.SimConfig) (*server.Server, server.CleanupFunc) {
-	err := simConfig.ServerConfig.SetupLogger()
-	if err == nil {
-		log.ReplaceGlobals(simConfig.ServerConfig.GetZapLogger(), simConfig.ServerConfig.GetZapLogProperties())
-	} else {
-		log.Fatal("setup logger error", zap.Error(err))
+	start := time.Now()
+	replayer, err := simulator.NewReplayer(local.GetAddr(), files, *replaySpeed, *anonymize)
+	if err != nil {
+		simutil.Logger.Fatal("create replayer error", zap.Error(err))
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc,
+		syscall.SIGHUP,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+		syscall.SIGQUIT)
+	go func() {
+		<-sc
+		cancel()
+	}()
 
-	err = logutil.InitLogger(&simConfig.ServerConfig.Log)
+	err = replayer.Run(ctx)
+	cancel()
+	replayer.Close()
 	if err != nil {
-		log.Fatal("initialize logger error", zap.Error(err))
+		simutil.Logger.Error("replay error", zap.Error(err))
 	}
 
+	stats := replayer.Stats()
+	fmt.Printf("replayed region heartbeats: %d, store heartbeats: %d, time cost: %v\n", stats.RegionHeartbeats, stats.StoreHeartbeats, time.Since(start))
+	fmt.Printf("operators responded: transfer leader: %d, change peer: %d, merge: %d, split: %d\n", stats.TransferLeader, stats.ChangePeer, stats.Merge, stats.SplitRegion)
+}
+
+// NewSingleServer creates a pd server for simulator.
+func NewSingleServer(simConfig *simulator.SimConfig) (*server.Server, server.CleanupFunc) {
+	setupServerLogger(simConfig.ServerConfig)
 	s, err := server.CreateServer(simConfig.ServerConfig, api.NewHandler)
 	if err != nil {
 		panic("create server failed")
@@ -129,40 +194,115 @@ func NewSingleServer(simConfig *simulator.SimConfig) (*server.Server, server.Cle
 	return s, cleanup
 }
 
+func setupServerLogger(cfg *config.Config) {
+	err := cfg.SetupLogger()
+	if err == nil {
+		log.ReplaceGlobals(cfg.GetZapLogger(), cfg.GetZapLogProperties())
+	} else {
+		log.Fatal("setup logger error", zap.Error(err))
+	}
+
+	err = logutil.InitLogger(&cfg.Log)
+	if err != nil {
+		log.Fatal("initialize logger error", zap.Error(err))
+	}
+}
+
 func cleanServer(cfg *config.Config) {
 	// Clean data directory
 	os.RemoveAll(cfg.DataDir)
 }
 
-func simStart(pdAddr string, simCase string, simConfig *simulator.SimConfig, clean ...server.CleanupFunc) {
+// simStart runs the case and prints the result.
+func simStart(pdAddr string, pdCount int, simCase string, simConfig *simulator.SimConfig) {
 	start := time.Now()
+	driver, simResult := simulate(pdAddr, pdCount, simCase, simConfig)
+	duration := time.Since(start)
+	fmt.Printf("%s [%s] total iteration: %d, time cost: %v, seed: %d\n", simResult, simCase, driver.TickCount(), duration, simConfig.Seed)
+	driver.PrintStatistics()
+	report := driver.Report(simCase, simResult, duration)
+	if n := len(report.Samples); n > 0 {
+		last := report.Samples[n-1]
+		var violations int
+		for _, count := range report.ViolationCounts {
+			violations += count
+		}
+		fmt.Printf("region count variance: %.2f, leader count variance: %.2f, moved size: %dMB, violations: %d\n",
+			last.RegionCountVariance, last.LeaderCountVariance, report.MovedSize>>20, violations)
+	}
+	if *reportDir != "" {
+		if err := report.WriteFiles(*reportDir); err != nil {
+			simutil.Logger.Error("failed to write report", zap.Error(err))
+		}
+	}
+	if analysis.GetTransferCounter().IsValid {
+		analysis.GetTransferCounter().PrintResult()
+	}
+
+	if simResult != "OK" {
+		os.Exit(1)
+	}
+}
+
+// simulate runs the case until it is finished or interrupted, and returns the
+// driver and the result. The PD servers are started by the simulator if pdAddr
+// is empty.
+func simulate(pdAddr string, pdCount int, simCase string, simConfig *simulator.SimConfig) (*simulator.Driver, string) {
+	if simConfig.Seed == 0 {
+		simConfig.Seed = time.Now().UnixNano()
+	}
+	rand.Seed(simConfig.Seed)
+	if simConfig.VirtualClock {
+		// It must be set before PD starts to make the scheduling use it.
+		clock.SetVirtual(clock.NewVirtual(time.Now()))
+	}
+
+	var cluster *pdCluster
+	if pdAddr == "" {
+		cluster = newPDCluster(simConfig, pdCount)
+		if err := cluster.run(); err != nil {
+			simutil.Logger.Fatal("run server error", zap.Error(err))
+		}
+		defer cluster.clean()
+		pdAddr = cluster.addrs()
+	}
+
 	driver, err := simulator.NewDriver(pdAddr, simCase, simConfig)
 	if err != nil {
 		simutil.Logger.Fatal("create driver error", zap.Error(err))
 	}
+	if cluster != nil {
+		driver.SetPDCluster(cluster)
+	}
 
 	err = driver.Prepare()
 	if err != nil {
 		simutil.Logger.Fatal("simulator prepare error", zap.Error(err))
 	}
 
-	tickInterval := simConfig.SimTickInterval.Duration
-
-	tick := time.NewTicker(tickInterval)
-	defer tick.Stop()
+	// The ticks run one after another on the virtual clock.
+	closedCh := make(chan time.Time)
+	close(closedCh)
+	var tickCh <-chan time.Time = closedCh
+	if !simConfig.VirtualClock {
+		tick := time.NewTicker(simConfig.SimTickInterval.Duration)
+		defer tick.Stop()
+		tickCh = tick.C
+	}
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc,
 		syscall.SIGHUP,
 		syscall.SIGINT,
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
+	defer signal.Stop(sc)
 
 	simResult := "FAIL"
 
 EXIT:
 	for {
 		select {
-		case <-tick.C:
+		case <-tickCh:
 			driver.Tick()
 			if driver.Check() {
 				simResult = "OK"
@@ -174,17 +314,5 @@ EXIT:
 	}
 
 	driver.Stop()
-	if len(clean) != 0 {
-		clean[0]()
-	}
-
-	fmt.Printf("%s [%s] total iteration: %d, time cost: %v\n", simResult, simCase, driver.TickCount(), time.Since(start))
-	driver.PrintStatistics()
-	if analysis.GetTransferCounter().IsValid {
-		analysis.GetTransferCounter().PrintResult()
-	}
-
-	if simResult != "OK" {
-		os.Exit(1)
-	}
+	return driver, simResult
 }
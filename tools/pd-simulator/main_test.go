@@ -0,0 +1,61 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/pkg/clock"
+	"github.com/pingcap/pd/server/statistics"
+	"github.com/pingcap/pd/tools/pd-simulator/simulator"
+	"github.com/pingcap/pd/tools/pd-simulator/simulator/simutil"
+)
+
+func TestSimulator(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testSimulatorSuite{})
+
+type testSimulatorSuite struct{}
+
+func (s *testSimulatorSuite) SetUpSuite(c *C) {
+	simutil.InitLogger("fatal")
+	simutil.InitCaseConfig(0, 0, false)
+	statistics.Denoising = false
+}
+
+func (s *testSimulatorSuite) TearDownSuite(c *C) {
+	clock.SetVirtual(nil)
+}
+
+func (s *testSimulatorSuite) TestVirtualClockReproducible(c *C) {
+	simCase := "balance-leader"
+	var reports []*simulator.Report
+	for i := 0; i < 2; i++ {
+		simConfig := simulator.NewSimConfig("fatal")
+		_, err := toml.DecodeFile("../../conf/simconfig.toml", simConfig)
+		c.Assert(err, IsNil)
+		c.Assert(simConfig.Adjust(), IsNil)
+		simConfig.VirtualClock = true
+		simConfig.Seed = 1
+		driver, result := simulate("", 1, simCase, simConfig)
+		c.Assert(result, Equals, "OK")
+		reports = append(reports, driver.Report(simCase, result, 0))
+	}
+	// The runs with the same seed converge in the same way.
+	c.Assert(reports[0], DeepEquals, reports[1])
+}
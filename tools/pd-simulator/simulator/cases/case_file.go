@@ -0,0 +1,765 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cases
+
+import (
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ghodss/yaml"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/table"
+	"github.com/pingcap/pd/tools/pd-simulator/simulator/info"
+	"github.com/pingcap/pd/tools/pd-simulator/simulator/simutil"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// The region layouts.
+const (
+	// LayoutUniform places the peers on the stores round-robin.
+	LayoutUniform = "uniform"
+	// LayoutRandom places the peers on the random stores.
+	LayoutRandom = "random"
+	// LayoutSkewed places the leaders of a part of the regions on the first store.
+	LayoutSkewed = "skewed"
+)
+
+// The event types.
+const (
+	EventAddNodes    = "add-nodes"
+	EventDeleteNodes = "delete-nodes"
+	EventStoreDown   = "store-down"
+	EventHotWrite    = "hot-write"
+	EventHotRead     = "hot-read"
+	EventWriteSpot   = "write-spot"
+
+	EventPartitionStores = "partition-stores"
+	EventSlowHeartbeat   = "slow-heartbeat"
+	EventSlowSnapshot    = "slow-snapshot"
+	EventFillDisk        = "fill-disk"
+	EventKillPDLeader    = "kill-pd-leader"
+)
+
+// The aggregations of the checks.
+const (
+	AggregateEach   = "each"
+	AggregateSum    = "sum"
+	AggregateSpread = "spread"
+)
+
+// CaseFile describes a case declaratively. It is decoded from a TOML file, or
+// a YAML file if the file name ends with ".yaml" or ".yml".
+type CaseFile struct {
+	Name    string        `toml:"name" json:"name"`
+	Stores  []StoreGroup  `toml:"stores" json:"stores"`
+	Regions []RegionGroup `toml:"regions" json:"regions"`
+	// TableNumber makes the keys of the regions belong to the tables, so the
+	// regions are laid out per table.
+	TableNumber     int               `toml:"table-number" json:"table-number"`
+	RegionSplitSize typeutil.ByteSize `toml:"region-split-size" json:"region-split-size"`
+	RegionSplitKeys int64             `toml:"region-split-keys" json:"region-split-keys"`
+	Events          []EventSpec       `toml:"events" json:"events"`
+	Checks          []CheckSpec       `toml:"checks" json:"checks"`
+}
+
+// StoreGroup describes the stores with the same settings. The IDs of the
+// stores start from 1 in order, and the reserved stores are numbered after
+// all the others.
+type StoreGroup struct {
+	Count     int               `toml:"count" json:"count"`
+	Capacity  typeutil.ByteSize `toml:"capacity" json:"capacity"`
+	Available typeutil.ByteSize `toml:"available" json:"available"`
+	Version   string            `toml:"version" json:"version"`
+	Labels    map[string]string `toml:"labels" json:"labels"`
+	// Reserved stores are not started at the beginning, they are started by
+	// the add-nodes events with the store settings of the simulator config.
+	Reserved bool `toml:"reserved" json:"reserved"`
+}
+
+// RegionGroup describes the regions generated by a layout.
+type RegionGroup struct {
+	Count    int               `toml:"count" json:"count"`
+	Replicas int               `toml:"replicas" json:"replicas"`
+	Size     typeutil.ByteSize `toml:"size" json:"size"`
+	Keys     int64             `toml:"keys" json:"keys"`
+	Layout   string            `toml:"layout" json:"layout"`
+	// Stores are the stores to place the peers, all the initial stores are
+	// used if it is empty.
+	Stores []uint64 `toml:"stores" json:"stores"`
+	// Skew is the ratio of the regions whose leaders are on the first store
+	// for the skewed layout.
+	Skew float64 `toml:"skew" json:"skew"`
+}
+
+// EventSpec describes an event which takes effect in [StartTick, EndTick),
+// EndTick 0 means the event never ends.
+type EventSpec struct {
+	Type      string `toml:"type" json:"type"`
+	StartTick int64  `toml:"start-tick" json:"start-tick"`
+	EndTick   int64  `toml:"end-tick" json:"end-tick"`
+	// Interval is the ticks between two nodes are added or deleted.
+	Interval int64 `toml:"interval" json:"interval"`
+	// Count is the number of the nodes to add or delete, the number of the
+	// hot regions, or the times to kill the PD leader.
+	Count int `toml:"count" json:"count"`
+	// Stores are the nodes to add or delete, the stores where the leaders of
+	// the hot regions are, or the stores with the faults.
+	Stores []uint64 `toml:"stores" json:"stores"`
+	// Flow is the bytes per tick written or read for each region or key, or
+	// filled for each store.
+	Flow typeutil.ByteSize `toml:"flow" json:"flow"`
+	// Delay is the ticks to delay the heartbeats for the slow-heartbeat event.
+	Delay int64 `toml:"delay" json:"delay"`
+	// Slowdown is the times the snapshots are slower for the slow-snapshot
+	// event.
+	Slowdown int64 `toml:"slowdown" json:"slowdown"`
+	// UsedRatio is the used ratio to stop filling the disks for the fill-disk
+	// event, 0 means just crossing the low-space-ratio of PD.
+	UsedRatio float64 `toml:"used-ratio" json:"used-ratio"`
+	// Keys and Tables are the spots to write for the write-spot event.
+	Keys   []string `toml:"keys" json:"keys"`
+	Tables []int64  `toml:"tables" json:"tables"`
+}
+
+// CheckSpec is a threshold of a metric of the stores. The case is finished
+// when all checks pass.
+type CheckSpec struct {
+	Metric string `toml:"metric" json:"metric"`
+	// Stores are the stores to check, all the running stores are checked if
+	// it is empty.
+	Stores []uint64 `toml:"stores" json:"stores"`
+	// Aggregate decides the value compared with the thresholds: the value of
+	// each store, the sum of them, or the difference between the max and the
+	// min one.
+	Aggregate string     `toml:"aggregate" json:"aggregate"`
+	Min       *Threshold `toml:"min" json:"min"`
+	Max       *Threshold `toml:"max" json:"max"`
+}
+
+// Threshold is a bound of a check, it accepts both integers and floats.
+type Threshold float64
+
+// UnmarshalTOML implements the toml.Unmarshaler interface.
+func (t *Threshold) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case int64:
+		*t = Threshold(v)
+	case float64:
+		*t = Threshold(v)
+	default:
+		return errors.Errorf("invalid threshold %v", v)
+	}
+	return nil
+}
+
+// checkContext holds the information to evaluate the metrics.
+type checkContext struct {
+	regions    *core.RegionsInfo
+	stats      []info.StoreStats
+	hotRegions map[uint64]struct{}
+	// peerCount is the number of the peers on the running stores.
+	peerCount int
+}
+
+type metricFunc func(c *checkContext, storeID uint64) float64
+
+func statsMetric(f func(s *info.StoreStats) uint64) metricFunc {
+	return func(c *checkContext, storeID uint64) float64 {
+		if storeID >= uint64(len(c.stats)) {
+			return 0
+		}
+		return float64(f(&c.stats[storeID]))
+	}
+}
+
+func hotMetric(leader bool) metricFunc {
+	return func(c *checkContext, storeID uint64) float64 {
+		var count int
+		for id := range c.hotRegions {
+			region := c.regions.GetRegion(id)
+			if region == nil {
+				continue
+			}
+			if leader && region.GetLeader().GetStoreId() == storeID {
+				count++
+			}
+			if !leader && region.GetStorePeer(storeID) != nil {
+				count++
+			}
+		}
+		return float64(count)
+	}
+}
+
+var metrics = map[string]metricFunc{
+	"leader-count": func(c *checkContext, storeID uint64) float64 {
+		return float64(c.regions.GetStoreLeaderCount(storeID))
+	},
+	"region-count": func(c *checkContext, storeID uint64) float64 {
+		return float64(c.regions.GetStoreRegionCount(storeID))
+	},
+	// region-ratio is the ratio of the peers on the store.
+	"region-ratio": func(c *checkContext, storeID uint64) float64 {
+		if c.peerCount == 0 {
+			return 0
+		}
+		return float64(c.regions.GetStoreRegionCount(storeID)) / float64(c.peerCount)
+	},
+	"hot-leader-count": hotMetric(true),
+	"hot-peer-count":   hotMetric(false),
+	"capacity":         statsMetric(func(s *info.StoreStats) uint64 { return s.GetCapacity() }),
+	"available":        statsMetric(func(s *info.StoreStats) uint64 { return s.GetAvailable() }),
+	"used-size":        statsMetric(func(s *info.StoreStats) uint64 { return s.GetUsedSize() }),
+	"to-compaction-size": statsMetric(func(s *info.StoreStats) uint64 {
+		return s.ToCompactionSize
+	}),
+	"bytes-written":        statsMetric(func(s *info.StoreStats) uint64 { return s.GetBytesWritten() }),
+	"bytes-read":           statsMetric(func(s *info.StoreStats) uint64 { return s.GetBytesRead() }),
+	"keys-written":         statsMetric(func(s *info.StoreStats) uint64 { return s.GetKeysWritten() }),
+	"keys-read":            statsMetric(func(s *info.StoreStats) uint64 { return s.GetKeysRead() }),
+	"sending-snap-count":   statsMetric(func(s *info.StoreStats) uint64 { return uint64(s.GetSendingSnapCount()) }),
+	"receiving-snap-count": statsMetric(func(s *info.StoreStats) uint64 { return uint64(s.GetReceivingSnapCount()) }),
+	"applying-snap-count":  statsMetric(func(s *info.StoreStats) uint64 { return uint64(s.GetApplyingSnapCount()) }),
+}
+
+// LoadCaseFile loads and validates a case file.
+func LoadCaseFile(path string) (*CaseFile, error) {
+	f := &CaseFile{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err := yaml.Unmarshal(data, f); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	default:
+		if _, err := toml.DecodeFile(path, f); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if f.Name == "" {
+		base := filepath.Base(path)
+		f.Name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	if err := f.adjust(); err != nil {
+		return nil, errors.Wrapf(err, "invalid case file %s", path)
+	}
+	return f, nil
+}
+
+func (f *CaseFile) adjust() error {
+	var initial, total int
+	for i := range f.Stores {
+		s := &f.Stores[i]
+		if s.Count == 0 {
+			s.Count = 1
+		}
+		if s.Count < 0 {
+			return errors.Errorf("store count %d is invalid", s.Count)
+		}
+		if s.Capacity == 0 {
+			s.Capacity = 1 * TB
+		}
+		if s.Available == 0 {
+			s.Available = s.Capacity * 9 / 10
+		}
+		if s.Available > s.Capacity {
+			return errors.New("store available is larger than capacity")
+		}
+		if s.Version == "" {
+			s.Version = "2.1.0"
+		}
+		if !s.Reserved {
+			initial += s.Count
+		}
+		total += s.Count
+	}
+	if initial == 0 {
+		return errors.New("no store is started at the beginning")
+	}
+	validStore := func(id uint64, maxID int) error {
+		if id == 0 || id > uint64(maxID) {
+			return errors.Errorf("store %d is not found", id)
+		}
+		return nil
+	}
+
+	if len(f.Regions) == 0 {
+		return errors.New("no region is specified")
+	}
+	for i := range f.Regions {
+		r := &f.Regions[i]
+		if r.Count <= 0 {
+			return errors.Errorf("region count %d is invalid", r.Count)
+		}
+		if r.Replicas == 0 {
+			r.Replicas = 3
+		}
+		if r.Size == 0 {
+			r.Size = 96 * MB
+		}
+		if r.Keys == 0 {
+			r.Keys = int64(r.Size) / 100
+		}
+		if r.Layout == "" {
+			r.Layout = LayoutUniform
+		}
+		if r.Layout != LayoutUniform && r.Layout != LayoutRandom && r.Layout != LayoutSkewed {
+			return errors.Errorf("region layout %s is invalid", r.Layout)
+		}
+		if r.Skew == 0 {
+			r.Skew = 1
+		}
+		if r.Skew < 0 || r.Skew > 1 {
+			return errors.Errorf("region skew %v is invalid", r.Skew)
+		}
+		for _, id := range r.Stores {
+			if err := validStore(id, initial); err != nil {
+				return err
+			}
+		}
+		stores := len(r.Stores)
+		if stores == 0 {
+			stores = initial
+		}
+		if r.Replicas <= 0 || r.Replicas > stores {
+			return errors.Errorf("region replicas %d is invalid for %d stores", r.Replicas, stores)
+		}
+	}
+	if f.TableNumber < 0 {
+		return errors.Errorf("table number %d is invalid", f.TableNumber)
+	}
+
+	for i := range f.Events {
+		e := &f.Events[i]
+		if e.EndTick != 0 && e.EndTick <= e.StartTick {
+			return errors.Errorf("event %s ends before it starts", e.Type)
+		}
+		if e.Interval == 0 {
+			e.Interval = 1
+		}
+		if e.Interval < 0 {
+			return errors.Errorf("event %s interval %d is invalid", e.Type, e.Interval)
+		}
+		for _, id := range e.Stores {
+			if err := validStore(id, total); err != nil {
+				return err
+			}
+		}
+		switch e.Type {
+		case EventAddNodes:
+			if total == initial && len(e.Stores) == 0 {
+				return errors.New("no reserved store to add")
+			}
+		case EventDeleteNodes:
+		case EventStoreDown:
+			if len(e.Stores) == 0 {
+				return errors.New("no store is specified to be down")
+			}
+		case EventHotWrite, EventHotRead:
+			if e.Count <= 0 || e.Flow == 0 {
+				return errors.Errorf("event %s needs count and flow", e.Type)
+			}
+		case EventWriteSpot:
+			if len(e.Keys)+len(e.Tables) == 0 || e.Flow == 0 {
+				return errors.Errorf("event %s needs keys or tables and flow", e.Type)
+			}
+		case EventPartitionStores, EventSlowHeartbeat, EventSlowSnapshot, EventFillDisk:
+			if len(e.Stores) == 0 {
+				return errors.Errorf("event %s needs stores", e.Type)
+			}
+			if e.Type == EventSlowHeartbeat && e.Delay <= 0 {
+				return errors.Errorf("event %s needs delay", e.Type)
+			}
+			if e.Type == EventSlowSnapshot && e.Slowdown <= 1 {
+				return errors.Errorf("event %s needs slowdown larger than 1", e.Type)
+			}
+			if e.Type == EventFillDisk && (e.Flow == 0 || e.UsedRatio < 0 || e.UsedRatio > 1) {
+				return errors.Errorf("event %s needs flow and used ratio in [0, 1]", e.Type)
+			}
+		case EventKillPDLeader:
+		default:
+			return errors.Errorf("event type %s is invalid", e.Type)
+		}
+		if e.Count == 0 {
+			e.Count = 1
+		}
+	}
+
+	if len(f.Checks) == 0 {
+		return errors.New("no check is specified")
+	}
+	for i := range f.Checks {
+		c := &f.Checks[i]
+		if _, ok := metrics[c.Metric]; !ok {
+			return errors.Errorf("check metric %s is invalid", c.Metric)
+		}
+		if c.Aggregate == "" {
+			c.Aggregate = AggregateEach
+		}
+		if c.Aggregate != AggregateEach && c.Aggregate != AggregateSum && c.Aggregate != AggregateSpread {
+			return errors.Errorf("check aggregate %s is invalid", c.Aggregate)
+		}
+		if c.Min == nil && c.Max == nil {
+			return errors.Errorf("check %s has no threshold", c.Metric)
+		}
+		for _, id := range c.Stores {
+			if err := validStore(id, total); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NewCase creates a case from the case file. It can be called for each run
+// since the events and checks of a case are stateful.
+func (f *CaseFile) NewCase() *Case {
+	simCase := &Case{
+		RegionSplitSize: int64(f.RegionSplitSize),
+		RegionSplitKeys: f.RegionSplitKeys,
+		TableNumber:     f.TableNumber,
+	}
+
+	var alive, reserved []uint64
+	for _, reservedGroup := range []bool{false, true} {
+		for _, s := range f.Stores {
+			if s.Reserved != reservedGroup {
+				continue
+			}
+			for i := 0; i < s.Count; i++ {
+				id := IDAllocator.nextID()
+				if s.Reserved {
+					reserved = append(reserved, id)
+					continue
+				}
+				alive = append(alive, id)
+				simCase.Stores = append(simCase.Stores, &Store{
+					ID:        id,
+					Status:    metapb.StoreState_Up,
+					Capacity:  uint64(s.Capacity),
+					Available: uint64(s.Available),
+					Version:   s.Version,
+					Labels:    newLabels(s.Labels),
+				})
+			}
+		}
+	}
+
+	for _, r := range f.Regions {
+		stores := r.Stores
+		if len(stores) == 0 {
+			stores = alive
+		}
+		for i := 0; i < r.Count; i++ {
+			peers := make([]*metapb.Peer, 0, r.Replicas)
+			for _, storeID := range pickStores(&r, stores, i) {
+				peers = append(peers, &metapb.Peer{Id: IDAllocator.nextID(), StoreId: storeID})
+			}
+			simCase.Regions = append(simCase.Regions, Region{
+				ID:     IDAllocator.nextID(),
+				Peers:  peers,
+				Leader: peers[0],
+				Size:   int64(r.Size),
+				Keys:   r.Keys,
+			})
+		}
+	}
+
+	// The nodes are added and deleted by the events in order, so they share
+	// the state of the running and reserved stores.
+	nodes := &nodeState{alive: alive, reserved: reserved}
+	hotRegions := make(map[uint64]struct{})
+	for i := range f.Events {
+		e := f.Events[i]
+		switch e.Type {
+		case EventAddNodes, EventDeleteNodes, EventStoreDown:
+			simCase.Events = append(simCase.Events, nodes.newEvent(&e))
+		case EventHotWrite, EventHotRead:
+			flow := make(map[uint64]int64, e.Count)
+			for _, r := range simCase.Regions {
+				if len(e.Stores) == 0 || containsStore(e.Stores, r.Leader.GetStoreId()) {
+					flow[r.ID] = int64(e.Flow)
+					hotRegions[r.ID] = struct{}{}
+					if len(flow) == e.Count {
+						break
+					}
+				}
+			}
+			step := func(tick int64) map[uint64]int64 {
+				if !e.active(tick) {
+					return nil
+				}
+				return flow
+			}
+			if e.Type == EventHotWrite {
+				simCase.Events = append(simCase.Events, &WriteFlowOnRegionDescriptor{Step: step})
+			} else {
+				simCase.Events = append(simCase.Events, &ReadFlowOnRegionDescriptor{Step: step})
+			}
+		case EventWriteSpot:
+			flow := make(map[string]int64, len(e.Keys)+len(e.Tables))
+			for _, key := range e.Keys {
+				flow[key] = int64(e.Flow)
+			}
+			for _, tableID := range e.Tables {
+				flow[string(table.EncodeBytes(table.GenerateTableKey(tableID)))] = int64(e.Flow)
+			}
+			simCase.Events = append(simCase.Events, &WriteFlowOnSpotDescriptor{
+				Step: func(tick int64) map[string]int64 {
+					if !e.active(tick) {
+						return nil
+					}
+					return flow
+				},
+			})
+		case EventPartitionStores:
+			simCase.Events = append(simCase.Events, &PartitionStoresDescriptor{
+				Step: func(tick int64) []uint64 {
+					if !e.active(tick) {
+						return nil
+					}
+					return e.Stores
+				},
+			})
+		case EventSlowHeartbeat:
+			delays := make(map[uint64]uint64, len(e.Stores))
+			for _, id := range e.Stores {
+				delays[id] = uint64(e.Delay)
+			}
+			simCase.Events = append(simCase.Events, &SlowHeartbeatDescriptor{
+				Step: func(tick int64) map[uint64]uint64 {
+					if !e.active(tick) {
+						return nil
+					}
+					return delays
+				},
+			})
+		case EventSlowSnapshot, EventFillDisk:
+			values := make(map[uint64]int64, len(e.Stores))
+			for _, id := range e.Stores {
+				if e.Type == EventSlowSnapshot {
+					values[id] = e.Slowdown
+				} else {
+					values[id] = int64(e.Flow)
+				}
+			}
+			step := func(tick int64) map[uint64]int64 {
+				if !e.active(tick) {
+					return nil
+				}
+				return values
+			}
+			if e.Type == EventSlowSnapshot {
+				simCase.Events = append(simCase.Events, &SlowSnapshotDescriptor{Step: step})
+			} else {
+				simCase.Events = append(simCase.Events, &FillDiskDescriptor{Step: step, UsedRatio: e.UsedRatio})
+			}
+		case EventKillPDLeader:
+			var killed int
+			simCase.Events = append(simCase.Events, &KillPDLeaderDescriptor{
+				Step: func(tick int64) bool {
+					if killed >= e.Count || !e.active(tick) || (tick-e.StartTick)%e.Interval != 0 {
+						return false
+					}
+					killed++
+					return true
+				},
+			})
+		}
+	}
+
+	checks := f.Checks
+	simCase.Checker = func(regions *core.RegionsInfo, stats []info.StoreStats) bool {
+		c := &checkContext{regions: regions, stats: stats, hotRegions: hotRegions}
+		var running []uint64
+		for i := range stats {
+			if stats[i].GetStoreId() != 0 {
+				running = append(running, stats[i].GetStoreId())
+				c.peerCount += regions.GetStoreRegionCount(stats[i].GetStoreId())
+			}
+		}
+		res := true
+		for _, check := range checks {
+			stores := check.Stores
+			if len(stores) == 0 {
+				stores = running
+			}
+			values := make([]float64, 0, len(stores))
+			for _, id := range stores {
+				values = append(values, metrics[check.Metric](c, id))
+			}
+			ok := check.pass(values)
+			simutil.Logger.Info("check the case",
+				zap.String("metric", check.Metric),
+				zap.Uint64s("stores", stores),
+				zap.Float64s("values", values),
+				zap.Bool("pass", ok))
+			res = res && ok
+		}
+		return res
+	}
+	return simCase
+}
+
+func (e *EventSpec) active(tick int64) bool {
+	return tick >= e.StartTick && (e.EndTick == 0 || tick < e.EndTick)
+}
+
+func (c *CheckSpec) pass(values []float64) bool {
+	var compared []float64
+	switch c.Aggregate {
+	case AggregateEach:
+		compared = values
+	case AggregateSum:
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		compared = []float64{sum}
+	case AggregateSpread:
+		min, max := math.Inf(1), math.Inf(-1)
+		for _, v := range values {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+		if len(values) == 0 {
+			min, max = 0, 0
+		}
+		compared = []float64{max - min}
+	}
+	for _, v := range compared {
+		if c.Min != nil && v < float64(*c.Min) {
+			return false
+		}
+		if c.Max != nil && v > float64(*c.Max) {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeState tracks the running and reserved stores for the node events.
+type nodeState struct {
+	alive    []uint64
+	reserved []uint64
+}
+
+func (s *nodeState) newEvent(e *EventSpec) EventDescriptor {
+	var done int
+	step := func(tick int64) uint64 {
+		if done >= e.Count && e.Type != EventStoreDown || !e.active(tick) || (tick-e.StartTick)%e.Interval != 0 {
+			return 0
+		}
+		var id uint64
+		switch e.Type {
+		case EventAddNodes:
+			id = s.pick(&s.reserved, e.Stores, false)
+			if id != 0 {
+				s.alive = append(s.alive, id)
+			}
+		case EventDeleteNodes:
+			id = s.pick(&s.alive, e.Stores, true)
+		case EventStoreDown:
+			if done >= len(e.Stores) {
+				return 0
+			}
+			id = s.pick(&s.alive, e.Stores[done:done+1], false)
+		}
+		done++
+		return id
+	}
+	if e.Type == EventAddNodes {
+		return &AddNodesDescriptor{Step: step}
+	}
+	return &DeleteNodesDescriptor{Step: step}
+}
+
+// pick removes a store from the list and returns it. The store is chosen from
+// the candidates if there are any.
+func (s *nodeState) pick(list *[]uint64, candidates []uint64, random bool) uint64 {
+	var indexes []int
+	for i, id := range *list {
+		if len(candidates) == 0 || containsStore(candidates, id) {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return 0
+	}
+	idx := indexes[0]
+	if random {
+		idx = indexes[rand.Intn(len(indexes))]
+	}
+	id := (*list)[idx]
+	*list = append((*list)[:idx], (*list)[idx+1:]...)
+	return id
+}
+
+// pickStores returns the stores of the peers of the ith region, the first one
+// is the leader.
+func pickStores(r *RegionGroup, stores []uint64, i int) []uint64 {
+	res := make([]uint64, 0, r.Replicas)
+	switch r.Layout {
+	case LayoutRandom:
+		for _, idx := range rand.Perm(len(stores))[:r.Replicas] {
+			res = append(res, stores[idx])
+		}
+		return res
+	case LayoutSkewed:
+		if float64(i) < r.Skew*float64(r.Count) {
+			res = append(res, stores[0])
+			others := stores[1:]
+			for j := 0; j < r.Replicas-1; j++ {
+				res = append(res, others[(i+j)%len(others)])
+			}
+			return res
+		}
+	}
+	for j := 0; j < r.Replicas; j++ {
+		res = append(res, stores[(i+j)%len(stores)])
+	}
+	return res
+}
+
+func newLabels(labels map[string]string) []*metapb.StoreLabel {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res := make([]*metapb.StoreLabel, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, &metapb.StoreLabel{Key: k, Value: labels[k]})
+	}
+	return res
+}
+
+func containsStore(stores []uint64, id uint64) bool {
+	for _, s := range stores {
+		if s == id {
+			return true
+		}
+	}
+	return false
+}
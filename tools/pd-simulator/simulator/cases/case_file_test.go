@@ -0,0 +1,325 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cases
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/tools/pd-simulator/simulator/info"
+	"github.com/pingcap/pd/tools/pd-simulator/simulator/simutil"
+)
+
+func TestCases(t *testing.T) {
+	simutil.InitLogger("fatal")
+	TestingT(t)
+}
+
+var _ = Suite(&testCaseFileSuite{})
+
+type testCaseFileSuite struct{}
+
+func (s *testCaseFileSuite) SetUpTest(c *C) {
+	IDAllocator.ResetID()
+}
+
+func writeCaseFile(c *C, name, content string) string {
+	path := filepath.Join(c.MkDir(), name)
+	c.Assert(ioutil.WriteFile(path, []byte(content), 0644), IsNil)
+	return path
+}
+
+func (s *testCaseFileSuite) TestExamples(c *C) {
+	files, err := filepath.Glob("../../cases/*")
+	c.Assert(err, IsNil)
+	c.Assert(files, Not(HasLen), 0)
+	for _, file := range files {
+		f, err := LoadCaseFile(file)
+		c.Assert(err, IsNil, Commentf("%s", file))
+		_, ok := CaseMap[f.Name]
+		c.Assert(ok, IsTrue, Commentf("%s", file))
+		IDAllocator.ResetID()
+		simCase := f.NewCase()
+		c.Assert(simCase.Stores, Not(HasLen), 0)
+		c.Assert(simCase.Regions, Not(HasLen), 0)
+	}
+}
+
+func (s *testCaseFileSuite) TestLoad(c *C) {
+	toml := writeCaseFile(c, "test.toml", `
+[[stores]]
+count = 3
+labels = { zone = "z1" }
+
+[[regions]]
+count = 10
+
+[[checks]]
+metric = "leader-count"
+max = 4
+`)
+	yaml := writeCaseFile(c, "test.yaml", `
+stores:
+  - count: 3
+    labels: {zone: z1}
+regions:
+  - count: 10
+checks:
+  - metric: leader-count
+    max: 4
+`)
+	for _, path := range []string{toml, yaml} {
+		f, err := LoadCaseFile(path)
+		c.Assert(err, IsNil)
+		c.Assert(f.Name, Equals, "test")
+		c.Assert(f.Stores[0].Available, Equals, f.Stores[0].Capacity*9/10)
+		c.Assert(f.Regions[0].Replicas, Equals, 3)
+		c.Assert(f.Regions[0].Layout, Equals, LayoutUniform)
+		c.Assert(*f.Checks[0].Max, Equals, Threshold(4))
+	}
+
+	for _, content := range []string{
+		// No initial store.
+		"[[stores]]\nreserved = true\n[[regions]]\ncount = 1\n[[checks]]\nmetric = \"region-count\"\nmin = 1",
+		// Too many replicas.
+		"[[stores]]\ncount = 2\n[[regions]]\ncount = 1\n[[checks]]\nmetric = \"region-count\"\nmin = 1",
+		// Unknown metric.
+		"[[stores]]\ncount = 3\n[[regions]]\ncount = 1\n[[checks]]\nmetric = \"unknown\"\nmin = 1",
+		// No threshold.
+		"[[stores]]\ncount = 3\n[[regions]]\ncount = 1\n[[checks]]\nmetric = \"region-count\"",
+		// Unknown store.
+		"[[stores]]\ncount = 3\n[[regions]]\ncount = 1\n[[events]]\ntype = \"store-down\"\nstores = [4]\n[[checks]]\nmetric = \"region-count\"\nmin = 1",
+		// No reserved store to add.
+		"[[stores]]\ncount = 3\n[[regions]]\ncount = 1\n[[events]]\ntype = \"add-nodes\"\n[[checks]]\nmetric = \"region-count\"\nmin = 1",
+	} {
+		_, err := LoadCaseFile(writeCaseFile(c, "invalid.toml", content))
+		c.Assert(err, NotNil, Commentf("%s", content))
+	}
+	_, err := LoadCaseFile(filepath.Join(c.MkDir(), "not-exist.toml"))
+	c.Assert(err, NotNil)
+}
+
+func (s *testCaseFileSuite) TestNewCase(c *C) {
+	f, err := LoadCaseFile(writeCaseFile(c, "case.toml", `
+[[stores]]
+count = 2
+reserved = true
+
+[[stores]]
+count = 4
+labels = { zone = "z1", host = "h1" }
+
+[[regions]]
+count = 10
+layout = "skewed"
+skew = 0.5
+
+[[regions]]
+count = 2
+replicas = 1
+stores = [4]
+
+[[events]]
+type = "add-nodes"
+start-tick = 10
+interval = 5
+count = 2
+
+[[events]]
+type = "store-down"
+start-tick = 20
+stores = [2, 5]
+
+[[events]]
+type = "hot-write"
+count = 2
+stores = [1]
+flow = "1MiB"
+end-tick = 30
+
+[[checks]]
+metric = "leader-count"
+stores = [1]
+min = 5
+
+[[checks]]
+metric = "region-count"
+aggregate = "sum"
+max = 32
+`))
+	c.Assert(err, IsNil)
+	simCase := f.NewCase()
+
+	// The reserved stores are numbered after the others.
+	c.Assert(simCase.Stores, HasLen, 4)
+	c.Assert(simCase.Stores[0].ID, Equals, uint64(1))
+	c.Assert(simCase.Stores[0].Labels, DeepEquals, []*metapb.StoreLabel{{Key: "host", Value: "h1"}, {Key: "zone", Value: "z1"}})
+	c.Assert(simCase.Regions, HasLen, 12)
+	var leaders [5]int
+	for _, r := range simCase.Regions[:10] {
+		c.Assert(r.Peers, HasLen, 3)
+		leaders[r.Leader.GetStoreId()]++
+	}
+	c.Assert(leaders[1], Equals, 6)
+	for _, r := range simCase.Regions[10:] {
+		c.Assert(r.Peers, HasLen, 1)
+		c.Assert(r.Leader.GetStoreId(), Equals, uint64(4))
+	}
+
+	c.Assert(simCase.Events, HasLen, 3)
+	add := simCase.Events[0].(*AddNodesDescriptor)
+	down := simCase.Events[1].(*DeleteNodesDescriptor)
+	var added, deleted []uint64
+	for tick := int64(1); tick <= 40; tick++ {
+		if id := add.Step(tick); id != 0 {
+			added = append(added, id)
+		}
+		if id := down.Step(tick); id != 0 {
+			deleted = append(deleted, id)
+		}
+	}
+	c.Assert(added, DeepEquals, []uint64{5, 6})
+	c.Assert(deleted, DeepEquals, []uint64{2, 5})
+	hot := simCase.Events[2].(*WriteFlowOnRegionDescriptor)
+	c.Assert(hot.Step(1), HasLen, 2)
+	c.Assert(hot.Step(30), HasLen, 0)
+
+	regions := core.NewRegionsInfo()
+	for i, r := range simCase.Regions {
+		meta := &metapb.Region{Id: r.ID, Peers: r.Peers, StartKey: []byte{byte(i)}, EndKey: []byte{byte(i + 1)}}
+		regions.AddRegion(core.NewRegionInfo(meta, r.Leader))
+	}
+	stats := make([]info.StoreStats, 5)
+	for i := 1; i <= 4; i++ {
+		stats[i] = info.StoreStats{StoreStats: pdpb.StoreStats{StoreId: uint64(i)}}
+	}
+	c.Assert(simCase.Checker(regions, stats), IsTrue)
+	// The leaders are balanced.
+	for _, r := range simCase.Regions[:5] {
+		region := regions.GetRegion(r.ID)
+		regions.AddRegion(region.Clone(core.WithLeader(region.GetStorePeer(r.Peers[1].GetStoreId()))))
+	}
+	c.Assert(simCase.Checker(regions, stats), IsFalse)
+}
+
+func (s *testCaseFileSuite) TestFaultEvents(c *C) {
+	f, err := LoadCaseFile(writeCaseFile(c, "case.toml", `
+[[stores]]
+count = 3
+
+[[regions]]
+count = 3
+
+[[events]]
+type = "partition-stores"
+start-tick = 10
+end-tick = 20
+stores = [1]
+
+[[events]]
+type = "slow-heartbeat"
+stores = [2]
+delay = 5
+
+[[events]]
+type = "slow-snapshot"
+stores = [3]
+slowdown = 10
+
+[[events]]
+type = "fill-disk"
+stores = [1, 2]
+flow = "1GiB"
+used-ratio = 0.9
+
+[[events]]
+type = "kill-pd-leader"
+start-tick = 100
+interval = 50
+count = 2
+
+[[checks]]
+metric = "region-count"
+min = 1
+`))
+	c.Assert(err, IsNil)
+	simCase := f.NewCase()
+	c.Assert(simCase.Events, HasLen, 5)
+
+	partition := simCase.Events[0].(*PartitionStoresDescriptor)
+	c.Assert(partition.Step(9), HasLen, 0)
+	c.Assert(partition.Step(10), DeepEquals, []uint64{1})
+	c.Assert(partition.Step(20), HasLen, 0)
+	c.Assert(simCase.Events[1].(*SlowHeartbeatDescriptor).Step(1), DeepEquals, map[uint64]uint64{2: 5})
+	c.Assert(simCase.Events[2].(*SlowSnapshotDescriptor).Step(1), DeepEquals, map[uint64]int64{3: 10})
+	fill := simCase.Events[3].(*FillDiskDescriptor)
+	c.Assert(fill.UsedRatio, Equals, 0.9)
+	c.Assert(fill.Step(1), DeepEquals, map[uint64]int64{1: GB, 2: GB})
+
+	kill := simCase.Events[4].(*KillPDLeaderDescriptor)
+	var kills []int64
+	for tick := int64(1); tick <= 300; tick++ {
+		if kill.Step(tick) {
+			kills = append(kills, tick)
+		}
+	}
+	c.Assert(kills, DeepEquals, []int64{100, 150})
+
+	for _, content := range []string{
+		"[[events]]\ntype = \"partition-stores\"",
+		"[[events]]\ntype = \"slow-heartbeat\"\nstores = [1]",
+		"[[events]]\ntype = \"slow-snapshot\"\nstores = [1]\nslowdown = 1",
+		"[[events]]\ntype = \"fill-disk\"\nstores = [1]",
+	} {
+		_, err = LoadCaseFile(writeCaseFile(c, "invalid.toml", `
+[[stores]]
+count = 3
+[[regions]]
+count = 3
+[[checks]]
+metric = "region-count"
+min = 1
+`+content))
+		c.Assert(err, NotNil)
+	}
+}
+
+func (s *testCaseFileSuite) TestCheck(c *C) {
+	newThreshold := func(v float64) *Threshold {
+		t := Threshold(v)
+		return &t
+	}
+	values := []float64{1, 3, 2}
+	testCases := []struct {
+		aggregate string
+		min, max  *Threshold
+		pass      bool
+	}{
+		{AggregateEach, newThreshold(1), newThreshold(3), true},
+		{AggregateEach, newThreshold(2), nil, false},
+		{AggregateSum, newThreshold(6), newThreshold(6), true},
+		{AggregateSum, nil, newThreshold(5), false},
+		{AggregateSpread, nil, newThreshold(2), true},
+		{AggregateSpread, nil, newThreshold(1), false},
+	}
+	for _, t := range testCases {
+		check := &CheckSpec{Aggregate: t.aggregate, Min: t.min, Max: t.max}
+		c.Assert(check.pass(values), Equals, t.pass, Commentf("%+v", t))
+	}
+}
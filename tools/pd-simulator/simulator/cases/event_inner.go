@@ -67,3 +67,62 @@ type DeleteNodesDescriptor struct {
 func (w *DeleteNodesDescriptor) Type() string {
 	return "delete-nodes"
 }
+
+// PartitionStoresDescriptor partitions the stores from PD, so their
+// heartbeats are lost.
+type PartitionStoresDescriptor struct {
+	Step func(tick int64) []uint64
+}
+
+// Type implements the EventDescriptor interface.
+func (w *PartitionStoresDescriptor) Type() string {
+	return "partition-stores"
+}
+
+// SlowHeartbeatDescriptor delays the heartbeats of the stores.
+type SlowHeartbeatDescriptor struct {
+	// Step returns the ticks to delay the heartbeats of the stores.
+	Step func(tick int64) map[uint64]uint64
+}
+
+// Type implements the EventDescriptor interface.
+func (w *SlowHeartbeatDescriptor) Type() string {
+	return "slow-heartbeat"
+}
+
+// SlowSnapshotDescriptor slows down the snapshots sent or received by the
+// stores.
+type SlowSnapshotDescriptor struct {
+	// Step returns the times the snapshots of the stores are slower.
+	Step func(tick int64) map[uint64]int64
+}
+
+// Type implements the EventDescriptor interface.
+func (w *SlowSnapshotDescriptor) Type() string {
+	return "slow-snapshot"
+}
+
+// FillDiskDescriptor fills the disks of the stores with the data not managed
+// by PD.
+type FillDiskDescriptor struct {
+	// Step returns the bytes to fill for the stores.
+	Step func(tick int64) map[uint64]int64
+	// UsedRatio is the used ratio to stop filling, 0 means just crossing the
+	// low-space-ratio of PD.
+	UsedRatio float64
+}
+
+// Type implements the EventDescriptor interface.
+func (w *FillDiskDescriptor) Type() string {
+	return "fill-disk"
+}
+
+// KillPDLeaderDescriptor kills the PD leader.
+type KillPDLeaderDescriptor struct {
+	Step func(tick int64) bool
+}
+
+// Type implements the EventDescriptor interface.
+func (w *KillPDLeaderDescriptor) Type() string {
+	return "kill-pd-leader"
+}
@@ -13,9 +13,8 @@
 package cases
 
 import (
-	"time"
-
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/tools/pd-simulator/simulator/info"
 	"github.com/pingcap/pd/tools/pd-simulator/simulator/simutil"
@@ -70,7 +69,7 @@ func newRedundantBalanceRegion() *Case {
 	storeLastAvailable := make([]uint64, storeNum+1, storeNum+1)
 	simCase.Checker = func(regions *core.RegionsInfo, stats []info.StoreStats) bool {
 		res := true
-		curTime := time.Now().Unix()
+		curTime := clock.Now().Unix()
 		storesAvailable := make([]uint64, 0, storeNum+1)
 		for i := 1; i <= storeNum; i++ {
 			available := stats[i].GetAvailable()
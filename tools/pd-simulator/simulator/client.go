@@ -23,6 +23,7 @@ import (
 
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/tools/pd-simulator/simulator/simutil"
 	"github.com/pkg/errors"
@@ -52,9 +53,14 @@ var (
 )
 
 type client struct {
-	url        string
-	tag        string
-	clusterID  uint64
+	// urls are the addresses of the PD servers, the client connects to the
+	// leader of them.
+	urls      []string
+	tag       string
+	clusterID uint64
+
+	connMu     sync.RWMutex
+	leaderURL  string
 	clientConn *grpc.ClientConn
 
 	reportRegionHeartbeatCh  chan *core.RegionInfo
@@ -65,23 +71,23 @@ type client struct {
 	cancel context.CancelFunc
 }
 
-// NewClient creates a PD client.
+// NewClient creates a PD client, pdAddr can be the addresses of the PD servers
+// separated by comma.
 func NewClient(pdAddr string, tag string) (Client, <-chan *pdpb.RegionHeartbeatResponse, error) {
 	simutil.Logger.Info("create pd client with endpoints", zap.String("tag", tag), zap.String("pd-address", pdAddr))
 	ctx, cancel := context.WithCancel(context.Background())
 	c := &client{
-		url:                      pdAddr,
+		urls:                     strings.Split(pdAddr, ","),
 		reportRegionHeartbeatCh:  make(chan *core.RegionInfo, 1),
 		receiveRegionHeartbeatCh: make(chan *pdpb.RegionHeartbeatResponse, 1),
 		ctx:                      ctx,
 		cancel:                   cancel,
 		tag:                      tag,
 	}
-	cc, err := c.createConn()
-	if err != nil {
+	if err := c.updateLeader(); err != nil {
+		cancel()
 		return nil, nil, err
 	}
-	c.clientConn = cc
 	if err := c.initClusterID(); err != nil {
 		return nil, nil, err
 	}
@@ -93,9 +99,63 @@ func NewClient(pdAddr string, tag string) (Client, <-chan *pdpb.RegionHeartbeatR
 }
 
 func (c *client) pdClient() pdpb.PDClient {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
 	return pdpb.NewPDClient(c.clientConn)
 }
 
+// updateLeader connects to the current PD leader, which changes if the leader
+// is killed.
+func (c *client) updateLeader() error {
+	// The address is used as it is, which may differ from the advertised one.
+	if len(c.urls) == 1 {
+		return c.switchLeader(c.urls[0])
+	}
+	for _, u := range c.urls {
+		cc, err := createConn(u)
+		if err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(c.ctx, pdTimeout)
+		members, err := pdpb.NewPDClient(cc).GetMembers(ctx, &pdpb.GetMembersRequest{})
+		cancel()
+		cc.Close()
+		if err != nil || len(members.GetLeader().GetClientUrls()) == 0 {
+			continue
+		}
+		return c.switchLeader(members.GetLeader().GetClientUrls()[0])
+	}
+	return errors.Errorf("[pd] failed to get leader from %v", c.urls)
+}
+
+func (c *client) switchLeader(leaderURL string) error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if leaderURL == c.leaderURL {
+		return nil
+	}
+	cc, err := createConn(leaderURL)
+	if err != nil {
+		return err
+	}
+	if c.clientConn != nil {
+		c.clientConn.Close()
+	}
+	simutil.Logger.Info("switch PD leader", zap.String("tag", c.tag), zap.String("leader", leaderURL))
+	c.leaderURL, c.clientConn = leaderURL, cc
+	return nil
+}
+
+// onError connects to the new leader if the error is caused by the leader
+// change.
+func (c *client) onError(err error) {
+	if err != nil && len(c.urls) > 1 {
+		if err := c.updateLeader(); err != nil {
+			simutil.Logger.Error("update PD leader error", zap.String("tag", c.tag), zap.Error(err))
+		}
+	}
+}
+
 func (c *client) initClusterID() error {
 	ctx, cancel := context.WithCancel(c.ctx)
 	defer cancel()
@@ -120,8 +180,8 @@ func (c *client) getMembers(ctx context.Context) (*pdpb.GetMembersResponse, erro
 	return members, nil
 }
 
-func (c *client) createConn() (*grpc.ClientConn, error) {
-	cc, err := grpc.Dial(strings.TrimPrefix(c.url, "http://"), grpc.WithInsecure())
+func createConn(url string) (*grpc.ClientConn, error) {
+	cc, err := grpc.Dial(strings.TrimPrefix(url, "http://"), grpc.WithInsecure())
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -141,6 +201,7 @@ func (c *client) createHeartbeatStream() (pdpb.PD_RegionHeartbeatClient, context
 		if err != nil {
 			simutil.Logger.Error("create region heartbeat stream error", zap.String("tag", c.tag), zap.Error(err))
 			cancel()
+			c.onError(err)
 			select {
 			case <-time.After(time.Second):
 				continue
@@ -170,6 +231,9 @@ func (c *client) heartbeatStreamLoop() {
 		case err := <-errCh:
 			simutil.Logger.Error("heartbeat stream get error", zap.String("tag", c.tag), zap.Error(err))
 			cancel()
+			// The heartbeats and the responses on the stream may be lost.
+			clock.ResetWork()
+			c.onError(err)
 		case <-c.ctx.Done():
 			simutil.Logger.Info("cancel heartbeat stream loop")
 			return
@@ -225,6 +289,8 @@ func (c *client) Close() {
 	c.cancel()
 	c.wg.Wait()
 
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
 	if err := c.clientConn.Close(); err != nil {
 		simutil.Logger.Error("failed to close grpc client connection", zap.String("tag", c.tag), zap.Error(err))
 	}
@@ -241,6 +307,7 @@ func (c *client) AllocID(ctx context.Context) (uint64, error) {
 	})
 	cancel()
 	if err != nil {
+		c.onError(err)
 		return 0, err
 	}
 	return resp.GetId(), nil
@@ -280,6 +347,7 @@ func (c *client) PutStore(ctx context.Context, store *metapb.Store) error {
 	})
 	cancel()
 	if err != nil {
+		c.onError(err)
 		return err
 	}
 	if resp.Header.GetError() != nil {
@@ -297,6 +365,7 @@ func (c *client) StoreHeartbeat(ctx context.Context, stats *pdpb.StoreStats) err
 	})
 	cancel()
 	if err != nil {
+		c.onError(err)
 		return err
 	}
 	if resp.Header.GetError() != nil {
@@ -307,6 +376,8 @@ func (c *client) StoreHeartbeat(ctx context.Context, stats *pdpb.StoreStats) err
 }
 
 func (c *client) RegionHeartbeat(ctx context.Context, region *core.RegionInfo) error {
+	// The heartbeat is a work until PD handles it on the virtual clock.
+	clock.StartWork()
 	c.reportRegionHeartbeatCh <- region
 	return nil
 }
@@ -30,6 +30,11 @@ const (
 type SimConfig struct {
 	// tick
 	SimTickInterval typeutil.Duration `toml:"sim-tick-interval"`
+	// VirtualClock makes the simulator and the scheduling of PD move forward
+	// on the simulated time, and the ticks run as fast as possible.
+	VirtualClock bool `toml:"virtual-clock"`
+	// Seed is the seed of the randomness, 0 means a random seed.
+	Seed int64 `toml:"seed"`
 	// store
 	StoreCapacityGB    uint64 `toml:"store-capacity"`
 	StoreAvailableGB   uint64 `toml:"store-available"`
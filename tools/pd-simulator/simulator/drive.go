@@ -15,11 +15,14 @@ package simulator
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/clock"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/tools/pd-simulator/simulator/cases"
 	"github.com/pingcap/pd/tools/pd-simulator/simulator/info"
@@ -38,6 +41,11 @@ type Driver struct {
 	raftEngine  *RaftEngine
 	conn        *Connection
 	simConfig   *SimConfig
+	// virtual is the virtual clock advanced by the ticks, it is nil if the
+	// wall clock is used.
+	virtual   *clock.Virtual
+	reporter  *reporter
+	pdCluster PDCluster
 }
 
 // NewDriver returns a driver.
@@ -50,9 +58,17 @@ func NewDriver(pdAddr string, caseName string, simConfig *SimConfig) (*Driver, e
 		pdAddr:    pdAddr,
 		simCase:   simCase,
 		simConfig: simConfig,
+		virtual:   clock.GetVirtual(),
+		reporter:  newReporter(simConfig),
 	}, nil
 }
 
+// SetPDCluster sets the PD servers started by the simulator for the events
+// killing PD, it should be called before Prepare.
+func (d *Driver) SetPDCluster(pdCluster PDCluster) {
+	d.pdCluster = pdCluster
+}
+
 // Prepare initializes cluster information, bootstraps cluster and starts nodes.
 func (d *Driver) Prepare() error {
 	conn, err := NewConnection(d.simCase, d.pdAddr, d.simConfig)
@@ -62,7 +78,7 @@ func (d *Driver) Prepare() error {
 	d.conn = conn
 
 	d.raftEngine = NewRaftEngine(d.simCase, d.conn, d.simConfig)
-	d.eventRunner = NewEventRunner(d.simCase.Events, d.raftEngine)
+	d.eventRunner = NewEventRunner(d.simCase.Events, d.raftEngine, d.pdCluster)
 
 	// Bootstrap.
 	store, region, err := d.GetBootstrapInfo(d.raftEngine)
@@ -107,12 +123,39 @@ func (d *Driver) Tick() {
 	d.tickCount++
 	d.raftEngine.stepRegions()
 	d.eventRunner.Tick(d.tickCount)
-	for _, n := range d.conn.Nodes {
-		n.reportRegionChange()
+	if d.virtual != nil {
+		d.tickInOrder()
+	} else {
+		for _, n := range d.conn.Nodes {
+			n.reportRegionChange()
+			d.wg.Add(1)
+			go n.Tick(&d.wg)
+		}
+		d.wg.Wait()
+	}
+	d.reporter.tick(d.tickCount, d.raftEngine)
+}
+
+// tickInOrder ticks the nodes one by one in the order of the IDs, then it
+// advances the virtual clock. It waits for PD to handle the heartbeats of a node
+// and the stores to handle the responses before the next step, so the same case
+// gives the same result with the same seed.
+func (d *Driver) tickInOrder() {
+	ids := make([]uint64, 0, len(d.conn.Nodes))
+	for id := range d.conn.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		d.conn.Nodes[id].reportRegionChange()
+		d.virtual.Settle()
+	}
+	for _, id := range ids {
 		d.wg.Add(1)
-		go n.Tick(&d.wg)
+		d.conn.Nodes[id].Tick(&d.wg)
+		d.virtual.Settle()
 	}
-	d.wg.Wait()
+	d.virtual.Advance(d.simConfig.SimTickInterval.Duration)
 }
 
 // Check checks if the simulation is completed.
@@ -127,7 +170,9 @@ func (d *Driver) Check() bool {
 	for index, node := range d.conn.Nodes {
 		stats[index] = *node.stats
 	}
-	return d.simCase.Checker(d.raftEngine.regionsInfo, stats)
+	finished := d.simCase.Checker(d.raftEngine.regionsInfo, stats)
+	d.reporter.check(d.tickCount, finished)
+	return finished
 }
 
 // PrintStatistics prints the statistics of the scheduler.
@@ -135,6 +180,12 @@ func (d *Driver) PrintStatistics() {
 	d.raftEngine.schedulerStats.PrintStatistics()
 }
 
+// Report returns the scheduling-quality report of the run, it should be
+// called after the run is finished.
+func (d *Driver) Report(caseName, result string, duration time.Duration) *Report {
+	return d.reporter.finish(caseName, result, d.tickCount, duration, d.raftEngine)
+}
+
 // Start starts all nodes.
 func (d *Driver) Start() error {
 	for _, n := range d.conn.Nodes {
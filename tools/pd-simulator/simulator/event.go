@@ -27,17 +27,24 @@ type Event interface {
 	Run(raft *RaftEngine, tickCount int64) bool
 }
 
+// PDCluster controls the PD servers started by the simulator.
+type PDCluster interface {
+	// KillLeader stops the PD leader, the other servers elect a new one.
+	KillLeader() error
+}
+
 // EventRunner includes all events.
 type EventRunner struct {
 	events     []Event
 	raftEngine *RaftEngine
 }
 
-// NewEventRunner creates an event runner.
-func NewEventRunner(events []cases.EventDescriptor, raftEngine *RaftEngine) *EventRunner {
+// NewEventRunner creates an event runner, pdCluster is nil if PD is not
+// started by the simulator.
+func NewEventRunner(events []cases.EventDescriptor, raftEngine *RaftEngine, pdCluster PDCluster) *EventRunner {
 	er := &EventRunner{events: make([]Event, 0, len(events)), raftEngine: raftEngine}
 	for _, e := range events {
-		event := parserEvent(e)
+		event := parserEvent(e, pdCluster)
 		if event != nil {
 			er.events = append(er.events, event)
 		}
@@ -45,7 +52,7 @@ func NewEventRunner(events []cases.EventDescriptor, raftEngine *RaftEngine) *Eve
 	return er
 }
 
-func parserEvent(e cases.EventDescriptor) Event {
+func parserEvent(e cases.EventDescriptor, pdCluster PDCluster) Event {
 	switch t := e.(type) {
 	case *cases.WriteFlowOnSpotDescriptor:
 		return &WriteFlowOnSpot{descriptor: t}
@@ -57,12 +64,26 @@ func parserEvent(e cases.EventDescriptor) Event {
 		return &AddNodes{descriptor: t}
 	case *cases.DeleteNodesDescriptor:
 		return &DeleteNodes{descriptor: t}
+	case *cases.PartitionStoresDescriptor:
+		return &PartitionStores{descriptor: t}
+	case *cases.SlowHeartbeatDescriptor:
+		return &SlowHeartbeat{descriptor: t}
+	case *cases.SlowSnapshotDescriptor:
+		return &SlowSnapshot{descriptor: t}
+	case *cases.FillDiskDescriptor:
+		return &FillDisk{descriptor: t}
+	case *cases.KillPDLeaderDescriptor:
+		return &KillPDLeader{descriptor: t, pdCluster: pdCluster}
 	}
 	return nil
 }
 
 // Tick ticks the event run
 func (er *EventRunner) Tick(tickCount int64) {
+	// The faults are injected again by the events which are still active.
+	for _, n := range er.raftEngine.conn.Nodes {
+		n.faults = nodeFaults{}
+	}
 	var finishedIndex int
 	for i, e := range er.events {
 		isFinished := e.Run(er.raftEngine, tickCount)
@@ -198,3 +219,91 @@ func (e *DeleteNodes) Run(raft *RaftEngine, tickCount int64) bool {
 	}
 	return false
 }
+
+// PartitionStores partitions the stores from PD.
+type PartitionStores struct {
+	descriptor *cases.PartitionStoresDescriptor
+}
+
+// Run implements the event interface.
+func (e *PartitionStores) Run(raft *RaftEngine, tickCount int64) bool {
+	for _, id := range e.descriptor.Step(tickCount) {
+		if n := raft.conn.Nodes[id]; n != nil {
+			n.faults.partitioned = true
+		}
+	}
+	return false
+}
+
+// SlowHeartbeat delays the heartbeats of the stores.
+type SlowHeartbeat struct {
+	descriptor *cases.SlowHeartbeatDescriptor
+}
+
+// Run implements the event interface.
+func (e *SlowHeartbeat) Run(raft *RaftEngine, tickCount int64) bool {
+	for id, delay := range e.descriptor.Step(tickCount) {
+		if n := raft.conn.Nodes[id]; n != nil && delay > n.faults.heartbeatDelay {
+			n.faults.heartbeatDelay = delay
+		}
+	}
+	return false
+}
+
+// SlowSnapshot slows down the snapshots of the stores.
+type SlowSnapshot struct {
+	descriptor *cases.SlowSnapshotDescriptor
+}
+
+// Run implements the event interface.
+func (e *SlowSnapshot) Run(raft *RaftEngine, tickCount int64) bool {
+	for id, slowdown := range e.descriptor.Step(tickCount) {
+		if n := raft.conn.Nodes[id]; n != nil && slowdown > n.faults.snapshotSlowdown {
+			n.faults.snapshotSlowdown = slowdown
+		}
+	}
+	return false
+}
+
+// FillDisk fills the disks of the stores.
+type FillDisk struct {
+	descriptor *cases.FillDiskDescriptor
+}
+
+// lowSpaceMargin makes the used ratio cross the low-space-ratio of PD.
+const lowSpaceMargin = 0.01
+
+// Run implements the event interface.
+func (e *FillDisk) Run(raft *RaftEngine, tickCount int64) bool {
+	usedRatio := e.descriptor.UsedRatio
+	if usedRatio == 0 {
+		usedRatio = raft.storeConfig.ServerConfig.Schedule.LowSpaceRatio + lowSpaceMargin
+	}
+	for id, size := range e.descriptor.Step(tickCount) {
+		if n := raft.conn.Nodes[id]; n != nil {
+			n.fillDisk(size, usedRatio)
+		}
+	}
+	return false
+}
+
+// KillPDLeader kills the PD leader.
+type KillPDLeader struct {
+	descriptor *cases.KillPDLeaderDescriptor
+	pdCluster  PDCluster
+}
+
+// Run implements the event interface.
+func (e *KillPDLeader) Run(raft *RaftEngine, tickCount int64) bool {
+	if !e.descriptor.Step(tickCount) {
+		return false
+	}
+	if e.pdCluster == nil {
+		simutil.Logger.Error("killing PD leader needs the PD started by the simulator")
+		return true
+	}
+	if err := e.pdCluster.KillLeader(); err != nil {
+		simutil.Logger.Error("kill PD leader failed", zap.Error(err))
+	}
+	return false
+}
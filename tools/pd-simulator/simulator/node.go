@@ -17,13 +17,15 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sort"
 	"sync"
-	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/pkg/clock"
+	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/tools/pd-simulator/simulator/cases"
 	"github.com/pingcap/pd/tools/pd-simulator/simulator/info"
 	"github.com/pingcap/pd/tools/pd-simulator/simulator/simutil"
@@ -50,6 +52,29 @@ type Node struct {
 	raftEngine               *RaftEngine
 	ioRate                   int64
 	sizeMutex                sync.Mutex
+	// faults are injected by the events, they are reset every tick.
+	faults nodeFaults
+	// delayed are the heartbeats delayed by the faults.
+	delayed []*delayedHeartbeat
+}
+
+// nodeFaults are the faults of a node.
+type nodeFaults struct {
+	// partitioned loses the heartbeats to PD.
+	partitioned bool
+	// heartbeatDelay is the ticks to delay the heartbeats.
+	heartbeatDelay uint64
+	// snapshotSlowdown is the times the snapshots are slower, 0 means not
+	// slower.
+	snapshotSlowdown int64
+}
+
+// delayedHeartbeat is a heartbeat to send at the tick, one of the stats and
+// the region is set.
+type delayedHeartbeat struct {
+	tick   uint64
+	stats  *pdpb.StoreStats
+	region *core.RegionInfo
 }
 
 // NewNode returns a Node.
@@ -67,7 +92,7 @@ func NewNode(s *cases.Store, pdAddr string, ioRate int64) (*Node, error) {
 			StoreId:   s.ID,
 			Capacity:  s.Capacity,
 			Available: s.Available,
-			StartTime: uint32(time.Now().Unix()),
+			StartTime: uint32(clock.Now().Unix()),
 		},
 	}
 	tag := fmt.Sprintf("store %d", s.ID)
@@ -112,6 +137,7 @@ func (n *Node) receiveRegionHeartbeat() {
 			if task != nil {
 				n.AddTask(task)
 			}
+			clock.EndWork()
 		case <-n.ctx.Done():
 			return
 		}
@@ -138,7 +164,14 @@ func (n *Node) GetState() metapb.StoreState {
 func (n *Node) stepTask() {
 	n.Lock()
 	defer n.Unlock()
-	for _, task := range n.tasks {
+	regionIDs := make([]uint64, 0, len(n.tasks))
+	for regionID := range n.tasks {
+		regionIDs = append(regionIDs, regionID)
+	}
+	// Keep the order stable to make the simulation reproducible.
+	sort.Slice(regionIDs, func(i, j int) bool { return regionIDs[i] < regionIDs[j] })
+	for _, regionID := range regionIDs {
+		task := n.tasks[regionID]
 		task.Step(n.raftEngine)
 		if task.IsFinished() {
 			simutil.Logger.Debug("task finished",
@@ -151,6 +184,7 @@ func (n *Node) stepTask() {
 }
 
 func (n *Node) stepHeartBeat() {
+	n.sendDelayedHeartbeats()
 	if n.tick%storeHeartBeatPeriod == 0 {
 		n.storeHeartBeat()
 	}
@@ -166,11 +200,20 @@ func (n *Node) stepCompaction() {
 }
 
 func (n *Node) storeHeartBeat() {
-	if n.GetState() != metapb.StoreState_Up {
+	if n.GetState() != metapb.StoreState_Up || n.faults.partitioned {
+		return
+	}
+	if n.faults.heartbeatDelay > 0 {
+		stats := n.stats.StoreStats
+		n.delayed = append(n.delayed, &delayedHeartbeat{tick: n.tick + n.faults.heartbeatDelay, stats: &stats})
 		return
 	}
+	n.sendStoreHeartbeat(&n.stats.StoreStats)
+}
+
+func (n *Node) sendStoreHeartbeat(stats *pdpb.StoreStats) {
 	ctx, cancel := context.WithTimeout(n.ctx, pdTimeout)
-	err := n.client.StoreHeartbeat(ctx, &n.stats.StoreStats)
+	err := n.client.StoreHeartbeat(ctx, stats)
 	if err != nil {
 		simutil.Logger.Info("report heartbeat error",
 			zap.Uint64("node-id", n.GetId()),
@@ -179,6 +222,27 @@ func (n *Node) storeHeartBeat() {
 	cancel()
 }
 
+// sendDelayedHeartbeats sends the delayed heartbeats which are due, they are
+// held while the node is partitioned.
+func (n *Node) sendDelayedHeartbeats() {
+	if n.faults.partitioned {
+		return
+	}
+	var remain []*delayedHeartbeat
+	for _, h := range n.delayed {
+		if h.tick > n.tick && n.faults.heartbeatDelay > 0 {
+			remain = append(remain, h)
+			continue
+		}
+		if h.stats != nil {
+			n.sendStoreHeartbeat(h.stats)
+		} else {
+			n.sendRegionHeartbeat(h.region)
+		}
+	}
+	n.delayed = remain
+}
+
 func (n *Node) compaction() {
 	n.sizeMutex.Lock()
 	defer n.sizeMutex.Unlock()
@@ -188,42 +252,75 @@ func (n *Node) compaction() {
 }
 
 func (n *Node) regionHeartBeat() {
-	if n.GetState() != metapb.StoreState_Up {
+	if n.GetState() != metapb.StoreState_Up || n.faults.partitioned {
 		return
 	}
 	regions := n.raftEngine.GetRegions()
 	for _, region := range regions {
 		if region.GetLeader() != nil && region.GetLeader().GetStoreId() == n.Id {
-			ctx, cancel := context.WithTimeout(n.ctx, pdTimeout)
-			err := n.client.RegionHeartbeat(ctx, region)
-			if err != nil {
-				simutil.Logger.Info("report heartbeat error",
-					zap.Uint64("node-id", n.Id),
-					zap.Uint64("region-id", region.GetID()),
-					zap.Error(err))
-			}
-			cancel()
+			n.reportRegion(region)
 		}
 	}
 }
 
 func (n *Node) reportRegionChange() {
+	// The changes are reported after the partition is recovered.
+	if n.faults.partitioned {
+		return
+	}
 	regionIDs := n.raftEngine.GetRegionChange(n.Id)
 	for _, regionID := range regionIDs {
 		region := n.raftEngine.GetRegion(regionID)
-		ctx, cancel := context.WithTimeout(n.ctx, pdTimeout)
-		err := n.client.RegionHeartbeat(ctx, region)
-		if err != nil {
-			simutil.Logger.Info("report heartbeat error",
-				zap.Uint64("node-id", n.Id),
-				zap.Uint64("region-id", region.GetID()),
-				zap.Error(err))
-		}
+		n.reportRegion(region)
 		n.raftEngine.ResetRegionChange(n.Id, regionID)
-		cancel()
 	}
 }
 
+func (n *Node) reportRegion(region *core.RegionInfo) {
+	if n.faults.heartbeatDelay > 0 {
+		n.delayed = append(n.delayed, &delayedHeartbeat{tick: n.tick + n.faults.heartbeatDelay, region: region})
+		return
+	}
+	n.sendRegionHeartbeat(region)
+}
+
+func (n *Node) sendRegionHeartbeat(region *core.RegionInfo) {
+	ctx, cancel := context.WithTimeout(n.ctx, pdTimeout)
+	err := n.client.RegionHeartbeat(ctx, region)
+	if err != nil {
+		simutil.Logger.Info("report heartbeat error",
+			zap.Uint64("node-id", n.Id),
+			zap.Uint64("region-id", region.GetID()),
+			zap.Error(err))
+	}
+	cancel()
+}
+
+// snapshotRate returns the bytes of the snapshots sent or received per tick.
+func (n *Node) snapshotRate() int64 {
+	if n.faults.snapshotSlowdown > 1 {
+		return n.ioRate / n.faults.snapshotSlowdown
+	}
+	return n.ioRate
+}
+
+// fillDisk fills the disk with the data not managed by PD until the used
+// ratio reaches usedRatio.
+func (n *Node) fillDisk(size int64, usedRatio float64) {
+	n.sizeMutex.Lock()
+	defer n.sizeMutex.Unlock()
+	capacity := n.stats.GetCapacity()
+	minAvailable := uint64(float64(capacity) * (1 - usedRatio))
+	if n.stats.Available <= minAvailable {
+		return
+	}
+	fill := n.stats.Available - minAvailable
+	if size < int64(fill) {
+		fill = uint64(size)
+	}
+	n.stats.Available -= fill
+}
+
 // AddTask adds task in this node.
 func (n *Node) AddTask(task Task) {
 	n.Lock()
@@ -15,6 +15,7 @@ package simulator
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -269,7 +270,10 @@ func (r *RaftEngine) ResetRegionChange(storeID uint64, regionID uint64) {
 func (r *RaftEngine) GetRegions() []*core.RegionInfo {
 	r.RLock()
 	defer r.RUnlock()
-	return r.regionsInfo.GetRegions()
+	regions := r.regionsInfo.GetRegions()
+	// Keep the order stable to make the simulation reproducible.
+	sort.Slice(regions, func(i, j int) bool { return regions[i].GetID() < regions[j].GetID() })
+	return regions
 }
 
 // SetRegion sets the RegionInfo with regionID
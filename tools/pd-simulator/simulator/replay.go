@@ -0,0 +1,407 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/server/recorder"
+	"github.com/pingcap/pd/tools/pd-simulator/simulator/simutil"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// ReplayStats counts the replayed heartbeats and the operators PD responds.
+type ReplayStats struct {
+	RegionHeartbeats int64
+	StoreHeartbeats  int64
+	TransferLeader   int64
+	ChangePeer       int64
+	Merge            int64
+	SplitRegion      int64
+}
+
+// Replayer sends the heartbeats recorded by PD to another PD, to reproduce
+// the scheduling. The responses are only counted, the operators are not
+// executed, so the replayed cluster always follows the recorded one.
+type Replayer struct {
+	files []string
+	// speed is the multiple of the recorded speed, the records are sent as
+	// fast as possible if it is not positive.
+	speed float64
+	// keys maps the original keys to the anonymized ones, it is nil if the
+	// keys are not anonymized.
+	keys map[string][]byte
+
+	conn      *grpc.ClientConn
+	clusterID uint64
+
+	bootstrapped bool
+	stores       map[uint64]struct{}
+	streams      map[uint64]pdpb.PD_RegionHeartbeatClient
+	// firstTime is the time of the first record, and startTime is when it is
+	// replayed.
+	firstTime time.Time
+	startTime time.Time
+
+	stats  ReplayStats
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewReplayer creates a Replayer which replays the record files in order.
+func NewReplayer(pdAddr string, files []string, speed float64, anonymize bool) (*Replayer, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Replayer{
+		files:   files,
+		speed:   speed,
+		stores:  make(map[uint64]struct{}),
+		streams: make(map[uint64]pdpb.PD_RegionHeartbeatClient),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	if anonymize {
+		keys, err := collectKeys(files)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.keys = anonymizeKeys(keys)
+	}
+	conn, err := grpc.Dial(strings.TrimPrefix(pdAddr, "http://"), grpc.WithInsecure())
+	if err != nil {
+		cancel()
+		return nil, errors.WithStack(err)
+	}
+	r.conn = conn
+	if err = r.initClusterID(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Replayer) pdClient() pdpb.PDClient {
+	return pdpb.NewPDClient(r.conn)
+}
+
+func (r *Replayer) initClusterID() error {
+	for i := 0; i < maxInitClusterRetries; i++ {
+		ctx, cancel := context.WithTimeout(r.ctx, pdTimeout)
+		members, err := r.pdClient().GetMembers(ctx, &pdpb.GetMembersRequest{})
+		cancel()
+		if err != nil || members.GetHeader() == nil {
+			simutil.Logger.Error("failed to get cluster id", zap.Error(err))
+			continue
+		}
+		r.clusterID = members.GetHeader().GetClusterId()
+		return nil
+	}
+	return errors.WithStack(errFailInitClusterID)
+}
+
+func (r *Replayer) requestHeader() *pdpb.RequestHeader {
+	return &pdpb.RequestHeader{ClusterId: r.clusterID}
+}
+
+// Run replays all records, it stops early if ctx is done.
+func (r *Replayer) Run(ctx context.Context) error {
+	for _, file := range r.files {
+		err := readRecords(file, func(record *recorder.Record) error {
+			if err := r.wait(ctx, record.Time); err != nil {
+				return err
+			}
+			if record.RegionHeartbeat != nil {
+				return r.replayRegionHeartbeat(record.RegionHeartbeat)
+			}
+			return r.replayStoreHeartbeat(record.StoreHeartbeat)
+		})
+		if err == context.Canceled {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wait waits until the time to replay the record recorded at t.
+func (r *Replayer) wait(ctx context.Context, t time.Time) error {
+	if r.startTime.IsZero() {
+		r.firstTime, r.startTime = t, time.Now()
+	}
+	if r.speed <= 0 {
+		return ctx.Err()
+	}
+	d := time.Duration(float64(t.Sub(r.firstTime))/r.speed) - time.Since(r.startTime)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Replayer) replayRegionHeartbeat(request *pdpb.RegionHeartbeatRequest) error {
+	region := request.GetRegion()
+	if region == nil || request.GetLeader() == nil {
+		return nil
+	}
+	if r.keys != nil {
+		region.StartKey = r.keys[string(region.GetStartKey())]
+		region.EndKey = r.keys[string(region.GetEndKey())]
+	}
+	leaderStoreID := request.GetLeader().GetStoreId()
+	if !r.bootstrapped {
+		if err := r.bootstrap(region, request.GetLeader()); err != nil {
+			return err
+		}
+	}
+	for _, peer := range region.GetPeers() {
+		if err := r.putStore(peer.GetStoreId()); err != nil {
+			return err
+		}
+	}
+
+	stream, err := r.getStream(leaderStoreID)
+	if err != nil {
+		return err
+	}
+	request.Header = r.requestHeader()
+	if err = stream.Send(request); err != nil {
+		// The stream is created again for the next heartbeat.
+		simutil.Logger.Error("replay region heartbeat error", zap.Uint64("store-id", leaderStoreID), zap.Error(err))
+		delete(r.streams, leaderStoreID)
+		return nil
+	}
+	atomic.AddInt64(&r.stats.RegionHeartbeats, 1)
+	return nil
+}
+
+func (r *Replayer) replayStoreHeartbeat(request *pdpb.StoreHeartbeatRequest) error {
+	storeID := request.GetStats().GetStoreId()
+	if !r.bootstrapped || storeID == 0 {
+		// The stores are registered after bootstrapping with the first
+		// region heartbeat.
+		return nil
+	}
+	if err := r.putStore(storeID); err != nil {
+		return err
+	}
+	request.Header = r.requestHeader()
+	ctx, cancel := context.WithTimeout(r.ctx, pdTimeout)
+	defer cancel()
+	resp, err := r.pdClient().StoreHeartbeat(ctx, request)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if resp.GetHeader().GetError() != nil {
+		simutil.Logger.Error("replay store heartbeat error", zap.Reflect("error", resp.GetHeader().GetError()))
+		return nil
+	}
+	atomic.AddInt64(&r.stats.StoreHeartbeats, 1)
+	return nil
+}
+
+// bootstrap bootstraps the cluster with the leader store and the region of
+// the first region heartbeat, the region is updated by its heartbeats later.
+func (r *Replayer) bootstrap(region *metapb.Region, leader *metapb.Peer) error {
+	ctx, cancel := context.WithTimeout(r.ctx, pdTimeout)
+	defer cancel()
+	_, err := r.pdClient().Bootstrap(ctx, &pdpb.BootstrapRequest{
+		Header: r.requestHeader(),
+		Store:  newReplayStore(leader.GetStoreId()),
+		Region: &metapb.Region{
+			Id:          region.GetId(),
+			RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+			Peers:       []*metapb.Peer{leader},
+		},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	r.bootstrapped = true
+	r.stores[leader.GetStoreId()] = struct{}{}
+	return nil
+}
+
+func (r *Replayer) putStore(storeID uint64) error {
+	if _, ok := r.stores[storeID]; ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, pdTimeout)
+	defer cancel()
+	resp, err := r.pdClient().PutStore(ctx, &pdpb.PutStoreRequest{
+		Header: r.requestHeader(),
+		Store:  newReplayStore(storeID),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if resp.GetHeader().GetError() != nil {
+		return errors.Errorf("put store %d error: %v", storeID, resp.GetHeader().GetError())
+	}
+	r.stores[storeID] = struct{}{}
+	return nil
+}
+
+func newReplayStore(storeID uint64) *metapb.Store {
+	return &metapb.Store{
+		Id:      storeID,
+		Address: fmt.Sprintf("mock://tikv-%d", storeID),
+		Version: defaultStoreVersion,
+	}
+}
+
+// getStream returns the region heartbeat stream of the store, like TiKV
+// every store sends the heartbeats of its leaders in its own stream.
+func (r *Replayer) getStream(storeID uint64) (pdpb.PD_RegionHeartbeatClient, error) {
+	if stream, ok := r.streams[storeID]; ok {
+		return stream, nil
+	}
+	stream, err := r.pdClient().RegionHeartbeat(r.ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	r.streams[storeID] = stream
+	r.wg.Add(1)
+	go r.receive(stream)
+	return stream, nil
+}
+
+func (r *Replayer) receive(stream pdpb.PD_RegionHeartbeatClient) {
+	defer r.wg.Done()
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return
+		}
+		switch {
+		case resp.GetTransferLeader() != nil:
+			atomic.AddInt64(&r.stats.TransferLeader, 1)
+		case resp.GetChangePeer() != nil:
+			atomic.AddInt64(&r.stats.ChangePeer, 1)
+		case resp.GetMerge() != nil:
+			atomic.AddInt64(&r.stats.Merge, 1)
+		case resp.GetSplitRegion() != nil:
+			atomic.AddInt64(&r.stats.SplitRegion, 1)
+		}
+	}
+}
+
+// Stats returns the statistics of the replay.
+func (r *Replayer) Stats() ReplayStats {
+	return ReplayStats{
+		RegionHeartbeats: atomic.LoadInt64(&r.stats.RegionHeartbeats),
+		StoreHeartbeats:  atomic.LoadInt64(&r.stats.StoreHeartbeats),
+		TransferLeader:   atomic.LoadInt64(&r.stats.TransferLeader),
+		ChangePeer:       atomic.LoadInt64(&r.stats.ChangePeer),
+		Merge:            atomic.LoadInt64(&r.stats.Merge),
+		SplitRegion:      atomic.LoadInt64(&r.stats.SplitRegion),
+	}
+}
+
+// Close stops receiving the responses and closes the connection.
+func (r *Replayer) Close() {
+	for _, stream := range r.streams {
+		if err := stream.CloseSend(); err != nil {
+			simutil.Logger.Error("failed to close region heartbeat stream", zap.Error(err))
+		}
+	}
+	r.cancel()
+	r.wg.Wait()
+	if r.conn != nil {
+		if err := r.conn.Close(); err != nil {
+			simutil.Logger.Error("failed to close grpc client connection", zap.Error(err))
+		}
+	}
+}
+
+func readRecords(file string, f func(*recorder.Record) error) error {
+	fd, err := os.Open(file)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fd.Close()
+	reader := recorder.NewReader(fd)
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err == io.ErrUnexpectedEOF {
+			// The last record may be incomplete if PD exits while writing.
+			simutil.Logger.Warn("ignore the incomplete record", zap.String("file", file))
+			return nil
+		}
+		if err != nil {
+			return errors.WithMessage(err, file)
+		}
+		if err = f(record); err != nil {
+			return err
+		}
+	}
+}
+
+// collectKeys returns the distinct region keys in the record files.
+func collectKeys(files []string) (map[string]struct{}, error) {
+	keys := make(map[string]struct{})
+	for _, file := range files {
+		err := readRecords(file, func(record *recorder.Record) error {
+			if region := record.RegionHeartbeat.GetRegion(); region != nil {
+				keys[string(region.GetStartKey())] = struct{}{}
+				keys[string(region.GetEndKey())] = struct{}{}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// anonymizeKeys replaces the keys with the numbered ones in the same order, so
+// the ranges of the regions are kept. The empty key still means unbounded.
+func anonymizeKeys(keys map[string]struct{}) map[string][]byte {
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		if key != "" {
+			sorted = append(sorted, key)
+		}
+	}
+	sort.Strings(sorted)
+	m := make(map[string][]byte, len(sorted)+1)
+	m[""] = nil
+	for i, key := range sorted {
+		m[key] = []byte(fmt.Sprintf("key-%010d", i+1))
+	}
+	return m
+}
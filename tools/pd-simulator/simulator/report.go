@@ -0,0 +1,572 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// reportSampleTicks is the interval in ticks to sample the stores.
+	reportSampleTicks = 10
+	// maxReportViolations is the max number of the violations listed in the
+	// report, all of them are counted.
+	maxReportViolations = 100
+	// operatorCounterName is the metric counting the operators by the
+	// description, which is the scheduler or checker creating them.
+	operatorCounterName = "pd_schedule_operators_count"
+)
+
+// The kinds of the constraint violations.
+const (
+	violationMissReplica  = "miss-replica"
+	violationExtraReplica = "extra-replica"
+	violationIsolation    = "isolation"
+)
+
+// Report is the scheduling-quality report of a simulation run, two runs of
+// the same case can be compared to find the scheduling regressions.
+type Report struct {
+	Case         string `json:"case"`
+	Result       string `json:"result"`
+	Seed         int64  `json:"seed"`
+	VirtualClock bool   `json:"virtual_clock"`
+	TickInterval string `json:"tick_interval"`
+	Ticks        int64  `json:"ticks"`
+	Duration     string `json:"duration"`
+	// ConvergeTick is the first tick the case is checked to be finished, it
+	// is 0 if the case never finishes.
+	ConvergeTick int64 `json:"converge_tick"`
+	// LastOperatorTick is the last tick any operator step is finished.
+	LastOperatorTick int64           `json:"last_operator_tick"`
+	Samples          []*ReportSample `json:"samples"`
+	// OperatorsByKind counts the operator steps finished by the nodes.
+	OperatorsByKind map[string]int `json:"operators_by_kind"`
+	// OperatorsBySource counts the events of the operators by the scheduler
+	// or checker creating them. It is only available when PD is started by
+	// the simulator.
+	OperatorsBySource map[string]map[string]int `json:"operators_by_source,omitempty"`
+	// MovedSize is the size of the data moved by the snapshots in bytes.
+	MovedSize       int64          `json:"moved_size"`
+	ViolationCounts map[string]int `json:"violation_counts"`
+	Violations      []*Violation   `json:"violations"`
+}
+
+// ReportSample is the status of the stores at a tick.
+type ReportSample struct {
+	Tick   int64          `json:"tick"`
+	Stores []*StoreSample `json:"stores"`
+	// The variances of the stores which are up, the region size is in MB.
+	RegionCountVariance float64 `json:"region_count_variance"`
+	LeaderCountVariance float64 `json:"leader_count_variance"`
+	RegionSizeVariance  float64 `json:"region_size_variance"`
+}
+
+// StoreSample is the status of a store.
+type StoreSample struct {
+	StoreID     uint64 `json:"store_id"`
+	RegionCount int    `json:"region_count"`
+	LeaderCount int    `json:"leader_count"`
+	// RegionSize is in bytes.
+	RegionSize int64 `json:"region_size"`
+}
+
+// Violation is a region breaking the replication constraints at the end.
+type Violation struct {
+	RegionID uint64   `json:"region_id"`
+	Kind     string   `json:"kind"`
+	Stores   []uint64 `json:"stores"`
+	// Label is the location label shared by the replicas of the isolation
+	// violation.
+	Label string `json:"label,omitempty"`
+}
+
+// reporter collects the report during the run.
+type reporter struct {
+	simConfig *SimConfig
+	report    *Report
+	// baseline is the operator counters before the run, as the counters are
+	// shared by the runs in the process.
+	baseline  map[string]map[string]int
+	lastTasks int
+}
+
+func newReporter(simConfig *SimConfig) *reporter {
+	return &reporter{
+		simConfig: simConfig,
+		report: &Report{
+			VirtualClock: simConfig.VirtualClock,
+			TickInterval: simConfig.SimTickInterval.String(),
+		},
+		baseline: gatherOperatorCounts(),
+	}
+}
+
+// tick is called after every tick.
+func (r *reporter) tick(tickCount int64, raft *RaftEngine) {
+	var tasks int
+	for _, count := range raft.schedulerStats.taskStats.getCounts() {
+		tasks += count
+	}
+	if tasks != r.lastTasks {
+		r.lastTasks = tasks
+		r.report.LastOperatorTick = tickCount
+	}
+	if tickCount%reportSampleTicks == 0 {
+		r.sample(tickCount, raft)
+	}
+}
+
+// check is called with the result of every check.
+func (r *reporter) check(tickCount int64, finished bool) {
+	if finished && r.report.ConvergeTick == 0 {
+		r.report.ConvergeTick = tickCount
+	}
+}
+
+func (r *reporter) sample(tickCount int64, raft *RaftEngine) {
+	if n := len(r.report.Samples); n > 0 && r.report.Samples[n-1].Tick == tickCount {
+		return
+	}
+	sample := &ReportSample{Tick: tickCount}
+	var regionCounts, leaderCounts, regionSizes []float64
+	raft.RLock()
+	for _, id := range sortedNodeIDs(raft.conn.Nodes) {
+		s := &StoreSample{
+			StoreID:     id,
+			RegionCount: raft.regionsInfo.GetStoreRegionCount(id),
+			LeaderCount: raft.regionsInfo.GetStoreLeaderCount(id),
+			RegionSize:  raft.regionsInfo.GetStoreRegionSize(id),
+		}
+		sample.Stores = append(sample.Stores, s)
+		if raft.conn.nodeHealth(id) {
+			regionCounts = append(regionCounts, float64(s.RegionCount))
+			leaderCounts = append(leaderCounts, float64(s.LeaderCount))
+			regionSizes = append(regionSizes, float64(s.RegionSize)/(1<<20))
+		}
+	}
+	raft.RUnlock()
+	sample.RegionCountVariance = variance(regionCounts)
+	sample.LeaderCountVariance = variance(leaderCounts)
+	sample.RegionSizeVariance = variance(regionSizes)
+	r.report.Samples = append(r.report.Samples, sample)
+}
+
+// finish completes the report at the end of the run.
+func (r *reporter) finish(caseName, result string, tickCount int64, duration time.Duration, raft *RaftEngine) *Report {
+	r.sample(tickCount, raft)
+	report := r.report
+	report.Case = caseName
+	report.Result = result
+	report.Seed = r.simConfig.Seed
+	report.Ticks = tickCount
+	report.Duration = duration.String()
+	report.OperatorsByKind = raft.schedulerStats.taskStats.getCounts()
+	report.OperatorsBySource = subOperatorCounts(gatherOperatorCounts(), r.baseline)
+	report.MovedSize = raft.schedulerStats.taskStats.getMovedSize()
+
+	replication := r.simConfig.ServerConfig.Replication
+	stores := make(map[uint64]*metapb.Store)
+	for id, node := range raft.conn.Nodes {
+		if raft.conn.nodeHealth(id) {
+			stores[id] = node.Store
+		}
+	}
+	violations := checkViolations(raft.GetRegions(), stores, int(replication.MaxReplicas), replication.LocationLabels)
+	report.ViolationCounts = make(map[string]int)
+	for _, v := range violations {
+		report.ViolationCounts[v.Kind]++
+	}
+	if len(violations) > maxReportViolations {
+		violations = violations[:maxReportViolations]
+	}
+	report.Violations = violations
+	return report
+}
+
+// checkViolations returns the regions breaking the replication constraints.
+// The replicas of a region must be isolated at the first level of the
+// location labels which has enough distinct locations for them.
+func checkViolations(regions []*core.RegionInfo, stores map[uint64]*metapb.Store, maxReplicas int, locationLabels []string) []*Violation {
+	// domains[i] is the number of the distinct locations at the level i.
+	domains := make([]int, len(locationLabels))
+	for i := range locationLabels {
+		locations := make(map[string]struct{})
+		for _, store := range stores {
+			locations[storeLocation(store, locationLabels[:i+1])] = struct{}{}
+		}
+		domains[i] = len(locations)
+	}
+
+	violations := make([]*Violation, 0)
+	for _, region := range regions {
+		peers := region.GetPeers()
+		storeIDs := make([]uint64, 0, len(peers))
+		for _, peer := range peers {
+			storeIDs = append(storeIDs, peer.GetStoreId())
+		}
+		sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+		v := &Violation{RegionID: region.GetID(), Stores: storeIDs}
+		switch {
+		case len(peers) < maxReplicas:
+			v.Kind = violationMissReplica
+		case len(peers) > maxReplicas:
+			v.Kind = violationExtraReplica
+		default:
+			v.Label = isolationViolation(storeIDs, stores, locationLabels, domains)
+			if v.Label == "" {
+				continue
+			}
+			v.Kind = violationIsolation
+		}
+		violations = append(violations, v)
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].RegionID < violations[j].RegionID })
+	return violations
+}
+
+// isolationViolation returns the location label shared by the replicas, it
+// returns empty if they are isolated.
+func isolationViolation(storeIDs []uint64, stores map[uint64]*metapb.Store, locationLabels []string, domains []int) string {
+	for i, label := range locationLabels {
+		if domains[i] < len(storeIDs) {
+			continue
+		}
+		locations := make(map[string]struct{})
+		for _, id := range storeIDs {
+			store, ok := stores[id]
+			if !ok {
+				// The store is down or deleted, it doesn't break the isolation.
+				locations[fmt.Sprintf("store-%d", id)] = struct{}{}
+				continue
+			}
+			locations[storeLocation(store, locationLabels[:i+1])] = struct{}{}
+		}
+		if len(locations) < len(storeIDs) {
+			return label
+		}
+		return ""
+	}
+	return ""
+}
+
+func storeLocation(store *metapb.Store, labels []string) string {
+	values := make([]string, 0, len(labels))
+	for _, label := range labels {
+		var value string
+		for _, l := range store.GetLabels() {
+			if strings.EqualFold(l.GetKey(), label) {
+				value = l.GetValue()
+			}
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, "/")
+}
+
+func variance(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var res float64
+	for _, v := range values {
+		res += (v - mean) * (v - mean)
+	}
+	return res / float64(len(values))
+}
+
+func sortedNodeIDs(nodes map[uint64]*Node) []uint64 {
+	ids := make([]uint64, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// gatherOperatorCounts returns the operator counters of the PD in the
+// process by source and event, it is empty if PD is not started by the
+// simulator.
+func gatherOperatorCounts() map[string]map[string]int {
+	counts := make(map[string]map[string]int)
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return counts
+	}
+	for _, family := range families {
+		if family.GetName() != operatorCounterName {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			var source, event string
+			for _, label := range m.GetLabel() {
+				switch label.GetName() {
+				case "type":
+					source = label.GetValue()
+				case "event":
+					event = label.GetValue()
+				}
+			}
+			if counts[source] == nil {
+				counts[source] = make(map[string]int)
+			}
+			counts[source][event] = int(m.GetCounter().GetValue())
+		}
+	}
+	return counts
+}
+
+func subOperatorCounts(counts, baseline map[string]map[string]int) map[string]map[string]int {
+	res := make(map[string]map[string]int)
+	for source, events := range counts {
+		for event, count := range events {
+			if count -= baseline[source][event]; count > 0 {
+				if res[source] == nil {
+					res[source] = make(map[string]int)
+				}
+				res[source][event] = count
+			}
+		}
+	}
+	return res
+}
+
+// WriteFiles writes the report in JSON and HTML to the directory, the files
+// are named after the case.
+func (r *Report) WriteFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, r.Case+".json"), data, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.Create(filepath.Join(dir, r.Case+".html"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	return r.WriteHTML(f)
+}
+
+// WriteHTML writes the report as a self-contained HTML page.
+func (r *Report) WriteHTML(w io.Writer) error {
+	return errors.WithStack(reportTemplate.Execute(w, r.htmlData()))
+}
+
+const (
+	chartWidth  = 720
+	chartHeight = 240
+)
+
+var chartColors = []string{
+	"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+	"#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf",
+}
+
+type htmlChart struct {
+	Title  string
+	Max    float64
+	Lines  []htmlLine
+	Width  int
+	Height int
+}
+
+type htmlLine struct {
+	Name   string
+	Color  string
+	Points string
+}
+
+type htmlCount struct {
+	Name  string
+	Count int
+}
+
+type htmlSource struct {
+	Source string
+	Events []htmlCount
+}
+
+type htmlData struct {
+	*Report
+	Charts  []htmlChart
+	Kinds   []htmlCount
+	Sources []htmlSource
+}
+
+func (r *Report) htmlData() *htmlData {
+	data := &htmlData{Report: r}
+	data.Charts = []htmlChart{
+		r.storeChart("Region count", func(s *StoreSample) float64 { return float64(s.RegionCount) }),
+		r.storeChart("Leader count", func(s *StoreSample) float64 { return float64(s.LeaderCount) }),
+		r.storeChart("Region size (MB)", func(s *StoreSample) float64 { return float64(s.RegionSize) / (1 << 20) }),
+		r.varianceChart(),
+	}
+	data.Kinds = sortedCounts(r.OperatorsByKind)
+	for source, events := range r.OperatorsBySource {
+		data.Sources = append(data.Sources, htmlSource{Source: source, Events: sortedCounts(events)})
+	}
+	sort.Slice(data.Sources, func(i, j int) bool { return data.Sources[i].Source < data.Sources[j].Source })
+	return data
+}
+
+func (r *Report) storeChart(title string, value func(*StoreSample) float64) htmlChart {
+	series := make(map[uint64][][2]float64)
+	for _, sample := range r.Samples {
+		for _, s := range sample.Stores {
+			series[s.StoreID] = append(series[s.StoreID], [2]float64{float64(sample.Tick), value(s)})
+		}
+	}
+	ids := make([]uint64, 0, len(series))
+	for id := range series {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	names := make([]string, 0, len(ids))
+	points := make([][][2]float64, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, fmt.Sprintf("store %d", id))
+		points = append(points, series[id])
+	}
+	return r.newChart(title, names, points)
+}
+
+func (r *Report) varianceChart() htmlChart {
+	var regionCount, leaderCount [][2]float64
+	for _, sample := range r.Samples {
+		regionCount = append(regionCount, [2]float64{float64(sample.Tick), sample.RegionCountVariance})
+		leaderCount = append(leaderCount, [2]float64{float64(sample.Tick), sample.LeaderCountVariance})
+	}
+	return r.newChart("Variance", []string{"region count", "leader count"}, [][][2]float64{regionCount, leaderCount})
+}
+
+func (r *Report) newChart(title string, names []string, series [][][2]float64) htmlChart {
+	chart := htmlChart{Title: title, Width: chartWidth, Height: chartHeight}
+	for _, points := range series {
+		for _, p := range points {
+			if p[1] > chart.Max {
+				chart.Max = p[1]
+			}
+		}
+	}
+	maxX, maxY := float64(r.Ticks), chart.Max
+	if maxX == 0 {
+		maxX = 1
+	}
+	if maxY == 0 {
+		maxY = 1
+	}
+	for i, points := range series {
+		coords := make([]string, 0, len(points))
+		for _, p := range points {
+			x := p[0] / maxX * chartWidth
+			y := chartHeight - p[1]/maxY*chartHeight
+			coords = append(coords, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		chart.Lines = append(chart.Lines, htmlLine{
+			Name:   names[i],
+			Color:  chartColors[i%len(chartColors)],
+			Points: strings.Join(coords, " "),
+		})
+	}
+	return chart
+}
+
+func sortedCounts(m map[string]int) []htmlCount {
+	counts := make([]htmlCount, 0, len(m))
+	for name, count := range m {
+		counts = append(counts, htmlCount{Name: name, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Name < counts[j].Name })
+	return counts
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Case}} - pd-simulator report</title>
+<style>
+body { font-family: sans-serif; margin: 24px; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+svg { border: 1px solid #ccc; }
+.legend span { margin-right: 12px; }
+</style>
+</head>
+<body>
+<h1>{{.Case}}: {{.Result}}</h1>
+<table>
+<tr><th>Seed</th><td>{{.Seed}}</td></tr>
+<tr><th>Virtual clock</th><td>{{.VirtualClock}}</td></tr>
+<tr><th>Tick interval</th><td>{{.TickInterval}}</td></tr>
+<tr><th>Ticks</th><td>{{.Ticks}}</td></tr>
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+<tr><th>Converge tick</th><td>{{.ConvergeTick}}</td></tr>
+<tr><th>Last operator tick</th><td>{{.LastOperatorTick}}</td></tr>
+<tr><th>Moved size (bytes)</th><td>{{.MovedSize}}</td></tr>
+</table>
+{{range .Charts}}
+<h2>{{.Title}}</h2>
+<div>max: {{.Max}}</div>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}">
+{{range .Lines}}<polyline fill="none" stroke="{{.Color}}" stroke-width="1.5" points="{{.Points}}"/>
+{{end}}</svg>
+<div class="legend">{{range .Lines}}<span style="color: {{.Color}}">&#9632; {{.Name}}</span>{{end}}</div>
+{{end}}
+<h2>Operators by kind</h2>
+<table>
+<tr><th>Kind</th><th>Count</th></tr>
+{{range .Kinds}}<tr><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+<h2>Operators by source</h2>
+{{if .Sources}}<table>
+<tr><th>Source</th><th>Events</th></tr>
+{{range .Sources}}<tr><td>{{.Source}}</td><td>{{range .Events}}{{.Name}}: {{.Count}} {{end}}</td></tr>
+{{end}}</table>{{else}}<p>Only available when PD is started by the simulator.</p>{{end}}
+<h2>Constraint violations</h2>
+{{if .ViolationCounts}}<table>
+<tr><th>Kind</th><th>Count</th></tr>
+{{range $kind, $count := .ViolationCounts}}<tr><td>{{$kind}}</td><td>{{$count}}</td></tr>
+{{end}}</table>
+<table>
+<tr><th>Region</th><th>Kind</th><th>Stores</th><th>Label</th></tr>
+{{range .Violations}}<tr><td>{{.RegionID}}</td><td>{{.Kind}}</td><td>{{.Stores}}</td><td>{{.Label}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+</body>
+</html>
+`))
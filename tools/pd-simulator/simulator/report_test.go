@@ -0,0 +1,134 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+)
+
+func TestSimulator(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testReportSuite{})
+
+type testReportSuite struct{}
+
+func newTestStore(id uint64, zone, host string) *metapb.Store {
+	return &metapb.Store{
+		Id: id,
+		Labels: []*metapb.StoreLabel{
+			{Key: "zone", Value: zone},
+			{Key: "host", Value: host},
+		},
+	}
+}
+
+func newTestRegion(id uint64, storeIDs ...uint64) *core.RegionInfo {
+	peers := make([]*metapb.Peer, 0, len(storeIDs))
+	for i, storeID := range storeIDs {
+		peers = append(peers, &metapb.Peer{Id: id*10 + uint64(i), StoreId: storeID})
+	}
+	return core.NewRegionInfo(&metapb.Region{Id: id, Peers: peers}, peers[0])
+}
+
+func (s *testReportSuite) TestCheckViolations(c *C) {
+	stores := map[uint64]*metapb.Store{
+		1: newTestStore(1, "z1", "h1"),
+		2: newTestStore(2, "z1", "h2"),
+		3: newTestStore(3, "z2", "h3"),
+		4: newTestStore(4, "z3", "h4"),
+	}
+	labels := []string{"zone", "host"}
+	regions := []*core.RegionInfo{
+		newTestRegion(1, 1, 3, 4),
+		newTestRegion(2, 1, 2, 3),
+		newTestRegion(3, 1, 3),
+		newTestRegion(4, 1, 2, 3, 4),
+	}
+	violations := checkViolations(regions, stores, 3, labels)
+	c.Assert(violations, HasLen, 3)
+	c.Assert(*violations[0], DeepEquals, Violation{RegionID: 2, Kind: violationIsolation, Stores: []uint64{1, 2, 3}, Label: "zone"})
+	c.Assert(violations[1].Kind, Equals, violationMissReplica)
+	c.Assert(violations[2].Kind, Equals, violationExtraReplica)
+
+	// There are not enough zones to isolate the replicas, so the hosts are
+	// checked.
+	delete(stores, 4)
+	violations = checkViolations(regions[1:2], stores, 3, labels)
+	c.Assert(violations, HasLen, 0)
+	stores[5] = newTestStore(5, "z1", "h1")
+	violations = checkViolations([]*core.RegionInfo{newTestRegion(5, 1, 3, 5)}, stores, 3, labels)
+	c.Assert(violations, HasLen, 1)
+	c.Assert(violations[0].Label, Equals, "host")
+
+	c.Assert(checkViolations(regions[1:2], stores, 3, nil), HasLen, 0)
+}
+
+func (s *testReportSuite) TestWriteFiles(c *C) {
+	dir, err := ioutil.TempDir("", "simulator_report")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+
+	report := &Report{
+		Case:   "test",
+		Result: "OK",
+		Ticks:  20,
+		Samples: []*ReportSample{
+			{Tick: 10, Stores: []*StoreSample{{StoreID: 1, RegionCount: 2}, {StoreID: 2}}, RegionCountVariance: 1},
+			{Tick: 20, Stores: []*StoreSample{{StoreID: 1, RegionCount: 1}, {StoreID: 2, RegionCount: 1}}},
+		},
+		OperatorsByKind:   map[string]int{taskAddPeer: 1},
+		OperatorsBySource: map[string]map[string]int{"balance-region": {"create": 1}},
+		ViolationCounts:   map[string]int{},
+	}
+	c.Assert(report.WriteFiles(dir), IsNil)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "test.json"))
+	c.Assert(err, IsNil)
+	var decoded Report
+	c.Assert(json.Unmarshal(data, &decoded), IsNil)
+	c.Assert(decoded.Samples, DeepEquals, report.Samples)
+
+	var buf bytes.Buffer
+	c.Assert(report.WriteHTML(&buf), IsNil)
+	html := buf.String()
+	c.Assert(strings.Count(html, "<polyline"), Equals, 3*2+2)
+	c.Assert(strings.Contains(html, `points="360.0,0.0 720.0,120.0"`), IsTrue)
+	c.Assert(strings.Contains(html, "balance-region"), IsTrue)
+}
+
+func (s *testReportSuite) TestSubOperatorCounts(c *C) {
+	counts := map[string]map[string]int{
+		"balance-leader": {"create": 3, "finish": 2},
+		"balance-region": {"create": 1},
+	}
+	baseline := map[string]map[string]int{
+		"balance-leader": {"create": 1, "finish": 2},
+		"balance-region": {"create": 1},
+	}
+	c.Assert(subOperatorCounts(counts, baseline), DeepEquals, map[string]map[string]int{
+		"balance-leader": {"create": 2},
+	})
+}
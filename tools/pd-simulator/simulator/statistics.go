@@ -27,6 +27,7 @@ type taskStatistics struct {
 	promoteLeaner  map[uint64]int
 	transferLeader map[uint64]map[uint64]int
 	mergeRegion    int
+	movedSize      int64
 }
 
 func newTaskStatistics() *taskStatistics {
@@ -39,15 +40,29 @@ func newTaskStatistics() *taskStatistics {
 	}
 }
 
-func (t *taskStatistics) getStatistics() map[string]int {
+// The kinds of the tasks, which are the operator steps applied by the nodes.
+const (
+	taskAddPeer        = "add-peer"
+	taskRemovePeer     = "remove-peer"
+	taskAddLearner     = "add-learner"
+	taskPromoteLearner = "promote-learner"
+	taskTransferLeader = "transfer-leader"
+	taskMergeRegion    = "merge-region"
+)
+
+var taskNames = map[string]string{
+	taskAddPeer:        "Add Peer (task)",
+	taskRemovePeer:     "Remove Peer (task)",
+	taskAddLearner:     "Add Learner (task)",
+	taskPromoteLearner: "Promote Learner (task)",
+	taskTransferLeader: "Transfer Leader (task)",
+	taskMergeRegion:    "Merge Region (task)",
+}
+
+// getCounts returns the number of the finished tasks by kind.
+func (t *taskStatistics) getCounts() map[string]int {
 	t.RLock()
 	defer t.RUnlock()
-	stats := make(map[string]int)
-	addpeer := getSum(t.addPeer)
-	removePeer := getSum(t.removePeer)
-	addLearner := getSum(t.addLearner)
-	promoteLeaner := getSum(t.promoteLeaner)
-
 	var transferLeader int
 	for _, to := range t.transferLeader {
 		for _, v := range to {
@@ -55,16 +70,37 @@ func (t *taskStatistics) getStatistics() map[string]int {
 		}
 	}
 
-	stats["Add Peer (task)"] = addpeer
-	stats["Remove Peer (task)"] = removePeer
-	stats["Add Learner (task)"] = addLearner
-	stats["Promote Learner (task)"] = promoteLeaner
-	stats["Transfer Leader (task)"] = transferLeader
-	stats["Merge Region (task)"] = t.mergeRegion
+	return map[string]int{
+		taskAddPeer:        getSum(t.addPeer),
+		taskRemovePeer:     getSum(t.removePeer),
+		taskAddLearner:     getSum(t.addLearner),
+		taskPromoteLearner: getSum(t.promoteLeaner),
+		taskTransferLeader: transferLeader,
+		taskMergeRegion:    t.mergeRegion,
+	}
+}
 
+func (t *taskStatistics) getStatistics() map[string]int {
+	stats := make(map[string]int)
+	for kind, count := range t.getCounts() {
+		stats[taskNames[kind]] = count
+	}
 	return stats
 }
 
+// addMovedSize records the size of the data moved by the snapshots.
+func (t *taskStatistics) addMovedSize(size int64) {
+	t.Lock()
+	defer t.Unlock()
+	t.movedSize += size
+}
+
+func (t *taskStatistics) getMovedSize() int64 {
+	t.RLock()
+	defer t.RUnlock()
+	return t.movedSize
+}
+
 func (t *taskStatistics) incAddPeer(regionID uint64) {
 	t.Lock()
 	defer t.Unlock()
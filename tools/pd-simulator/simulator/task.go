@@ -260,6 +260,7 @@ func (a *addPeer) Step(r *RaftEngine) {
 		if region.GetPeer(a.peer.GetId()) == nil {
 			opts = append(opts, core.WithAddPeer(a.peer))
 			r.schedulerStats.taskStats.incAddPeer(region.GetID())
+			r.schedulerStats.taskStats.addMovedSize(snapshotSize)
 		} else {
 			opts = append(opts, core.WithPromoteLearner(a.peer.GetId()))
 			r.schedulerStats.taskStats.incPromoteLeaner(region.GetID())
@@ -374,7 +375,11 @@ func (a *addLearner) Step(r *RaftEngine) {
 		return
 	}
 
-	a.size -= a.speed
+	speed := a.speed
+	if n := r.conn.Nodes[a.peer.GetStoreId()]; n != nil && n.faults.snapshotSlowdown > 1 {
+		speed /= n.faults.snapshotSlowdown
+	}
+	a.size -= speed
 	if a.size < 0 {
 		if region.GetPeer(a.peer.GetId()) == nil {
 			newRegion := region.Clone(
@@ -384,6 +389,7 @@ func (a *addLearner) Step(r *RaftEngine) {
 			r.SetRegion(newRegion)
 			r.recordRegionChange(newRegion)
 			r.schedulerStats.taskStats.incAddLeaner(region.GetID())
+			r.schedulerStats.taskStats.addMovedSize(region.GetApproximateSize())
 		}
 		a.finished = true
 		if analysis.GetTransferCounter().IsValid {
@@ -409,7 +415,7 @@ func processSnapshot(n *Node, stat *snapshotStat, snapshotSize int64) bool {
 			n.stats.ReceivingSnapCount++
 		}
 	}
-	stat.remainSize -= n.ioRate
+	stat.remainSize -= n.snapshotRate()
 	// The sending or receiving process has not finished yet.
 	if stat.remainSize > 0 {
 		return false